@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// setAdminChannelCommand returns the /set-admin-channel command definition
+func setAdminChannelCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "set-admin-channel",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:     discordgo.ApplicationCommandOptionChannel,
+				Name:     "channel",
+				Required: false,
+				ChannelTypes: []discordgo.ChannelType{
+					discordgo.ChannelTypeGuildText,
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleSetAdminChannel(s DiscordSession, i *discordgo.InteractionCreate) {
+	// Default member permissions can be overridden by server admins, so check again
+	if !hasPermission(i, discordgo.PermissionManageServer) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "admin.manage-server"))
+		return
+	}
+
+	channelID := i.ChannelID
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		channelID = options[0].ChannelValue(nil).ID
+	}
+
+	b.setAdminChannel(i.GuildID, channelID)
+	slog.Info("Admin channel set", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", channelID)
+
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "admin.set", channelID))
+}
+
+// requireAdminChannel responds with an error and returns false unless the
+// interaction happened in the guild's admin channel
+func (b *Bot) requireAdminChannel(s DiscordSession, i *discordgo.InteractionCreate) bool {
+	adminChannelID, isAdmin, hasAdminChannel := b.verifyAdminChannel(i.GuildID, i.ChannelID)
+
+	if !hasAdminChannel {
+		// First use in an unconfigured server: set it up inline instead of pointing at commands or env vars
+		if hasPermission(i, discordgo.PermissionManageServer) && !b.guildHasSettings(i.GuildID) {
+			b.respondWithSetup(s, i)
+			return false
+		}
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "admin.not-set"))
+		return false
+	}
+
+	if !isAdmin {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "admin.wrong-channel", adminChannelID))
+		return false
+	}
+	return true
+}
+
+// hasPermission reports whether the invoking member has a permission
+func hasPermission(i *discordgo.InteractionCreate, permission int64) bool {
+	if i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&permission == permission || i.Member.Permissions&discordgo.PermissionAdministrator != 0
+}
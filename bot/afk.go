@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// afkSetting controls how a guild's AFK channel is announced. The zero
+	// value, the default, leaves the AFK channel out of notifications.
+	afkSetting struct {
+		Notify  bool `json:"notify,omitempty"`   // announce the AFK channel like any other channel
+		WentAFK bool `json:"went_afk,omitempty"` // announce moves into the AFK channel as going AFK
+	}
+)
+
+// getAFKSetting returns how a guild's AFK channel is announced
+func (b *Bot) getAFKSetting(guildID string) afkSetting {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.afkSettings[guildID]
+}
+
+// excludedAFKChannel returns the guild's AFK channel if it is left out of
+// notifications, or "" if the guild has none or announces it
+func (b *Bot) excludedAFKChannel(guildID string) string {
+	guild, err := b.session.State.Guild(guildID)
+	if err != nil || guild.AfkChannelID == "" || b.getAFKSetting(guildID).Notify {
+		return ""
+	}
+	return guild.AfkChannelID
+}
+
+// notifyWentAFK tells the subscriptions of the channel a user left for the
+// AFK channel that they went AFK. Subscriptions that receive leaves or moves
+// get it instead of the leave message.
+func (b *Bot) notifyWentAFK(s DiscordSession, guildID, voiceChannelID, userID, username string, stayed time.Duration) {
+	subs := b.sessionEventSubscriptions(s, voiceChannelID, userID, func(sub subscription) bool { return sub.Events.has(eventLeave | eventMove) })
+	if len(subs) == 0 || !b.claim(fmt.Sprintf("afk:%s:%s", voiceChannelID, userID), b.debounceInterval) {
+		return
+	}
+
+	content := b.renderMessage(guildID, TemplateEvent{
+		Type:      TemplateEventAFK,
+		User:      username,
+		UserID:    userID,
+		Channel:   b.getChannelName(s, voiceChannelID),
+		ChannelID: voiceChannelID,
+		Guild:     b.getGuildName(s, guildID),
+		Count:     b.occupancy.count(voiceChannelID),
+		Time:      time.Now(),
+		Duration:  stayed,
+	})
+
+	for _, sub := range subs {
+		sent := b.deliverNotification(s, sub, &discordgo.MessageSend{Content: content})
+		b.trackSent(sub, sent, "")
+	}
+}
+
+// describeAFK explains an AFK setting to admins
+func (b *Bot) describeAFK(guildID string, setting afkSetting) string {
+	if setting.Notify {
+		return b.t(guildID, "afk.notify")
+	}
+	if setting.WentAFK {
+		return b.t(guildID, "afk.went-afk")
+	}
+	return b.t(guildID, "afk.excluded")
+}
+
+// handleConfigAFK sets how the guild's AFK channel is announced
+func (b *Bot) handleConfigAFK(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	setting := b.getAFKSetting(i.GuildID)
+	if len(options) == 0 {
+		note := ""
+		if guild, err := b.session.State.Guild(i.GuildID); err == nil && guild.AfkChannelID == "" {
+			note = "\n" + b.t(i.GuildID, "afk.no-channel")
+		}
+		respondEphemeral(s, i.Interaction, "ℹ️ "+b.describeAFK(i.GuildID, setting)+note)
+		return
+	}
+
+	if opt, ok := options["notify"]; ok {
+		setting.Notify = opt.BoolValue()
+	}
+	if opt, ok := options["went-afk"]; ok {
+		setting.WentAFK = opt.BoolValue()
+	}
+
+	b.mu.Lock()
+	if setting == (afkSetting{}) {
+		delete(b.afkSettings, i.GuildID)
+	} else {
+		b.afkSettings[i.GuildID] = setting
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("AFK channel handling changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "notify", setting.Notify, "went_afk", setting.WentAFK)
+
+	response := "✅ " + b.describeAFK(i.GuildID, setting)
+	if setting.Notify && setting.WentAFK {
+		response += "\n" + b.t(i.GuildID, "afk.went-afk-ignored")
+	}
+	respondEphemeral(s, i.Interaction, response)
+}
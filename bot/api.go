@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// apiServer exposes an authenticated HTTP API for managing the bot. It is
+	// served by Servers.
+	apiServer struct {
+		bot     *Bot
+		token   string
+		handler http.Handler
+	}
+
+	apiError struct {
+		Error string `json:"error"`
+	}
+
+	apiAdminChannel struct {
+		GuildId   string `json:"guild_id"`
+		ChannelId string `json:"channel_id"`
+	}
+)
+
+// newAPIServer creates the bot's API, or returns nil when no port is
+// configured. Federated events are accepted on it when federation is set up.
+func newAPIServer(b *Bot, cfg config.API, federationCfg config.Federation) *apiServer {
+	if cfg.Port == 0 {
+		return nil
+	}
+
+	a := &apiServer{
+		bot:   b,
+		token: cfg.Token,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", b.handleHealthz)
+	mux.HandleFunc("GET /api/subscriptions", a.auth(a.listSubscriptions))
+	mux.HandleFunc("POST /api/subscriptions", a.auth(a.createSubscription))
+	mux.HandleFunc("DELETE /api/subscriptions", a.auth(a.deleteSubscription))
+	mux.HandleFunc("GET /api/admin-channels", a.auth(a.listAdminChannels))
+	mux.HandleFunc("PUT /api/admin-channels/{guildID}", a.auth(a.putAdminChannel))
+	mux.HandleFunc("DELETE /api/admin-channels/{guildID}", a.auth(a.deleteAdminChannel))
+	mux.HandleFunc("GET /api/guilds/{guildID}/export", a.auth(a.exportGuild))
+	mux.HandleFunc("POST /api/guilds/{guildID}/import", a.auth(a.importGuild))
+	mux.HandleFunc("GET /api/guilds/{guildID}/debounce-stats", a.auth(a.debounceStats))
+	mux.HandleFunc("GET /api/guilds/{guildID}/subscription-health", a.auth(a.subscriptionHealth))
+	if federation := newFederationReceiver(b, federationCfg); federation != nil {
+		// Peers authenticate with their own token, not API_TOKEN
+		mux.HandleFunc("POST /api/federation/events", federation.handleEvent)
+		slog.Info("Accepting federated events", "peers", len(federation.peers), "channel_id", federation.channelID)
+	}
+
+	a.handler = mux
+	return a
+}
+
+// auth wraps a handler with bearer token authentication
+func (a *apiServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, apiError{Error: "unauthorized"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (a *apiServer) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Query().Get("guild_id")
+
+	subs := append([]subscription{}, a.bot.subscriptions.Filter(func(sub subscription) bool {
+		return guildID == "" || sub.GuildId == guildID
+	})...)
+
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].VoiceChannelId != subs[j].VoiceChannelId {
+			return subs[i].VoiceChannelId < subs[j].VoiceChannelId
+		}
+		return subs[i].TextChannelId < subs[j].TextChannelId
+	})
+
+	writeJSON(w, http.StatusOK, subs)
+}
+
+func (a *apiServer) createSubscription(w http.ResponseWriter, r *http.Request) {
+	var sub subscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid JSON body"})
+		return
+	}
+
+	if sub.VoiceChannelId == "" || sub.TextChannelId == "" || sub.GuildId == "" {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "voice_channel_id, text_channel_id and guild_id are required"})
+		return
+	}
+
+	// Make sure both channels exist and belong to the guild, so a token
+	// holder can't forward one server's activity into another
+	voice, err := a.bot.channel(a.bot.rest, sub.VoiceChannelId)
+	if err != nil || voice.GuildID != sub.GuildId || voice.Type != discordgo.ChannelTypeGuildVoice {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "voice_channel_id is not a voice channel in this guild"})
+		return
+	}
+	text, err := a.bot.channel(a.bot.rest, sub.TextChannelId)
+	if err != nil || text.GuildID != sub.GuildId || (text.Type != discordgo.ChannelTypeGuildText && text.Type != discordgo.ChannelTypeGuildNews) {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "text_channel_id is not a text channel in this guild"})
+		return
+	}
+
+	alreadySubscribed, err := a.bot.addSubscription(sub.VoiceChannelId, sub.TextChannelId, sub.GuildId)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, apiError{Error: err.Error()})
+		return
+	}
+	if alreadySubscribed {
+		writeJSON(w, http.StatusOK, sub)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+func (a *apiServer) deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	voiceChannelID := r.URL.Query().Get("voice_channel_id")
+	textChannelID := r.URL.Query().Get("text_channel_id")
+
+	if voiceChannelID == "" || textChannelID == "" {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "voice_channel_id and text_channel_id query parameters are required"})
+		return
+	}
+
+	if !a.bot.removeSubscription(voiceChannelID, textChannelID) {
+		writeJSON(w, http.StatusNotFound, apiError{Error: "subscription not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) listAdminChannels(w http.ResponseWriter, r *http.Request) {
+	a.bot.mu.RLock()
+	channels := []apiAdminChannel{}
+	for guildID, channelID := range a.bot.adminChannels {
+		channels = append(channels, apiAdminChannel{GuildId: guildID, ChannelId: channelID})
+	}
+	a.bot.mu.RUnlock()
+
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].GuildId < channels[j].GuildId
+	})
+
+	writeJSON(w, http.StatusOK, channels)
+}
+
+func (a *apiServer) putAdminChannel(w http.ResponseWriter, r *http.Request) {
+	var body apiAdminChannel
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ChannelId == "" {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "channel_id is required"})
+		return
+	}
+	body.GuildId = r.PathValue("guildID")
+
+	// Like subscriptions, the channel must be a text channel of the guild
+	channel, err := a.bot.channel(a.bot.rest, body.ChannelId)
+	if err != nil || channel.GuildID != body.GuildId || (channel.Type != discordgo.ChannelTypeGuildText && channel.Type != discordgo.ChannelTypeGuildNews) {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "channel_id is not a text channel in this guild"})
+		return
+	}
+
+	a.bot.setAdminChannel(body.GuildId, body.ChannelId)
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (a *apiServer) deleteAdminChannel(w http.ResponseWriter, r *http.Request) {
+	if !a.bot.removeAdminChannel(r.PathValue("guildID")) {
+		writeJSON(w, http.StatusNotFound, apiError{Error: "admin channel not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) exportGuild(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.bot.exportGuild(r.PathValue("guildID")))
+}
+
+func (a *apiServer) debounceStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.bot.debounceStats.get(r.PathValue("guildID")))
+}
+
+func (a *apiServer) subscriptionHealth(w http.ResponseWriter, r *http.Request) {
+	health := a.bot.subscriptionHealth(r.PathValue("guildID"))
+	if health == nil {
+		health = []SubscriptionHealth{}
+	}
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (a *apiServer) importGuild(w http.ResponseWriter, r *http.Request) {
+	var export GuildExport
+	if err := json.NewDecoder(r.Body).Decode(&export); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid JSON body"})
+		return
+	}
+
+	result, err := a.bot.importGuild(a.bot.rest, r.PathValue("guildID"), &export)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Error writing API response", "error", err)
+	}
+}
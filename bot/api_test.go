@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+// newAPITestBot returns a bot that knows guild 1 with voice channel 11 and
+// text channel 12, and guild 2 with text channel 22
+func newAPITestBot(t *testing.T) (*Bot, *apiServer) {
+	t.Helper()
+	b := newTestBot(t)
+	for _, guild := range []*discordgo.Guild{
+		{ID: "1", Channels: []*discordgo.Channel{
+			{ID: "11", GuildID: "1", Type: discordgo.ChannelTypeGuildVoice},
+			{ID: "12", GuildID: "1", Type: discordgo.ChannelTypeGuildText},
+		}},
+		{ID: "2", Channels: []*discordgo.Channel{
+			{ID: "22", GuildID: "2", Type: discordgo.ChannelTypeGuildText},
+		}},
+	} {
+		if err := b.session.State.GuildAdd(guild); err != nil {
+			t.Fatalf("GuildAdd: %v", err)
+		}
+	}
+	return b, newAPIServer(b, config.API{Port: 8080, Token: "secret"}, config.Federation{})
+}
+
+func TestCreateSubscriptionChecksTextChannelGuild(t *testing.T) {
+	b, api := newAPITestBot(t)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"text channel of another guild", `{"guild_id": "1", "voice_channel_id": "11", "text_channel_id": "22"}`, http.StatusBadRequest},
+		{"voice channel as text channel", `{"guild_id": "1", "voice_channel_id": "11", "text_channel_id": "11"}`, http.StatusBadRequest},
+		{"same guild", `{"guild_id": "1", "voice_channel_id": "11", "text_channel_id": "12"}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			api.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("POST %s = %d %s, want %d", tt.body, rec.Code, rec.Body, tt.want)
+			}
+		})
+	}
+
+	if subs := b.subscriptions.Channel("11"); len(subs) != 1 || subs[0].TextChannelId != "12" {
+		t.Errorf("subscriptions of the voice channel = %v, want only text channel 12", subs)
+	}
+}
+
+func TestPutAdminChannelChecksChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		want    int
+	}{
+		{"text channel of another guild", "22", http.StatusBadRequest},
+		{"voice channel", "11", http.StatusBadRequest},
+		{"unknown channel", "99", http.StatusBadRequest},
+		{"text channel of the guild", "12", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, api := newAPITestBot(t)
+			req := httptest.NewRequest(http.MethodPut, "/api/admin-channels/1", strings.NewReader(`{"channel_id": "`+tt.channel+`"}`))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			api.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("PUT channel %s = %d %s, want %d", tt.channel, rec.Code, rec.Body, tt.want)
+			}
+
+			want := ""
+			if tt.want == http.StatusOK {
+				want = tt.channel
+			}
+			if got := adminChannelOf(b, "1"); got != want {
+				t.Errorf("admin channel %q, want %q", got, want)
+			}
+		})
+	}
+}
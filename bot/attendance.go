@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxAttendanceLines limits how many attendees are listed in the message; the CSV has all of them
+const maxAttendanceLines = 25
+
+var manageEventsPermission int64 = discordgo.PermissionManageEvents
+
+type (
+	// attendee is one user's presence in a voice channel during a window
+	attendee struct {
+		UserId    string
+		FirstSeen time.Time
+		LastSeen  time.Time
+		Duration  time.Duration
+	}
+)
+
+// attendanceCommand returns the /attendance command definition
+func attendanceCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "attendance",
+		DefaultMemberPermissions: &manageEventsPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type:     discordgo.ApplicationCommandOptionString,
+				Name:     "since",
+				Required: true,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleAttendance(s DiscordSession, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	voiceChannelID := options["channel"].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	now := time.Now()
+	since, err := parseSince(options["since"].StringValue(), now)
+	if err != nil {
+		respondWithError(s, i.Interaction, "❌ "+b.errorText(i.GuildID, err))
+		return
+	}
+
+	attendees := b.attendance(i.GuildID, voiceChannelID, since, now)
+	if len(attendees) == 0 {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "attendance.empty", channelName, since.Unix()))
+		return
+	}
+
+	var lines []string
+	for idx, a := range attendees {
+		if idx == maxAttendanceLines {
+			lines = append(lines, b.t(i.GuildID, "attendance.more", len(attendees)-maxAttendanceLines))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("<@%s> — %s", a.UserId, formatDuration(a.Duration)))
+	}
+
+	csvData, err := b.attendanceCSV(s, i.GuildID, attendees)
+	if err != nil {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "attendance.failed"))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       b.t(i.GuildID, "attendance.title", channelName),
+					Description: strings.Join(lines, "\n"),
+					Color:       0x5865F2,
+					Footer: &discordgo.MessageEmbedFooter{
+						Text: b.t(i.GuildID, "attendance.footer", len(attendees), since.UTC().Format("2006-01-02 15:04")),
+					},
+				},
+			},
+			Files: []*discordgo.File{
+				{
+					Name:        fmt.Sprintf("attendance-%s-%s.csv", channelName, since.UTC().Format("20060102-1504")),
+					ContentType: "text/csv",
+					Reader:      bytes.NewReader(csvData),
+				},
+			},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+			Flags:           discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// attendance sums each user's time in a voice channel during [from, to),
+// longest attendance first
+func (b *Bot) attendance(guildID, voiceChannelID string, from, to time.Time) []attendee {
+	byUser := make(map[string]*attendee)
+	for _, session := range b.sessions.sessions(guildID, from, to) {
+		if session.ChannelId != voiceChannelID {
+			continue
+		}
+		duration := session.overlap(from, to)
+		if duration <= 0 {
+			continue
+		}
+
+		start, end := session.Start, session.End
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		a, exists := byUser[session.UserId]
+		if !exists {
+			a = &attendee{UserId: session.UserId, FirstSeen: start, LastSeen: end}
+			byUser[session.UserId] = a
+		}
+		if start.Before(a.FirstSeen) {
+			a.FirstSeen = start
+		}
+		if end.After(a.LastSeen) {
+			a.LastSeen = end
+		}
+		a.Duration += duration
+	}
+
+	attendees := make([]attendee, 0, len(byUser))
+	for _, a := range byUser {
+		attendees = append(attendees, *a)
+	}
+	slices.SortFunc(attendees, func(x, y attendee) int {
+		if x.Duration != y.Duration {
+			return int(y.Duration - x.Duration)
+		}
+		return strings.Compare(x.UserId, y.UserId)
+	})
+	return attendees
+}
+
+// attendanceCSV renders attendees as CSV with display names from the state cache
+func (b *Bot) attendanceCSV(s DiscordSession, guildID string, attendees []attendee) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"user_id", "name", "first_seen", "last_seen", "duration_minutes"})
+
+	for _, a := range attendees {
+		name := a.UserId
+		if member, err := b.session.State.Member(guildID, a.UserId); err == nil && member.User != nil {
+			name = getUsername(member)
+		}
+		writer.Write([]string{
+			a.UserId,
+			name,
+			a.FirstSeen.UTC().Format(time.RFC3339),
+			a.LastSeen.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(a.Duration.Minutes(), 'f', 1, 64),
+		})
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// parseSince parses a window start given as a duration before now or a UTC time
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return now.Add(-duration), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if !t.Before(now) {
+				return time.Time{}, newUserError("attendance.future", value)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, newUserError("attendance.invalid-since", value)
+}
+
+// formatDuration renders a duration as "1h 25m" or "40m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
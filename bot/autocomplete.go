@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxAutocompleteChoices is the most suggestions Discord shows
+const maxAutocompleteChoices = 25
+
+// voiceChannelAutocompleteOption returns the autocompleted voice channel option of
+// /subscribe and /unsubscribe. Channel options can't autocomplete, so the
+// value is a string: the ID of a suggestion, or a channel name typed as is.
+func voiceChannelAutocompleteOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "voice-channel",
+		Autocomplete: true,
+	}
+}
+
+// handleAutocomplete suggests values for the option being typed
+func (b *Bot) handleAutocomplete(s DiscordSession, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, opt := range data.Options {
+		if opt.Focused {
+			focused = opt
+		}
+	}
+	if focused == nil || focused.Name != "voice-channel" {
+		return
+	}
+
+	query := focused.StringValue()
+	var channels []*discordgo.Channel
+	switch data.Name {
+	case "subscribe":
+		channels = b.stateVoiceChannels(i.GuildID)
+	case "unsubscribe":
+		channels = b.subscribedVoiceChannels(i.GuildID, i.ChannelID)
+	default:
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: voiceChannelChoices(channels, query)},
+	})
+	if err != nil {
+		slog.Debug("Error answering autocomplete", "guild_id", i.GuildID, "error", err)
+	}
+}
+
+// stateVoiceChannels returns a guild's voice channels from the cache, in
+// channel list order
+func (b *Bot) stateVoiceChannels(guildID string) []*discordgo.Channel {
+	guild, err := b.session.State.Guild(guildID)
+	if err != nil {
+		return nil
+	}
+
+	b.session.State.RLock()
+	var channels []*discordgo.Channel
+	for _, channel := range guild.Channels {
+		if channel.Type == discordgo.ChannelTypeGuildVoice {
+			channels = append(channels, channel)
+		}
+	}
+	b.session.State.RUnlock()
+
+	slices.SortStableFunc(channels, func(a, c *discordgo.Channel) int { return a.Position - c.Position })
+	return channels
+}
+
+// subscribedVoiceChannels returns the voice channels a text channel is
+// subscribed to
+func (b *Bot) subscribedVoiceChannels(guildID, textChannelID string) []*discordgo.Channel {
+	var channels []*discordgo.Channel
+	for _, channel := range b.stateVoiceChannels(guildID) {
+		if _, ok := b.subscriptions.Get(channel.ID, textChannelID); ok {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// voiceChannelChoices returns the channels whose name contains query, names
+// starting with it first
+func voiceChannelChoices(channels []*discordgo.Channel, query string) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var prefixed, contained []*discordgo.Channel
+	for _, channel := range channels {
+		name := strings.ToLower(channel.Name)
+		switch {
+		case strings.HasPrefix(name, query):
+			prefixed = append(prefixed, channel)
+		case strings.Contains(name, query):
+			contained = append(contained, channel)
+		}
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	for _, channel := range append(prefixed, contained...) {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncateMessage("🔊 "+channel.Name, 100),
+			Value: channel.ID,
+		})
+	}
+	return choices
+}
+
+// resolveVoiceChannel returns the voice channel an autocompleted option refers
+// to: a suggestion's ID, or a name typed without picking a suggestion
+func (b *Bot) resolveVoiceChannel(s DiscordSession, guildID, value string) (string, error) {
+	if channel, err := b.channel(s, value); err == nil && channel.GuildID == guildID && channel.Type == discordgo.ChannelTypeGuildVoice {
+		return channel.ID, nil
+	}
+
+	name := strings.TrimPrefix(strings.TrimSpace(value), "🔊 ")
+	for _, channel := range b.stateVoiceChannels(guildID) {
+		if strings.EqualFold(channel.Name, name) {
+			return channel.ID, nil
+		}
+	}
+	return "", newUserError("subscribe.unknown-channel", truncateMessage(name, 100))
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// autoDeleteCheckInterval is how often expired notifications are deleted
+const autoDeleteCheckInterval = 30 * time.Second
+
+type (
+	// sentNotification is a notification message that will be deleted later
+	sentNotification struct {
+		TextChannelId  string    `json:"text_channel_id"`
+		MessageId      string    `json:"message_id"`
+		VoiceChannelId string    `json:"voice_channel_id"`
+		GuildId        string    `json:"guild_id,omitempty"`
+		UserId         string    `json:"user_id,omitempty"` // set for join notifications deleted on leave
+		DeleteAt       time.Time `json:"delete_at,omitzero"`
+	}
+)
+
+// autoDeleteCommand returns the /auto-delete command definition
+func autoDeleteCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "auto-delete",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionString,
+				Name: "after",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "on-leave",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleAutoDelete(s DiscordSession, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	var after time.Duration
+	if opt, ok := options["after"]; ok {
+		duration, err := time.ParseDuration(opt.StringValue())
+		if err != nil || duration < time.Minute {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "autodelete.too-short"))
+			return
+		}
+		after = duration
+	}
+	onLeave := false
+	if opt, ok := options["on-leave"]; ok {
+		onLeave = opt.BoolValue()
+	}
+
+	found := b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+		sub.DeleteAfter = ""
+		if after > 0 {
+			sub.DeleteAfter = after.String()
+		}
+		sub.DeleteOnLeave = onLeave
+	})
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.not-subscribed", channelName))
+		return
+	}
+
+	switch {
+	case after > 0 && onLeave:
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "autodelete.both", channelName, formatDuration(after)))
+	case after > 0:
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "autodelete.after", channelName, formatDuration(after)))
+	case onLeave:
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "autodelete.on-leave", channelName))
+	default:
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "autodelete.off", channelName))
+	}
+}
+
+// trackSent remembers a delivered notification if its subscription deletes them
+func (b *Bot) trackSent(sub subscription, message *discordgo.Message, userID string) {
+	if message == nil || (sub.DeleteAfter == "" && !sub.DeleteOnLeave) {
+		return
+	}
+
+	sent := sentNotification{
+		TextChannelId:  sub.TextChannelId,
+		MessageId:      message.ID,
+		VoiceChannelId: sub.VoiceChannelId,
+		GuildId:        sub.GuildId,
+	}
+	if after, err := time.ParseDuration(sub.DeleteAfter); err == nil && after > 0 {
+		sent.DeleteAt = time.Now().Add(after)
+	}
+	if sub.DeleteOnLeave {
+		sent.UserId = userID
+	}
+	if sent.DeleteAt.IsZero() && sent.UserId == "" {
+		return
+	}
+
+	b.mu.Lock()
+	b.sentNotifications = append(b.sentNotifications, sent)
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+}
+
+// deleteOnLeave deletes the join notifications of a user who left a voice channel
+func (b *Bot) deleteOnLeave(s DiscordSession, userID, voiceChannelID string) {
+	due := b.takeSentNotifications(func(sent sentNotification) bool {
+		return sent.UserId == userID && sent.VoiceChannelId == voiceChannelID
+	})
+	// Deletes need no ordering, so they don't hold up later voice event handlers
+	go deleteMessages(s, due)
+}
+
+// cleanupNotifications deletes expired notifications until ctx is done
+func (b *Bot) cleanupNotifications(ctx context.Context) {
+	ticker := time.NewTicker(autoDeleteCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		b.deleteSentNotifications(b.rest, func(sent sentNotification) bool {
+			return !sent.DeleteAt.IsZero() && !now.Before(sent.DeleteAt)
+		})
+	}
+}
+
+// deleteSentNotifications deletes and forgets every tracked message matching fn
+func (b *Bot) deleteSentNotifications(s DiscordSession, fn func(sent sentNotification) bool) {
+	deleteMessages(s, b.takeSentNotifications(fn))
+}
+
+// takeSentNotifications forgets and returns every tracked message matching fn
+func (b *Bot) takeSentNotifications(fn func(sent sentNotification) bool) []sentNotification {
+	b.mu.Lock()
+	var due []sentNotification
+	// A new slice, a copy of the old one may still be in use
+	remaining := make([]sentNotification, 0, len(b.sentNotifications))
+	for _, sent := range b.sentNotifications {
+		if fn(sent) {
+			due = append(due, sent)
+		} else {
+			remaining = append(remaining, sent)
+		}
+	}
+	b.sentNotifications = remaining
+	if len(due) > 0 {
+		b.savePersistedDataAsync()
+	}
+	b.mu.Unlock()
+	return due
+}
+
+// deleteMessages deletes notifications, ignoring ones that are already gone
+func deleteMessages(s DiscordSession, due []sentNotification) {
+	for _, sent := range due {
+		if err := s.ChannelMessageDelete(sent.TextChannelId, sent.MessageId); err != nil {
+			slog.Debug("Could not delete notification", "channel_id", sent.TextChannelId, "message_id", sent.MessageId, "error", err)
+		}
+	}
+}
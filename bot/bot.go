@@ -1,169 +1,475 @@
 package bot
 
 import (
+	"cmp"
+	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/CS-5/VoiceActivityBot/config"
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	// maxMessageLength is Discord's message content limit
+	maxMessageLength = 2000
+	// maxSelectOptions is Discord's limit of options in a select menu
+	maxSelectOptions = 25
+)
+
 type (
 	Bot struct {
-		session          *discordgo.Session
-		subscriptions    map[string][]subscription // key: voiceChannelID
-		mu               sync.RWMutex
-		registeredCmdIds map[string][]*discordgo.ApplicationCommand // guildID -> commands
-		debounceInterval time.Duration
-		debouncers       map[string]*debouncer // key: userID:channelID
-		debounceMu       sync.RWMutex
-		persistence      *Persistence
-		adminChannels    map[string]string // guildID -> channelID
+		session                 *discordgo.Session
+		rest                    DiscordSession // REST calls outside handlers, e.g. from background loops; session unless replaced with UseSession
+		subscriptions           *subscriptions // has its own lock, see subscriptions.go
+		mu                      sync.RWMutex
+		registeredCmdIds        map[string][]*discordgo.ApplicationCommand // guildID -> commands
+		debounceInterval        time.Duration
+		defaultDebounceStrategy string
+		debounceStrategies      map[string]string // guildID -> strategy, when not the default
+		plainTextGuilds         map[string]bool   // guildIDs that post without emoji
+		minimalGuilds           map[string]bool   // guildIDs that also post without markdown, see presentation.go
+		timestampGuilds         map[string]bool   // guildIDs that add Discord timestamps to notifications
+		tones                   map[string]string // guildID -> tone of built-in messages, when not casual
+		languages               map[string]string // guildID -> locale of built-in messages, when not English
+		catalog                 messageCatalog
+		afkSettings             map[string]afkSetting            // guildID -> AFK channel handling, when not the default
+		pauses                  map[string]notificationPause     // guildID -> paused notifications
+		patternSubscriptions    map[string][]patternSubscription // guildID -> voice channel name pattern subscriptions
+		groupWindows            map[string]map[string]string     // guildID -> textChannelID -> window of grouped notifications
+		notificationGroups      *notificationGroups
+		digests                 map[string]voiceDigest // guildID -> scheduled digest
+		debouncers              map[string]*debouncer  // key: userID:channelID:strategy
+		debounceMu              sync.RWMutex
+		debounceStats           *debounceStatsStore
+		eventRates              *eventRates                // decides which guilds skip debouncing
+		voiceBus                voiceBus                   // hands normalized voice events to consumers
+		externals               map[string]*externalBridge // provider -> Slack and Telegram forwarding
+		eventWebhook            *eventWebhook              // outbound voice event stream, nil when disabled
+		eventHistory            *eventHistory              // time-series event store, nil when disabled
+		cache                   *entityCache               // channels and members the state cache doesn't have
+		deliveryStats           *deliveryStatsStore
+		name                    string // the instance's name, empty for a single bot
+		persistence             Store
+		adminChannels           map[string]string            // guildID -> channelID
+		watchlist               map[string][]string          // guildID -> voiceChannelIDs
+		templates               map[string]map[string]string // guildID -> event -> template
+		goals                   map[string]*voiceGoal        // guildID -> goal
+		ignored                 map[string][]string          // guildID -> userIDs that are never announced
+		optOuts                 map[string][]string          // guildID -> userIDs that are neither announced nor recorded
+		logChannels             map[string]string            // guildID -> session log channelID
+		fallbackChannels        map[string]string            // guildID -> channelID that takes over subscriptions of deleted channels
+		sentNotifications       []sentNotification           // messages awaiting auto-delete
+		follows                 []follow
+		subscribeAccess         map[string]subscribeAccess // guildID -> who may subscribe, default Manage Channels
+		sessions                *sessionStore
+		api                     *apiServer
+		dashboard               *dashboardServer
+		shard                   *shardInfo // nil when running unsharded
+		lastSave                time.Time
+		lastSaveErr             error
+		healthMu                sync.Mutex
+		pendingImports          []*GuildExport // from IMPORT_FILE, applied once the guild is available
+		notificationMode        string
+		summaryWindow           time.Duration
+		summaries               map[string]*summaryBuffer // key: voiceChannelID
+		summaryMu               sync.Mutex
+		occupancy               *occupancy
+		voiceChannels           *voiceChannels // last known channel per user, see replay.go
+		threadButton            bool           // attach "Open chat thread" to session-start notifications
+		reminderDelay           time.Duration  // attach "Remind me" to session-start notifications, 0 when off
+		reminders               *reminderScheduler
+		quietQueues             map[string]*quietQueue // key: voiceChannelID:textChannelID
+		quietMu                 sync.Mutex
+		webhookDelivery         bool
+		moveNotifications       bool // announce channel switches as one "moved from X to Y" message
+		fastStart               bool // keep commands across restarts and register them late, see faststart.go
+		startedAt               time.Time
+		registerMu              sync.Mutex                  // deferred command registrations run one at a time
+		channelRecreateGrace    time.Duration               // how long a deleted voice channel's subscriptions wait for a recreated channel
+		deletedChannels         map[string][]deletedChannel // guildID -> recently deleted voice channels
+		deletedMu               sync.Mutex
+		webhooks                map[string]*discordgo.Webhook // key: textChannelID
+		webhookMu               sync.Mutex
+		permissionRecheck       time.Duration
+		ctx                     context.Context // canceled on Stop to end background loops
+		cancel                  context.CancelFunc
+		saver                   *saver
+		rateLimiter             *rateLimiter
+		statusBoardTimers       map[string]*time.Timer // key: voiceChannelID
+		statusBoardMu           sync.Mutex
+		userCooldown            *userCooldown
+		rejoinCooldown          *userCooldown // keyed by voice channel and user
+		seenInteractions        *userCooldown // interaction IDs already handled, see idempotency.go
+		stateCooldown           *userCooldown // mute and stream announcements per channel and user, see eventmask.go
+		retries                 *retryQueue
+		subscriptionLimits      subscriptionLimits
+		eventSelections         *eventSelections
+		moderation              *moderation   // content filters applied before delivery
+		mentionCooldown         *userCooldown // keyed by text channel and mentioned role or user
+		duplicateAction         string
+		archiveDir              string      // where removed guilds' exports are kept, empty when off
+		standingDown            atomic.Bool // set when another instance was detected and we backed off
+		lastDuplicateAlert      time.Time
+		duplicateMu             sync.Mutex
 	}
 
 	subscription struct {
-		VoiceChannelId string `json:"voice_channel_id"`
-		TextChannelId  string `json:"text_channel_id"`
-		GuildId        string `json:"guild_id"`
+		VoiceChannelId   string           `json:"voice_channel_id"`
+		TextChannelId    string           `json:"text_channel_id"`   // the DM channel for DM subscriptions
+		UserId           string           `json:"user_id,omitempty"` // set for DM subscriptions, see dm.go
+		GuildId          string           `json:"guild_id"`
+		QuietHours       *quietHours      `json:"quiet_hours,omitempty"`
+		WebhookName      string           `json:"webhook_name,omitempty"`
+		WebhookAvatarURL string           `json:"webhook_avatar_url,omitempty"`
+		Broken           string           `json:"broken,omitempty"` // why delivery is paused, empty when healthy
+		StatusBoard      bool             `json:"status_board,omitempty"`
+		StatusMessageId  string           `json:"status_message_id,omitempty"`
+		MinUsers         int              `json:"min_users,omitempty"`    // notify only when this many users are present
+		DeleteAfter      string           `json:"delete_after,omitempty"` // duration after which notifications are deleted
+		DeleteOnLeave    bool             `json:"delete_on_leave,omitempty"`
+		Style            string           `json:"style,omitempty"` // how notifications look, see styles.go
+		MentionRoleIds   []string         `json:"mention_role_ids,omitempty"`
+		MentionUserIds   []string         `json:"mention_user_ids,omitempty"`
+		EventUntil       time.Time        `json:"event_until,omitzero"`        // full-detail announcements until then, see eventmode.go
+		Events           eventMask        `json:"events,omitzero"`             // which events are announced, see eventmask.go
+		MaxLength        int              `json:"max_length,omitempty"`        // message content limit, 0 for Discord's, see truncation.go
+		LastFiredAt      time.Time        `json:"last_fired_at,omitzero"`      // last delivered notification, see usage.go
+		Externals        []externalTarget `json:"externals,omitempty"`         // Slack and Telegram forwards, see external.go
+		Label            string           `json:"label,omitempty"`             // admin note like "raid team pings", see labels.go
+		DebounceStrategy string           `json:"debounce_strategy,omitempty"` // overrides the guild's strategy, see debounce.go
+		Pattern          string           `json:"-"`                           // set when derived from a name pattern, see patterns.go
 	}
 
 	debouncer struct {
-		timer   *time.Timer
-		message string
-		mu      sync.Mutex
+		guildID      string
+		userID       string
+		channelID    string
+		strategy     string // the subscriptions it notifies are debounced with this strategy
+		timer        *time.Timer
+		message      string
+		sessionStart bool
+		fromChannel  string
+		pending      bool // an event is waiting to be sent
+		open         bool // a debounce window is running
+		mu           sync.Mutex
+	}
+
+	// notification is a message to fan out to a voice channel's subscribers
+	notification struct {
+		content       string
+		sessionStart  bool   // the user started a session in an empty channel
+		userID        string // the user a join notification is about, empty for summaries
+		fromChannelID string // the previous channel of a move, whose subscribers are notified too
+		strategy      string // only subscriptions debounced with this strategy are notified, empty for all
 	}
 )
 
-func NewBot(token string) (*Bot, error) {
-	dg, err := discordgo.New("Bot " + token)
+func NewBot(cfg *config.Config) (*Bot, error) {
+	dg, err := discordgo.New("Bot " + cfg.Token)
 	if err != nil {
 		return nil, err
 	}
-	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildVoiceStates
-
-	// Get debounce interval from environment or use default
-	debounceInterval := 3 * time.Second // Default 3 seconds
-	if envInterval := os.Getenv("DEBOUNCE_INTERVAL"); envInterval != "" {
-		if duration, err := time.ParseDuration(envInterval); err == nil {
-			debounceInterval = duration
-		} else {
-			log.Printf("Invalid DEBOUNCE_INTERVAL value '%s', using default 3s", envInterval)
-		}
+	configureGateway(dg, cfg.Gateway)
+	shard, err := configureSharding(dg, cfg.Gateway)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get persistence file path from environment or use default
-	persistenceFile := os.Getenv("PERSISTENCE_FILE")
-	if persistenceFile == "" {
-		persistenceFile = "subscriptions.json"
+	store, err := newStore(cfg.Storage)
+	if err != nil {
+		return nil, err
 	}
 
 	bot := &Bot{
-		session:          dg,
-		subscriptions:    make(map[string][]subscription),
-		registeredCmdIds: make(map[string][]*discordgo.ApplicationCommand),
-		debounceInterval: debounceInterval,
-		debouncers:       make(map[string]*debouncer),
-		persistence:      NewPersistence(persistenceFile),
-		adminChannels:    make(map[string]string),
+		session:                 dg,
+		rest:                    dg,
+		shard:                   shard,
+		registeredCmdIds:        make(map[string][]*discordgo.ApplicationCommand),
+		debounceInterval:        cfg.Debounce.Interval,
+		defaultDebounceStrategy: cfg.Debounce.Strategy,
+		debounceStrategies:      make(map[string]string),
+		plainTextGuilds:         make(map[string]bool),
+		minimalGuilds:           make(map[string]bool),
+		timestampGuilds:         make(map[string]bool),
+		tones:                   make(map[string]string),
+		languages:               make(map[string]string),
+		catalog:                 loadCatalog(cfg.LocalesDir),
+		afkSettings:             make(map[string]afkSetting),
+		pauses:                  make(map[string]notificationPause),
+		patternSubscriptions:    make(map[string][]patternSubscription),
+		groupWindows:            make(map[string]map[string]string),
+		notificationGroups:      newNotificationGroups(),
+		externals:               newExternalBridges(cfg.TelegramToken),
+		eventWebhook:            newEventWebhook(cfg.EventWebhook, cfg.Name),
+		eventHistory:            newEventHistory(cfg.EventHistory),
+		cache:                   newEntityCache(cfg.Gateway.CacheTTL),
+		digests:                 make(map[string]voiceDigest),
+		debouncers:              make(map[string]*debouncer),
+		debounceStats:           newDebounceStatsStore(),
+		eventRates:              newEventRates(cfg.Debounce.FastPath),
+		deliveryStats:           newDeliveryStatsStore(),
+		name:                    cfg.Name,
+		persistence:             store,
+		adminChannels:           make(map[string]string),
+		watchlist:               make(map[string][]string),
+		templates:               make(map[string]map[string]string),
+		goals:                   make(map[string]*voiceGoal),
+		ignored:                 make(map[string][]string),
+		optOuts:                 make(map[string][]string),
+		logChannels:             make(map[string]string),
+		fallbackChannels:        make(map[string]string),
+		subscribeAccess:         make(map[string]subscribeAccess),
+		sessions:                newSessionStore(cfg.Storage),
+		pendingImports:          loadImportFile(cfg.Storage.ImportFile),
+		notificationMode:        cfg.Notifications.Mode,
+		summaryWindow:           cfg.Notifications.SummaryWindow,
+		summaries:               make(map[string]*summaryBuffer),
+		occupancy:               newOccupancy(),
+		voiceChannels:           newVoiceChannels(),
+		threadButton:            cfg.Notifications.ThreadButton,
+		reminderDelay:           cfg.Notifications.ReminderDelay,
+		reminders:               &reminderScheduler{},
+		quietQueues:             make(map[string]*quietQueue),
+		webhookDelivery:         cfg.Notifications.WebhookDelivery,
+		moveNotifications:       cfg.Notifications.Moves,
+		fastStart:               cfg.Gateway.FastStart,
+		channelRecreateGrace:    cfg.Notifications.ChannelRecreateGrace,
+		deletedChannels:         make(map[string][]deletedChannel),
+		webhooks:                make(map[string]*discordgo.Webhook),
+		permissionRecheck:       cfg.Notifications.PermissionRecheck,
+		rateLimiter:             newRateLimiter(cfg.Notifications.RateLimit),
+		statusBoardTimers:       make(map[string]*time.Timer),
+		userCooldown:            newUserCooldown(cfg.Notifications.UserCooldown),
+		rejoinCooldown:          newUserCooldown(cfg.Notifications.RejoinCooldown),
+		seenInteractions:        newUserCooldown(interactionReplayWindow),
+		stateCooldown:           newUserCooldown(time.Minute),
+		retries:                 newRetryQueue(cfg.Notifications),
+		subscriptionLimits:      subscriptionLimits{PerGuild: cfg.Limits.GuildSubscriptions, PerVoiceChannel: cfg.Limits.ChannelSubscriptions},
+		saver:                   newSaver(cfg.Storage.SaveInterval),
+		eventSelections:         newEventSelections(),
+		moderation:              newModeration(cfg.Moderation),
+		mentionCooldown:         newUserCooldown(cfg.Notifications.MentionCooldown),
+		duplicateAction:         cfg.Gateway.DuplicateAction,
+		archiveDir:              cfg.Storage.ArchiveDir,
 	}
+	bot.subscriptions = newSubscriptions(bot.savePersistedDataAsync)
+	bot.ctx, bot.cancel = context.WithCancel(context.Background())
+	bot.watchForDuplicates(dg)
 
 	// Load persisted data
 	if err := bot.loadPersistedData(); err != nil {
-		log.Printf("Warning: Failed to load persisted data: %v", err)
+		slog.Warn("Failed to load persisted data", "error", err)
 	}
 
-	// Load admin channels from environment variable
-	bot.loadAdminChannelsFromEnv()
+	// Pre-configured admin channels override persisted ones
+	bot.loadAdminChannels(cfg.AdminChannels)
 
-	// Ready handler registers commands in the bot's guilds
-	dg.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
-		log.Printf("Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
-		for _, guild := range r.Guilds {
-			bot.registerCommands(s, guild.ID)
-		}
-	})
+	// Optional HTTP API for managing subscriptions, served by Servers with
+	// the other bots of the process
+	bot.api = newAPIServer(bot, cfg.API, cfg.Federation)
+	bot.dashboard = newDashboardServer(bot, cfg.Dashboard)
 
-	// Voice state update handler (Notified when user joins or moves voice channels)
-	dg.AddHandler(func(s *discordgo.Session, vsu *discordgo.VoiceStateUpdate) {
-		bot.voiceStateUpdate(s, vsu)
-	})
+	bot.registerVoiceConsumers()
 
-	// Interaction create handler (Handles slash commands and component interactions)
-	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		bot.interactionCreate(s, i)
+	// Gateway events are passed to their handlers by dispatch
+	dg.AddHandler(func(s *discordgo.Session, event any) {
+		bot.dispatch(s, event)
 	})
 
 	return bot, nil
 }
 
-func (b *Bot) Start() error {
-	return b.session.Open()
+func (b *Bot) Start(ctx context.Context) error {
+	b.ctx, b.cancel = context.WithCancel(ctx)
+	b.startedAt = time.Now()
+
+	if err := b.session.Open(); err != nil {
+		return err
+	}
+
+	if b.dashboard != nil {
+		b.dashboard.start()
+	}
+
+	go b.runSaver(b.ctx)
+	if store, ok := b.persistence.(guildStore); ok {
+		go store.watch(b.ctx, b.reloadGuilds)
+	}
+	go b.recheckBrokenSubscriptions(b.ctx, b.permissionRecheck)
+	go b.cleanupNotifications(b.ctx)
+	go b.runDigests(b.ctx)
+	go b.runReminders(b.ctx)
+	go b.runEventModes(b.ctx)
+	go b.runPauses(b.ctx)
+	for _, bridge := range b.externals {
+		go bridge.run(b.ctx)
+	}
+	if b.eventWebhook != nil {
+		b.eventWebhook.start(b.ctx)
+	}
+	if b.eventHistory != nil {
+		go b.eventHistory.run(b.ctx)
+	}
+	return nil
 }
 
-func (b *Bot) Stop() {
-	// Save subscriptions before shutting down
-	if err := b.savePersistedData(); err != nil {
-		log.Printf("Error saving persisted data: %v", err)
+// Stop shuts the bot down: pending notifications and event history are
+// flushed, unsaved changes are written, and commands are unregistered. Steps that don't finish before
+// ctx is done are skipped and ctx's error is returned.
+func (b *Bot) Stop(ctx context.Context) error {
+	b.cancel()
+
+	if b.dashboard != nil {
+		b.dashboard.stop(ctx)
 	}
 
-	// Unregister all commands from all guilds
-	for guildId, commands := range b.registeredCmdIds {
+	// Summaries and debounced events may still be grouped or queued below
+	b.drainDebouncers(ctx)
+	b.drainSummaries(ctx)
+	b.drainNotificationGroups(ctx)
+	b.drainQuietQueues(ctx)
+	if b.eventWebhook != nil {
+		b.eventWebhook.drain(ctx)
+	}
+	if b.eventHistory != nil {
+		b.eventHistory.flush(ctx)
+	}
+
+	// Write changes made since the last interval, waiting for a write in progress
+	if err := b.flushSaves(); err != nil {
+		slog.Error("Error saving persisted data", "error", err)
+	}
+
+	// Unregister all commands from all guilds, unless they are kept for the next start
+	b.mu.RLock()
+	registered := maps.Clone(b.registeredCmdIds)
+	b.mu.RUnlock()
+	if b.fastStart {
+		registered = nil
+	}
+	for guildId, commands := range registered {
 		for _, cmd := range commands {
-			err := b.session.ApplicationCommandDelete(b.session.State.User.ID, guildId, cmd.ID)
+			if ctx.Err() != nil {
+				break
+			}
+			err := b.rest.ApplicationCommandDelete(b.session.State.User.ID, guildId, cmd.ID, discordgo.WithContext(ctx))
 			if err != nil {
-				log.Printf("Failed to delete command %v in guild %v: %v", cmd.Name, guildId, err)
+				slog.Error("Failed to delete command", "command", cmd.Name, "guild_id", guildId, "error", err)
 			}
 		}
 	}
 
 	b.session.Close()
+
+	if closer, ok := b.persistence.(interface{ Close() }); ok {
+		closer.Close()
+	}
+	return ctx.Err()
 }
 
-func (b *Bot) registerCommands(s *discordgo.Session, guildId string) {
+// drainDebouncers stops all debounce timers and sends their pending
+// notifications while ctx allows, dropping the rest
+func (b *Bot) drainDebouncers(ctx context.Context) {
+	b.debounceMu.Lock()
+	debouncers := b.debouncers
+	b.debouncers = make(map[string]*debouncer)
+	b.debounceMu.Unlock()
+
+	dropped := 0
+	for _, deb := range debouncers {
+		deb.mu.Lock()
+		pending := deb.timer != nil && deb.timer.Stop() && deb.pending
+		final := notification{content: deb.message, sessionStart: deb.sessionStart, userID: deb.userID, fromChannelID: deb.fromChannel, strategy: deb.strategy}
+		deb.mu.Unlock()
+
+		if !pending {
+			continue
+		}
+		if ctx.Err() != nil {
+			dropped++
+			continue
+		}
+		if b.userCooldown.allow(b.debounceScope(deb.guildID, deb.guildID, final), deb.userID) {
+			b.sendNotifications(b.rest, deb.channelID, final)
+		}
+	}
+
+	if dropped > 0 {
+		slog.Warn("Dropped pending notifications on shutdown", "count", dropped)
+	}
+}
+
+// commandDefinitions returns every slash command the bot registers
+func commandDefinitions() []*discordgo.ApplicationCommand {
 	commands := []*discordgo.ApplicationCommand{
 		{
-			Name:        "subscribe",
-			Description: "Subscribe to voice channel notifications",
+			Name: "subscribe",
 			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionChannel,
-					Name:        "voice-channel",
-					Description: "The voice channel to monitor",
-					Required:    false,
-					ChannelTypes: []discordgo.ChannelType{
-						discordgo.ChannelTypeGuildVoice,
-					},
-				},
+				voiceChannelAutocompleteOption(),
+				labelOption(),
+			},
+		},
+		{
+			Name: "unsubscribe",
+			Options: []*discordgo.ApplicationCommandOption{
+				voiceChannelAutocompleteOption(),
 			},
 		},
 		{
-			Name:        "unsubscribe",
-			Description: "Unsubscribe from voice channel notifications",
+			Name: "list-subscriptions",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionChannel,
-					Name:        "voice-channel",
-					Description: "The voice channel to stop monitoring",
-					Required:    false,
-					ChannelTypes: []discordgo.ChannelType{
-						discordgo.ChannelTypeGuildVoice,
+					Type: discordgo.ApplicationCommandOptionString,
+					Name: "sort",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Value: listSortName},
+						{Value: listSortLastFired},
 					},
 				},
+				{
+					Type:     discordgo.ApplicationCommandOptionInteger,
+					Name:     "unused-days",
+					MinValue: &[]float64{1}[0],
+					MaxValue: 365,
+				},
 			},
 		},
-		{
-			Name:        "list-subscriptions",
-			Description: "List all voice channel subscriptions (admin channel only)",
-		},
 	}
+	commands = append(commands, unsubscribeAllCommand(), setAdminChannelCommand())
+	commands = append(commands, watchlistCommands()...)
+	commands = append(commands, ignoreCommands()...)
+	commands = append(commands, pauseCommands()...)
+	commands = append(commands, externalCommand())
+	commands = append(commands, patternCommands()...)
+	commands = append(commands, groupNotificationsCommand())
+	commands = append(commands, resetBotCommand())
+	commands = append(commands, privacyCommand())
+	commands = append(commands, followCommands()...)
+	commands = append(commands, dmSubscriptionCommands()...)
+	commands = append(commands, exportImportCommands()...)
+	commands = append(commands, digestCommand(), subscriptionHealthCommand())
+	commands = append(commands, previewFormatsCommand(), configCommand())
+	commands = append(commands, quietHoursCommand(), subscriptionSettingsCommand(), templateCommand(), goalCommand(), statusBoardCommand(), attendanceCommand(), heatmapCommand(), leaderboardCommand(), minUsersCommand(), debounceStatsCommand(), logChannelCommand())
+	commands = append(commands, refreshCommandsCommand(), autoDeleteCommand(), mentionsCommand(), eventModeCommand(), statusCommand(), sessionEventsCommand(), whoIsInCommand())
+	commands = append(commands, helpCommand())
+	return commands
+}
+
+func (b *Bot) registerCommands(s DiscordSession, guildId string) {
+	commands := b.localizeCommands(commandDefinitions())
 
 	for _, cmd := range commands {
-		registeredCmd, err := s.ApplicationCommandCreate(s.State.User.ID, guildId, cmd)
+		registeredCmd, err := s.ApplicationCommandCreate(b.session.State.User.ID, guildId, cmd)
 		if err != nil {
-			log.Printf("Cannot create '%v' command in guild %v: %v", cmd.Name, guildId, err)
+			slog.Error("Cannot create command", "command", cmd.Name, "guild_id", guildId, "error", err)
 		} else {
 			// Store registered command IDs for cleanup
 			b.mu.Lock()
@@ -173,8 +479,19 @@ func (b *Bot) registerCommands(s *discordgo.Session, guildId string) {
 	}
 }
 
-func (b *Bot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) interactionCreate(s DiscordSession, i *discordgo.InteractionCreate) {
+	if b.standingDown.Load() {
+		return
+	}
+
+	// Autocomplete requests are read-only and answered on every keystroke
+	if i.Type != discordgo.InteractionApplicationCommandAutocomplete && !b.firstDelivery(i) {
+		return
+	}
+
 	switch i.Type {
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		b.handleAutocomplete(s, i)
 	case discordgo.InteractionApplicationCommand:
 		data := i.ApplicationCommandData()
 
@@ -183,14 +500,118 @@ func (b *Bot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCr
 			b.handleSubscribe(s, i)
 		case "unsubscribe":
 			b.handleUnsubscribe(s, i)
+		case "unsubscribe-all":
+			b.handleUnsubscribeAll(s, i)
+		case "help":
+			b.handleHelp(s, i)
 		case "list-subscriptions":
 			b.handleListSubscriptions(s, i)
+		case "set-admin-channel":
+			b.handleSetAdminChannel(s, i)
+		case "watch":
+			b.handleWatch(s, i, true)
+		case "unwatch":
+			b.handleWatch(s, i, false)
+		case "watchlist":
+			b.handleWatchlist(s, i)
+		case "quiet-hours":
+			b.handleQuietHours(s, i)
+		case "config":
+			b.handleConfig(s, i)
+		case "preview-formats":
+			b.handlePreviewFormats(s, i)
+		case "subscription-settings":
+			b.handleSubscriptionSettings(s, i)
+		case "template":
+			b.handleTemplate(s, i)
+		case "goal":
+			b.handleGoal(s, i)
+		case "status-board":
+			b.handleStatusBoard(s, i)
+		case "attendance":
+			b.handleAttendance(s, i)
+		case "activity-heatmap":
+			b.handleActivityHeatmap(s, i)
+		case "voice-leaderboard":
+			b.handleVoiceLeaderboard(s, i)
+		case "pause-notifications":
+			b.handlePauseNotifications(s, i)
+		case "resume-notifications":
+			b.handleResumeNotifications(s, i)
+		case "subscribe-external":
+			b.handleSubscribeExternal(s, i)
+		case "subscribe-pattern":
+			b.handleSubscribePattern(s, i)
+		case "unsubscribe-pattern":
+			b.handleUnsubscribePattern(s, i)
+		case "group-notifications":
+			b.handleGroupNotifications(s, i)
+		case "reset-bot":
+			b.handleResetBot(s, i)
+		case "privacy":
+			b.handlePrivacy(s, i)
+		case "min-users":
+			b.handleMinUsers(s, i)
+		case "mentions":
+			b.handleMentions(s, i)
+		case "session-events":
+			b.handleSessionEvents(s, i)
+		case "who-is-in":
+			b.handleWhoIsIn(s, i)
+		case "status":
+			b.handleStatus(s, i)
+		case "event-mode":
+			b.handleEventMode(s, i)
+		case "debounce-stats":
+			b.handleDebounceStats(s, i)
+		case "log-channel":
+			b.handleLogChannel(s, i)
+		case "refresh-commands":
+			b.handleRefreshCommands(s, i)
+		case "auto-delete":
+			b.handleAutoDelete(s, i)
+		case "subscription-health":
+			b.handleSubscriptionHealth(s, i)
+		case "digest":
+			b.handleDigest(s, i)
+		case "export-subscriptions":
+			b.handleExportSubscriptions(s, i)
+		case "import-subscriptions":
+			b.handleImportSubscriptions(s, i)
+		case "subscribe-dm":
+			b.handleSubscribeDM(s, i)
+		case "unsubscribe-dm":
+			b.handleUnsubscribeDM(s, i)
+		case "follow":
+			b.handleFollow(s, i)
+		case "unfollow":
+			b.handleUnfollow(s, i)
+		case "ignore-user":
+			b.handleIgnoreUser(s, i, true)
+		case "unignore-user":
+			b.handleIgnoreUser(s, i, false)
+		case "announce":
+			b.handleAnnounce(s, i)
 		}
 	case discordgo.InteractionMessageComponent:
 		data := i.MessageComponentData()
 
 		if strings.HasPrefix(data.CustomID, "remove_sub:") {
 			b.handleRemoveSubscriptionButton(s, i)
+		} else if strings.HasPrefix(data.CustomID, "open_thread:") {
+			b.handleOpenThreadButton(s, i)
+		} else if strings.HasPrefix(data.CustomID, "subscribe_page:") {
+			b.handleSubscribePageButton(s, i)
+		} else if strings.HasPrefix(data.CustomID, "unsubscribe_page:") {
+			b.handleUnsubscribePageButton(s, i)
+		} else if strings.HasPrefix(data.CustomID, "list_page:") {
+			b.handleListPageButton(s, i)
+		} else if strings.HasPrefix(data.CustomID, "leaderboard_page:") {
+			b.handleLeaderboardPageButton(s, i)
+		} else if strings.HasPrefix(data.CustomID, "remind_me:") {
+			b.handleRemindMeButton(s, i)
+		} else if strings.HasPrefix(data.CustomID, "subscribe_events:") {
+			b.handleEventSelect(s, i)
 		} else {
 			switch data.CustomID {
 			case "subscribe_channel_select":
@@ -201,99 +622,202 @@ func (b *Bot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCr
 				b.handleManageSubscriptionSelect(s, i)
 			case "back_to_subscription_list":
 				b.handleBackToSubscriptionList(s, i)
+			case "setup_admin_channel", "setup_use_current":
+				b.handleSetupAdminChannel(s, i)
+			case "reset_bot_confirm", "reset_bot_cancel":
+				b.handleResetBotButton(s, i)
+			case "unsubscribe_all_confirm", "unsubscribe_all_cancel":
+				b.handleUnsubscribeAllButton(s, i)
+			case "help_topic":
+				b.handleHelpTopicSelect(s, i)
+			case "help_subscribe", "help_unsubscribe":
+				b.handleHelpButton(s, i)
 			}
 		}
+	case discordgo.InteractionModalSubmit:
+		data := i.ModalSubmitData()
+
+		if strings.HasPrefix(data.CustomID, "subscription_settings:") {
+			b.handleSubscriptionSettingsSubmit(s, i)
+		}
 	}
 }
 
-func (b *Bot) handleSubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
+func (b *Bot) handleSubscribe(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	options := optionMap(i.ApplicationCommandData().Options)
 
 	// Get the text channel where the command was issued
 	textChannelID := i.ChannelID
 	guildID := i.GuildID
 
 	// Check if a voice channel was provided
-	if len(options) == 0 {
+	channelOpt, ok := options["voice-channel"]
+	if !ok {
+		if _, labeled := options["label"]; labeled {
+			respondWithError(s, i.Interaction, b.t(guildID, "subscribe.label-without-channel"))
+			return
+		}
 		// No voice channel provided - show selection dialog
 		b.handleSubscribeWithDialog(s, i)
 		return
 	}
 
 	// Voice channel was provided
-	voiceChannelID := options[0].ChannelValue(s).ID
-	alreadySubscribed := b.addSubscription(voiceChannelID, textChannelID, guildID)
+	voiceChannelID, err := b.resolveVoiceChannel(s, guildID, channelOpt.StringValue())
+	if err != nil {
+		respondWithError(s, i.Interaction, b.errorText(guildID, err))
+		return
+	}
+	alreadySubscribed, err := b.addSubscription(voiceChannelID, textChannelID, guildID)
+	if err != nil {
+		respondWithError(s, i.Interaction, b.t(guildID, "subscribe.failed", b.getChannelName(s, voiceChannelID), b.errorText(guildID, err)))
+		return
+	}
 
-	responseText := b.formatSubscribeResponse(s, voiceChannelID, alreadySubscribed)
+	responseText := b.formatSubscribeResponse(s, i.GuildID, voiceChannelID, alreadySubscribed)
+	if opt, ok := options["label"]; ok {
+		label := cleanLabel(opt.StringValue())
+		b.setLabel(voiceChannelID, textChannelID, label)
+		if label != "" {
+			responseText += "\n" + b.t(guildID, "subscribe.labeled", label)
+		}
+	}
+	var components []discordgo.MessageComponent
+	if !alreadySubscribed {
+		// Second step: choose which events to receive
+		components = b.eventSelectComponents(guildID, textChannelID, []string{voiceChannelID}, 0)
+	}
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: responseText,
-			Flags:   discordgo.MessageFlagsEphemeral,
+			Content:    responseText,
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
 		},
 	})
 }
 
-func (b *Bot) handleSubscribeWithDialog(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleSubscribeWithDialog(s DiscordSession, i *discordgo.InteractionCreate) {
+	b.respondSubscribeDialog(s, i, discordgo.InteractionResponseChannelMessageWithSource, 0)
+}
+
+// handleSubscribePageButton switches the subscribe dialog to another page
+func (b *Bot) handleSubscribePageButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	page, _ := strconv.Atoi(strings.TrimPrefix(i.MessageComponentData().CustomID, "subscribe_page:"))
+	b.respondSubscribeDialog(s, i, discordgo.InteractionResponseUpdateMessage, page)
+}
+
+// respondSubscribeDialog shows one page of the voice channel multi-select.
+// Select menus hold at most 25 options, so larger servers get page buttons.
+func (b *Bot) respondSubscribeDialog(s DiscordSession, i *discordgo.InteractionCreate, responseType discordgo.InteractionResponseType, page int) {
 	guildID := i.GuildID
 
 	// Get all voice channels in the guild
 	channels, err := s.GuildChannels(guildID)
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Type: responseType,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Error fetching channels",
+				Content: b.t(guildID, "subscribe.channels-failed"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
 		return
 	}
 
-	// Filter voice channels and create select menu options
-	var options []discordgo.SelectMenuOption
+	var voiceChannels []*discordgo.Channel
 	for _, channel := range channels {
 		if channel.Type == discordgo.ChannelTypeGuildVoice {
-			options = append(options, discordgo.SelectMenuOption{
-				Label: channel.Name,
-				Value: channel.ID,
-			})
+			voiceChannels = append(voiceChannels, channel)
 		}
 	}
+	slices.SortStableFunc(voiceChannels, func(a, c *discordgo.Channel) int { return a.Position - c.Position })
 
-	if len(options) == 0 {
+	if len(voiceChannels) == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Type: responseType,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ No voice channels found in this server",
+				Content: b.t(guildID, "subscribe.no-voice-channels"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
 		return
 	}
 
-	// Respond with a select menu
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Select a voice channel to monitor:",
-			Flags:   discordgo.MessageFlagsEphemeral,
+	pages := (len(voiceChannels) + maxSelectOptions - 1) / maxSelectOptions
+	page = max(0, min(page, pages-1))
+	pageChannels := voiceChannels[page*maxSelectOptions : min((page+1)*maxSelectOptions, len(voiceChannels))]
+
+	// Create select menu options, marking channels this channel already follows
+	var options []discordgo.SelectMenuOption
+	for _, channel := range pageChannels {
+		option := discordgo.SelectMenuOption{
+			Label: channel.Name,
+			Value: channel.ID,
+		}
+		if _, subscribed := b.getSubscription(channel.ID, i.ChannelID); subscribed {
+			option.Description = b.t(guildID, "subscribe.option.subscribed")
+		}
+		options = append(options, option)
+	}
+
+	content := b.t(guildID, "subscribe.select")
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.SelectMenu{
-							CustomID:    "subscribe_channel_select",
-							Placeholder: "Choose a voice channel",
-							Options:     options,
-						},
-					},
+				discordgo.SelectMenu{
+					CustomID:    "subscribe_channel_select",
+					Placeholder: b.t(guildID, "subscribe.placeholder"),
+					MinValues:   &[]int{1}[0],
+					MaxValues:   len(options),
+					Options:     options,
 				},
 			},
 		},
+	}
+	if pages > 1 {
+		content = b.t(guildID, "subscribe.select.page", page+1, pages)
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    b.t(guildID, "common.previous"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("subscribe_page:%d", page-1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    b.t(guildID, "common.next"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("subscribe_page:%d", page+1),
+					Disabled: page == pages-1,
+				},
+			},
+		})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: responseType,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Flags:      discordgo.MessageFlagsEphemeral,
+			Components: components,
+		},
 	})
 }
 
-func (b *Bot) handleChannelSelect(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleChannelSelect(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
 	data := i.MessageComponentData()
 
 	// Get the selected voice channel ID
@@ -301,30 +825,77 @@ func (b *Bot) handleChannelSelect(s *discordgo.Session, i *discordgo.Interaction
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ No channel selected",
+				Content: b.t(i.GuildID, "common.no-channel-selected"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
 		return
 	}
 
-	voiceChannelID := data.Values[0]
 	textChannelID := i.ChannelID
 	guildID := i.GuildID
 
-	alreadySubscribed := b.addSubscription(voiceChannelID, textChannelID, guildID)
-	responseText := b.formatSubscribeResponse(s, voiceChannelID, alreadySubscribed)
+	var responseText string
+	var addedIDs []string
+	if len(data.Values) == 1 {
+		alreadySubscribed, err := b.addSubscription(data.Values[0], textChannelID, guildID)
+		if err != nil {
+			responseText = b.t(guildID, "subscribe.failed", b.getChannelName(s, data.Values[0]), b.errorText(guildID, err))
+		} else {
+			responseText = b.formatSubscribeResponse(s, i.GuildID, data.Values[0], alreadySubscribed)
+		}
+		if err == nil && !alreadySubscribed {
+			addedIDs = data.Values
+		}
+	} else {
+		var added, existing, limited []string
+		var limitErr error
+		for _, voiceChannelID := range data.Values {
+			name := fmt.Sprintf("**%s**", b.getChannelName(s, voiceChannelID))
+			alreadySubscribed, err := b.addSubscription(voiceChannelID, textChannelID, guildID)
+			switch {
+			case err != nil:
+				limited = append(limited, name)
+				limitErr = err
+			case alreadySubscribed:
+				existing = append(existing, name)
+			default:
+				added = append(added, name)
+				addedIDs = append(addedIDs, voiceChannelID)
+			}
+		}
+		var lines []string
+		if len(added) > 0 {
+			lines = append(lines, b.t(guildID, "subscribe.added.many", strings.Join(added, ", ")))
+		}
+		if len(existing) > 0 {
+			lines = append(lines, b.t(guildID, "subscribe.exists.many", strings.Join(existing, ", ")))
+		}
+		if len(limited) > 0 {
+			lines = append(lines, b.t(guildID, "subscribe.failed.many", strings.Join(limited, ", "), b.errorText(guildID, limitErr)))
+		}
+		responseText = strings.Join(lines, "\n")
+	}
 
+	// Replace the channel select with the event select for new subscriptions
+	components := []discordgo.MessageComponent{}
+	if len(addedIDs) > 0 {
+		components = b.eventSelectComponents(guildID, textChannelID, addedIDs, 0)
+	}
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
 		Data: &discordgo.InteractionResponseData{
 			Content:    responseText,
-			Components: []discordgo.MessageComponent{}, // Remove the select menu
+			Components: components,
 		},
 	})
 }
 
-func (b *Bot) handleUnsubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleUnsubscribe(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
 	options := i.ApplicationCommandData().Options
 	textChannelID := i.ChannelID
 	guildID := i.GuildID
@@ -337,9 +908,13 @@ func (b *Bot) handleUnsubscribe(s *discordgo.Session, i *discordgo.InteractionCr
 	}
 
 	// Voice channel was provided
-	voiceChannelID := options[0].ChannelValue(s).ID
+	voiceChannelID, err := b.resolveVoiceChannel(s, guildID, options[0].StringValue())
+	if err != nil {
+		respondWithError(s, i.Interaction, b.errorText(guildID, err))
+		return
+	}
 	removed := b.removeSubscription(voiceChannelID, textChannelID)
-	responseText := b.formatUnsubscribeResponse(s, voiceChannelID, removed)
+	responseText := b.formatUnsubscribeResponse(s, i.GuildID, voiceChannelID, removed)
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -350,25 +925,20 @@ func (b *Bot) handleUnsubscribe(s *discordgo.Session, i *discordgo.InteractionCr
 	})
 }
 
-func (b *Bot) handleUnsubscribeWithoutChannel(s *discordgo.Session, i *discordgo.InteractionCreate, textChannelID, guildID string) {
+func (b *Bot) handleUnsubscribeWithoutChannel(s DiscordSession, i *discordgo.InteractionCreate, textChannelID, guildID string) {
 	// Find all subscriptions for this text channel
-	b.mu.RLock()
 	var matchingVoiceChannels []string
-	for voiceChannelID, subs := range b.subscriptions {
-		for _, sub := range subs {
-			if sub.TextChannelId == textChannelID && sub.GuildId == guildID {
-				matchingVoiceChannels = append(matchingVoiceChannels, voiceChannelID)
-				break
-			}
+	for _, sub := range b.subscriptions.Guild(guildID) {
+		if sub.TextChannelId == textChannelID {
+			matchingVoiceChannels = append(matchingVoiceChannels, sub.VoiceChannelId)
 		}
 	}
-	b.mu.RUnlock()
 
 	if len(matchingVoiceChannels) == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "ℹ️ No active subscriptions in this channel",
+				Content: b.t(guildID, "unsubscribe.none"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -379,7 +949,7 @@ func (b *Bot) handleUnsubscribeWithoutChannel(s *discordgo.Session, i *discordgo
 		// Single subscription - unsubscribe automatically
 		voiceChannelID := matchingVoiceChannels[0]
 		b.removeSubscription(voiceChannelID, textChannelID)
-		responseText := b.formatUnsubscribeResponse(s, voiceChannelID, true)
+		responseText := b.formatUnsubscribeResponse(s, i.GuildID, voiceChannelID, true)
 
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -392,42 +962,97 @@ func (b *Bot) handleUnsubscribeWithoutChannel(s *discordgo.Session, i *discordgo
 	}
 
 	// Multiple subscriptions - show selection dialog
-	b.handleUnsubscribeWithDialog(s, i, matchingVoiceChannels)
+	b.respondUnsubscribeDialog(s, i, discordgo.InteractionResponseChannelMessageWithSource, 0)
+}
+
+// handleUnsubscribePageButton switches the unsubscribe dialog to another page
+func (b *Bot) handleUnsubscribePageButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	page, _ := strconv.Atoi(strings.TrimPrefix(i.MessageComponentData().CustomID, "unsubscribe_page:"))
+	b.respondUnsubscribeDialog(s, i, discordgo.InteractionResponseUpdateMessage, page)
 }
 
-func (b *Bot) handleUnsubscribeWithDialog(s *discordgo.Session, i *discordgo.InteractionCreate, voiceChannelIDs []string) {
-	// Create select menu options from voice channel IDs
+// respondUnsubscribeDialog shows one page of the voice channels the text
+// channel is subscribed to. Select menus hold at most 25 options, so channels
+// subscribed to more get page buttons.
+func (b *Bot) respondUnsubscribeDialog(s DiscordSession, i *discordgo.InteractionCreate, responseType discordgo.InteractionResponseType, page int) {
 	var options []discordgo.SelectMenuOption
-	for _, channelID := range voiceChannelIDs {
-		channelName := b.getChannelName(s, channelID)
-		options = append(options, discordgo.SelectMenuOption{
-			Label: channelName,
-			Value: channelID,
+	for _, sub := range b.subscriptions.Guild(i.GuildID) {
+		if sub.TextChannelId == i.ChannelID {
+			options = append(options, discordgo.SelectMenuOption{
+				Label:       b.getChannelName(s, sub.VoiceChannelId),
+				Value:       sub.VoiceChannelId,
+				Description: sub.Label,
+			})
+		}
+	}
+	if len(options) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: responseType,
+			Data: &discordgo.InteractionResponseData{
+				Content:    b.t(i.GuildID, "unsubscribe.none"),
+				Flags:      discordgo.MessageFlagsEphemeral,
+				Components: []discordgo.MessageComponent{},
+			},
 		})
+		return
 	}
+	slices.SortFunc(options, func(x, y discordgo.SelectMenuOption) int { return cmp.Compare(x.Label, y.Label) })
 
-	// Respond with a select menu
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Select a voice channel to unsubscribe from:",
-			Flags:   discordgo.MessageFlagsEphemeral,
+	pages := (len(options) + maxSelectOptions - 1) / maxSelectOptions
+	page = max(0, min(page, pages-1))
+	pageOptions := options[page*maxSelectOptions : min((page+1)*maxSelectOptions, len(options))]
+
+	content := b.t(i.GuildID, "unsubscribe.select")
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.SelectMenu{
-							CustomID:    "unsubscribe_channel_select",
-							Placeholder: "Choose a voice channel",
-							Options:     options,
-						},
-					},
+				discordgo.SelectMenu{
+					CustomID:    "unsubscribe_channel_select",
+					Placeholder: b.t(i.GuildID, "unsubscribe.placeholder"),
+					Options:     pageOptions,
+				},
+			},
+		},
+	}
+	if pages > 1 {
+		content = b.t(i.GuildID, "unsubscribe.select.page", page+1, pages)
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    b.t(i.GuildID, "common.previous"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("unsubscribe_page:%d", page-1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    b.t(i.GuildID, "common.next"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("unsubscribe_page:%d", page+1),
+					Disabled: page == pages-1,
 				},
 			},
+		})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: responseType,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Flags:      discordgo.MessageFlagsEphemeral,
+			Components: components,
 		},
 	})
 }
 
-func (b *Bot) handleUnsubscribeChannelSelect(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleUnsubscribeChannelSelect(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
 	data := i.MessageComponentData()
 
 	// Get the selected voice channel ID
@@ -435,7 +1060,7 @@ func (b *Bot) handleUnsubscribeChannelSelect(s *discordgo.Session, i *discordgo.
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ No channel selected",
+				Content: b.t(i.GuildID, "common.no-channel-selected"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -446,7 +1071,7 @@ func (b *Bot) handleUnsubscribeChannelSelect(s *discordgo.Session, i *discordgo.
 	textChannelID := i.ChannelID
 
 	removed := b.removeSubscription(voiceChannelID, textChannelID)
-	responseText := b.formatUnsubscribeResponse(s, voiceChannelID, removed)
+	responseText := b.formatUnsubscribeResponse(s, i.GuildID, voiceChannelID, removed)
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
@@ -457,31 +1082,35 @@ func (b *Bot) handleUnsubscribeChannelSelect(s *discordgo.Session, i *discordgo.
 	})
 }
 
-func (b *Bot) handleListSubscriptions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleListSubscriptions(s DiscordSession, i *discordgo.InteractionCreate) {
 	guildID := i.GuildID
-	channelID := i.ChannelID
 
 	// Check if this is the admin channel
-	adminChannelID, isAdmin, hasAdminChannel := b.verifyAdminChannel(guildID, channelID)
-
-	if !hasAdminChannel {
-		respondWithError(s, i.Interaction, "❌ No admin channel has been set for this server. Please configure it using the ADMIN_CHANNELS environment variable.")
+	if !b.requireAdminChannel(s, i) {
 		return
 	}
 
-	if !isAdmin {
-		respondWithError(s, i.Interaction, fmt.Sprintf("❌ This command can only be used in the admin channel: <#%s>", adminChannelID))
-		return
+	var view subscriptionListView
+	options := optionMap(i.ApplicationCommandData().Options)
+	if opt, ok := options["sort"]; ok {
+		view.sort = opt.StringValue()
+	}
+	if opt, ok := options["unused-days"]; ok {
+		view.unusedFor = time.Duration(opt.IntValue()) * 24 * time.Hour
 	}
 
 	// Build the subscription list embed
-	embed, components, count := b.buildSubscriptionListEmbed(s, guildID)
+	embed, components, count := b.buildSubscriptionListEmbed(s, guildID, view)
 
 	if count == 0 {
+		content := b.t(guildID, "list.empty")
+		if view.unusedFor > 0 {
+			content = b.t(guildID, "list.none-unused", int(view.unusedFor.Hours()/24))
+		}
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "ℹ️ No active subscriptions in this server",
+				Content: content,
 			},
 		})
 		return
@@ -496,7 +1125,7 @@ func (b *Bot) handleListSubscriptions(s *discordgo.Session, i *discordgo.Interac
 	})
 }
 
-func (b *Bot) handleManageSubscriptionSelect(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleManageSubscriptionSelect(s DiscordSession, i *discordgo.InteractionCreate) {
 	data := i.MessageComponentData()
 	guildID := i.GuildID
 
@@ -505,7 +1134,7 @@ func (b *Bot) handleManageSubscriptionSelect(s *discordgo.Session, i *discordgo.
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseUpdateMessage,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ No channel selected",
+				Content: b.t(i.GuildID, "common.no-channel-selected"),
 			},
 		})
 		return
@@ -514,29 +1143,13 @@ func (b *Bot) handleManageSubscriptionSelect(s *discordgo.Session, i *discordgo.
 	voiceChannelID := data.Values[0]
 	voiceChannelName := b.getChannelName(s, voiceChannelID)
 
-	// Get subscriptions for this voice channel
-	b.mu.RLock()
-	subs, exists := b.subscriptions[voiceChannelID]
-	b.mu.RUnlock()
-
-	if !exists {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseUpdateMessage,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("ℹ️ No subscriptions found for **%s**", voiceChannelName),
-			},
-		})
-		return
-	}
-
-	// Filter for this guild
-	guildSubs := filterGuildSubscriptions(subs, guildID)
-
+	// Only this guild's subscriptions, whatever the select menu sent
+	guildSubs := b.subscriptions.ChannelIn(guildID, voiceChannelID)
 	if len(guildSubs) == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseUpdateMessage,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("ℹ️ No subscriptions found for **%s** in this server", voiceChannelName),
+				Content: b.t(guildID, "manage.empty", voiceChannelName),
 			},
 		})
 		return
@@ -545,14 +1158,36 @@ func (b *Bot) handleManageSubscriptionSelect(s *discordgo.Session, i *discordgo.
 	// Build buttons for each subscription
 	var buttons []discordgo.MessageComponent
 	var description string
-	description = fmt.Sprintf("**Voice Channel:** 🔊 %s\n\n**Notification Channels:**\n", voiceChannelName)
+	description = b.t(guildID, "manage.header", voiceChannelName)
 
 	for idx, sub := range guildSubs {
-		description += fmt.Sprintf("%d. <#%s>\n", idx+1, sub.TextChannelId)
+		description += fmt.Sprintf("%d. %s%s\n", idx+1, b.target(sub), sub.labelText())
+		if sub.QuietHours != nil {
+			description += "   " + b.t(guildID, "manage.quiet-hours", sub.QuietHours) + "\n"
+		}
+		if sub.Broken != "" {
+			description += "   " + b.t(guildID, "manage.broken", b.brokenReason(guildID, sub.Broken)) + "\n"
+		}
+		if sub.StatusBoard {
+			description += "   " + b.t(guildID, "manage.status-board") + "\n"
+		}
+		if sub.MinUsers > 0 {
+			description += "   " + b.t(guildID, "manage.min-users", sub.MinUsers) + "\n"
+		}
+		if sub.Events != 0 {
+			description += "   " + b.eventLabels(guildID, sub.Events) + "\n"
+		}
+		if sub.DeleteAfter != "" {
+			description += "   " + b.t(guildID, "manage.delete-after", sub.DeleteAfter) + "\n"
+		}
+		if sub.DeleteOnLeave {
+			description += "   " + b.t(guildID, "manage.delete-on-leave") + "\n"
+		}
+		description += "   " + b.lastFiredText(sub) + "\n"
 
 		// Create remove button
 		button := discordgo.Button{
-			Label:    fmt.Sprintf("Remove #%d", idx+1),
+			Label:    b.t(guildID, "manage.remove", idx+1),
 			Style:    discordgo.DangerButton,
 			CustomID: fmt.Sprintf("remove_sub:%s:%s", voiceChannelID, sub.TextChannelId),
 		}
@@ -575,7 +1210,7 @@ func (b *Bot) handleManageSubscriptionSelect(s *discordgo.Session, i *discordgo.
 	components = append(components, discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
 			discordgo.Button{
-				Label:    "← Back to List",
+				Label:    b.t(guildID, "manage.back"),
 				Style:    discordgo.SecondaryButton,
 				CustomID: "back_to_subscription_list",
 			},
@@ -583,11 +1218,11 @@ func (b *Bot) handleManageSubscriptionSelect(s *discordgo.Session, i *discordgo.
 	})
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "🔧 Manage Subscriptions",
+		Title:       b.t(guildID, "manage.title"),
 		Description: description,
 		Color:       0x5865F2,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Managing subscriptions for voice channel: %s", voiceChannelName),
+			Text: b.t(guildID, "manage.footer", voiceChannelName),
 		},
 	}
 
@@ -600,7 +1235,7 @@ func (b *Bot) handleManageSubscriptionSelect(s *discordgo.Session, i *discordgo.
 	})
 }
 
-func (b *Bot) handleRemoveSubscriptionButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleRemoveSubscriptionButton(s DiscordSession, i *discordgo.InteractionCreate) {
 	data := i.MessageComponentData()
 	guildID := i.GuildID
 
@@ -610,7 +1245,7 @@ func (b *Bot) handleRemoveSubscriptionButton(s *discordgo.Session, i *discordgo.
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Invalid button data",
+				Content: b.t(guildID, "manage.invalid-button"),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -624,20 +1259,20 @@ func (b *Bot) handleRemoveSubscriptionButton(s *discordgo.Session, i *discordgo.
 	_, isAdmin, hasAdminChannel := b.verifyAdminChannel(guildID, i.ChannelID)
 
 	if !hasAdminChannel || !isAdmin {
-		respondWithError(s, i.Interaction, "❌ This action can only be performed in the admin channel")
+		respondWithError(s, i.Interaction, b.t(guildID, "admin.required"))
 		return
 	}
 
-	// Remove the subscription
-	removed := b.removeSubscription(voiceChannelID, textChannelID)
+	// Remove the subscription, if it belongs to this guild
+	removed := b.subscriptions.RemoveIn(guildID, voiceChannelID, textChannelID)
 
 	voiceChannelName := b.getChannelName(s, voiceChannelID)
 
 	if removed {
 		// Show success message with button to go back to list
 		embed := &discordgo.MessageEmbed{
-			Title:       "✅ Subscription Removed",
-			Description: fmt.Sprintf("Successfully removed subscription:\n\n🔊 **%s** → <#%s>", voiceChannelName, textChannelID),
+			Title:       b.t(guildID, "manage.removed.title"),
+			Description: b.t(guildID, "manage.removed", voiceChannelName, textChannelID),
 			Color:       0x57F287, // Green
 		}
 
@@ -649,7 +1284,7 @@ func (b *Bot) handleRemoveSubscriptionButton(s *discordgo.Session, i *discordgo.
 					discordgo.ActionsRow{
 						Components: []discordgo.MessageComponent{
 							discordgo.Button{
-								Label:    "← Back to List",
+								Label:    b.t(guildID, "manage.back"),
 								Style:    discordgo.PrimaryButton,
 								CustomID: "back_to_subscription_list",
 							},
@@ -662,12 +1297,12 @@ func (b *Bot) handleRemoveSubscriptionButton(s *discordgo.Session, i *discordgo.
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseUpdateMessage,
 			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("ℹ️ Subscription not found: **%s** → <#%s>", voiceChannelName, textChannelID),
+				Content: b.t(guildID, "manage.not-found", voiceChannelName, textChannelID),
 				Components: []discordgo.MessageComponent{
 					discordgo.ActionsRow{
 						Components: []discordgo.MessageComponent{
 							discordgo.Button{
-								Label:    "← Back to List",
+								Label:    b.t(guildID, "manage.back"),
 								Style:    discordgo.SecondaryButton,
 								CustomID: "back_to_subscription_list",
 							},
@@ -680,17 +1315,32 @@ func (b *Bot) handleRemoveSubscriptionButton(s *discordgo.Session, i *discordgo.
 	}
 }
 
-func (b *Bot) handleBackToSubscriptionList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleBackToSubscriptionList(s DiscordSession, i *discordgo.InteractionCreate) {
+	b.updateSubscriptionList(s, i, subscriptionListView{})
+}
+
+// handleListPageButton switches the subscription list to another page
+func (b *Bot) handleListPageButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	view, ok := parseListPage(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	b.updateSubscriptionList(s, i, view)
+}
+
+// updateSubscriptionList replaces the message of a list component with the
+// subscription list in the given view
+func (b *Bot) updateSubscriptionList(s DiscordSession, i *discordgo.InteractionCreate, view subscriptionListView) {
 	guildID := i.GuildID
 
 	// Build the subscription list embed
-	embed, components, count := b.buildSubscriptionListEmbed(s, guildID)
+	embed, components, count := b.buildSubscriptionListEmbed(s, guildID, view)
 
 	if count == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseUpdateMessage,
 			Data: &discordgo.InteractionResponseData{
-				Content:    "ℹ️ No active subscriptions in this server",
+				Content:    b.t(guildID, "list.empty"),
 				Components: []discordgo.MessageComponent{},
 			},
 		})
@@ -706,138 +1356,261 @@ func (b *Bot) handleBackToSubscriptionList(s *discordgo.Session, i *discordgo.In
 	})
 }
 
+const (
+	storageBackendFile     = "file"
+	storageBackendPostgres = "postgres"
+	storageBackendMemory   = "memory"
+	storageBackendRedis    = "redis"
+)
+
+// newStore creates the configured storage backend
+func newStore(storage config.Storage) (Store, error) {
+	switch storage.Backend {
+	case storageBackendMemory:
+		slog.Info("Using in-memory storage, nothing will be persisted")
+		return NewMemoryStore(), nil
+	case storageBackendRedis:
+		store, err := NewRedisStore(storage.RedisURL, storage.RedisPrefix)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Using Redis persistence with cross-instance coordination")
+		return store, nil
+	case storageBackendPostgres:
+		store, err := NewPostgresStore(storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Using PostgreSQL persistence")
+		return store, nil
+	}
+
+	persistence := NewPersistence(storage.File)
+	persistence.backups = storage.Backups
+	return persistence, nil
+}
+
 // loadPersistedData loads subscriptions and admin channels from disk
 func (b *Bot) loadPersistedData() error {
 	data, err := b.persistence.Load()
 	if err != nil {
 		return err
 	}
+	if b.shard != nil {
+		data = data.filterGuilds(b.shard.owns)
+	}
+	if _, ok := b.persistence.(guildStore); ok {
+		if err := b.rememberSavedGuilds(data); err != nil {
+			return err
+		}
+	}
+
+	b.subscriptions.Replace(data.Subscriptions)
 
 	b.mu.Lock()
-	b.subscriptions = data.Subscriptions
+	b.watchlist = data.Watchlist
+	b.templates = data.Templates
+	b.goals = data.Goals
+	b.ignored = data.Ignored
+	b.optOuts = data.OptOuts
+	b.logChannels = data.LogChannels
+	b.fallbackChannels = data.FallbackChannels
+	b.sentNotifications = data.SentNotifications
+	b.follows = data.Follows
+	b.subscribeAccess = data.SubscribeAccess
+	b.debounceStrategies = data.DebounceStrategies
+	b.plainTextGuilds = data.PlainText
+	b.minimalGuilds = data.Minimal
+	b.timestampGuilds = data.Timestamps
+	b.tones = data.Tones
+	b.languages = data.Languages
+	b.afkSettings = data.AFK
+	b.pauses = data.Pauses
+	b.patternSubscriptions = data.PatternSubscriptions
+	b.groupWindows = data.GroupWindows
+	b.digests = data.Digests
+	for guildID, channelID := range data.AdminChannels {
+		b.adminChannels[guildID] = channelID
+	}
 	b.mu.Unlock()
 
-	log.Printf("Loaded %d voice channel subscriptions", len(data.Subscriptions))
+	slog.Info("Loaded voice channel subscriptions", "count", len(data.Subscriptions))
 	return nil
 }
 
-// loadAdminChannelsFromEnv loads admin channels from ADMIN_CHANNELS environment variable
+// loadAdminChannels applies pre-configured admin channels
 // Format: ADMIN_CHANNELS=guildID:channelID,guildID:channelID
-func (b *Bot) loadAdminChannelsFromEnv() {
-	envAdminChannels := os.Getenv("ADMIN_CHANNELS")
-	if envAdminChannels == "" {
+func (b *Bot) loadAdminChannels(adminChannels map[string]string) {
+	if len(adminChannels) == 0 {
 		return
 	}
 
-	pairs := strings.Split(envAdminChannels, ",")
-	count := 0
-	for _, pair := range pairs {
-		parts := strings.Split(strings.TrimSpace(pair), ":")
-		if len(parts) == 2 {
-			guildID := strings.TrimSpace(parts[0])
-			channelID := strings.TrimSpace(parts[1])
-			if guildID != "" && channelID != "" {
-				b.mu.Lock()
-				b.adminChannels[guildID] = channelID
-				b.mu.Unlock()
-				count++
-			}
-		}
+	b.mu.Lock()
+	for guildID, channelID := range adminChannels {
+		b.adminChannels[guildID] = channelID
 	}
+	b.mu.Unlock()
 
-	if count > 0 {
-		log.Printf("Loaded %d admin channels from ADMIN_CHANNELS environment variable", count)
+	slog.Info("Loaded pre-configured admin channels", "count", len(adminChannels))
+}
+
+// setAdminChannel sets the admin channel for a guild
+func (b *Bot) setAdminChannel(guildID, channelID string) {
+	b.mu.Lock()
+	b.adminChannels[guildID] = channelID
+	b.mu.Unlock()
+
+	b.savePersistedDataAsync()
+}
+
+// removeAdminChannel removes the admin channel for a guild and returns whether it existed
+func (b *Bot) removeAdminChannel(guildID string) bool {
+	b.mu.Lock()
+	_, exists := b.adminChannels[guildID]
+	delete(b.adminChannels, guildID)
+	b.mu.Unlock()
+
+	if exists {
+		b.savePersistedDataAsync()
 	}
+	return exists
 }
 
 // savePersistedData saves subscriptions and admin channels to disk
 func (b *Bot) savePersistedData() error {
-	b.mu.RLock()
-	data := &PersistentData{
-		Subscriptions: b.subscriptions,
+	data, err := b.persistedData()
+	if err != nil {
+		b.recordSave(err)
+		return err
 	}
-	b.mu.RUnlock()
 
-	return b.persistence.Save(data)
-}
+	if store, ok := b.persistence.(guildStore); ok {
+		// Shared stores only get the guilds that changed, see guildstore.go
+		err = b.saveChangedGuilds(store, data)
+		b.recordSave(err)
+		return err
+	}
 
-// savePersistedDataAsync saves subscriptions and admin channels to disk asynchronously
-func (b *Bot) savePersistedDataAsync() {
-	go func() {
-		if err := b.savePersistedData(); err != nil {
-			log.Printf("Error saving persisted data: %v", err)
+	if b.shard != nil {
+		b.shard.mu.Lock()
+		defer b.shard.mu.Unlock()
+		// Other shards may save to the same file in between our load and save
+		if store, ok := b.persistence.(lockingStore); ok {
+			unlock, err := store.lock()
+			if err != nil {
+				b.recordSave(err)
+				return err
+			}
+			defer unlock()
 		}
-	}()
+
+		merged, err := b.shard.mergeForSave(b.persistence, data)
+		if err != nil {
+			b.recordSave(err)
+			return err
+		}
+		data = merged
+	}
+
+	err = b.persistence.Save(data)
+	b.recordSave(err)
+	return err
 }
 
-// addSubscription adds a subscription and returns whether it already existed
-func (b *Bot) addSubscription(voiceChannelID, textChannelID, guildID string) bool {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+// persistedData returns a copy of the state that is persisted, limited to
+// this shard's guilds
+func (b *Bot) persistedData() (*PersistentData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 
-	if b.subscriptions[voiceChannelID] == nil {
-		b.subscriptions[voiceChannelID] = []subscription{}
+	data := &PersistentData{
+		Subscriptions:        b.subscriptions.Snapshot(),
+		AdminChannels:        b.adminChannels,
+		Watchlist:            b.watchlist,
+		Templates:            b.templates,
+		Goals:                b.goals,
+		Ignored:              b.ignored,
+		OptOuts:              b.optOuts,
+		LogChannels:          b.logChannels,
+		FallbackChannels:     b.fallbackChannels,
+		SentNotifications:    b.sentNotifications,
+		Follows:              b.follows,
+		SubscribeAccess:      b.subscribeAccess,
+		DebounceStrategies:   b.debounceStrategies,
+		PlainText:            b.plainTextGuilds,
+		Minimal:              b.minimalGuilds,
+		Timestamps:           b.timestampGuilds,
+		Tones:                b.tones,
+		Languages:            b.languages,
+		AFK:                  b.afkSettings,
+		Pauses:               b.pauses,
+		PatternSubscriptions: b.patternSubscriptions,
+		GroupWindows:         b.groupWindows,
+		Digests:              b.digests,
 	}
-
-	// Check if already subscribed
-	for _, sub := range b.subscriptions[voiceChannelID] {
-		if sub.TextChannelId == textChannelID && sub.VoiceChannelId == voiceChannelID {
-			return true
-		}
+	if b.shard != nil {
+		// Other shards' guilds are merged in below
+		data = data.filterGuilds(b.shard.owns)
 	}
+	// Stores encode without b.mu, so they get a copy that handlers can't change
+	return data.clone()
+}
+
+// savePersistedDataAsync marks the persisted data as changed. The background
+// writer saves it within SAVE_INTERVAL, and Stop saves it at the latest. It
+// never blocks, so it may be called while holding b.mu.
+func (b *Bot) savePersistedDataAsync() {
+	b.saver.dirty.Store(true)
+}
 
-	// Add new subscription
-	b.subscriptions[voiceChannelID] = append(b.subscriptions[voiceChannelID], subscription{
+// addSubscription adds a subscription and returns whether it already existed.
+// It fails if the guild or voice channel has reached its subscription limit.
+func (b *Bot) addSubscription(voiceChannelID, textChannelID, guildID string) (alreadySubscribed bool, err error) {
+	added, err := b.subscriptions.AddWithin(subscription{
 		VoiceChannelId: voiceChannelID,
 		TextChannelId:  textChannelID,
 		GuildId:        guildID,
-	})
+	}, b.subscriptionLimits)
+	if err != nil {
+		slog.Warn("Subscription rejected", "guild_id", guildID, "channel_id", voiceChannelID, "error", err)
+		return false, err
+	}
+	return !added, nil
+}
 
-	// Save to persistence asynchronously (non-blocking)
-	b.savePersistedDataAsync()
+// getSubscription returns a copy of a subscription and whether it exists
+func (b *Bot) getSubscription(voiceChannelID, textChannelID string) (subscription, bool) {
+	return b.subscriptions.Get(voiceChannelID, textChannelID)
+}
 
-	return false
+// updateSubscription applies fn to a subscription and returns whether it existed
+func (b *Bot) updateSubscription(voiceChannelID, textChannelID string, fn func(sub *subscription)) bool {
+	return b.subscriptions.Update(voiceChannelID, textChannelID, fn)
 }
 
 // removeSubscription removes a subscription and returns whether it existed
 func (b *Bot) removeSubscription(voiceChannelID, textChannelID string) bool {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	subs, exists := b.subscriptions[voiceChannelID]
-	if !exists {
-		return false
-	}
-
-	for idx, sub := range subs {
-		if sub.TextChannelId == textChannelID && sub.VoiceChannelId == voiceChannelID {
-			// Remove this subscription
-			b.subscriptions[voiceChannelID] = append(subs[:idx], subs[idx+1:]...)
-
-			// Clean up empty subscription lists
-			if len(b.subscriptions[voiceChannelID]) == 0 {
-				delete(b.subscriptions, voiceChannelID)
-			}
-
-			// Save to persistence asynchronously (non-blocking)
-			// No need to unlock/relock - savePersistedDataAsync is non-blocking
-			b.savePersistedDataAsync()
-
-			return true
-		}
-	}
-	return false
+	return b.subscriptions.Remove(voiceChannelID, textChannelID)
 }
 
 // getChannelName fetches the channel name or returns the ID if fetching fails
-func (b *Bot) getChannelName(s *discordgo.Session, channelID string) string {
-	channel, err := s.Channel(channelID)
+func (b *Bot) getChannelName(s DiscordSession, channelID string) string {
+	channel, err := b.channel(s, channelID)
 	if err == nil {
 		return channel.Name
 	}
 	return channelID
 }
 
+// getGuildName returns the guild name from state or the ID if it is unknown
+func (b *Bot) getGuildName(s DiscordSession, guildID string) string {
+	guild, err := b.session.State.Guild(guildID)
+	if err == nil {
+		return guild.Name
+	}
+	return guildID
+}
+
 // getUsername returns the user's display name (nickname if available, otherwise username)
 func getUsername(member *discordgo.Member) string {
 	if member.Nick != "" {
@@ -846,19 +1619,50 @@ func getUsername(member *discordgo.Member) string {
 	return member.User.Username
 }
 
-// filterGuildSubscriptions returns subscriptions for a specific guild
-func filterGuildSubscriptions(subs []subscription, guildID string) []subscription {
-	var filtered []subscription
-	for _, sub := range subs {
-		if sub.GuildId == guildID {
-			filtered = append(filtered, sub)
-		}
+// optionMap indexes command options by name
+func optionMap(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	m := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		m[opt.Name] = opt
 	}
-	return filtered
+	return m
+}
+
+// optionUser returns the user a user option refers to, from the users Discord
+// resolved with the interaction
+func optionUser(i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) *discordgo.User {
+	user := opt.UserValue(nil)
+	if resolved := i.ApplicationCommandData().Resolved; resolved != nil && resolved.Users[user.ID] != nil {
+		return resolved.Users[user.ID]
+	}
+	return user
+}
+
+// truncateMessage shortens content to at most limit characters, never leaving
+// a markdown escape that would swallow the ellipsis
+func truncateMessage(content string, limit int) string {
+	runes := []rune(content)
+	if len(runes) <= limit {
+		return content
+	}
+	kept := runes[:limit-1]
+	escapes := 0
+	for idx := len(kept) - 1; idx >= 0 && kept[idx] == '\\'; idx-- {
+		escapes++
+	}
+	if escapes%2 == 1 {
+		kept = kept[:len(kept)-1]
+	}
+	return string(kept) + "…"
 }
 
 // respondWithError sends an ephemeral error response
-func respondWithError(s *discordgo.Session, i *discordgo.Interaction, message string) error {
+func respondWithError(s DiscordSession, i *discordgo.Interaction, message string) error {
+	return respondEphemeral(s, i, message)
+}
+
+// respondEphemeral sends a message only the invoking user can see
+func respondEphemeral(s DiscordSession, i *discordgo.Interaction, message string) error {
 	return s.InteractionRespond(i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -869,56 +1673,109 @@ func respondWithError(s *discordgo.Session, i *discordgo.Interaction, message st
 }
 
 // buildSubscriptionListEmbed builds the subscription list embed and components for a guild
-func (b *Bot) buildSubscriptionListEmbed(s *discordgo.Session, guildID string) (*discordgo.MessageEmbed, []discordgo.MessageComponent, int) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-
-	var fields []*discordgo.MessageEmbedField
-	var selectOptions []discordgo.SelectMenuOption
-	count := 0
+func (b *Bot) buildSubscriptionListEmbed(s DiscordSession, guildID string, view subscriptionListView) (*discordgo.MessageEmbed, []discordgo.MessageComponent, int) {
+	type channelGroup struct {
+		voiceChannelID string
+		name           string
+		subs           []subscription
+		lastFired      time.Time // most recent of subs
+	}
 
-	for voiceChannelID, subs := range b.subscriptions {
-		guildSubs := filterGuildSubscriptions(subs, guildID)
-		if len(guildSubs) == 0 {
+	now := time.Now()
+	var groups []channelGroup
+	for voiceChannelID, subs := range b.subscriptions.GuildChannels(guildID) {
+		group := channelGroup{voiceChannelID: voiceChannelID}
+		for _, sub := range subs {
+			if view.unusedFor > 0 && now.Sub(sub.LastFiredAt) < view.unusedFor {
+				continue
+			}
+			group.subs = append(group.subs, sub)
+			if sub.LastFiredAt.After(group.lastFired) {
+				group.lastFired = sub.LastFiredAt
+			}
+		}
+		if len(group.subs) == 0 {
 			continue
 		}
+		group.name = b.getChannelName(s, voiceChannelID)
+		groups = append(groups, group)
+	}
 
-		voiceChannelName := b.getChannelName(s, voiceChannelID)
+	// Least recently fired first makes dead wiring easy to spot
+	if view.sort == listSortLastFired {
+		slices.SortFunc(groups, func(x, y channelGroup) int {
+			return cmp.Or(x.lastFired.Compare(y.lastFired), cmp.Compare(x.name, y.name))
+		})
+		for _, group := range groups {
+			slices.SortStableFunc(group.subs, func(x, y subscription) int { return x.LastFiredAt.Compare(y.LastFiredAt) })
+		}
+	} else {
+		slices.SortFunc(groups, func(x, y channelGroup) int { return cmp.Compare(x.name, y.name) })
+	}
+
+	count := 0
+	for _, group := range groups {
+		count += len(group.subs)
+	}
+
+	// Embeds hold at most 25 fields and select menus 25 options, so larger
+	// servers page through their voice channels
+	pages := max(1, (len(groups)+maxSelectOptions-1)/maxSelectOptions)
+	view.page = max(0, min(view.page, pages-1))
+	pageGroups := groups[min(view.page*maxSelectOptions, len(groups)):min((view.page+1)*maxSelectOptions, len(groups))]
+
+	var fields []*discordgo.MessageEmbedField
+	var selectOptions []discordgo.SelectMenuOption
+	for _, group := range pageGroups {
 		var notifyChannels string
-		for _, sub := range guildSubs {
-			notifyChannels += fmt.Sprintf("→ <#%s>\n", sub.TextChannelId)
-			count++
+		for _, sub := range group.subs {
+			notifyChannels += "→ " + b.target(sub) + sub.labelText()
+			if sub.Broken != "" {
+				notifyChannels += " " + b.t(guildID, "list.broken", b.brokenReason(guildID, sub.Broken))
+			}
+			if sub.eventActive(now) {
+				notifyChannels += " " + b.t(guildID, "list.event-until", sub.EventUntil.Unix())
+			}
+			notifyChannels += " · " + b.lastFiredText(sub)
+			notifyChannels += "\n"
 		}
 
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   fmt.Sprintf("🔊 %s", voiceChannelName),
-			Value:  notifyChannels,
+			Name:   fmt.Sprintf("🔊 %s", group.name),
+			Value:  truncateMessage(notifyChannels, 1024),
 			Inline: true,
 		})
 
-		// Add to select menu (limit 25 options)
-		if len(selectOptions) < 25 {
-			description := fmt.Sprintf("%d subscription(s)", len(guildSubs))
-			selectOptions = append(selectOptions, discordgo.SelectMenuOption{
-				Label:       voiceChannelName,
-				Value:       voiceChannelID,
-				Description: description,
-				Emoji: &discordgo.ComponentEmoji{
-					Name: "🔊",
-				},
-			})
-		}
+		selectOptions = append(selectOptions, discordgo.SelectMenuOption{
+			Label:       group.name,
+			Value:       group.voiceChannelID,
+			Description: b.t(guildID, "list.option", len(group.subs)),
+			Emoji: &discordgo.ComponentEmoji{
+				Name: "🔊",
+			},
+		})
+	}
+
+	description := b.t(guildID, "list.total", count, len(groups))
+	if view.unusedFor > 0 {
+		description = b.t(guildID, "list.unused", formatDuration(view.unusedFor), count, len(groups))
+	}
+	if view.unusedFor == 0 {
+		description += b.patternListText(guildID)
+	}
+	if pages > 1 {
+		description += "\n" + b.t(guildID, "common.page", view.page+1, pages)
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "📋 Active Voice Channel Subscriptions",
-		Description: fmt.Sprintf("**Total:** %d subscription(s) across %d voice channel(s)\n\nSelect a voice channel below to view and manage its subscriptions.", count, len(selectOptions)),
+		Title:       b.t(guildID, "list.title"),
+		Description: description,
 		Color:       0x5865F2, // Discord Blurple
 		Fields:      fields,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Select a channel to remove specific subscriptions",
+			Text: b.t(guildID, "list.footer"),
 		},
-		Timestamp: time.Now().Format(time.RFC3339),
+		Timestamp: now.Format(time.RFC3339),
 	}
 
 	components := []discordgo.MessageComponent{
@@ -926,12 +1783,30 @@ func (b *Bot) buildSubscriptionListEmbed(s *discordgo.Session, guildID string) (
 			Components: []discordgo.MessageComponent{
 				discordgo.SelectMenu{
 					CustomID:    "manage_subscription_select",
-					Placeholder: "Select a voice channel to manage...",
+					Placeholder: b.t(guildID, "list.placeholder"),
 					Options:     selectOptions,
 				},
 			},
 		},
 	}
+	if pages > 1 {
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    b.t(guildID, "common.previous"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: view.pageCustomID(view.page - 1),
+					Disabled: view.page == 0,
+				},
+				discordgo.Button{
+					Label:    b.t(guildID, "common.next"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: view.pageCustomID(view.page + 1),
+					Disabled: view.page == pages-1,
+				},
+			},
+		})
+	}
 
 	return embed, components, count
 }
@@ -950,83 +1825,258 @@ func (b *Bot) verifyAdminChannel(guildID, channelID string) (adminChannelID stri
 }
 
 // formatSubscribeResponse generates the response message for subscribe operations
-func (b *Bot) formatSubscribeResponse(s *discordgo.Session, voiceChannelID string, alreadySubscribed bool) string {
+func (b *Bot) formatSubscribeResponse(s DiscordSession, guildID, voiceChannelID string, alreadySubscribed bool) string {
 	channelName := b.getChannelName(s, voiceChannelID)
 
 	if alreadySubscribed {
-		return fmt.Sprintf("ℹ️ Already subscribed to **%s**", channelName)
+		return b.t(guildID, "subscribe.exists", channelName)
 	}
-	return fmt.Sprintf("✅ Subscribed! This channel will receive notifications for voice activity in **%s**", channelName)
+	return b.t(guildID, "subscribe.added", channelName)
 }
 
 // formatUnsubscribeResponse generates the response message for unsubscribe operations
-func (b *Bot) formatUnsubscribeResponse(s *discordgo.Session, voiceChannelID string, wasSubscribed bool) string {
+func (b *Bot) formatUnsubscribeResponse(s DiscordSession, guildID, voiceChannelID string, wasSubscribed bool) string {
 	channelName := b.getChannelName(s, voiceChannelID)
 
 	if !wasSubscribed {
-		return fmt.Sprintf("ℹ️ Not subscribed to **%s**", channelName)
+		return b.t(guildID, "unsubscribe.missing", channelName)
 	}
-	return fmt.Sprintf("✅ Unsubscribed from **%s**", channelName)
+	return b.t(guildID, "unsubscribe.removed", channelName)
 }
 
-func (b *Bot) voiceStateUpdate(s *discordgo.Session, vsu *discordgo.VoiceStateUpdate) {
+func (b *Bot) voiceStateUpdate(s DiscordSession, vsu *discordgo.VoiceStateUpdate) {
+	if b.standingDown.Load() {
+		return
+	}
+
+	// Recorded before anything can return early, so it never goes stale
+	previousChannelID := b.voiceChannels.swap(vsu.GuildID, vsu.UserID, vsu.ChannelID)
+
+	// Detect when user joins or leaves a voice channel. The last known channel
+	// is compared instead of BeforeUpdate, which is missing or stale for voice
+	// states replayed after a reconnect, so only genuine transitions count.
+	var joinedChannelID, leftChannelID string
+	if previousChannelID != vsu.ChannelID {
+		joinedChannelID = vsu.ChannelID
+		leftChannelID = previousChannelID
+	} else if vsu.BeforeUpdate == nil && vsu.ChannelID != "" {
+		slog.Debug("Ignoring replayed voice state", "guild_id", vsu.GuildID, "user_id", vsu.UserID, "channel_id", vsu.ChannelID)
+	}
+
 	// Get the member info
 	member := vsu.Member
 	if member == nil {
 		// Try to get member info
 		var err error
-		member, err = s.GuildMember(vsu.GuildID, vsu.UserID)
+		member, err = b.member(s, vsu.GuildID, vsu.UserID)
 		if err != nil {
-			log.Printf("Error getting member info: %v", err)
+			slog.Error("Error getting member info", "guild_id", vsu.GuildID, "user_id", vsu.UserID, "event_type", "voice_state_update", "error", err)
+			// The user can't be announced, but occupancy has to follow the
+			// swap above or channels keep counting users who left
+			b.occupancy.move(vsu.UserID, leftChannelID, joinedChannelID)
 			return
 		}
 	}
 
-	// Ignore bot users
+	// Ignore bot users. One counted while its member was unknown is removed.
 	if member.User.Bot {
+		b.occupancy.move(vsu.UserID, leftChannelID, "")
 		return
 	}
 
-	username := getUsername(member)
+	leftSince := b.occupancy.activeSince(leftChannelID)
+	previousCount := b.occupancy.move(vsu.UserID, leftChannelID, joinedChannelID)
+	// Opted-out users only count toward occupancy, which holds no history
+	if b.isOptedOut(vsu.GuildID, vsu.UserID) {
+		return
+	}
 
-	// Detect when user joins a voice channel
-	var joinedChannelID string
+	// Notifications, session stats, and every other feature consume the
+	// normalized events, see registerVoiceConsumers
+	events := voiceEvents(vsu, member, leftChannelID, joinedChannelID, b.isIgnored(vsu.GuildID, vsu.UserID))
+	for i := range events {
+		events[i].previousCount, events[i].leftSince = previousCount, leftSince
+	}
+	b.voiceBus.publish(s, events)
+}
+
+// notifyVoiceEvent sends the notifications of a voice event. It runs before
+// the session stats consumer, so the session in the channel left is still
+// active and tells how long the user stayed.
+func (b *Bot) notifyVoiceEvent(s DiscordSession, event VoiceEvent) {
+	joinedChannelID, leftChannelID := event.Joined(), event.Left()
+	flapped := false
+	if leftChannelID != "" {
+		// Flap detection: a join followed by a leave within the interval is never announced
+		flapped = b.cancelDebounce(event.GuildId, event.UserId, leftChannelID)
+	}
 
-	if vsu.BeforeUpdate == nil {
-		// User joined a voice channel (no previous state)
-		if vsu.ChannelID != "" {
-			joinedChannelID = vsu.ChannelID
+	// Ignored users are tracked but never announced
+	if event.Ignored {
+		return
+	}
+	username := getUsername(event.Member)
+
+	// Mute and stream changes within a channel are notified in both modes
+	afkChannelID := b.excludedAFKChannel(event.GuildId)
+	switch event.Type {
+	case VoiceMute:
+		if event.ChannelId != afkChannelID {
+			b.notifyVoiceState(s, event, username, eventMute, TemplateEventMute, event.Muted)
 		}
-	} else {
-		// User was already in a voice channel
-		oldChannelID := vsu.BeforeUpdate.ChannelID
-		newChannelID := vsu.ChannelID
+		return
+	case VoiceStream:
+		if event.ChannelId != afkChannelID {
+			b.notifyVoiceState(s, event, username, eventStream, TemplateEventStream, event.Streaming)
+		}
+		return
+	}
 
-		// Only notify if user moved to a different channel (ignore leaves)
-		if oldChannelID != newChannelID && newChannelID != "" {
-			joinedChannelID = newChannelID
+	var stayed time.Duration
+	if leftChannelID != "" {
+		if since, ok := b.sessions.activeSince(event.UserId, leftChannelID); ok {
+			stayed = event.At.Sub(since)
+		}
+	}
+	previousCount := event.previousCount
+	sessionStart := joinedChannelID != "" && previousCount == 0
+
+	// The AFK channel is left out of notifications unless the guild opts in.
+	// Moving into it can be announced as going AFK instead of leaving.
+	wentAFK := false
+	if afkChannelID != "" {
+		if joinedChannelID == afkChannelID {
+			if leftChannelID != "" && !flapped && b.getAFKSetting(event.GuildId).WentAFK {
+				b.notifyWentAFK(s, event.GuildId, leftChannelID, event.UserId, username, stayed)
+				wentAFK = true
+			}
+			joinedChannelID = ""
+			sessionStart = false
+		}
+		if leftChannelID == afkChannelID {
+			leftChannelID = ""
 		}
 	}
 
+	if joinedChannelID != "" {
+		b.notifyFollowers(s, event.GuildId, event.UserId, joinedChannelID)
+	}
+
+	// Session start and end events are notified in both modes
+	if sessionStart {
+		b.notifyChannelActive(s, event.GuildId, joinedChannelID, event.UserId, username)
+	}
+	if leftChannelID != "" && b.occupancy.count(leftChannelID) == 0 {
+		b.notifyChannelEmpty(s, event.GuildId, leftChannelID, event.UserId, username, event.leftSince)
+	}
+
+	// User limit alerts are notified in both modes
+	if leftChannelID != "" {
+		count := b.occupancy.count(leftChannelID)
+		b.notifyCapacity(s, event.GuildId, leftChannelID, event.UserId, count+1, count)
+	}
+	if joinedChannelID != "" {
+		b.notifyCapacity(s, event.GuildId, joinedChannelID, event.UserId, previousCount, b.occupancy.count(joinedChannelID))
+	}
+
+	// Threshold subscriptions are notified in both modes
+	if joinedChannelID != "" {
+		b.notifyThresholds(s, joinedChannelID, previousCount, b.occupancy.count(joinedChannelID))
+		b.notifyEventMilestones(s, joinedChannelID, previousCount, b.occupancy.count(joinedChannelID))
+	}
+
+	if b.notificationMode == notificationModeSummary {
+		if leftChannelID != "" {
+			b.recordSummaryEvent(s, leftChannelID, username, false)
+		}
+		if joinedChannelID != "" {
+			b.recordSummaryEvent(s, joinedChannelID, username, true)
+		}
+		return
+	}
+
+	// A leave is covered by the move notification unless the user left voice,
+	// and is not announced if the join never was
+	if leftChannelID != "" && !flapped && !wentAFK && (joinedChannelID == "" || !b.moveNotifications) {
+		b.notifyLeave(s, event.GuildId, leftChannelID, event.UserId, username, stayed)
+	}
+
 	// Send join notification if applicable
 	if joinedChannelID != "" {
-		channel, err := s.Channel(joinedChannelID)
+		channel, err := b.channel(s, joinedChannelID)
 		channelName := joinedChannelID
 		if err == nil {
 			channelName = channel.Name
 		}
-		message := fmt.Sprintf("🔊 **%s** joined **%s**", username, channelName)
-		b.debounceNotification(s, vsu.UserID, joinedChannelID, message)
+		templateEvent := TemplateEvent{
+			Type:      TemplateEventJoin,
+			User:      username,
+			UserID:    event.UserId,
+			Channel:   channelName,
+			ChannelID: joinedChannelID,
+			Guild:     b.getGuildName(s, event.GuildId),
+			Count:     b.occupancy.count(joinedChannelID),
+			Time:      time.Now(),
+		}
+
+		// A switch between channels is one message, unless the previous join was never announced
+		n := notification{sessionStart: sessionStart}
+		if b.moveNotifications && leftChannelID != "" && !flapped {
+			templateEvent.Type = TemplateEventMove
+			templateEvent.FromChannel = b.getChannelName(s, leftChannelID)
+			templateEvent.FromChannelID = leftChannelID
+			n.fromChannelID = leftChannelID
+		}
+
+		n.content = b.renderMessage(event.GuildId, templateEvent)
+		b.debounceNotification(s, event.GuildId, event.UserId, joinedChannelID, n)
 	}
 }
 
-func (b *Bot) debounceNotification(s *discordgo.Session, userID, channelID, message string) {
-	key := fmt.Sprintf("%s:%s", userID, channelID)
+func (b *Bot) debounceNotification(s DiscordSession, guildID, userID, channelID string, n notification) {
+	strategies := b.notificationStrategies(guildID, channelID, n)
+	fast := b.eventRates.fast(guildID, time.Now())
+
+	b.debounceMu.Lock()
+	running := false
+	for _, strategy := range strategies {
+		if _, exists := b.debouncers[debounceKey(userID, channelID, strategy)]; exists {
+			running = true
+		}
+	}
+	// Quiet guilds are notified at once, unless a window is already running
+	if !running && fast {
+		b.debounceMu.Unlock()
+		b.debounceStats.update(guildID, func(stats *DebounceStats) {
+			stats.RawEvents++
+			stats.FastPath++
+		})
+		n.userID = userID
+		go b.fireNotification(s, guildID, channelID, n)
+		return
+	}
+	b.debounceMu.Unlock()
+
+	b.debounceStats.update(guildID, func(stats *DebounceStats) { stats.RawEvents++ })
+	for _, strategy := range strategies {
+		n.strategy = strategy
+		b.debounceWith(s, guildID, userID, channelID, n)
+	}
+}
+
+func debounceKey(userID, channelID, strategy string) string {
+	return fmt.Sprintf("%s:%s:%s", userID, channelID, strategy)
+}
+
+// debounceWith debounces a notification for the subscriptions that use
+// n.strategy
+func (b *Bot) debounceWith(s DiscordSession, guildID, userID, channelID string, n notification) {
+	key := debounceKey(userID, channelID, n.strategy)
 
 	b.debounceMu.Lock()
 	deb, exists := b.debouncers[key]
 	if !exists {
-		deb = &debouncer{}
+		deb = &debouncer{guildID: guildID, userID: userID, channelID: channelID, strategy: n.strategy}
 		b.debouncers[key] = deb
 	}
 	b.debounceMu.Unlock()
@@ -1035,38 +2085,124 @@ func (b *Bot) debounceNotification(s *discordgo.Session, userID, channelID, mess
 	defer deb.mu.Unlock()
 
 	// Update the message (in case user quickly switches channels)
-	deb.message = message
+	deb.message = n.content
+	deb.fromChannel = n.fromChannelID
+	deb.sessionStart = deb.sessionStart || n.sessionStart
+	deb.pending = true
 
-	// If there's an existing timer, stop it and restart
-	if deb.timer != nil {
-		deb.timer.Stop()
-	}
-
-	// Create a timer to send the join notification after the debounce interval
-	deb.timer = time.AfterFunc(b.debounceInterval, func() {
+	// flush runs when the debounce window ends
+	flush := func() {
 		deb.mu.Lock()
-		finalMessage := deb.message
+		pending := deb.pending
+		final := notification{content: deb.message, sessionStart: deb.sessionStart, userID: userID, fromChannelID: deb.fromChannel, strategy: deb.strategy}
+		deb.pending = false
+		deb.open = false
 		deb.mu.Unlock()
 
-		// Send the notification
-		b.sendNotifications(s, channelID, finalMessage)
+		if pending {
+			b.fireNotification(s, guildID, channelID, final)
+		}
 
 		// Clean up the debouncer after sending
 		b.debounceMu.Lock()
-		delete(b.debouncers, key)
+		if b.debouncers[key] == deb {
+			delete(b.debouncers, key)
+		}
 		b.debounceMu.Unlock()
-	})
+	}
+
+	if debounceStrategies[n.strategy].schedule(deb, b.debounceInterval, flush) {
+		final := notification{content: deb.message, sessionStart: deb.sessionStart, userID: userID, fromChannelID: deb.fromChannel, strategy: deb.strategy}
+		deb.pending = false
+		go b.fireNotification(s, guildID, channelID, final)
+	}
 }
 
-func (b *Bot) sendNotifications(s *discordgo.Session, voiceChannelID string, message string) {
-	b.mu.RLock()
-	subscriptions := b.subscriptions[voiceChannelID]
-	b.mu.RUnlock()
+// fireNotification sends a debounced notification unless another instance
+// already did or the user is on cooldown
+func (b *Bot) fireNotification(s DiscordSession, guildID, channelID string, n notification) {
+	// Another instance sharing the store may already have sent it
+	if !b.claim("join:"+b.debounceScope(guildID, channelID, n)+":"+n.userID, b.debounceInterval) {
+		return
+	}
 
-	for _, sub := range subscriptions {
-		_, err := s.ChannelMessageSend(sub.TextChannelId, message)
-		if err != nil {
-			log.Printf("Error sending notification to channel %v: %v", sub.TextChannelId, err)
+	// A flaky connection rejoining the same channel is announced once per window
+	if !b.rejoinCooldown.allow(b.debounceScope(guildID, channelID, n), n.userID) {
+		b.debounceStats.update(guildID, func(stats *DebounceStats) { stats.RejoinSuppressed++ })
+		return
+	}
+
+	// Send the notification unless the user was announced recently in any channel
+	if b.userCooldown.allow(b.debounceScope(guildID, guildID, n), n.userID) {
+		b.debounceStats.update(guildID, func(stats *DebounceStats) { stats.Notifications++ })
+		b.sendNotifications(s, channelID, n)
+	} else {
+		b.debounceStats.update(guildID, func(stats *DebounceStats) { stats.CooldownSuppressed++ })
+	}
+}
+
+func (b *Bot) sendNotifications(s DiscordSession, voiceChannelID string, n notification) {
+	event := eventJoin
+	if n.fromChannelID != "" {
+		event = eventMove
+	}
+	for _, sub := range b.notificationSubscriptions(voiceChannelID, n) {
+		if sub.Broken != "" || sub.StatusBoard || ((sub.MinUsers > 0 || !sub.Events.has(event)) && !sub.eventActive(time.Now())) {
+			continue
+		}
+		if n.strategy != "" && b.subscriptionDebounceStrategy(sub) != n.strategy {
+			continue
+		}
+		// DM subscribers are not told about themselves or channels they can no longer see
+		if sub.isDM() && (sub.UserId == n.userID || !b.userCanView(s, sub.UserId, sub.VoiceChannelId)) {
+			continue
 		}
+		message := b.styledMessage(s, sub, n)
+		if n.sessionStart && !sub.isDM() {
+			message.Components = b.sessionComponents(sub.GuildId, voiceChannelID)
+		}
+		sent := b.deliverNotification(s, sub, message)
+		b.trackSent(sub, sent, n.userID)
+	}
+}
+
+// deliverNotification sends a message to one subscription, applying quiet
+// hours and the rate limit. It returns the sent message, or nil if it was held.
+func (b *Bot) deliverNotification(s DiscordSession, sub subscription, message *discordgo.MessageSend) *discordgo.Message {
+	if b.notificationsPaused(sub.GuildId) {
+		return nil
+	}
+	if b.holdForQuietHours(s, sub, notificationText(message)) {
+		return nil
+	}
+	if b.holdForGrouping(s, sub, notificationText(message)) {
+		return nil
+	}
+	if !b.rateLimiter.allow(sub, notificationText(message), b.sendOverflow(s)) {
+		return nil
+	}
+
+	final := silentMessage(sub, b.withMentions(sub, message))
+	sent, err := b.deliver(s, sub, final)
+	if err != nil {
+		slog.Error("Error sending notification", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "event_type", "notification", "error", err)
+		b.deliveryFailed(s, sub, final, 1, err)
+		return nil
+	}
+	b.retries.delivered(sub)
+	if sent != nil {
+		b.recordFired(sub)
+		b.forwardExternal(sub, final)
+	}
+	return sent
+}
+
+// claim reports whether this instance should handle a shared event. Without a
+// shared store there is only one instance and every claim succeeds.
+func (b *Bot) claim(key string, ttl time.Duration) bool {
+	c, ok := b.persistence.(coordinator)
+	if !ok {
+		return true
 	}
+	return c.claim(key, max(ttl, 5*time.Second))
 }
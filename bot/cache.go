@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxCacheEntries bounds each map; expired entries are dropped when it is
+	// reached, and everything if that isn't enough
+	maxCacheEntries = 10000
+)
+
+type (
+	cacheEntry[T any] struct {
+		value   T
+		expires time.Time
+	}
+
+	// entityCache keeps channels and members fetched over REST for a while, for
+	// lookups the state cache can't answer, e.g. when STATE_CACHE leaves out
+	// channels or members. Gateway updates replace entries, the TTL catches
+	// changes the bot doesn't receive events for.
+	entityCache struct {
+		ttl      time.Duration // 0 disables caching
+		mu       sync.Mutex
+		channels map[string]cacheEntry[*discordgo.Channel]
+		members  map[string]cacheEntry[*discordgo.Member] // key: guildID:userID
+	}
+)
+
+func newEntityCache(ttl time.Duration) *entityCache {
+	return &entityCache{
+		ttl:      ttl,
+		channels: make(map[string]cacheEntry[*discordgo.Channel]),
+		members:  make(map[string]cacheEntry[*discordgo.Member]),
+	}
+}
+
+// lookup returns an entry's value if it hasn't expired
+func lookup[T any](entries map[string]cacheEntry[T], key string, now time.Time) (T, bool) {
+	entry, ok := entries[key]
+	if !ok || now.After(entry.expires) {
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// store adds an entry, making room first if the map is full
+func store[T any](entries map[string]cacheEntry[T], key string, value T, now time.Time, ttl time.Duration) {
+	if len(entries) >= maxCacheEntries {
+		for k, entry := range entries {
+			if now.After(entry.expires) {
+				delete(entries, k)
+			}
+		}
+		if len(entries) >= maxCacheEntries {
+			clear(entries)
+		}
+	}
+	entries[key] = cacheEntry[T]{value: value, expires: now.Add(ttl)}
+}
+
+func (c *entityCache) channel(channelID string) (*discordgo.Channel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lookup(c.channels, channelID, time.Now())
+}
+
+func (c *entityCache) putChannel(channel *discordgo.Channel) {
+	if c.ttl == 0 || channel == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	store(c.channels, channel.ID, channel, time.Now(), c.ttl)
+}
+
+func (c *entityCache) removeChannel(channelID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.channels, channelID)
+}
+
+func (c *entityCache) member(guildID, userID string) (*discordgo.Member, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lookup(c.members, guildID+":"+userID, time.Now())
+}
+
+func (c *entityCache) putMember(guildID string, member *discordgo.Member) {
+	if c.ttl == 0 || member == nil || member.User == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	store(c.members, guildID+":"+member.User.ID, member, time.Now(), c.ttl)
+}
+
+func (c *entityCache) removeMember(guildID, userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.members, guildID+":"+userID)
+}
+
+// channel returns a channel from the state cache, the entity cache, or
+// Discord, in that order
+func (b *Bot) channel(s DiscordSession, channelID string) (*discordgo.Channel, error) {
+	if channel, err := b.session.State.Channel(channelID); err == nil {
+		return channel, nil
+	}
+	if channel, ok := b.cache.channel(channelID); ok {
+		return channel, nil
+	}
+	channel, err := s.Channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	b.cache.putChannel(channel)
+	return channel, nil
+}
+
+// member returns a guild member from the state cache, the entity cache, or
+// Discord, in that order
+func (b *Bot) member(s DiscordSession, guildID, userID string) (*discordgo.Member, error) {
+	if member, err := b.session.State.Member(guildID, userID); err == nil {
+		return member, nil
+	}
+	if member, ok := b.cache.member(guildID, userID); ok {
+		return member, nil
+	}
+	member, err := s.GuildMember(guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	b.cache.putMember(guildID, member)
+	return member, nil
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelDelete archives subscriptions of a deleted voice channel and moves
+// subscriptions that post into a deleted text channel to the fallback channel
+func (b *Bot) channelDelete(s DiscordSession, c *discordgo.ChannelDelete) {
+	switch c.Type {
+	case discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice:
+		description := b.t(c.GuildID, "archive.removed")
+		if b.channelRecreateGrace > 0 {
+			description = b.t(c.GuildID, "archive.recreate", c.Name, formatDuration(b.channelRecreateGrace))
+		}
+		subs, watched := b.archiveVoiceChannel(s, c.GuildID, c.ID, c.Name, "deleted", description)
+		b.rememberDeletedChannel(c.Channel, subs, watched)
+	default:
+		b.textChannelGone(s, c.GuildID, c.ID, c.Name)
+	}
+}
+
+// channelUpdate archives a voice channel's subscriptions when the bot can no
+// longer see it, e.g. after a permission change
+func (b *Bot) channelUpdate(s DiscordSession, c *discordgo.ChannelUpdate) {
+	if c.Type != discordgo.ChannelTypeGuildVoice && c.Type != discordgo.ChannelTypeGuildStageVoice {
+		return
+	}
+
+	if !b.subscriptions.Has(c.ID) {
+		return
+	}
+
+	permissions, err := s.UserChannelPermissions(b.session.State.User.ID, c.ID)
+	if err != nil || permissions&discordgo.PermissionViewChannel != 0 {
+		return
+	}
+	b.archiveVoiceChannel(s, c.GuildID, c.ID, c.Name, "hidden", b.t(c.GuildID, "archive.removed"))
+}
+
+// archiveVoiceChannel posts a final summary of a voice channel to its
+// subscribed text channels and removes the subscriptions. It returns the
+// removed subscriptions and whether the channel was watched. The reason,
+// "deleted" or "hidden", picks the summary's title.
+func (b *Bot) archiveVoiceChannel(s DiscordSession, guildID, voiceChannelID, channelName, reason, description string) ([]subscription, bool) {
+	subs := b.subscriptions.RemoveChannel(voiceChannelID)
+
+	watched := b.removeWatch(guildID, voiceChannelID)
+
+	if len(subs) == 0 {
+		return nil, watched
+	}
+
+	sessions, total, lastActivity := b.channelHistory(guildID, voiceChannelID)
+	last := b.t(guildID, "archive.never")
+	if !lastActivity.IsZero() {
+		last = fmt.Sprintf("<t:%d:R>", lastActivity.Unix())
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       b.t(guildID, "archive.title."+reason, channelName),
+		Description: description,
+		Color:       0x99AAB5,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: b.t(guildID, "archive.sessions"), Value: fmt.Sprintf("%d", sessions), Inline: true},
+			{Name: b.t(guildID, "archive.voice-time"), Value: formatDuration(total), Inline: true},
+			{Name: b.t(guildID, "archive.last-activity"), Value: last, Inline: true},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	b.presentEmbed(guildID, embed)
+
+	for _, sub := range subs {
+		if sub.Broken != "" {
+			continue
+		}
+		if sub.StatusBoard && sub.StatusMessageId != "" {
+			s.ChannelMessageUnpin(sub.TextChannelId, sub.StatusMessageId)
+		}
+		if _, err := s.ChannelMessageSendEmbed(sub.TextChannelId, embed); err != nil {
+			slog.Error("Error posting channel archive summary", "guild_id", guildID, "channel_id", sub.TextChannelId, "event_type", "channel_archive", "error", err)
+		}
+	}
+	slog.Info("Archived voice channel", "guild_id", guildID, "voice_channel_id", voiceChannelID, "reason", reason, "subscriptions", len(subs))
+	return subs, watched
+}
+
+// channelHistory returns the recorded sessions, total voice time, and last
+// activity of a voice channel
+func (b *Bot) channelHistory(guildID, voiceChannelID string) (sessions int, total time.Duration, lastActivity time.Time) {
+	now := time.Now()
+	for _, session := range b.sessions.sessions(guildID, time.Time{}, now) {
+		if session.ChannelId != voiceChannelID {
+			continue
+		}
+		sessions++
+		total += session.End.Sub(session.Start)
+		if session.End.After(lastActivity) {
+			lastActivity = session.End
+		}
+	}
+	return sessions, total, lastActivity
+}
@@ -0,0 +1,278 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// subscribeAccess controls who may subscribe and unsubscribe in a guild.
+	// Exactly one of RoleId and Permission is set.
+	subscribeAccess struct {
+		RoleId     string `json:"role_id,omitempty"`
+		Permission string `json:"permission,omitempty"` // a key of accessPermissions
+	}
+)
+
+// defaultAccessPermission is required to subscribe unless a guild configures otherwise
+const defaultAccessPermission = "manage-channels"
+
+// accessPermissions maps the permission choices of /config permission to Discord permissions
+var accessPermissions = map[string]int64{
+	"everyone":        0,
+	"manage-channels": discordgo.PermissionManageChannels,
+	"manage-messages": discordgo.PermissionManageMessages,
+	"manage-server":   discordgo.PermissionManageServer,
+}
+
+// configCommand returns the /config command definition
+func configCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "config",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "permission",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type: discordgo.ApplicationCommandOptionRole,
+						Name: "role",
+					},
+					{
+						Type: discordgo.ApplicationCommandOptionString,
+						Name: "permission",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Value: "everyone"},
+							{Value: "manage-channels"},
+							{Value: "manage-messages"},
+							{Value: "manage-server"},
+						},
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "debounce",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type: discordgo.ApplicationCommandOptionString,
+						Name: "strategy",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Value: debounceTrailing},
+							{Value: debounceLeading},
+							{Value: debounceBatch},
+						},
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "voice-channel",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "emoji",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type: discordgo.ApplicationCommandOptionBoolean,
+						Name: "enabled",
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "style",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type: discordgo.ApplicationCommandOptionString,
+						Name: "preset",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Value: presentationEmoji},
+							{Value: presentationPlain},
+							{Value: presentationMinimal},
+						},
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "timestamps",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type: discordgo.ApplicationCommandOptionBoolean,
+						Name: "enabled",
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "tone",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type: discordgo.ApplicationCommandOptionString,
+						Name: "preset",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Value: toneCasual},
+							{Value: toneFormal},
+							{Value: toneMeme},
+						},
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "fallback-channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type: discordgo.ApplicationCommandOptionBoolean,
+						Name: "clear",
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "afk",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type: discordgo.ApplicationCommandOptionBoolean,
+						Name: "notify",
+					},
+					{
+						Type: discordgo.ApplicationCommandOptionBoolean,
+						Name: "went-afk",
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "language",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:    discordgo.ApplicationCommandOptionString,
+						Name:    "locale",
+						Choices: languageChoices(),
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleConfig(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !hasPermission(i, discordgo.PermissionManageServer) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "config.manage-server"))
+		return
+	}
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "permission":
+		b.handleConfigPermission(s, i, optionMap(subcommand.Options))
+	case "debounce":
+		b.handleConfigDebounce(s, i, optionMap(subcommand.Options))
+	case "emoji":
+		b.handleConfigEmoji(s, i, optionMap(subcommand.Options))
+	case "style":
+		b.handleConfigStyle(s, i, optionMap(subcommand.Options))
+	case "timestamps":
+		b.handleConfigTimestamps(s, i, optionMap(subcommand.Options))
+	case "tone":
+		b.handleConfigTone(s, i, optionMap(subcommand.Options))
+	case "fallback-channel":
+		b.handleConfigFallbackChannel(s, i, optionMap(subcommand.Options))
+	case "afk":
+		b.handleConfigAFK(s, i, optionMap(subcommand.Options))
+	case "language":
+		b.handleConfigLanguage(s, i, optionMap(subcommand.Options))
+	}
+}
+
+func (b *Bot) handleConfigPermission(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	roleOpt, hasRole := options["role"]
+	permOpt, hasPerm := options["permission"]
+
+	if hasRole && hasPerm {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "config.role-or-permission"))
+		return
+	}
+
+	if !hasRole && !hasPerm {
+		access := b.getSubscribeAccess(i.GuildID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "access.current", b.describeAccess(i.GuildID, access)))
+		return
+	}
+
+	var access subscribeAccess
+	if hasRole {
+		access.RoleId = roleOpt.RoleValue(nil, i.GuildID).ID
+	} else {
+		access.Permission = permOpt.StringValue()
+	}
+
+	b.mu.Lock()
+	if access.Permission == defaultAccessPermission {
+		delete(b.subscribeAccess, i.GuildID)
+	} else {
+		b.subscribeAccess[i.GuildID] = access
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Subscribe permission changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "role_id", access.RoleId, "permission", access.Permission)
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "access.set", b.describeAccess(i.GuildID, access)))
+}
+
+// getSubscribeAccess returns the subscribe rule of a guild, or the default
+func (b *Bot) getSubscribeAccess(guildID string) subscribeAccess {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if access, ok := b.subscribeAccess[guildID]; ok {
+		return access
+	}
+	return subscribeAccess{Permission: defaultAccessPermission}
+}
+
+// describeAccess returns who a subscribe rule allows, for messages
+func (b *Bot) describeAccess(guildID string, access subscribeAccess) string {
+	if access.RoleId != "" {
+		return b.t(guildID, "access.role", access.RoleId)
+	}
+	if access.Permission == "everyone" {
+		return b.t(guildID, "access.everyone")
+	}
+	return b.t(guildID, "access.permission", b.t(guildID, "permission."+access.Permission))
+}
+
+// requireSubscribeAccess responds with an error and returns false unless the
+// invoking member may manage subscriptions. Manage Server always qualifies so
+// admins cannot lock themselves out.
+func (b *Bot) requireSubscribeAccess(s DiscordSession, i *discordgo.InteractionCreate) bool {
+	if hasPermission(i, discordgo.PermissionManageServer) {
+		return true
+	}
+
+	access := b.getSubscribeAccess(i.GuildID)
+	if access.RoleId != "" {
+		if i.Member != nil && slices.Contains(i.Member.Roles, access.RoleId) {
+			return true
+		}
+	} else if permission, ok := accessPermissions[access.Permission]; ok && (permission == 0 || hasPermission(i, permission)) {
+		return i.Member != nil
+	}
+
+	respondWithError(s, i.Interaction, b.t(i.GuildID, "access.denied", b.describeAccess(i.GuildID, access)))
+	return false
+}
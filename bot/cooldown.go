@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// userCooldown limits announcements per user across all channels of a
+	// guild. It also limits repeat joins per voice channel and user, and
+	// mentions per text channel and role or user.
+	userCooldown struct {
+		window time.Duration
+		last   map[string]time.Time // key: guildID:userID, voiceChannelID:userID, or textChannelID:mentionedID
+		mu     sync.Mutex
+	}
+)
+
+func newUserCooldown(window time.Duration) *userCooldown {
+	return &userCooldown{
+		window: window,
+		last:   make(map[string]time.Time),
+	}
+}
+
+// allow returns true if the user may be announced now and starts their cooldown
+func (c *userCooldown) allow(guildID, userID string) bool {
+	if c.window <= 0 {
+		return true
+	}
+
+	key := guildID + ":" + userID
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if last, ok := c.last[key]; ok && now.Sub(last) < c.window {
+		return false
+	}
+	c.last[key] = now
+
+	// Drop expired entries so the map doesn't grow with every user ever seen
+	for k, t := range c.last {
+		if now.Sub(t) >= c.window {
+			delete(c.last, k)
+		}
+	}
+	return true
+}
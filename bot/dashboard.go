@@ -0,0 +1,756 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	discordOAuthAuthorizeURL = "https://discord.com/oauth2/authorize"
+	discordOAuthTokenURL     = "https://discord.com/api/oauth2/token"
+	discordAPIURL            = "https://discord.com/api/v10"
+
+	dashboardCookie     = "voiceactivitybot_session"
+	dashboardStateTTL   = 10 * time.Minute
+	dashboardSessionTTL = 12 * time.Hour
+	dashboardGuildsTTL  = 5 * time.Minute // how long a lost Manage Server permission still works
+	dashboardPruneEvery = time.Minute     // how often expired sessions and logins are dropped
+)
+
+type (
+	// dashboardServer serves the web dashboard on DASHBOARD_PORT. Admins log
+	// in with Discord and can manage the guilds they have Manage Server in.
+	dashboardServer struct {
+		bot          *Bot
+		clientID     string
+		clientSecret string
+		baseURL      string // public URL of the dashboard, without trailing slash
+		server       *http.Server
+		client       *http.Client
+
+		sessions map[string]*dashboardSession // by session cookie
+		states   map[string]time.Time         // pending OAuth2 logins
+		mu       sync.Mutex
+	}
+
+	// dashboardSession is a logged in dashboard user
+	dashboardSession struct {
+		userID        string
+		username      string
+		accessToken   string            // the user's OAuth2 token, to check guilds again
+		guilds        map[string]string // guildID -> name of guilds the user may manage, replaced but never changed
+		guildsChecked time.Time
+		csrf          string
+		expires       time.Time
+	}
+
+	// dashboardGuildPage is the data of the guild page template
+	dashboardGuildPage struct {
+		GuildID       string
+		GuildName     string
+		Username      string
+		CSRF          string
+		Message       string
+		Subscriptions []dashboardSubscription
+		VoiceChannels []dashboardChannel
+		TextChannels  []dashboardChannel
+		Templates     []dashboardTemplate
+		Ignored       []string
+		QuietModes    []string
+	}
+
+	dashboardSubscription struct {
+		VoiceChannelId string
+		VoiceChannel   string
+		TextChannelId  string
+		Target         string
+		Label          string
+		QuietHours     *quietHours
+		Broken         string
+		LastFired      string
+	}
+
+	dashboardChannel struct {
+		Id   string
+		Name string
+	}
+
+	dashboardTemplate struct {
+		Event string
+		Text  string
+	}
+)
+
+// newDashboardServer creates the dashboard, or returns nil when no port is
+// configured
+func newDashboardServer(b *Bot, cfg config.Dashboard) *dashboardServer {
+	if cfg.Port == 0 {
+		return nil
+	}
+
+	d := &dashboardServer{
+		bot:          b,
+		clientID:     cfg.ClientId,
+		clientSecret: cfg.ClientSecret,
+		baseURL:      cfg.URL,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		sessions:     make(map[string]*dashboardSession),
+		states:       make(map[string]time.Time),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", d.handleIndex)
+	mux.HandleFunc("GET /login", d.handleLogin)
+	mux.HandleFunc("GET /callback", d.handleCallback)
+	mux.HandleFunc("POST /logout", d.handleLogout)
+	mux.HandleFunc("GET /guilds/{guildID}", d.guild(d.handleGuild))
+	mux.HandleFunc("POST /guilds/{guildID}/subscriptions", d.guildForm(d.handleAddSubscription))
+	mux.HandleFunc("POST /guilds/{guildID}/subscriptions/delete", d.guildForm(d.handleDeleteSubscription))
+	mux.HandleFunc("POST /guilds/{guildID}/quiet-hours", d.guildForm(d.handleSetQuietHours))
+	mux.HandleFunc("POST /guilds/{guildID}/templates", d.guildForm(d.handleSetTemplate))
+	mux.HandleFunc("POST /guilds/{guildID}/ignored", d.guildForm(d.handleIgnored))
+
+	d.server = &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return d
+}
+
+func (d *dashboardServer) start() {
+	go d.pruneSessions(d.bot.ctx)
+	go func() {
+		slog.Info("Dashboard listening", "addr", d.server.Addr, "url", d.baseURL)
+		if err := d.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Dashboard stopped", "error", err)
+		}
+	}()
+}
+
+func (d *dashboardServer) stop(ctx context.Context) {
+	if err := d.server.Shutdown(ctx); err != nil {
+		slog.Error("Error shutting down dashboard", "error", err)
+	}
+}
+
+// pruneSessions drops expired sessions and pending logins every
+// dashboardPruneEvery until ctx is done, so sessions nobody logs out of don't
+// pile up
+func (d *dashboardServer) pruneSessions(ctx context.Context) {
+	ticker := time.NewTicker(dashboardPruneEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.prune(now)
+		}
+	}
+}
+
+// prune drops the sessions and pending logins expired at now
+func (d *dashboardServer) prune(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, session := range d.sessions {
+		if now.After(session.expires) {
+			delete(d.sessions, id)
+		}
+	}
+	for state, expires := range d.states {
+		if now.After(expires) {
+			delete(d.states, state)
+		}
+	}
+}
+
+// randomToken returns a random hex string for session IDs, states, and CSRF tokens
+func randomToken() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// session returns a copy of the logged in user of a request, or nil. The
+// guilds the user may manage are fetched again from Discord once they are
+// older than dashboardGuildsTTL; if that fails, the user is logged out.
+func (d *dashboardServer) session(r *http.Request) *dashboardSession {
+	cookie, err := r.Cookie(dashboardCookie)
+	if err != nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	session, ok := d.sessions[cookie.Value]
+	if !ok || time.Now().After(session.expires) {
+		delete(d.sessions, cookie.Value)
+		d.mu.Unlock()
+		return nil
+	}
+	current := *session
+	d.mu.Unlock()
+
+	if time.Since(current.guildsChecked) < dashboardGuildsTTL {
+		return &current
+	}
+	guilds, err := d.manageableGuilds(r.Context(), current.accessToken)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sessions[cookie.Value] != session {
+		return nil // logged out meanwhile
+	}
+	if err != nil {
+		slog.Warn("Dashboard session ended, guilds could not be checked", "user_id", current.userID, "error", err)
+		delete(d.sessions, cookie.Value)
+		return nil
+	}
+	session.guilds = guilds
+	session.guildsChecked = time.Now()
+	current.guilds = guilds
+	return &current
+}
+
+// guild wraps a guild page handler, requiring a login with access to the guild
+func (d *dashboardServer) guild(next func(http.ResponseWriter, *http.Request, *dashboardSession, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session := d.session(r)
+		if session == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		guildID := r.PathValue("guildID")
+		if _, ok := session.guilds[guildID]; !ok {
+			http.Error(w, d.bot.translate(requestLocale(r), "dashboard.error.no-permission"), http.StatusForbidden)
+			return
+		}
+		next(w, r, session, guildID)
+	}
+}
+
+// guildForm wraps a form handler like guild and also checks the CSRF token.
+// The handler returns a status, whose dashboard.status message is shown after
+// redirecting back to the guild page. Only these fixed texts are shown, so a
+// link can't put text of its choosing on the page.
+func (d *dashboardServer) guildForm(next func(*http.Request, *dashboardSession, string) string) http.HandlerFunc {
+	return d.guild(func(w http.ResponseWriter, r *http.Request, session *dashboardSession, guildID string) {
+		if subtle.ConstantTimeCompare([]byte(r.PostFormValue("csrf")), []byte(session.csrf)) != 1 {
+			http.Error(w, d.bot.t(guildID, "dashboard.error.invalid-form"), http.StatusForbidden)
+			return
+		}
+		status := next(r, session, guildID)
+		http.Redirect(w, r, fmt.Sprintf("/guilds/%s?status=%s", guildID, url.QueryEscape(status)), http.StatusSeeOther)
+	})
+}
+
+func (d *dashboardServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	session := d.session(r)
+	if session == nil {
+		d.render(w, dashboardLoginTemplate, requestLocale(r), nil)
+		return
+	}
+
+	// Only list guilds the bot is in
+	var guilds []dashboardChannel
+	for guildID, name := range session.guilds {
+		if _, err := d.bot.session.State.Guild(guildID); err == nil {
+			guilds = append(guilds, dashboardChannel{Id: guildID, Name: name})
+		}
+	}
+	slices.SortFunc(guilds, func(x, y dashboardChannel) int { return cmp.Compare(x.Name, y.Name) })
+
+	d.render(w, dashboardIndexTemplate, requestLocale(r), map[string]any{
+		"Username": session.username,
+		"CSRF":     session.csrf,
+		"Guilds":   guilds,
+	})
+}
+
+func (d *dashboardServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state := randomToken()
+
+	d.mu.Lock()
+	d.states[state] = time.Now().Add(dashboardStateTTL)
+	d.mu.Unlock()
+
+	// Bind the state to this browser so a login can't be started for someone else
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie + "_state",
+		Value:    state,
+		Path:     "/",
+		MaxAge:   int(dashboardStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(d.baseURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := url.Values{
+		"client_id":     {d.clientID},
+		"redirect_uri":  {d.baseURL + "/callback"},
+		"response_type": {"code"},
+		"scope":         {"identify guilds"},
+		"state":         {state},
+		"prompt":        {"none"},
+	}
+	http.Redirect(w, r, discordOAuthAuthorizeURL+"?"+query.Encode(), http.StatusSeeOther)
+}
+
+func (d *dashboardServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(dashboardCookie + "_state")
+
+	d.mu.Lock()
+	expires, pending := d.states[state]
+	delete(d.states, state)
+	d.mu.Unlock()
+
+	if err != nil || cookie.Value != state || !pending || time.Now().After(expires) {
+		http.Error(w, d.bot.translate(requestLocale(r), "dashboard.error.login-expired"), http.StatusBadRequest)
+		return
+	}
+
+	accessToken, err := d.exchangeCode(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		slog.Warn("Dashboard login failed", "error", err)
+		http.Error(w, d.bot.translate(requestLocale(r), "dashboard.error.login-failed"), http.StatusBadGateway)
+		return
+	}
+
+	var user discordgo.User
+	if err := d.discordGet(r.Context(), accessToken, "/users/@me", &user); err != nil {
+		slog.Warn("Dashboard login failed", "error", err)
+		http.Error(w, d.bot.translate(requestLocale(r), "dashboard.error.login-failed"), http.StatusBadGateway)
+		return
+	}
+	guilds, err := d.manageableGuilds(r.Context(), accessToken)
+	if err != nil {
+		slog.Warn("Dashboard login failed", "user_id", user.ID, "error", err)
+		http.Error(w, d.bot.translate(requestLocale(r), "dashboard.error.login-failed"), http.StatusBadGateway)
+		return
+	}
+
+	session := &dashboardSession{
+		userID:        user.ID,
+		username:      user.Username,
+		accessToken:   accessToken,
+		guilds:        guilds,
+		guildsChecked: time.Now(),
+		csrf:          randomToken(),
+		expires:       time.Now().Add(dashboardSessionTTL),
+	}
+
+	sessionID := randomToken()
+	d.mu.Lock()
+	d.sessions[sessionID] = session
+	d.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie,
+		Value:    sessionID,
+		Path:     "/",
+		MaxAge:   int(dashboardSessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(d.baseURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	slog.Info("Dashboard login", "audit", true, "user_id", user.ID, "guilds", len(session.guilds))
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (d *dashboardServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(dashboardCookie); err == nil {
+		d.mu.Lock()
+		session, ok := d.sessions[cookie.Value]
+		// The CSRF token keeps other sites from logging users out
+		if ok && subtle.ConstantTimeCompare([]byte(r.PostFormValue("csrf")), []byte(session.csrf)) == 1 {
+			delete(d.sessions, cookie.Value)
+		}
+		d.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: dashboardCookie, Value: "", Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// exchangeCode trades an OAuth2 authorization code for an access token
+func (d *dashboardServer) exchangeCode(ctx context.Context, code string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {d.baseURL + "/callback"},
+		"client_id":     {d.clientID},
+		"client_secret": {d.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discordOAuthTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token exchange: %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// manageableGuilds returns the guilds a user owns or has Manage Server in,
+// by ID with their names
+func (d *dashboardServer) manageableGuilds(ctx context.Context, accessToken string) (map[string]string, error) {
+	var userGuilds []*discordgo.UserGuild
+	if err := d.discordGet(ctx, accessToken, "/users/@me/guilds", &userGuilds); err != nil {
+		return nil, err
+	}
+	guilds := make(map[string]string)
+	for _, guild := range userGuilds {
+		if guild.Owner || guild.Permissions&discordgo.PermissionManageServer != 0 || guild.Permissions&discordgo.PermissionAdministrator != 0 {
+			guilds[guild.ID] = guild.Name
+		}
+	}
+	return guilds, nil
+}
+
+// discordGet fetches a Discord API resource with a user's access token
+func (d *dashboardServer) discordGet(ctx context.Context, accessToken, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discordAPIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (d *dashboardServer) handleGuild(w http.ResponseWriter, r *http.Request, session *dashboardSession, guildID string) {
+	b := d.bot
+	page := dashboardGuildPage{
+		GuildID:    guildID,
+		GuildName:  session.guilds[guildID],
+		Username:   session.username,
+		CSRF:       session.csrf,
+		Message:    d.statusMessage(guildID, r.URL.Query().Get("status")),
+		QuietModes: []string{quietModeSuppress, quietModeQueue, quietModeSilent},
+	}
+
+	for _, sub := range b.subscriptions.Guild(guildID) {
+		page.Subscriptions = append(page.Subscriptions, dashboardSubscription{
+			VoiceChannelId: sub.VoiceChannelId,
+			VoiceChannel:   b.getChannelName(b.rest, sub.VoiceChannelId),
+			TextChannelId:  sub.TextChannelId,
+			Target:         sub.targetName(b.rest, b),
+			Label:          sub.Label,
+			QuietHours:     sub.QuietHours,
+			Broken:         b.brokenReason(guildID, sub.Broken),
+			LastFired:      d.formatLastFired(guildID, sub.LastFiredAt),
+		})
+	}
+	slices.SortFunc(page.Subscriptions, func(x, y dashboardSubscription) int {
+		return cmp.Or(cmp.Compare(x.VoiceChannel, y.VoiceChannel), cmp.Compare(x.Target, y.Target))
+	})
+
+	if guild, err := b.session.State.Guild(guildID); err == nil {
+		b.session.State.RLock()
+		for _, channel := range guild.Channels {
+			switch channel.Type {
+			case discordgo.ChannelTypeGuildVoice:
+				page.VoiceChannels = append(page.VoiceChannels, dashboardChannel{Id: channel.ID, Name: channel.Name})
+			case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews:
+				page.TextChannels = append(page.TextChannels, dashboardChannel{Id: channel.ID, Name: channel.Name})
+			}
+		}
+		b.session.State.RUnlock()
+	}
+	slices.SortFunc(page.VoiceChannels, func(x, y dashboardChannel) int { return cmp.Compare(x.Name, y.Name) })
+	slices.SortFunc(page.TextChannels, func(x, y dashboardChannel) int { return cmp.Compare(x.Name, y.Name) })
+
+	b.mu.RLock()
+	for _, event := range TemplateEvents {
+		page.Templates = append(page.Templates, dashboardTemplate{Event: event, Text: b.templates[guildID][event]})
+	}
+	page.Ignored = slices.Clone(b.ignored[guildID])
+	b.mu.RUnlock()
+
+	d.render(w, dashboardGuildTemplate, b.guildLanguage(guildID), page)
+}
+
+// formatLastFired describes when a subscription last fired, for the dashboard
+func (d *dashboardServer) formatLastFired(guildID string, at time.Time) string {
+	if at.IsZero() {
+		return d.bot.t(guildID, "dashboard.never-fired")
+	}
+	return d.bot.t(guildID, "dashboard.fired-ago", formatDuration(time.Since(at)))
+}
+
+// statusMessage returns the message of a form's status in the guild's
+// language, or nothing for unknown statuses
+func (d *dashboardServer) statusMessage(guildID, status string) string {
+	if status == "" {
+		return ""
+	}
+	if _, ok := d.bot.lookup(defaultLocale, "dashboard.status."+status); !ok {
+		return ""
+	}
+	return d.bot.t(guildID, "dashboard.status."+status)
+}
+
+// requestLocale returns the supported locale a browser prefers, English when
+// it prefers none of them
+func requestLocale(r *http.Request) string {
+	for _, tag := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		base, _, _ := strings.Cut(tag, "-")
+		for _, locale := range supportedLocales {
+			if strings.EqualFold(tag, locale) {
+				return locale
+			}
+		}
+		for _, locale := range supportedLocales {
+			if prefix, _, _ := strings.Cut(locale, "-"); strings.EqualFold(base, prefix) {
+				return locale
+			}
+		}
+	}
+	return defaultLocale
+}
+
+func (d *dashboardServer) handleAddSubscription(r *http.Request, session *dashboardSession, guildID string) string {
+	voiceChannelID := r.PostFormValue("voice_channel_id")
+	textChannelID := r.PostFormValue("text_channel_id")
+
+	voice, err := d.bot.session.State.Channel(voiceChannelID)
+	if err != nil || voice.GuildID != guildID || voice.Type != discordgo.ChannelTypeGuildVoice {
+		return "choose-voice-channel"
+	}
+	text, err := d.bot.session.State.Channel(textChannelID)
+	if err != nil || text.GuildID != guildID || (text.Type != discordgo.ChannelTypeGuildText && text.Type != discordgo.ChannelTypeGuildNews) {
+		return "choose-text-channel"
+	}
+
+	alreadySubscribed, err := d.bot.addSubscription(voiceChannelID, textChannelID, guildID)
+	if err != nil {
+		slog.Warn("Dashboard subscription failed", "guild_id", guildID, "user_id", session.userID, "channel_id", voiceChannelID, "text_channel_id", textChannelID, "error", err)
+		return "subscribe-failed"
+	}
+	if alreadySubscribed {
+		return "already-subscribed"
+	}
+	slog.Info("Subscription added", "audit", true, "source", "dashboard", "guild_id", guildID, "user_id", session.userID, "channel_id", voiceChannelID, "text_channel_id", textChannelID)
+	return "subscribed"
+}
+
+func (d *dashboardServer) handleDeleteSubscription(r *http.Request, session *dashboardSession, guildID string) string {
+	voiceChannelID := r.PostFormValue("voice_channel_id")
+	textChannelID := r.PostFormValue("text_channel_id")
+
+	if !d.bot.subscriptions.RemoveIn(guildID, voiceChannelID, textChannelID) {
+		return "subscription-not-found"
+	}
+	slog.Info("Subscription removed", "audit", true, "source", "dashboard", "guild_id", guildID, "user_id", session.userID, "channel_id", voiceChannelID, "text_channel_id", textChannelID)
+	return "unsubscribed"
+}
+
+func (d *dashboardServer) handleSetQuietHours(r *http.Request, session *dashboardSession, guildID string) string {
+	voiceChannelID := r.PostFormValue("voice_channel_id")
+	textChannelID := r.PostFormValue("text_channel_id")
+
+	if sub, ok := d.bot.getSubscription(voiceChannelID, textChannelID); !ok || sub.GuildId != guildID {
+		return "subscription-not-found"
+	}
+
+	var qh *quietHours
+	if start := r.PostFormValue("start"); start != "" {
+		qh = &quietHours{
+			Start:    start,
+			End:      r.PostFormValue("end"),
+			Timezone: cmp.Or(r.PostFormValue("timezone"), "UTC"),
+			Mode:     cmp.Or(r.PostFormValue("mode"), quietModeSuppress),
+		}
+		if err := qh.validate(); err != nil {
+			return "invalid-quiet-hours"
+		}
+	}
+
+	d.bot.updateSubscription(voiceChannelID, textChannelID, func(sub *subscription) {
+		sub.QuietHours = qh
+	})
+	slog.Info("Quiet hours changed", "audit", true, "source", "dashboard", "guild_id", guildID, "user_id", session.userID, "channel_id", voiceChannelID, "text_channel_id", textChannelID)
+	if qh == nil {
+		return "quiet-hours-removed"
+	}
+	return "quiet-hours-set"
+}
+
+func (d *dashboardServer) handleSetTemplate(r *http.Request, session *dashboardSession, guildID string) string {
+	event := r.PostFormValue("event")
+	text := strings.TrimSpace(r.PostFormValue("template"))
+	if !slices.Contains(TemplateEvents, event) {
+		return "unknown-event"
+	}
+
+	if text != "" {
+		if _, err := RenderTemplate(text, SampleEvents(event)[0]); err != nil {
+			return "invalid-template"
+		}
+	}
+	d.bot.setTemplate(guildID, event, text)
+	slog.Info("Template changed", "audit", true, "source", "dashboard", "guild_id", guildID, "user_id", session.userID, "event", event)
+	if text == "" {
+		return "template-reset"
+	}
+	return "template-saved"
+}
+
+func (d *dashboardServer) handleIgnored(r *http.Request, session *dashboardSession, guildID string) string {
+	userID := strings.TrimSpace(r.PostFormValue("user_id"))
+	if userID == "" || strings.Trim(userID, "0123456789") != "" {
+		return "invalid-user-id"
+	}
+
+	if r.PostFormValue("action") == "remove" {
+		if !d.bot.unignoreUser(guildID, userID) {
+			return "user-not-ignored"
+		}
+		slog.Info("User unignored", "audit", true, "source", "dashboard", "guild_id", guildID, "user_id", session.userID, "target_id", userID)
+		return "user-unignored"
+	}
+	if !d.bot.ignoreUser(guildID, userID) {
+		return "user-already-ignored"
+	}
+	slog.Info("User ignored", "audit", true, "source", "dashboard", "guild_id", guildID, "user_id", session.userID, "target_id", userID)
+	return "user-ignored"
+}
+
+// render writes a dashboard page in a locale
+func (d *dashboardServer) render(w http.ResponseWriter, page *template.Template, locale string, data any) {
+	localized, err := page.Clone()
+	if err != nil {
+		slog.Error("Error rendering dashboard page", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	localized.Funcs(template.FuncMap{
+		"t":    func(key string, args ...any) string { return d.bot.translate(locale, key, args...) },
+		"lang": func() string { return locale },
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Frame-Options", "DENY")
+	// The pages are the content of the layout, which must be what runs
+	if err := localized.ExecuteTemplate(w, "layout", data); err != nil {
+		slog.Error("Error rendering dashboard page", "error", err)
+	}
+}
+
+// dashboardFuncs are replaced with the locale's when a page is rendered
+var dashboardFuncs = template.FuncMap{
+	"t":    func(key string, args ...any) string { return key },
+	"lang": func() string { return defaultLocale },
+}
+
+// dashboardPage parses a page's content into the layout
+func dashboardPage(content string) *template.Template {
+	layout := template.Must(template.New("layout").Funcs(dashboardFuncs).Parse(dashboardLayout))
+	return template.Must(layout.New("content").Parse(content))
+}
+
+const dashboardLayout = `<!DOCTYPE html>
+<html lang="{{lang}}"><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>VoiceActivityBot</title>
+<style>
+body{font-family:system-ui,sans-serif;margin:0 auto;max-width:960px;padding:1rem 2rem;background:#313338;color:#dbdee1}
+a{color:#00a8fc}h1,h2{color:#f2f3f5}table{border-collapse:collapse;width:100%}
+td,th{border-bottom:1px solid #4e5058;padding:.4rem;text-align:left;vertical-align:top}
+input,select,textarea,button{background:#1e1f22;color:#dbdee1;border:1px solid #4e5058;border-radius:4px;padding:.3rem}
+button{background:#5865f2;border:none;color:#fff;cursor:pointer}button.danger{background:#da373c}
+.msg{background:#2b2d31;border-left:4px solid #5865f2;padding:.5rem 1rem}header{display:flex;justify-content:space-between;align-items:center}
+</style></head><body>{{template "content" .}}</body></html>`
+
+var (
+	dashboardLoginTemplate = dashboardPage(`
+<h1>🔊 VoiceActivityBot</h1>
+<p>{{t "dashboard.intro"}}</p>
+<p><a href="/login">{{t "dashboard.login"}}</a></p>`)
+
+	dashboardIndexTemplate = dashboardPage(`
+<header><h1>{{t "dashboard.servers"}}</h1>
+<form method="post" action="/logout"><input type="hidden" name="csrf" value="{{.CSRF}}">{{.Username}} <button>{{t "dashboard.logout"}}</button></form></header>
+{{range .Guilds}}<p><a href="/guilds/{{.Id}}">{{.Name}}</a></p>
+{{else}}<p>{{t "dashboard.no-servers"}}</p>{{end}}`)
+
+	dashboardGuildTemplate = dashboardPage(`
+<header><h1>{{.GuildName}}</h1><p><a href="/">{{t "dashboard.all-servers"}}</a> · {{.Username}}</p></header>
+{{if .Message}}<p class="msg">{{.Message}}</p>{{end}}
+
+<h2>{{t "dashboard.subscriptions"}}</h2>
+<table><tr><th>{{t "dashboard.voice-channel"}}</th><th>{{t "dashboard.notifies"}}</th><th>{{t "dashboard.last-fired"}}</th><th>{{t "dashboard.quiet-hours"}}</th><th></th></tr>
+{{range .Subscriptions}}<tr>
+<td>🔊 {{.VoiceChannel}}</td><td>{{.Target}}{{if .Label}}<br>🏷️ {{.Label}}{{end}}{{if .Broken}}<br>⚠️ {{.Broken}}{{end}}</td><td>{{.LastFired}}</td>
+<td><form method="post" action="/guilds/{{$.GuildID}}/quiet-hours">
+<input type="hidden" name="csrf" value="{{$.CSRF}}"><input type="hidden" name="voice_channel_id" value="{{.VoiceChannelId}}"><input type="hidden" name="text_channel_id" value="{{.TextChannelId}}">
+<input name="start" size="5" placeholder="22:00" value="{{with .QuietHours}}{{.Start}}{{end}}"> – <input name="end" size="5" placeholder="07:00" value="{{with .QuietHours}}{{.End}}{{end}}">
+<input name="timezone" size="14" placeholder="UTC" value="{{with .QuietHours}}{{.Timezone}}{{end}}">
+<select name="mode">{{$current := ""}}{{with .QuietHours}}{{$current = .Mode}}{{end}}{{range $.QuietModes}}<option value="{{.}}"{{if eq . $current}} selected{{end}}>{{t (printf "command.quiet-hours.set.mode.choice.%s" .)}}</option>{{end}}</select>
+<button>{{t "dashboard.save"}}</button></form></td>
+<td><form method="post" action="/guilds/{{$.GuildID}}/subscriptions/delete">
+<input type="hidden" name="csrf" value="{{$.CSRF}}"><input type="hidden" name="voice_channel_id" value="{{.VoiceChannelId}}"><input type="hidden" name="text_channel_id" value="{{.TextChannelId}}">
+<button class="danger">{{t "dashboard.remove"}}</button></form></td>
+</tr>{{else}}<tr><td colspan="5">{{t "dashboard.no-subscriptions"}}</td></tr>{{end}}
+</table>
+<p>{{t "dashboard.quiet-hours-hint"}}</p>
+
+<form method="post" action="/guilds/{{.GuildID}}/subscriptions"><input type="hidden" name="csrf" value="{{.CSRF}}">
+<select name="voice_channel_id">{{range .VoiceChannels}}<option value="{{.Id}}">🔊 {{.Name}}</option>{{end}}</select> →
+<select name="text_channel_id">{{range .TextChannels}}<option value="{{.Id}}"># {{.Name}}</option>{{end}}</select>
+<button>{{t "dashboard.subscribe"}}</button></form>
+
+<h2>{{t "dashboard.templates"}}</h2>
+<table>{{range .Templates}}<tr><td>{{.Event}}</td><td>
+<form method="post" action="/guilds/{{$.GuildID}}/templates"><input type="hidden" name="csrf" value="{{$.CSRF}}"><input type="hidden" name="event" value="{{.Event}}">
+<input name="template" size="60" placeholder="{{t "dashboard.template-default"}}" value="{{.Text}}"> <button>{{t "dashboard.save"}}</button></form></td></tr>{{end}}
+</table>
+<p>{{t "dashboard.template-fields"}} <code>{{"{{.User}}"}}</code>, <code>{{"{{.Channel}}"}}</code>, <code>{{"{{.Count}}"}}</code>, … {{t "dashboard.template-hint"}}</p>
+
+<h2>{{t "dashboard.ignored-users"}}</h2>
+<table>{{range .Ignored}}<tr><td>{{.}}</td><td>
+<form method="post" action="/guilds/{{$.GuildID}}/ignored"><input type="hidden" name="csrf" value="{{$.CSRF}}"><input type="hidden" name="user_id" value="{{.}}"><input type="hidden" name="action" value="remove">
+<button class="danger">{{t "dashboard.remove"}}</button></form></td></tr>{{else}}<tr><td>{{t "dashboard.nobody-ignored"}}</td></tr>{{end}}
+</table>
+<form method="post" action="/guilds/{{.GuildID}}/ignored"><input type="hidden" name="csrf" value="{{.CSRF}}">
+<input name="user_id" placeholder="{{t "dashboard.user-id"}}"> <button>{{t "dashboard.ignore"}}</button></form>`)
+)
@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// roundTripFunc answers a client's requests without a network
+	roundTripFunc func(*http.Request) (*http.Response, error)
+)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newTestDashboard returns a dashboard whose Discord API calls are answered by
+// discord, with a session for cookie "session" that managed guild 1 when its
+// guilds were checked at checked
+func newTestDashboard(t *testing.T, checked time.Time, discord http.HandlerFunc) *dashboardServer {
+	t.Helper()
+	b := newTestBot(t)
+	if err := b.session.State.GuildAdd(&discordgo.Guild{ID: "1", Name: "Gaming"}); err != nil {
+		t.Fatalf("GuildAdd: %v", err)
+	}
+	d := newDashboardServer(b, config.Dashboard{Port: 8083, ClientId: "client", ClientSecret: "secret", URL: "https://bot.example.com"})
+	d.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		discord(rec, r)
+		return rec.Result(), nil
+	})
+	d.sessions["session"] = &dashboardSession{
+		userID:        "200000000000000001",
+		accessToken:   "user-token",
+		guilds:        map[string]string{"1": "Gaming"},
+		guildsChecked: checked,
+		expires:       time.Now().Add(time.Hour),
+	}
+	return d
+}
+
+func getGuildPage(d *dashboardServer) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/guilds/1", nil)
+	req.AddCookie(&http.Cookie{Name: dashboardCookie, Value: "session"})
+	rec := httptest.NewRecorder()
+	d.server.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDashboardRechecksGuilds(t *testing.T) {
+	calls := 0
+	lostPermission := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer user-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		writeJSON(w, http.StatusOK, []*discordgo.UserGuild{{ID: "1", Name: "Gaming"}})
+	}
+
+	d := newTestDashboard(t, time.Now(), lostPermission)
+	rec := getGuildPage(d)
+	if rec.Code != http.StatusOK || calls != 0 {
+		t.Fatalf("recently checked session: %d after %d calls, want 200 without calling Discord", rec.Code, calls)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "<!DOCTYPE html>") {
+		t.Errorf("guild page is not wrapped in the layout: %.80q", rec.Body.String())
+	}
+
+	d = newTestDashboard(t, time.Now().Add(-dashboardGuildsTTL), lostPermission)
+	if rec := getGuildPage(d); rec.Code != http.StatusForbidden || calls != 1 {
+		t.Fatalf("stale session: %d after %d calls, want 403 after checking again", rec.Code, calls)
+	}
+}
+
+func TestDashboardEndsSessionWhenCheckFails(t *testing.T) {
+	d := newTestDashboard(t, time.Now().Add(-dashboardGuildsTTL), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	rec := getGuildPage(d)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/login" {
+		t.Fatalf("revoked token: %d to %q, want a redirect to /login", rec.Code, rec.Header().Get("Location"))
+	}
+	if _, ok := d.sessions["session"]; ok {
+		t.Error("session was kept after the check failed")
+	}
+}
+
+func TestDashboardAddSubscription(t *testing.T) {
+	tests := []struct {
+		name       string
+		voice      string
+		text       string
+		wantStatus string
+	}{
+		{name: "voice and text channel", voice: "11", text: "12", wantStatus: "subscribed"},
+		{name: "voice channel as text channel", voice: "11", text: "11", wantStatus: "choose-text-channel"},
+		{name: "category as text channel", voice: "11", text: "13", wantStatus: "choose-text-channel"},
+		{name: "text channel as voice channel", voice: "12", text: "12", wantStatus: "choose-voice-channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDashboard(t, time.Now(), nil)
+			for _, channel := range []*discordgo.Channel{
+				{ID: "11", GuildID: "1", Name: "Raid", Type: discordgo.ChannelTypeGuildVoice},
+				{ID: "12", GuildID: "1", Name: "general", Type: discordgo.ChannelTypeGuildText},
+				{ID: "13", GuildID: "1", Name: "Games", Type: discordgo.ChannelTypeGuildCategory},
+			} {
+				if err := d.bot.session.State.ChannelAdd(channel); err != nil {
+					t.Fatalf("ChannelAdd: %v", err)
+				}
+			}
+			d.sessions["session"].csrf = "token"
+
+			form := url.Values{"csrf": {"token"}, "voice_channel_id": {tt.voice}, "text_channel_id": {tt.text}}
+			req := httptest.NewRequest(http.MethodPost, "/guilds/1/subscriptions", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(&http.Cookie{Name: dashboardCookie, Value: "session"})
+			rec := httptest.NewRecorder()
+			d.server.Handler.ServeHTTP(rec, req)
+
+			if want := "/guilds/1?status=" + tt.wantStatus; rec.Header().Get("Location") != want {
+				t.Errorf("redirected to %q, want %q", rec.Header().Get("Location"), want)
+			}
+			if _, ok := d.bot.lookup(defaultLocale, "dashboard.status."+tt.wantStatus); !ok {
+				t.Errorf("status %q has no message", tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDashboardShowsOnlyKnownStatuses(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string // empty when no message is shown
+	}{
+		{status: "subscribed", want: "✅ Subscription added"},
+		{status: "<script>alert(1)</script>"},
+		{status: "Your account was suspended, log in at evil.example"},
+		{status: "intro"}, // a dashboard message, but not a status
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			d := newTestDashboard(t, time.Now(), nil)
+			req := httptest.NewRequest(http.MethodGet, "/guilds/1?status="+url.QueryEscape(tt.status), nil)
+			req.AddCookie(&http.Cookie{Name: dashboardCookie, Value: "session"})
+			rec := httptest.NewRecorder()
+			d.server.Handler.ServeHTTP(rec, req)
+
+			shown := strings.Contains(rec.Body.String(), `class="msg"`)
+			if shown != (tt.want != "") {
+				t.Errorf("message shown = %v, want %v", shown, tt.want != "")
+			}
+			if tt.want != "" && !strings.Contains(rec.Body.String(), template.HTMLEscapeString(tt.want)) {
+				t.Errorf("page doesn't show %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestDashboardLanguage(t *testing.T) {
+	d := newTestDashboard(t, time.Now(), nil)
+	d.bot.mu.Lock()
+	d.bot.languages["1"] = "de"
+	d.bot.mu.Unlock()
+	d.bot.subscriptions.Add(subscription{VoiceChannelId: "11", TextChannelId: "12", GuildId: "1"})
+
+	body := getGuildPage(d).Body.String()
+	for _, want := range []string{`<html lang="de">`, "Abonnements", "Zuletzt ausgelöst", "nie", "Sammeln (nach den Ruhezeiten posten)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("German guild page doesn't contain %q", want)
+		}
+	}
+}
+
+func TestRequestLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: "en"},
+		{header: "de-DE,de;q=0.9,en;q=0.8", want: "de"},
+		{header: "pt-BR,pt;q=0.9", want: "pt-BR"},
+		{header: "pt-PT", want: "pt-BR"},
+		{header: "ja,fr;q=0.5", want: "fr"},
+		{header: "ja", want: "en"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", tt.header)
+		if got := requestLocale(req); got != tt.want {
+			t.Errorf("requestLocale(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestDashboardPrune(t *testing.T) {
+	d := newTestDashboard(t, time.Now(), nil)
+	now := time.Now()
+	d.sessions["expired"] = &dashboardSession{expires: now.Add(-time.Second)}
+	d.states["expired"] = now.Add(-time.Second)
+	d.states["pending"] = now.Add(time.Minute)
+
+	d.prune(now)
+
+	if _, ok := d.sessions["expired"]; ok {
+		t.Error("expired session was kept")
+	}
+	if _, ok := d.sessions["session"]; !ok {
+		t.Error("active session was dropped")
+	}
+	if _, ok := d.states["expired"]; ok {
+		t.Error("expired login was kept")
+	}
+	if _, ok := d.states["pending"]; !ok {
+		t.Error("pending login was dropped")
+	}
+}
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Debounce strategies a guild can choose
+const (
+	debounceTrailing = "trailing" // wait until events stop for the interval, then send the latest (default)
+	debounceLeading  = "leading"  // send the first event at once, coalesce the rest until the interval passes
+	debounceBatch    = "batch"    // collect events for a fixed interval from the first one, then send the latest
+)
+
+type (
+	// debounceStrategy decides when a debouncer sends its notification
+	debounceStrategy interface {
+		// schedule is called with deb.mu held after every event. It arranges
+		// for flush to run when the window ends and returns true if the event
+		// must be sent right away.
+		schedule(deb *debouncer, interval time.Duration, flush func()) bool
+	}
+
+	trailingStrategy struct{}
+	leadingStrategy  struct{}
+	batchStrategy    struct{}
+)
+
+// debounceStrategies maps strategy names to implementations
+var debounceStrategies = map[string]debounceStrategy{
+	debounceTrailing: trailingStrategy{},
+	debounceLeading:  leadingStrategy{},
+	debounceBatch:    batchStrategy{},
+}
+
+func (trailingStrategy) schedule(deb *debouncer, interval time.Duration, flush func()) bool {
+	// Every event restarts the window
+	if deb.timer != nil {
+		deb.timer.Stop()
+	}
+	deb.open = true
+	deb.timer = time.AfterFunc(interval, flush)
+	return false
+}
+
+func (leadingStrategy) schedule(deb *debouncer, interval time.Duration, flush func()) bool {
+	if deb.open {
+		return false
+	}
+	deb.open = true
+	deb.timer = time.AfterFunc(interval, flush)
+	return true
+}
+
+func (batchStrategy) schedule(deb *debouncer, interval time.Duration, flush func()) bool {
+	if !deb.open {
+		deb.open = true
+		deb.timer = time.AfterFunc(interval, flush)
+	}
+	return false
+}
+
+// debounceStrategyName returns the strategy a guild uses
+func (b *Bot) debounceStrategyName(guildID string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if strategy, ok := b.debounceStrategies[guildID]; ok {
+		return strategy
+	}
+	return b.defaultDebounceStrategy
+}
+
+// subscriptionDebounceStrategy returns the strategy a subscription's
+// notifications are debounced with: its own, or else the guild's
+func (b *Bot) subscriptionDebounceStrategy(sub subscription) string {
+	if _, ok := debounceStrategies[sub.DebounceStrategy]; ok {
+		return sub.DebounceStrategy
+	}
+	return b.debounceStrategyName(sub.GuildId)
+}
+
+// notificationStrategies returns the strategies used by the subscriptions a
+// notification goes to, so each group gets its own debouncer. Without
+// subscriptions it is the guild's strategy.
+func (b *Bot) notificationStrategies(guildID, channelID string, n notification) []string {
+	var strategies []string
+	for _, sub := range b.notificationSubscriptions(channelID, n) {
+		if strategy := b.subscriptionDebounceStrategy(sub); !slices.Contains(strategies, strategy) {
+			strategies = append(strategies, strategy)
+		}
+	}
+	if len(strategies) == 0 {
+		strategies = append(strategies, b.debounceStrategyName(guildID))
+	}
+	return strategies
+}
+
+// debounceScope returns the claim and cooldown key of id for a notification.
+// Subscriptions with their own strategy get their own keys, so their
+// notifications don't suppress the guild's and vice versa.
+func (b *Bot) debounceScope(guildID, id string, n notification) string {
+	if n.strategy == "" || n.strategy == b.debounceStrategyName(guildID) {
+		return id
+	}
+	return id + "/" + n.strategy
+}
+
+// handleConfigDebounce sets the guild's debounce strategy, or with a voice
+// channel the strategy of its subscription in the current channel
+func (b *Bot) handleConfigDebounce(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	if voiceChannel, ok := options["voice-channel"]; ok {
+		b.handleSubscriptionDebounce(s, i, voiceChannel.ChannelValue(nil).ID, options["strategy"])
+		return
+	}
+
+	opt, ok := options["strategy"]
+	if !ok {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "debounce.current", b.debounceStrategyName(i.GuildID), b.debounceInterval))
+		return
+	}
+
+	strategy := opt.StringValue()
+	b.mu.Lock()
+	if strategy == b.defaultDebounceStrategy {
+		delete(b.debounceStrategies, i.GuildID)
+	} else {
+		b.debounceStrategies[i.GuildID] = strategy
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Debounce strategy changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "strategy", strategy)
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "debounce.set", strategy))
+}
+
+// handleSubscriptionDebounce sets the debounce strategy of one subscription.
+// Choosing the guild's strategy removes the override, so the subscription
+// follows later changes of the guild's strategy again.
+func (b *Bot) handleSubscriptionDebounce(s DiscordSession, i *discordgo.InteractionCreate, voiceChannelID string, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	channelName := b.getChannelName(s, voiceChannelID)
+	sub, found := b.getSubscription(voiceChannelID, i.ChannelID)
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "subscription.not-found", channelName))
+		return
+	}
+	if opt == nil {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "debounce.subscription.current", channelName, b.subscriptionDebounceStrategy(sub), b.debounceInterval))
+		return
+	}
+
+	strategy := opt.StringValue()
+	override := strategy
+	if strategy == b.debounceStrategyName(i.GuildID) {
+		override = ""
+	}
+	b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+		sub.DebounceStrategy = override
+	})
+
+	slog.Info("Subscription debounce strategy changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "voice_channel_id", voiceChannelID, "channel_id", i.ChannelID, "strategy", strategy)
+	if override == "" {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "debounce.subscription.server", channelName, strategy))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "debounce.subscription.set", channelName, strategy))
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDebounceStrategySchedule(t *testing.T) {
+	tests := []struct {
+		strategy    string
+		wantNow     []bool // whether each of two events is sent at once
+		wantRestart bool   // whether the second event restarts the window
+	}{
+		{strategy: debounceTrailing, wantNow: []bool{false, false}, wantRestart: true},
+		{strategy: debounceLeading, wantNow: []bool{true, false}},
+		{strategy: debounceBatch, wantNow: []bool{false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			deb := &debouncer{}
+			strategy := debounceStrategies[tt.strategy]
+
+			var timers []*time.Timer
+			for idx, wantNow := range tt.wantNow {
+				if now := strategy.schedule(deb, time.Hour, func() {}); now != wantNow {
+					t.Errorf("event %d sent at once = %v, want %v", idx+1, now, wantNow)
+				}
+				if !deb.open {
+					t.Errorf("no window open after event %d", idx+1)
+				}
+				timers = append(timers, deb.timer)
+			}
+			deb.timer.Stop()
+
+			if restarted := timers[0] != timers[1]; restarted != tt.wantRestart {
+				t.Errorf("window restarted = %v, want %v", restarted, tt.wantRestart)
+			}
+		})
+	}
+}
+
+func TestNotificationStrategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		guild     string // the guild's strategy, empty for the default
+		overrides []string
+		want      []string
+	}{
+		{name: "no subscriptions", want: []string{debounceTrailing}},
+		{name: "guild strategy", guild: debounceBatch, overrides: []string{""}, want: []string{debounceBatch}},
+		{name: "override", overrides: []string{debounceLeading}, want: []string{debounceLeading}},
+		{name: "override next to the guild's", overrides: []string{"", debounceLeading, ""}, want: []string{debounceTrailing, debounceLeading}},
+		{name: "unknown override", overrides: []string{"instant"}, want: []string{debounceTrailing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			if tt.guild != "" {
+				b.debounceStrategies["1"] = tt.guild
+			}
+			for idx, override := range tt.overrides {
+				b.subscriptions.Add(subscription{VoiceChannelId: "11", TextChannelId: string(rune('a' + idx)), GuildId: "1", DebounceStrategy: override})
+			}
+
+			got := b.notificationStrategies("1", "11", notification{})
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("strategies %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSubscriptionDebounceStrategy sends one join to a channel with a leading
+// and a trailing subscription: the leading one is notified at once, the
+// trailing one when the window ends, and neither one's user cooldown
+// suppresses the other
+func TestSubscriptionDebounceStrategy(t *testing.T) {
+	b := newTestBot(t)
+	b.debounceInterval = 50 * time.Millisecond
+	b.userCooldown = newUserCooldown(time.Minute)
+	session := &postingSession{}
+	b.subscriptions.Add(subscription{VoiceChannelId: "11", TextChannelId: "lobby", GuildId: "1"})
+	b.subscriptions.Add(subscription{VoiceChannelId: "11", TextChannelId: "raid", GuildId: "1", DebounceStrategy: debounceLeading})
+
+	b.debounceNotification(session, "1", "100", "11", notification{content: "Alice joined"})
+
+	waitFor(t, time.Second, func() bool { return len(session.messages("raid")) == 1 })
+	if sent := session.messages("lobby"); len(sent) != 0 {
+		t.Errorf("trailing subscription notified before the window ended: %q", sent)
+	}
+	waitFor(t, time.Second, func() bool { return len(session.messages("lobby")) == 1 })
+	if sent := session.messages("raid"); len(sent) != 1 {
+		t.Errorf("leading subscription got %q, want one message", sent)
+	}
+}
+
+// waitFor polls cond until it holds or timeout passes
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// DebounceStats counts how debouncing treated the join events of a guild
+	// since the bot started
+	DebounceStats struct {
+		RawEvents          int       `json:"raw_events"`          // joins that went into a debouncer
+		Notifications      int       `json:"notifications"`       // debounced notifications that were sent
+		FlapsSuppressed    int       `json:"flaps_suppressed"`    // joins canceled because the user left within the interval
+		CooldownSuppressed int       `json:"cooldown_suppressed"` // notifications dropped by USER_COOLDOWN
+		RejoinSuppressed   int       `json:"rejoin_suppressed"`   // notifications dropped by REJOIN_COOLDOWN
+		FastPath           int       `json:"fast_path"`           // joins sent without debouncing because the guild was quiet
+		Since              time.Time `json:"since"`
+	}
+
+	// debounceStatsStore keeps DebounceStats per guild
+	debounceStatsStore struct {
+		guilds map[string]*DebounceStats
+		mu     sync.Mutex
+	}
+)
+
+func newDebounceStatsStore() *debounceStatsStore {
+	return &debounceStatsStore{guilds: make(map[string]*DebounceStats)}
+}
+
+// update changes a guild's counters under the lock
+func (st *debounceStatsStore) update(guildID string, fn func(stats *DebounceStats)) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	stats, exists := st.guilds[guildID]
+	if !exists {
+		stats = &DebounceStats{Since: time.Now().UTC()}
+		st.guilds[guildID] = stats
+	}
+	fn(stats)
+}
+
+// get returns a copy of a guild's counters
+func (st *debounceStatsStore) get(guildID string) DebounceStats {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if stats, exists := st.guilds[guildID]; exists {
+		return *stats
+	}
+	return DebounceStats{}
+}
+
+// forget deletes a guild's counters
+func (st *debounceStatsStore) forget(guildID string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.guilds, guildID)
+}
+
+// cancelDebounce drops the pending join notifications, one per debounce
+// strategy in use, when the user leaves the channel again before they were
+// sent, and reports whether one was pending
+func (b *Bot) cancelDebounce(guildID, userID, channelID string) bool {
+	var debouncers []*debouncer
+	b.debounceMu.Lock()
+	for strategy := range debounceStrategies {
+		key := debounceKey(userID, channelID, strategy)
+		if deb, exists := b.debouncers[key]; exists {
+			debouncers = append(debouncers, deb)
+			delete(b.debouncers, key)
+		}
+	}
+	b.debounceMu.Unlock()
+
+	pending := false
+	for _, deb := range debouncers {
+		deb.mu.Lock()
+		if deb.timer != nil && deb.timer.Stop() && deb.pending {
+			pending = true
+		}
+		deb.mu.Unlock()
+	}
+
+	if pending {
+		b.debounceStats.update(guildID, func(stats *DebounceStats) { stats.FlapsSuppressed++ })
+	}
+	return pending
+}
+
+// debounceStatsCommand returns the /debounce-stats command definition
+func debounceStatsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "debounce-stats",
+		DefaultMemberPermissions: &manageServerPermission,
+	}
+}
+
+func (b *Bot) handleDebounceStats(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	stats := b.debounceStats.get(i.GuildID)
+	if stats.RawEvents == 0 {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "debounce.stats.empty"))
+		return
+	}
+
+	perNotification := "–"
+	if stats.Notifications > 0 {
+		perNotification = fmt.Sprintf("%.2f", float64(stats.RawEvents)/float64(stats.Notifications))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: b.t(i.GuildID, "debounce.stats.title"),
+		Description: b.t(i.GuildID, "debounce.stats.description",
+			stats.Since.Unix(), b.debounceInterval, b.debounceStrategyName(i.GuildID)),
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: b.t(i.GuildID, "debounce.stats.joins"), Value: fmt.Sprintf("%d", stats.RawEvents), Inline: true},
+			{Name: b.t(i.GuildID, "debounce.stats.sent"), Value: fmt.Sprintf("%d", stats.Notifications), Inline: true},
+			{Name: b.t(i.GuildID, "debounce.stats.per-notification"), Value: perNotification, Inline: true},
+			{Name: b.t(i.GuildID, "debounce.stats.flaps"), Value: fmt.Sprintf("%d", stats.FlapsSuppressed), Inline: true},
+			{Name: b.t(i.GuildID, "debounce.stats.cooldown"), Value: fmt.Sprintf("%d", stats.CooldownSuppressed), Inline: true},
+			{Name: b.t(i.GuildID, "debounce.stats.rejoin"), Value: fmt.Sprintf("%d", stats.RejoinSuppressed), Inline: true},
+			{Name: b.t(i.GuildID, "debounce.stats.fast-path"), Value: fmt.Sprintf("%d", stats.FastPath), Inline: true},
+		},
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// DeliveryStats counts the deliveries to one subscription since the bot started
+	DeliveryStats struct {
+		Sent        int       `json:"sent"`
+		Failed      int       `json:"failed"`
+		LastSent    time.Time `json:"last_sent,omitzero"`
+		LastError   string    `json:"last_error,omitempty"`
+		LastErrorAt time.Time `json:"last_error_at,omitzero"`
+	}
+
+	// SubscriptionHealth is the health of one subscription
+	SubscriptionHealth struct {
+		VoiceChannelId string `json:"voice_channel_id"`
+		TextChannelId  string `json:"text_channel_id"`
+		UserId         string `json:"user_id,omitempty"`
+		Broken         string `json:"broken,omitempty"`
+		DeliveryStats
+	}
+
+	// deliveryStatsStore keeps DeliveryStats per subscription
+	deliveryStatsStore struct {
+		subscriptions map[string]*DeliveryStats // key: voiceChannelID:textChannelID
+		mu            sync.Mutex
+	}
+)
+
+func newDeliveryStatsStore() *deliveryStatsStore {
+	return &deliveryStatsStore{subscriptions: make(map[string]*DeliveryStats)}
+}
+
+// record counts one delivery attempt to a subscription
+func (st *deliveryStatsStore) record(sub subscription, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	key := sub.VoiceChannelId + ":" + sub.TextChannelId
+	stats, exists := st.subscriptions[key]
+	if !exists {
+		stats = &DeliveryStats{}
+		st.subscriptions[key] = stats
+	}
+
+	now := time.Now().UTC()
+	if err != nil {
+		stats.Failed++
+		stats.LastError = err.Error()
+		stats.LastErrorAt = now
+		return
+	}
+	stats.Sent++
+	stats.LastSent = now
+}
+
+// get returns a copy of a subscription's counters
+func (st *deliveryStatsStore) get(sub subscription) DeliveryStats {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if stats, exists := st.subscriptions[sub.VoiceChannelId+":"+sub.TextChannelId]; exists {
+		return *stats
+	}
+	return DeliveryStats{}
+}
+
+// forget deletes the counters of subscriptions
+func (st *deliveryStatsStore) forget(subs []subscription) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, sub := range subs {
+		delete(st.subscriptions, sub.VoiceChannelId+":"+sub.TextChannelId)
+	}
+}
+
+// successRate returns the share of successful deliveries, or -1 without any
+func (stats DeliveryStats) successRate() float64 {
+	if stats.Sent+stats.Failed == 0 {
+		return -1
+	}
+	return float64(stats.Sent) / float64(stats.Sent+stats.Failed)
+}
+
+// subscriptionHealth returns the health of every subscription of a guild,
+// paused ones first
+func (b *Bot) subscriptionHealth(guildID string) []SubscriptionHealth {
+	var health []SubscriptionHealth
+	for _, sub := range b.subscriptions.Guild(guildID) {
+		health = append(health, SubscriptionHealth{
+			VoiceChannelId: sub.VoiceChannelId,
+			TextChannelId:  sub.TextChannelId,
+			UserId:         sub.UserId,
+			Broken:         sub.Broken,
+			DeliveryStats:  b.deliveryStats.get(sub),
+		})
+	}
+
+	slices.SortFunc(health, func(a, c SubscriptionHealth) int {
+		if (a.Broken != "") != (c.Broken != "") {
+			if a.Broken != "" {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.VoiceChannelId+a.TextChannelId, c.VoiceChannelId+c.TextChannelId)
+	})
+	return health
+}
+
+// subscriptionHealthCommand returns the /subscription-health command definition
+func subscriptionHealthCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "subscription-health",
+		DefaultMemberPermissions: &manageServerPermission,
+	}
+}
+
+func (b *Bot) handleSubscriptionHealth(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	health := b.subscriptionHealth(i.GuildID)
+	if len(health) == 0 {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "common.no-subscriptions"))
+		return
+	}
+
+	// Embeds hold at most 25 fields
+	var fields []*discordgo.MessageEmbedField
+	for _, h := range health[:min(25, len(health))] {
+		sub := subscription{TextChannelId: h.TextChannelId, UserId: h.UserId}
+
+		var lines []string
+		if h.Broken != "" {
+			lines = append(lines, b.t(i.GuildID, "health.paused", b.brokenReason(i.GuildID, h.Broken)))
+		} else {
+			lines = append(lines, b.t(i.GuildID, "health.active"))
+		}
+		if rate := h.successRate(); rate >= 0 {
+			lines = append(lines, b.t(i.GuildID, "health.delivered", rate*100, h.Sent, h.Sent+h.Failed))
+		} else {
+			lines = append(lines, b.t(i.GuildID, "health.nothing-sent"))
+		}
+		if !h.LastSent.IsZero() {
+			lines = append(lines, b.t(i.GuildID, "health.last-sent", h.LastSent.Unix()))
+		}
+		if h.LastError != "" {
+			lines = append(lines, fmt.Sprintf("❌ <t:%d:R>: %s", h.LastErrorAt.Unix(), truncateMessage(h.LastError, 200)))
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  truncateMessage(fmt.Sprintf("🔊 %s → %s", b.getChannelName(s, h.VoiceChannelId), sub.targetName(s, b)), 256),
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+
+	healthy := 0
+	for _, h := range health {
+		if h.Broken == "" {
+			healthy++
+		}
+	}
+	description := b.t(i.GuildID, "health.description", healthy, len(health))
+	if len(health) > len(fields) {
+		description += b.t(i.GuildID, "health.truncated", len(fields))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       b.t(i.GuildID, "health.title"),
+				Description: description,
+				Color:       0x5865F2,
+				Fields:      fields,
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,304 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+
+	// digestCheckInterval is how often the scheduler looks for due digests
+	digestCheckInterval = time.Minute
+)
+
+type (
+	// voiceDigest is a recurring activity summary posted to a text channel
+	voiceDigest struct {
+		ChannelId string    `json:"channel_id"`
+		Period    string    `json:"period"` // daily or weekly
+		Hour      int       `json:"hour"`   // UTC hour it is posted at; weekly digests are posted on Mondays
+		LastSent  time.Time `json:"last_sent,omitzero"`
+	}
+
+	// digestStats is the activity of a guild over a digest period
+	digestStats struct {
+		Total    time.Duration
+		Users    int
+		Peak     int
+		PeakAt   time.Time
+		Channels []channelVoiceTime // most active first
+	}
+
+	channelVoiceTime struct {
+		ChannelId string
+		Time      time.Duration
+	}
+)
+
+// digestCommand returns the /digest command definition
+func digestCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "digest",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "set",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:     discordgo.ApplicationCommandOptionString,
+						Name:     "period",
+						Required: true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Value: digestDaily},
+							{Value: digestWeekly},
+						},
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:     discordgo.ApplicationCommandOptionInteger,
+						Name:     "hour",
+						MinValue: &[]float64{0}[0],
+						MaxValue: 23,
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "preview",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "clear",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleDigest(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subcommand.Options)
+
+	switch subcommand.Name {
+	case "set":
+		digest := voiceDigest{
+			ChannelId: i.ChannelID,
+			Period:    options["period"].StringValue(),
+			Hour:      9,
+			// Nothing is due before the first scheduled time from now on
+			LastSent: time.Now(),
+		}
+		if opt, ok := options["channel"]; ok {
+			digest.ChannelId = opt.ChannelValue(nil).ID
+		}
+		if opt, ok := options["hour"]; ok {
+			digest.Hour = int(opt.IntValue())
+		}
+
+		b.mu.Lock()
+		b.digests[i.GuildID] = digest
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+
+		slog.Info("Digest scheduled", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", digest.ChannelId, "period", digest.Period)
+		next := digest.scheduledAt(time.Now()).Add(digest.length())
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "digest.scheduled."+digest.Period, digest.ChannelId, next.Unix()))
+	case "preview":
+		b.mu.RLock()
+		digest, exists := b.digests[i.GuildID]
+		period := digestWeekly
+		if exists {
+			period = digest.Period
+		}
+		b.mu.RUnlock()
+
+		to := time.Now()
+		from := to.Add(-voiceDigest{Period: period}.length())
+		embed := b.presentEmbed(i.GuildID, b.digestEmbed(s, i.GuildID, period, b.digestStats(i.GuildID, from, to), from, to))
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+				Flags:  discordgo.MessageFlagsEphemeral,
+			},
+		})
+	case "clear":
+		b.mu.Lock()
+		_, exists := b.digests[i.GuildID]
+		delete(b.digests, i.GuildID)
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+
+		if !exists {
+			respondEphemeral(s, i.Interaction, b.t(i.GuildID, "digest.none"))
+			return
+		}
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "digest.removed"))
+	}
+}
+
+// length returns the time span a digest covers
+func (digest voiceDigest) length() time.Duration {
+	if digest.Period == digestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// scheduledAt returns the latest scheduled posting time at or before now
+func (digest voiceDigest) scheduledAt(now time.Time) time.Time {
+	now = now.UTC()
+	at := time.Date(now.Year(), now.Month(), now.Day(), digest.Hour, 0, 0, 0, time.UTC)
+	if digest.Period == digestWeekly {
+		// Back to Monday
+		at = at.AddDate(0, 0, -((int(at.Weekday()) + 6) % 7))
+	}
+	if at.After(now) {
+		at = at.Add(-digest.length())
+	}
+	return at
+}
+
+// runDigests posts due digests until ctx is cancelled
+func (b *Bot) runDigests(ctx context.Context) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		type dueDigest struct {
+			guildID string
+			digest  voiceDigest
+			at      time.Time
+		}
+		var due []dueDigest
+
+		b.mu.Lock()
+		for guildID, digest := range b.digests {
+			at := digest.scheduledAt(now)
+			if digest.LastSent.Before(at) {
+				digest.LastSent = now
+				b.digests[guildID] = digest
+				due = append(due, dueDigest{guildID: guildID, digest: digest, at: at})
+			}
+		}
+		if len(due) > 0 {
+			b.savePersistedDataAsync()
+		}
+		b.mu.Unlock()
+
+		for _, d := range due {
+			if !b.claim(fmt.Sprintf("digest:%s:%d", d.guildID, d.at.Unix()), time.Hour) {
+				continue
+			}
+			from := d.at.Add(-d.digest.length())
+			embed := b.digestEmbed(b.rest, d.guildID, d.digest.Period, b.digestStats(d.guildID, from, d.at), from, d.at)
+			if _, err := b.rest.ChannelMessageSendEmbed(d.digest.ChannelId, b.presentEmbed(d.guildID, embed)); err != nil {
+				slog.Error("Error posting digest", "guild_id", d.guildID, "channel_id", d.digest.ChannelId, "event_type", "digest", "error", err)
+			}
+		}
+	}
+}
+
+// digestStats computes a guild's voice activity within [from, to)
+func (b *Bot) digestStats(guildID string, from, to time.Time) digestStats {
+	sessions := b.sessions.sessions(guildID, from, to)
+
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var stats digestStats
+	var edges []edge
+	users := make(map[string]bool)
+	perChannel := make(map[string]time.Duration)
+
+	for _, session := range sessions {
+		overlap := session.overlap(from, to)
+		if overlap <= 0 {
+			continue
+		}
+		stats.Total += overlap
+		perChannel[session.ChannelId] += overlap
+		users[session.UserId] = true
+
+		start := session.Start
+		if start.Before(from) {
+			start = from
+		}
+		edges = append(edges, edge{at: start, delta: 1}, edge{at: start.Add(overlap), delta: -1})
+	}
+	stats.Users = len(users)
+
+	// Leaves sort before joins at the same instant so hand-overs don't count twice
+	slices.SortFunc(edges, func(a, c edge) int {
+		if n := a.at.Compare(c.at); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.delta, c.delta)
+	})
+	current := 0
+	for _, e := range edges {
+		current += e.delta
+		if current > stats.Peak {
+			stats.Peak = current
+			stats.PeakAt = e.at
+		}
+	}
+
+	for channelID, total := range perChannel {
+		stats.Channels = append(stats.Channels, channelVoiceTime{ChannelId: channelID, Time: total})
+	}
+	slices.SortFunc(stats.Channels, func(a, c channelVoiceTime) int { return cmp.Compare(c.Time, a.Time) })
+	return stats
+}
+
+// digestEmbed renders digest statistics
+func (b *Bot) digestEmbed(s DiscordSession, guildID, period string, stats digestStats, from, to time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       b.t(guildID, "digest.title."+period),
+		Description: fmt.Sprintf("<t:%d:f> – <t:%d:f>", from.Unix(), to.Unix()),
+		Color:       0x5865F2,
+		Timestamp:   to.Format(time.RFC3339),
+	}
+	if stats.Total == 0 {
+		embed.Description += "\n\n" + b.t(guildID, "digest.empty")
+		return embed
+	}
+
+	var top []string
+	for idx, channel := range stats.Channels[:min(3, len(stats.Channels))] {
+		top = append(top, fmt.Sprintf("%d. **%s** · %s", idx+1, b.getChannelName(s, channel.ChannelId), formatDuration(channel.Time)))
+	}
+
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: b.t(guildID, "digest.total"), Value: b.t(guildID, "digest.hours", stats.Total.Hours()), Inline: true},
+		{Name: b.t(guildID, "digest.users"), Value: fmt.Sprintf("%d", stats.Users), Inline: true},
+		{Name: b.t(guildID, "digest.peak"), Value: fmt.Sprintf("%d (<t:%d:f>)", stats.Peak, stats.PeakAt.Unix()), Inline: true},
+		{Name: b.t(guildID, "digest.top"), Value: strings.Join(top, "\n")},
+	}
+	return embed
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// DiscordSession is the part of *discordgo.Session the bot's handlers use.
+	// Handlers take it instead of the session so subscription, debounce, and
+	// notification logic can run against a fake (see package discordfake).
+	// Calls outside handlers go through the same interface, see UseSession.
+	// Cached guild data is read from the Bot's own session State, which works
+	// without a gateway connection.
+	DiscordSession interface {
+		Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+		GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error)
+		ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+		ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+		ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+		ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+		ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+		ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error
+		ChannelMessageUnpin(channelID, messageID string, options ...discordgo.RequestOption) error
+		InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+		InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+		UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+		UserChannelPermissions(userID, channelID string, fetchOptions ...discordgo.RequestOption) (int64, error)
+		GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error)
+		GuildScheduledEvents(guildID string, userCount bool, options ...discordgo.RequestOption) ([]*discordgo.GuildScheduledEvent, error)
+		MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+		ChannelWebhooks(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Webhook, error)
+		WebhookCreate(channelID, name, avatar string, options ...discordgo.RequestOption) (*discordgo.Webhook, error)
+		WebhookExecute(webhookID, token string, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error)
+		Application(appID string) (*discordgo.Application, error)
+		ApplicationCommandCreate(appID string, guildID string, cmd *discordgo.ApplicationCommand, options ...discordgo.RequestOption) (*discordgo.ApplicationCommand, error)
+		ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+		ApplicationCommandDelete(appID, guildID, cmdID string, options ...discordgo.RequestOption) error
+	}
+)
+
+var _ DiscordSession = (*discordgo.Session)(nil)
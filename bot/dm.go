@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// brokenDMsClosed marks a DM subscription paused because the user does not accept DMs
+	brokenDMsClosed = "DMs closed"
+	// maxDMSubscriptions caps DM subscriptions per user and guild
+	maxDMSubscriptions = 25
+)
+
+// isDM reports whether a subscription delivers to a user's DMs instead of a text channel
+func (sub subscription) isDM() bool {
+	return sub.UserId != ""
+}
+
+// target describes where a subscription delivers, for admin views
+func (b *Bot) target(sub subscription) string {
+	if sub.isDM() {
+		return b.t(sub.GuildId, "dm.target", sub.UserId)
+	}
+	return fmt.Sprintf("<#%s>", sub.TextChannelId)
+}
+
+// targetName names where a subscription delivers, for embed titles where
+// mentions don't render
+func (sub subscription) targetName(s DiscordSession, b *Bot) string {
+	if sub.isDM() {
+		return b.t(sub.GuildId, "dm.target-name")
+	}
+	return "#" + b.getChannelName(s, sub.TextChannelId)
+}
+
+// isDMClosedError reports whether a REST error means the user does not accept DMs from the bot
+func isDMClosedError(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser
+}
+
+// dmSubscriptionCommands returns the /subscribe-dm and /unsubscribe-dm command definitions
+func dmSubscriptionCommands() []*discordgo.ApplicationCommand {
+	voiceChannelOption := func() []*discordgo.ApplicationCommandOption {
+		return []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+		}
+	}
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:    "subscribe-dm",
+			Options: voiceChannelOption(),
+		},
+		{
+			Name:    "unsubscribe-dm",
+			Options: voiceChannelOption(),
+		},
+	}
+}
+
+// respondDMClosed tells a member that the bot can't DM them
+func (b *Bot) respondDMClosed(s DiscordSession, i *discordgo.InteractionCreate, userID string, err error) {
+	slog.Warn("Could not DM subscriber", "guild_id", i.GuildID, "user_id", userID, "event_type", "subscribe_dm", "error", err)
+	respondWithError(s, i.Interaction, b.t(i.GuildID, "dm.closed"))
+}
+
+func (b *Bot) handleSubscribeDM(s DiscordSession, i *discordgo.InteractionCreate) {
+	voiceChannelID := i.ApplicationCommandData().Options[0].ChannelValue(nil).ID
+	userID := interactionUserID(i)
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	if !b.userCanView(s, userID, voiceChannelID) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "dm.cant-see", channelName))
+		return
+	}
+	if b.countDMSubscriptions(i.GuildID, userID) >= maxDMSubscriptions {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "dm.limit", maxDMSubscriptions))
+		return
+	}
+
+	dmChannel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		b.respondDMClosed(s, i, userID, err)
+		return
+	}
+
+	// Subscribe before the confirmation DM so a subscription limit doesn't
+	// leave the user with a confirmation for nothing
+	alreadySubscribed, err := b.addSubscription(voiceChannelID, dmChannel.ID, i.GuildID)
+	if err != nil {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "dm.failed", channelName, err))
+		return
+	}
+	if _, err := s.ChannelMessageSend(dmChannel.ID, b.t(i.GuildID, "dm.confirm", channelName, b.getGuildName(s, i.GuildID))); err != nil {
+		if !alreadySubscribed {
+			b.removeSubscription(voiceChannelID, dmChannel.ID)
+		}
+		b.respondDMClosed(s, i, userID, err)
+		return
+	}
+
+	b.updateSubscription(voiceChannelID, dmChannel.ID, func(sub *subscription) {
+		sub.UserId = userID
+		sub.Broken = ""
+	})
+
+	if alreadySubscribed {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "dm.already", channelName))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "dm.subscribed", channelName))
+}
+
+func (b *Bot) handleUnsubscribeDM(s DiscordSession, i *discordgo.InteractionCreate) {
+	voiceChannelID := i.ApplicationCommandData().Options[0].ChannelValue(nil).ID
+	userID := interactionUserID(i)
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	dmChannelID := ""
+	for _, sub := range b.subscriptions.Channel(voiceChannelID) {
+		if sub.UserId == userID {
+			dmChannelID = sub.TextChannelId
+		}
+	}
+
+	if dmChannelID == "" || !b.removeSubscription(voiceChannelID, dmChannelID) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "dm.not-subscribed", channelName))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "dm.unsubscribed", channelName))
+}
+
+// countDMSubscriptions returns how many DM subscriptions a user has in a guild
+func (b *Bot) countDMSubscriptions(guildID, userID string) int {
+	count := 0
+	for _, sub := range b.subscriptions.Guild(guildID) {
+		if sub.UserId == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// userCanView reports whether a user can see a channel. Unknown permissions
+// (e.g. members not cached) count as visible.
+func (b *Bot) userCanView(s DiscordSession, userID, channelID string) bool {
+	permissions, err := b.session.State.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		return true
+	}
+	return permissions&discordgo.PermissionViewChannel != 0
+}
@@ -0,0 +1,332 @@
+package bot
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+const (
+	// eventHistoryInterval is how often buffered events are written
+	eventHistoryInterval = 10 * time.Second
+	// eventHistoryBatch is how many buffered events trigger an early write
+	eventHistoryBatch = 500
+	// eventHistoryLimit is how many events are kept while the store is
+	// unreachable, older ones are dropped first
+	eventHistoryLimit = 10000
+	// eventHistoryMeasurement is the line protocol measurement and default
+	// ClickHouse table name
+	eventHistoryMeasurement = "voice_events"
+)
+
+type (
+	// eventHistory records every voice event in a time-series store so
+	// activity can be charted, e.g. in Grafana. Events are buffered and written
+	// in batches to a line protocol file, InfluxDB, or ClickHouse.
+	eventHistory struct {
+		writers []historyWriter
+		pending []VoiceEvent
+		mu      sync.Mutex
+		flushMu sync.Mutex    // one write at a time, keeping events in order
+		wake    chan struct{} // a full batch is waiting
+	}
+
+	// historyWriter appends a batch of events to a store
+	historyWriter interface {
+		name() string
+		write(ctx context.Context, events []VoiceEvent) error
+	}
+
+	// historyFile appends events to a file in InfluxDB line protocol, which
+	// Telegraf can tail or `influx write` can import
+	historyFile struct {
+		path string
+	}
+
+	// historyInflux posts events in line protocol to an InfluxDB write
+	// endpoint, v1 (/write?db=) or v2 (/api/v2/write?org=&bucket=)
+	historyInflux struct {
+		url    string
+		token  string
+		client *http.Client
+	}
+
+	// historyClickHouse inserts events into a ClickHouse table over HTTP
+	historyClickHouse struct {
+		url    string
+		table  string
+		user   string
+		token  string
+		client *http.Client
+	}
+
+	// clickHouseRow is a row of the ClickHouse event table
+	clickHouseRow struct {
+		Time          string `json:"time"`
+		Type          string `json:"type"`
+		GuildId       string `json:"guild_id"`
+		ChannelId     string `json:"channel_id"`
+		FromChannelId string `json:"from_channel_id"`
+		UserId        string `json:"user_id"`
+		UserName      string `json:"user_name"`
+		Muted         bool   `json:"muted"`
+		Streaming     bool   `json:"streaming"`
+		Ignored       bool   `json:"ignored"`
+	}
+)
+
+// newEventHistory returns the configured event history, or nil when no
+// store is configured
+func newEventHistory(cfg config.EventHistory) *eventHistory {
+	var writers []historyWriter
+	if cfg.File != "" {
+		writers = append(writers, &historyFile{path: cfg.File})
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch cfg.Backend {
+	case "influxdb":
+		writers = append(writers, &historyInflux{url: cfg.URL, token: cfg.Token, client: client})
+	case "clickhouse":
+		writers = append(writers, &historyClickHouse{url: cfg.URL, table: cmp.Or(cfg.Table, eventHistoryMeasurement), user: cfg.User, token: cfg.Token, client: client})
+	}
+
+	if len(writers) == 0 {
+		return nil
+	}
+	history := &eventHistory{writers: writers, wake: make(chan struct{}, 1)}
+	for _, writer := range writers {
+		slog.Info("Event history enabled", "store", writer.name())
+	}
+	return history
+}
+
+// record buffers a voice event. It never blocks, so it can be a voice event
+// handler.
+func (h *eventHistory) record(s DiscordSession, event VoiceEvent) {
+	event.State = nil // not written, don't keep it alive
+
+	h.mu.Lock()
+	h.pending = append(h.pending, event)
+	if dropped := len(h.pending) - eventHistoryLimit; dropped > 0 {
+		h.pending = h.pending[dropped:]
+		slog.Warn("Event history buffer full, dropping oldest events", "count", dropped)
+	}
+	full := len(h.pending) >= eventHistoryBatch
+	h.mu.Unlock()
+
+	if full {
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run writes buffered events every eventHistoryInterval, or sooner when a
+// batch is full, until ctx is done
+func (h *eventHistory) run(ctx context.Context) {
+	ticker := time.NewTicker(eventHistoryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-h.wake:
+		}
+		h.flush(ctx)
+	}
+}
+
+// flush writes buffered events to every store. Events are kept for the next
+// flush if any store fails, so a store that succeeded may see them again.
+func (h *eventHistory) flush(ctx context.Context) {
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+
+	h.mu.Lock()
+	events := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+	if len(events) == 0 {
+		return
+	}
+
+	failed := false
+	for _, writer := range h.writers {
+		if err := writer.write(ctx, events); err != nil {
+			// The URL may hold credentials, keep it out of logs
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			slog.Warn("Error writing event history", "store", writer.name(), "events", len(events), "error", err)
+			failed = true
+		}
+	}
+	if !failed {
+		return
+	}
+
+	// Put the batch back in front of events that arrived meanwhile
+	h.mu.Lock()
+	h.pending = append(events, h.pending...)
+	if dropped := len(h.pending) - eventHistoryLimit; dropped > 0 {
+		h.pending = h.pending[dropped:]
+		slog.Warn("Event history buffer full, dropping oldest events", "count", dropped)
+	}
+	h.mu.Unlock()
+}
+
+func (f *historyFile) name() string {
+	return "file"
+}
+
+func (f *historyFile) write(_ context.Context, events []VoiceEvent) error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(lineProtocol(events)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (w *historyInflux) name() string {
+	return "influxdb"
+}
+
+func (w *historyInflux) write(ctx context.Context, events []VoiceEvent) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(lineProtocol(events)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+	return doHistoryRequest(w.client, req)
+}
+
+func (w *historyClickHouse) name() string {
+	return "clickhouse"
+}
+
+func (w *historyClickHouse) write(ctx context.Context, events []VoiceEvent) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, event := range events {
+		row := clickHouseRow{
+			Time:          event.At.UTC().Format("2006-01-02 15:04:05.000"),
+			Type:          string(event.Type),
+			GuildId:       event.GuildId,
+			ChannelId:     event.ChannelId,
+			FromChannelId: event.FromChannelId,
+			UserId:        event.UserId,
+			Muted:         event.Muted,
+			Streaming:     event.Streaming,
+			Ignored:       event.Ignored,
+		}
+		if event.Member != nil {
+			row.UserName = getUsername(event.Member)
+		}
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	endpoint, err := url.Parse(w.url)
+	if err != nil {
+		return err
+	}
+	query := endpoint.Query()
+	query.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", w.table))
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), &body)
+	if err != nil {
+		return err
+	}
+	if w.user != "" {
+		req.Header.Set("X-ClickHouse-User", w.user)
+	}
+	if w.token != "" {
+		req.Header.Set("X-ClickHouse-Key", w.token)
+	}
+	return doHistoryRequest(w.client, req)
+}
+
+// doHistoryRequest sends a write request, failing on non-2xx responses
+func doHistoryRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("store answered %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return nil
+}
+
+// lineProtocol encodes events as InfluxDB line protocol, one line per event
+// with nanosecond timestamps. IDs and the event type are tags so they can be
+// grouped by; the user name is a field since it changes.
+func lineProtocol(events []VoiceEvent) []byte {
+	var buf bytes.Buffer
+	for _, event := range events {
+		buf.WriteString(eventHistoryMeasurement)
+		writeTag(&buf, "type", string(event.Type))
+		writeTag(&buf, "guild_id", event.GuildId)
+		writeTag(&buf, "channel_id", event.ChannelId)
+		writeTag(&buf, "from_channel_id", event.FromChannelId)
+		writeTag(&buf, "user_id", event.UserId)
+
+		buf.WriteString(" count=1i")
+		buf.WriteString(",ignored=" + strconv.FormatBool(event.Ignored))
+		switch event.Type {
+		case VoiceMute:
+			buf.WriteString(",muted=" + strconv.FormatBool(event.Muted))
+		case VoiceStream:
+			buf.WriteString(",streaming=" + strconv.FormatBool(event.Streaming))
+		}
+		if event.Member != nil {
+			buf.WriteString(`,user_name="` + fieldEscaper.Replace(getUsername(event.Member)) + `"`)
+		}
+
+		buf.WriteString(" " + strconv.FormatInt(event.At.UnixNano(), 10) + "\n")
+	}
+	return buf.Bytes()
+}
+
+var (
+	// tagEscaper escapes line protocol tag values
+	tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	// fieldEscaper escapes line protocol string field values
+	fieldEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// writeTag appends a tag, skipping empty values which line protocol doesn't allow
+func writeTag(buf *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+	buf.WriteString("," + key + "=" + tagEscaper.Replace(value))
+}
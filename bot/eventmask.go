@@ -0,0 +1,275 @@
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Events a subscription can receive
+const (
+	eventJoin   eventMask = 1 << iota // someone joined the channel
+	eventLeave                        // someone left, with how long they stayed
+	eventMove                         // someone moved between channels (with MOVE_NOTIFICATIONS)
+	eventMute                         // someone muted or unmuted
+	eventStream                       // someone started or stopped streaming
+	eventActive                       // the first person joined the empty channel
+	eventEmpty                        // the last person left
+	eventFull                         // the channel hit its user limit, or a slot freed up
+
+	// defaultEvents are the events of subscriptions that never chose any
+	defaultEvents = eventJoin | eventMove
+
+	// eventSelectionTTL is how long the event select menu after subscribing stays usable
+	eventSelectionTTL = 15 * time.Minute
+)
+
+type (
+	// eventMask is the set of events a subscription receives. The zero value
+	// means defaultEvents. It is stored as a list of event names.
+	eventMask uint16
+
+	// eventInfo describes an event in the select menu. Its label and
+	// description are the catalog's "event.<name>" and
+	// "event.<name>.description".
+	eventInfo struct {
+		mask  eventMask
+		name  string
+		emoji string
+	}
+
+	// pendingEventSelection remembers which subscriptions an event select
+	// menu applies to
+	pendingEventSelection struct {
+		guildID         string
+		textChannelID   string
+		voiceChannelIDs []string
+		expires         time.Time
+	}
+
+	// eventSelections holds the pending event select menus by token
+	eventSelections struct {
+		pending map[string]pendingEventSelection
+		mu      sync.Mutex
+	}
+)
+
+// eventInfos lists the selectable events in menu order
+var eventInfos = []eventInfo{
+	{eventJoin, "join", "🔊"},
+	{eventLeave, "leave", "🔇"},
+	{eventMove, "move", "↔️"},
+	{eventMute, "mute", "🎙️"},
+	{eventStream, "stream", "📺"},
+	{eventActive, "active", "🟢"},
+	{eventEmpty, "empty", "⚫"},
+	{eventFull, "full", "🈵"},
+}
+
+// effective returns the events a subscription receives
+func (m eventMask) effective() eventMask {
+	if m == 0 {
+		return defaultEvents
+	}
+	return m
+}
+
+// has reports whether the subscription receives an event
+func (m eventMask) has(event eventMask) bool {
+	return m.effective()&event != 0
+}
+
+// names returns the names of the events in the mask
+func (m eventMask) names() []string {
+	var names []string
+	for _, info := range eventInfos {
+		if m&info.mask != 0 {
+			names = append(names, info.name)
+		}
+	}
+	return names
+}
+
+// eventLabels describes the events a subscription receives in the guild's
+// language, e.g. "🔊 Joins, ⚫ Session end"
+func (b *Bot) eventLabels(guildID string, m eventMask) string {
+	var labels []string
+	for _, info := range eventInfos {
+		if m.has(info.mask) {
+			labels = append(labels, info.emoji+" "+b.t(guildID, "event."+info.name))
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+func (m eventMask) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.names())
+}
+
+// UnmarshalJSON reads a list of event names; unknown names are ignored
+func (m *eventMask) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*m = parseEventNames(names)
+	return nil
+}
+
+// parseEventNames builds a mask from event names, ignoring unknown ones
+func parseEventNames(names []string) eventMask {
+	var mask eventMask
+	for _, name := range names {
+		for _, info := range eventInfos {
+			if info.name == name {
+				mask |= info.mask
+			}
+		}
+	}
+	return mask
+}
+
+func newEventSelections() *eventSelections {
+	return &eventSelections{pending: make(map[string]pendingEventSelection)}
+}
+
+// add remembers the subscriptions of a select menu and returns its token
+func (es *eventSelections) add(guildID, textChannelID string, voiceChannelIDs []string) string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+	now := time.Now()
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	for key, pending := range es.pending {
+		if now.After(pending.expires) {
+			delete(es.pending, key)
+		}
+	}
+	es.pending[token] = pendingEventSelection{
+		guildID:         guildID,
+		textChannelID:   textChannelID,
+		voiceChannelIDs: voiceChannelIDs,
+		expires:         now.Add(eventSelectionTTL),
+	}
+	return token
+}
+
+// take returns and forgets the subscriptions of a select menu
+func (es *eventSelections) take(token string) (pendingEventSelection, bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	pending, ok := es.pending[token]
+	delete(es.pending, token)
+	if !ok || time.Now().After(pending.expires) {
+		return pendingEventSelection{}, false
+	}
+	return pending, true
+}
+
+// eventSelectComponents returns the multi-select for choosing the events of
+// the given subscriptions, preselecting current
+func (b *Bot) eventSelectComponents(guildID, textChannelID string, voiceChannelIDs []string, current eventMask) []discordgo.MessageComponent {
+	token := b.eventSelections.add(guildID, textChannelID, voiceChannelIDs)
+
+	options := make([]discordgo.SelectMenuOption, 0, len(eventInfos))
+	for _, info := range eventInfos {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       b.t(guildID, "event."+info.name),
+			Value:       info.name,
+			Description: b.t(guildID, "event."+info.name+".description"),
+			Emoji:       &discordgo.ComponentEmoji{Name: info.emoji},
+			Default:     current.has(info.mask),
+		})
+	}
+
+	minValues := 1
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    "subscribe_events:" + token,
+					Placeholder: b.t(guildID, "events.placeholder"),
+					MinValues:   &minValues,
+					MaxValues:   len(options),
+					Options:     options,
+				},
+			},
+		},
+	}
+}
+
+// handleEventSelect stores the events chosen in the select menu
+func (b *Bot) handleEventSelect(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	data := i.MessageComponentData()
+	pending, ok := b.eventSelections.take(strings.TrimPrefix(data.CustomID, "subscribe_events:"))
+	if !ok || pending.guildID != i.GuildID || pending.textChannelID != i.ChannelID {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "events.expired"))
+		return
+	}
+
+	mask := parseEventNames(data.Values)
+	if mask == 0 {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "events.none"))
+		return
+	}
+
+	var names []string
+	for _, voiceChannelID := range pending.voiceChannelIDs {
+		if b.updateSubscription(voiceChannelID, pending.textChannelID, func(sub *subscription) { sub.Events = mask }) {
+			names = append(names, fmt.Sprintf("**%s**", b.getChannelName(s, voiceChannelID)))
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    b.t(i.GuildID, "events.chosen", strings.Join(names, ", "), b.eventLabels(i.GuildID, mask)),
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// notifyVoiceState announces a mute or stream change of a user who stayed in
+// the same voice channel to subscriptions that asked for it. Users toggling
+// quickly are announced at most once per minute per channel and event.
+func (b *Bot) notifyVoiceState(s DiscordSession, voiceEvent VoiceEvent, username string, event eventMask, eventType string, on bool) {
+	channelID, userID := voiceEvent.ChannelId, voiceEvent.UserId
+	subs := b.sessionEventSubscriptions(s, channelID, userID, func(sub subscription) bool { return sub.Events.has(event) })
+	if len(subs) == 0 || !b.stateCooldown.allow(channelID+":"+eventType, userID) {
+		return
+	}
+	if !b.claim(fmt.Sprintf("%s:%s:%s:%t", eventType, channelID, userID, on), b.debounceInterval) {
+		return
+	}
+
+	content := b.renderMessage(voiceEvent.GuildId, TemplateEvent{
+		Type:      eventType,
+		User:      username,
+		UserID:    userID,
+		Channel:   b.getChannelName(s, channelID),
+		ChannelID: channelID,
+		Guild:     b.getGuildName(s, voiceEvent.GuildId),
+		Count:     b.occupancy.count(channelID),
+		On:        on,
+		Time:      time.Now(),
+	})
+
+	for _, sub := range subs {
+		sent := b.deliverNotification(s, sub, &discordgo.MessageSend{Content: content})
+		b.trackSent(sub, sent, userID)
+	}
+}
@@ -0,0 +1,222 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// eventModeCheckInterval is how often expired event modes are reverted
+	eventModeCheckInterval = time.Minute
+	// maxEventDuration is the longest an event mode may run
+	maxEventDuration = 24 * time.Hour
+)
+
+// eventMilestones are the user counts announced while event mode is on
+var eventMilestones = []int{5, 10, 15, 20, 25, 30, 40, 50, 75, 100}
+
+// eventActive reports whether the subscription is in event mode
+func (sub subscription) eventActive(now time.Time) bool {
+	return sub.EventUntil.After(now)
+}
+
+// eventModeCommand returns the /event-mode command definition
+func eventModeCommand() *discordgo.ApplicationCommand {
+	voiceChannelOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionChannel,
+		Name:         "voice-channel",
+		Required:     true,
+		ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice},
+	}
+	return &discordgo.ApplicationCommand{
+		Name:                     "event-mode",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "start",
+				Options: []*discordgo.ApplicationCommandOption{
+					voiceChannelOption,
+					{
+						Type: discordgo.ApplicationCommandOptionString,
+						Name: "duration",
+					},
+				},
+			},
+			{
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Name:    "stop",
+				Options: []*discordgo.ApplicationCommandOption{voiceChannelOption},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleEventMode(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subcommand.Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	if len(b.subscriptions.ChannelIn(i.GuildID, voiceChannelID)) == 0 {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.channel-no-subscriptions", channelName))
+		return
+	}
+
+	if subcommand.Name == "stop" {
+		if b.setEventMode(i.GuildID, voiceChannelID, time.Time{}) == 0 {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "eventmode.not-on", channelName))
+			return
+		}
+		slog.Info("Event mode stopped", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "voice_channel_id", voiceChannelID)
+		b.announceEventMode(s, i.GuildID, voiceChannelID, b.t(i.GuildID, "eventmode.ended", channelName))
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "eventmode.off", channelName))
+		return
+	}
+
+	until, err := eventEnd(s, i.GuildID, voiceChannelID, options)
+	if err != nil {
+		respondWithError(s, i.Interaction, "❌ "+b.errorText(i.GuildID, err))
+		return
+	}
+
+	b.setEventMode(i.GuildID, voiceChannelID, until)
+	slog.Info("Event mode started", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "voice_channel_id", voiceChannelID, "until", until)
+	b.announceEventMode(s, i.GuildID, voiceChannelID, b.t(i.GuildID, "eventmode.announce-on", channelName, until.Unix()))
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "eventmode.on", channelName, until.Unix()))
+}
+
+// eventEnd returns when an event mode ends: after the given duration, or at
+// the end of the voice channel's active or upcoming scheduled event
+func eventEnd(s DiscordSession, guildID, voiceChannelID string, options map[string]*discordgo.ApplicationCommandInteractionDataOption) (time.Time, error) {
+	now := time.Now()
+	if option, ok := options["duration"]; ok {
+		duration, err := time.ParseDuration(option.StringValue())
+		if err != nil || duration <= 0 {
+			return time.Time{}, newUserError("eventmode.invalid-duration")
+		}
+		if duration > maxEventDuration {
+			return time.Time{}, newUserError("eventmode.too-long", formatDuration(maxEventDuration))
+		}
+		return now.Add(duration), nil
+	}
+
+	events, err := s.GuildScheduledEvents(guildID, false)
+	if err != nil {
+		return time.Time{}, newUserError("eventmode.no-events")
+	}
+	for _, event := range events {
+		if event.ChannelID != voiceChannelID || (event.Status != discordgo.GuildScheduledEventStatusActive && event.Status != discordgo.GuildScheduledEventStatusScheduled) {
+			continue
+		}
+		if event.ScheduledEndTime == nil || !event.ScheduledEndTime.After(now) {
+			return time.Time{}, newUserError("eventmode.no-end", event.Name)
+		}
+		if limit := now.Add(maxEventDuration); event.ScheduledEndTime.After(limit) {
+			return limit, nil
+		}
+		return *event.ScheduledEndTime, nil
+	}
+	return time.Time{}, newUserError("eventmode.no-event")
+}
+
+// setEventMode sets the event end of every subscription of a voice channel in
+// a guild and returns how many had event mode on before. A zero until turns
+// event mode off.
+func (b *Bot) setEventMode(guildID, voiceChannelID string, until time.Time) int {
+	now := time.Now()
+	active := 0
+	for _, sub := range b.subscriptions.ChannelIn(guildID, voiceChannelID) {
+		b.updateSubscription(voiceChannelID, sub.TextChannelId, func(existing *subscription) {
+			if existing.eventActive(now) {
+				active++
+			}
+			existing.EventUntil = until
+		})
+	}
+	return active
+}
+
+// announceEventMode posts an event mode change to the subscribed text channels
+func (b *Bot) announceEventMode(s DiscordSession, guildID, voiceChannelID, content string) {
+	content = b.presentText(guildID, content)
+	for _, sub := range b.subscriptions.ChannelIn(guildID, voiceChannelID) {
+		if sub.Broken != "" || sub.StatusBoard || sub.isDM() {
+			continue
+		}
+		if _, err := s.ChannelMessageSend(sub.TextChannelId, content); err != nil {
+			slog.Error("Error announcing event mode", "guild_id", guildID, "channel_id", sub.TextChannelId, "event_type", "event_mode", "error", err)
+		}
+	}
+}
+
+// notifyEventMilestones announces milestone user counts to subscriptions in
+// event mode
+func (b *Bot) notifyEventMilestones(s DiscordSession, voiceChannelID string, previousCount, count int) {
+	milestone := 0
+	for _, m := range eventMilestones {
+		if previousCount < m && m <= count {
+			milestone = m
+		}
+	}
+	if milestone == 0 {
+		return
+	}
+
+	now := time.Now()
+	var active []subscription
+	for _, sub := range b.subscriptions.Channel(voiceChannelID) {
+		if sub.eventActive(now) && sub.Broken == "" && !sub.StatusBoard {
+			active = append(active, sub)
+		}
+	}
+	if len(active) == 0 || !b.claim(fmt.Sprintf("milestone:%s:%d", voiceChannelID, milestone), b.debounceInterval) {
+		return
+	}
+
+	channelName := b.getChannelName(s, voiceChannelID)
+	for _, sub := range active {
+		content := b.t(sub.GuildId, "eventmode.milestone", milestone, channelName)
+		sent := b.deliverNotification(s, sub, &discordgo.MessageSend{Content: content})
+		b.trackSent(sub, sent, "")
+	}
+}
+
+// runEventModes reverts subscriptions whose event mode has ended
+func (b *Bot) runEventModes(ctx context.Context) {
+	ticker := time.NewTicker(eventModeCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		ended := b.subscriptions.Filter(func(sub subscription) bool {
+			return !sub.EventUntil.IsZero() && !sub.eventActive(now)
+		})
+		announced := make(map[string]bool)
+		for _, sub := range ended {
+			b.updateSubscription(sub.VoiceChannelId, sub.TextChannelId, func(existing *subscription) {
+				existing.EventUntil = time.Time{}
+			})
+			if announced[sub.VoiceChannelId] || !b.claim("event_end:"+sub.VoiceChannelId, eventModeCheckInterval) {
+				continue
+			}
+			announced[sub.VoiceChannelId] = true
+			slog.Info("Event mode ended", "guild_id", sub.GuildId, "voice_channel_id", sub.VoiceChannelId)
+			b.announceEventMode(b.rest, sub.GuildId, sub.VoiceChannelId, b.t(sub.GuildId, "eventmode.ended", b.getChannelName(b.rest, sub.VoiceChannelId)))
+		}
+	}
+}
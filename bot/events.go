@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// dispatch passes a gateway event to the bot's handler for it
+func (b *Bot) dispatch(s DiscordSession, event any) {
+	switch e := event.(type) {
+	case *discordgo.Ready:
+		slog.Info("Logged in", "user", e.User.Username+"#"+e.User.Discriminator)
+
+	// Resumed sessions replay missed events, voice states are deduplicated
+	// against the last known channels, see replay.go
+	case *discordgo.Resumed:
+		slog.Info("Gateway session resumed")
+
+	// Guild create registers commands and seeds voice channel occupancy
+	// (sent for every guild after Ready and when the bot joins a new guild)
+	case *discordgo.GuildCreate:
+		b.guildCreate(s, e)
+
+	// Guild delete cleans up when the bot is removed from a guild
+	case *discordgo.GuildDelete:
+		b.guildDelete(s, e)
+
+	// Channel events archive subscriptions of deleted or hidden channels and
+	// move them to recreated channels
+	case *discordgo.ChannelCreate:
+		b.channelCreate(s, e)
+	case *discordgo.ChannelDelete:
+		b.cache.removeChannel(e.ID)
+		b.channelDelete(s, e)
+	case *discordgo.ChannelUpdate:
+		b.cache.putChannel(e.Channel)
+		b.channelUpdate(s, e)
+
+	// Member events keep cached members current, see entityCache
+	case *discordgo.GuildMemberUpdate:
+		if e.Member != nil {
+			b.cache.putMember(e.GuildID, e.Member)
+		}
+	case *discordgo.GuildMemberRemove:
+		if e.Member != nil && e.User != nil {
+			b.cache.removeMember(e.GuildID, e.User.ID)
+		}
+
+	// Voice state updates are sent when users join, leave, or move voice channels
+	case *discordgo.VoiceStateUpdate:
+		b.voiceStateUpdate(s, e)
+
+	// Interactions are slash commands and component interactions
+	case *discordgo.InteractionCreate:
+		b.interactionCreate(s, e)
+	}
+}
+
+// HandleEvent feeds a gateway event to the bot without a connection: the
+// state cache is updated first, as discordgo does for received events, then
+// the event's handler runs and answers through s. Package bottest uses it to
+// drive the bot with synthetic events.
+func (b *Bot) HandleEvent(s DiscordSession, event any) {
+	if err := b.session.State.OnInterface(b.session, event); err != nil {
+		slog.Debug("Event not applied to the state cache", "error", err)
+	}
+	b.dispatch(s, event)
+}
+
+// UseSession sends the REST calls the bot makes outside of handlers, e.g.
+// scheduled digests and flushed debounces, through s instead of the Discord
+// session. Package bottest uses it so the fake sees every call.
+func (b *Bot) UseSession(s DiscordSession) {
+	b.rest = s
+}
+
+// State returns the bot's cache of guilds, channels, and voice states
+func (b *Bot) State() *discordgo.State {
+	return b.session.State
+}
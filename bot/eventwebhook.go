@@ -0,0 +1,265 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+const (
+	// eventWebhookQueueSize is how many events may wait for delivery before
+	// new ones are dropped
+	eventWebhookQueueSize = 1000
+	// eventWebhookTimeout bounds one delivery attempt
+	eventWebhookTimeout = 10 * time.Second
+)
+
+type (
+	// eventWebhook posts every voice event as JSON to an external endpoint,
+	// signed with HMAC-SHA256 when a secret is set. Events are delivered one at
+	// a time in order, transient failures are retried with backoff.
+	eventWebhook struct {
+		bot         string // the instance's name, empty for a single bot
+		url         string
+		secret      []byte
+		attempts    int           // deliveries per event, including the first
+		retryDelay  time.Duration // wait before the first retry, doubled for each further one
+		client      *http.Client
+		queue       chan eventWebhookPayload
+		started     atomic.Bool
+		done        chan struct{}        // closed when run returns
+		interrupted *eventWebhookPayload // the event run was delivering when it stopped, set before done is closed
+	}
+
+	// eventWebhookPayload is the JSON body of an event webhook request
+	eventWebhookPayload struct {
+		Id            string         `json:"id"`            // unique per event, for deduplication by the receiver
+		Bot           string         `json:"bot,omitempty"` // the bot's name when a process runs several
+		Type          VoiceEventType `json:"type"`
+		GuildId       string         `json:"guild_id"`
+		UserId        string         `json:"user_id"`
+		UserName      string         `json:"user_name,omitempty"`
+		ChannelId     string         `json:"channel_id"`
+		FromChannelId string         `json:"from_channel_id,omitempty"`
+		Muted         *bool          `json:"muted,omitempty"`     // for mute events
+		Streaming     *bool          `json:"streaming,omitempty"` // for stream events
+		Ignored       bool           `json:"ignored,omitempty"`   // the user is not announced in Discord, see /ignore-user
+		Time          time.Time      `json:"time"`
+	}
+
+	// eventWebhookError is a failed delivery, retried unless permanent
+	eventWebhookError struct {
+		status    int
+		permanent bool
+	}
+)
+
+func (err *eventWebhookError) Error() string {
+	return fmt.Sprintf("endpoint answered %d %s", err.status, http.StatusText(err.status))
+}
+
+// newEventWebhook returns the configured event webhook of the bot named
+// name, or nil when it is disabled
+func newEventWebhook(cfg config.EventWebhook, name string) *eventWebhook {
+	if cfg.URL == "" {
+		return nil
+	}
+
+	webhook := &eventWebhook{
+		bot:        name,
+		url:        cfg.URL,
+		secret:     []byte(cfg.Secret),
+		attempts:   cfg.Retries + 1,
+		retryDelay: retryBaseDelay,
+		client:     &http.Client{Timeout: eventWebhookTimeout},
+		queue:      make(chan eventWebhookPayload, eventWebhookQueueSize),
+		done:       make(chan struct{}),
+	}
+	if len(webhook.secret) == 0 {
+		slog.Warn("The event webhook secret is not set, event webhook requests are not signed")
+	}
+	slog.Info("Event webhook enabled", "retries", webhook.attempts-1, "signed", len(webhook.secret) > 0)
+	return webhook
+}
+
+// publish queues a voice event, dropping it if the queue is full. It never
+// blocks, so it can be a voice event handler.
+func (w *eventWebhook) publish(s DiscordSession, event VoiceEvent) {
+	payload := eventWebhookPayload{
+		Id:            newEventId(),
+		Bot:           w.bot,
+		Type:          event.Type,
+		GuildId:       event.GuildId,
+		UserId:        event.UserId,
+		ChannelId:     event.ChannelId,
+		FromChannelId: event.FromChannelId,
+		Ignored:       event.Ignored,
+		Time:          event.At.UTC(),
+	}
+	if event.Member != nil {
+		payload.UserName = getUsername(event.Member)
+	}
+	switch event.Type {
+	case VoiceMute:
+		payload.Muted = &event.Muted
+	case VoiceStream:
+		payload.Streaming = &event.Streaming
+	}
+
+	select {
+	case w.queue <- payload:
+	default:
+		slog.Warn("Event webhook queue full, dropping event", "guild_id", event.GuildId, "user_id", event.UserId, "event_type", string(event.Type))
+	}
+}
+
+// start delivers queued events in the background until ctx is done. The rest
+// are delivered by drain.
+func (w *eventWebhook) start(ctx context.Context) {
+	w.started.Store(true)
+	go w.run(ctx)
+}
+
+func (w *eventWebhook) run(ctx context.Context) {
+	defer close(w.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload := <-w.queue:
+			if !w.deliver(ctx, payload) {
+				w.interrupted = &payload
+				return
+			}
+		}
+	}
+}
+
+// drain delivers the events left when run stopped, in order, as long as ctx
+// allows. Events that don't make it are counted and dropped.
+func (w *eventWebhook) drain(ctx context.Context) {
+	if w.started.Load() {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	var pending []eventWebhookPayload
+	if w.interrupted != nil {
+		pending = append(pending, *w.interrupted)
+	}
+	for len(w.queue) > 0 {
+		pending = append(pending, <-w.queue)
+	}
+
+	for idx, payload := range pending {
+		if !w.deliver(ctx, payload) {
+			slog.Warn("Dropped event webhook events on shutdown", "events", len(pending)-idx)
+			return
+		}
+	}
+}
+
+// deliver posts one event, retrying transient failures with exponential
+// backoff. It returns false if ctx ended before the event
+// was delivered or given up on.
+func (w *eventWebhook) deliver(ctx context.Context, payload eventWebhookPayload) bool {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Error encoding event webhook payload", "guild_id", payload.GuildId, "error", err)
+		return true
+	}
+
+	delay := w.retryDelay
+	for attempt := 1; ; attempt++ {
+		err = w.post(ctx, body)
+		if err != nil && ctx.Err() != nil {
+			return false
+		}
+		var webhookErr *eventWebhookError
+		if err == nil || attempt >= w.attempts || (errors.As(err, &webhookErr) && webhookErr.permanent) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	if err != nil {
+		slog.Warn("Event webhook delivery failed", "guild_id", payload.GuildId, "user_id", payload.UserId, "event_type", string(payload.Type), "event_id", payload.Id, "error", err)
+	}
+	return true
+}
+
+// post sends one request. Each attempt is signed with the current time, so
+// receivers can reject replayed requests: the X-Signature-256 header is the
+// hex HMAC-SHA256 of "<X-Signature-Timestamp>.<body>" prefixed with "sha256=".
+func (w *eventWebhook) post(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, eventWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VoiceActivityBot")
+	if len(w.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Signature-Timestamp", timestamp)
+		req.Header.Set("X-Signature-256", "sha256="+signEventWebhook(w.secret, timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// The URL may hold a token, keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	// Client errors won't change on retry, except rate limits and timeouts
+	permanent := resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout
+	return &eventWebhookError{status: resp.StatusCode, permanent: permanent}
+}
+
+// signEventWebhook returns the hex HMAC-SHA256 of a request's timestamp and
+// body, joined by a dot
+func signEventWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newEventId returns a random 128-bit hex ID
+func newEventId() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"context"
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+func TestSignEventWebhook(t *testing.T) {
+	// Computed independently with Python's hmac module
+	tests := []struct {
+		timestamp string
+		body      string
+		want      string
+	}{
+		{timestamp: "1792231314", body: `{"id":"1"}`, want: "aea65958d8e850e63797d3235d91ee4971e23db35c189ddb8edf2da24b197ff8"},
+		{timestamp: "1792231315", body: `{"id":"1"}`, want: "1d7f37290996b3b3ab59f3aa752be2b50646a83d7d1d9917af74a9d7ae12d55f"},
+		{timestamp: "1792231314", body: `{"id":"2"}`, want: "0e4fd189be9a09410f65b56ddc4687bab15130c2d640f1a9e0cc6d4afe4d8940"},
+	}
+	for _, tt := range tests {
+		if got := signEventWebhook([]byte("s3cret"), tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("signEventWebhook(%s, %s) = %s, want %s", tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
+
+// webhookReceiver records the requests of an event webhook and answers them
+// with statuses in turn, then 200
+type webhookReceiver struct {
+	statuses []int
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	status := http.StatusOK
+	if len(rcv.requests) < len(rcv.statuses) {
+		status = rcv.statuses[len(rcv.requests)]
+	}
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	w.WriteHeader(status)
+}
+
+func (rcv *webhookReceiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return len(rcv.requests)
+}
+
+func newTestEventWebhook(t *testing.T, rcv *webhookReceiver, secret string, retries int) *eventWebhook {
+	t.Helper()
+	server := httptest.NewServer(rcv)
+	t.Cleanup(server.Close)
+	w := newEventWebhook(config.EventWebhook{URL: server.URL, Secret: secret, Retries: retries}, "")
+	w.retryDelay = time.Millisecond
+	return w
+}
+
+func TestEventWebhookSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "signed", secret: "s3cret"},
+		{name: "unsigned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv := &webhookReceiver{}
+			w := newTestEventWebhook(t, rcv, tt.secret, 0)
+			w.deliver(context.Background(), eventWebhookPayload{Id: "1", Type: VoiceJoin})
+
+			if rcv.count() != 1 {
+				t.Fatalf("%d requests, want 1", rcv.count())
+			}
+			req, body := rcv.requests[0], rcv.bodies[0]
+			timestamp, signature := req.Header.Get("X-Signature-Timestamp"), req.Header.Get("X-Signature-256")
+			if tt.secret == "" {
+				if timestamp != "" || signature != "" {
+					t.Errorf("unsigned request has timestamp %q and signature %q", timestamp, signature)
+				}
+				return
+			}
+
+			// What receivers are told to check
+			sent, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil || time.Since(time.Unix(sent, 0)).Abs() > 5*time.Minute {
+				t.Errorf("timestamp %q is not the current Unix time", timestamp)
+			}
+			want := "sha256=" + signEventWebhook([]byte(tt.secret), timestamp, body)
+			if !hmac.Equal([]byte(signature), []byte(want)) {
+				t.Errorf("signature %q, want %q", signature, want)
+			}
+			if signature == "sha256="+signEventWebhook([]byte(tt.secret), "", body) {
+				t.Error("the signature doesn't cover the timestamp")
+			}
+		})
+	}
+}
+
+func TestEventWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		want     int // requests
+	}{
+		{name: "delivered at once", want: 1},
+		{name: "server error is retried", statuses: []int{500, 502}, retries: 4, want: 3},
+		{name: "rate limit is retried", statuses: []int{429}, retries: 4, want: 2},
+		{name: "gives up after the retries", statuses: []int{500, 500, 500, 500}, retries: 2, want: 3},
+		{name: "client error is permanent", statuses: []int{400}, retries: 4, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv := &webhookReceiver{statuses: tt.statuses}
+			w := newTestEventWebhook(t, rcv, "s3cret", tt.retries)
+			if !w.deliver(context.Background(), eventWebhookPayload{Id: "1", Type: VoiceJoin}) {
+				t.Fatal("delivery was interrupted")
+			}
+			if rcv.count() != tt.want {
+				t.Errorf("%d requests, want %d", rcv.count(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEventWebhookDrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		expired bool // the shutdown deadline passed before draining
+		want    int
+	}{
+		{name: "delivers waiting events", want: 3},
+		{name: "drops them after the deadline", expired: true, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv := &webhookReceiver{}
+			w := newTestEventWebhook(t, rcv, "", 0)
+			runCtx, stop := context.WithCancel(context.Background())
+			stop() // the bot stopped before the worker got to the queue
+			w.start(runCtx)
+			for _, id := range []string{"1", "2", "3"} {
+				w.queue <- eventWebhookPayload{Id: id, Type: VoiceJoin}
+			}
+
+			ctx := context.Background()
+			if tt.expired {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				<-w.done
+				cancel()
+			}
+			w.drain(ctx)
+
+			if rcv.count() != tt.want {
+				t.Fatalf("%d requests on shutdown, want %d", rcv.count(), tt.want)
+			}
+			for idx, body := range rcv.bodies {
+				if want := `"id":"` + strconv.Itoa(idx+1) + `"`; !strings.Contains(string(body), want) {
+					t.Errorf("request %d is %s, want events in order", idx+1, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const guildExportVersion = 1
+
+type (
+	// GuildExport is a portable snapshot of a guild's configuration
+	GuildExport struct {
+		Version              int                   `json:"version"`
+		GuildId              string                `json:"guild_id"`
+		ExportedAt           time.Time             `json:"exported_at"`
+		AdminChannelId       string                `json:"admin_channel_id,omitempty"`
+		Subscriptions        []subscription        `json:"subscriptions"`
+		Watchlist            []string              `json:"watchlist,omitempty"`
+		Templates            map[string]string     `json:"templates,omitempty"`
+		Goal                 *voiceGoal            `json:"goal,omitempty"`
+		Ignored              []string              `json:"ignored,omitempty"`  // users that are never announced
+		OptOuts              []string              `json:"opt_outs,omitempty"` // users that are neither announced nor recorded
+		LogChannelId         string                `json:"log_channel_id,omitempty"`
+		FallbackChannelId    string                `json:"fallback_channel_id,omitempty"`
+		SubscribeAccess      *subscribeAccess      `json:"subscribe_access,omitempty"`
+		DebounceStrategy     string                `json:"debounce_strategy,omitempty"`
+		PlainText            bool                  `json:"plain_text,omitempty"` // post without emoji
+		Minimal              bool                  `json:"minimal,omitempty"`    // post without emoji and markdown
+		Timestamps           bool                  `json:"timestamps,omitempty"` // add Discord timestamps to notifications
+		Tone                 string                `json:"tone,omitempty"`
+		Language             string                `json:"language,omitempty"`
+		AFK                  *afkSetting           `json:"afk,omitempty"`
+		Digest               *voiceDigest          `json:"digest,omitempty"`
+		PatternSubscriptions []patternSubscription `json:"pattern_subscriptions,omitempty"` // voice channel name patterns
+		GroupWindows         map[string]string     `json:"group_windows,omitempty"`         // textChannelID -> grouping window
+	}
+
+	// ImportResult reports what an import recreated and what it had to skip
+	ImportResult struct {
+		Imported          int      `json:"imported"`
+		AlreadyPresent    int      `json:"already_present"`
+		Skipped           []string `json:"skipped,omitempty"`
+		AdminChannelSet   bool     `json:"admin_channel_set"`
+		AdminChannelError string   `json:"admin_channel_error,omitempty"`
+	}
+)
+
+// exportGuild builds an export of all subscriptions and settings of a guild
+func (b *Bot) exportGuild(guildID string) *GuildExport {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	export := &GuildExport{
+		Version:              guildExportVersion,
+		GuildId:              guildID,
+		ExportedAt:           time.Now().UTC(),
+		AdminChannelId:       b.adminChannels[guildID],
+		Subscriptions:        []subscription{},
+		Watchlist:            slices.Clone(b.watchlist[guildID]),
+		Templates:            maps.Clone(b.templates[guildID]),
+		Ignored:              slices.Clone(b.ignored[guildID]),
+		OptOuts:              slices.Clone(b.optOuts[guildID]),
+		LogChannelId:         b.logChannels[guildID],
+		FallbackChannelId:    b.fallbackChannels[guildID],
+		DebounceStrategy:     b.debounceStrategies[guildID],
+		PlainText:            b.plainTextGuilds[guildID],
+		Minimal:              b.minimalGuilds[guildID],
+		Timestamps:           b.timestampGuilds[guildID],
+		Tone:                 b.tones[guildID],
+		Language:             b.languages[guildID],
+		PatternSubscriptions: slices.Clone(b.patternSubscriptions[guildID]),
+		GroupWindows:         maps.Clone(b.groupWindows[guildID]),
+	}
+	if access, ok := b.subscribeAccess[guildID]; ok {
+		export.SubscribeAccess = &access
+	}
+	if afk, ok := b.afkSettings[guildID]; ok {
+		export.AFK = &afk
+	}
+	if digest, ok := b.digests[guildID]; ok {
+		export.Digest = &digest
+	}
+	if goal, ok := b.goals[guildID]; ok {
+		goalCopy := *goal
+		export.Goal = &goalCopy
+	}
+
+	export.Subscriptions = append(export.Subscriptions, b.subscriptions.Guild(guildID)...)
+	return export
+}
+
+// isEmpty reports whether an export holds no configuration at all. It
+// compares the encoded export with a zero one, so new sections are covered
+// without listing them here.
+func (export *GuildExport) isEmpty() bool {
+	trimmed := *export
+	trimmed.Version, trimmed.GuildId, trimmed.ExportedAt = 0, "", time.Time{}
+	if len(trimmed.Subscriptions) == 0 {
+		trimmed.Subscriptions = nil
+	}
+
+	encoded, err := json.Marshal(&trimmed)
+	if err != nil {
+		return false
+	}
+	zero, _ := json.Marshal(&GuildExport{})
+	return bytes.Equal(encoded, zero)
+}
+
+// importGuild recreates the subscriptions and settings of an export in a guild.
+// Only entries whose channel IDs still exist in the guild are imported.
+func (b *Bot) importGuild(s DiscordSession, guildID string, export *GuildExport) (*ImportResult, error) {
+	if export.Version > guildExportVersion {
+		return nil, newUserError("import.unsupported-version", export.Version)
+	}
+	if export.GuildId != "" && export.GuildId != guildID {
+		return nil, fmt.Errorf("export belongs to guild %s, not %s", export.GuildId, guildID)
+	}
+
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching guild channels: %w", err)
+	}
+
+	channelTypes := make(map[string]discordgo.ChannelType, len(channels))
+	for _, channel := range channels {
+		channelTypes[channel.ID] = channel.Type
+	}
+
+	result := &ImportResult{}
+	for _, sub := range export.Subscriptions {
+		if channelType, ok := channelTypes[sub.VoiceChannelId]; !ok || channelType != discordgo.ChannelTypeGuildVoice {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("voice channel %s not found", sub.VoiceChannelId))
+			continue
+		}
+		if _, ok := channelTypes[sub.TextChannelId]; !ok {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("text channel %s not found", sub.TextChannelId))
+			continue
+		}
+
+		alreadySubscribed, err := b.addSubscription(sub.VoiceChannelId, sub.TextChannelId, guildID)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("voice channel %s: %v", sub.VoiceChannelId, err))
+			continue
+		}
+		if alreadySubscribed {
+			result.AlreadyPresent++
+		} else {
+			result.Imported++
+		}
+
+		// Carry over per-subscription settings
+		imported := sub
+		imported.GuildId = guildID
+		b.updateSubscription(sub.VoiceChannelId, sub.TextChannelId, func(existing *subscription) {
+			*existing = imported
+		})
+	}
+
+	for _, voiceChannelID := range export.Watchlist {
+		if channelTypes[voiceChannelID] == discordgo.ChannelTypeGuildVoice {
+			b.addWatch(guildID, voiceChannelID)
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("watched voice channel %s not found", voiceChannelID))
+		}
+	}
+
+	for event, text := range export.Templates {
+		if _, err := ParseTemplate(text); err != nil || !slices.Contains(TemplateEvents, event) {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("invalid %s template", event))
+			continue
+		}
+		b.setTemplate(guildID, event, text)
+	}
+
+	for _, userID := range export.Ignored {
+		b.ignoreUser(guildID, userID)
+	}
+	for _, userID := range export.OptOuts {
+		b.optOut(guildID, userID)
+	}
+
+	if export.Goal != nil {
+		if _, ok := channelTypes[export.Goal.ChannelId]; ok {
+			goal := *export.Goal
+			b.mu.Lock()
+			b.goals[guildID] = &goal
+			b.mu.Unlock()
+			b.savePersistedDataAsync()
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("goal channel %s not found", export.Goal.ChannelId))
+		}
+	}
+
+	if export.LogChannelId != "" {
+		if _, ok := channelTypes[export.LogChannelId]; ok {
+			b.mu.Lock()
+			b.logChannels[guildID] = export.LogChannelId
+			b.savePersistedDataAsync()
+			b.mu.Unlock()
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("log channel %s not found", export.LogChannelId))
+		}
+	}
+
+	if export.FallbackChannelId != "" {
+		if _, ok := channelTypes[export.FallbackChannelId]; ok {
+			b.mu.Lock()
+			b.fallbackChannels[guildID] = export.FallbackChannelId
+			b.savePersistedDataAsync()
+			b.mu.Unlock()
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("fallback channel %s not found", export.FallbackChannelId))
+		}
+	}
+
+	if export.SubscribeAccess != nil {
+		if _, known := accessPermissions[export.SubscribeAccess.Permission]; export.SubscribeAccess.RoleId != "" || known {
+			b.mu.Lock()
+			b.subscribeAccess[guildID] = *export.SubscribeAccess
+			b.savePersistedDataAsync()
+			b.mu.Unlock()
+		} else {
+			result.Skipped = append(result.Skipped, "invalid subscribe permission")
+		}
+	}
+
+	if export.Digest != nil {
+		if _, ok := channelTypes[export.Digest.ChannelId]; ok && (export.Digest.Period == digestDaily || export.Digest.Period == digestWeekly) {
+			b.mu.Lock()
+			b.digests[guildID] = *export.Digest
+			b.savePersistedDataAsync()
+			b.mu.Unlock()
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("digest channel %s not found", export.Digest.ChannelId))
+		}
+	}
+
+	if export.Minimal {
+		b.mu.Lock()
+		b.setPresentation(guildID, presentationMinimal)
+		b.mu.Unlock()
+	} else if export.PlainText {
+		b.mu.Lock()
+		b.setPresentation(guildID, presentationPlain)
+		b.mu.Unlock()
+	}
+
+	if export.Timestamps {
+		b.mu.Lock()
+		b.timestampGuilds[guildID] = true
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+	}
+
+	if export.Tone != "" {
+		if slices.Contains(tonePresets, export.Tone) {
+			b.mu.Lock()
+			b.tones[guildID] = export.Tone
+			b.savePersistedDataAsync()
+			b.mu.Unlock()
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("unknown tone %s", export.Tone))
+		}
+	}
+
+	if export.Language != "" {
+		if slices.Contains(supportedLocales, export.Language) {
+			b.mu.Lock()
+			b.languages[guildID] = export.Language
+			b.savePersistedDataAsync()
+			b.mu.Unlock()
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("unknown language %s", export.Language))
+		}
+	}
+
+	if export.AFK != nil {
+		b.mu.Lock()
+		b.afkSettings[guildID] = *export.AFK
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+	}
+
+	if export.DebounceStrategy != "" {
+		if _, ok := debounceStrategies[export.DebounceStrategy]; ok {
+			b.mu.Lock()
+			b.debounceStrategies[guildID] = export.DebounceStrategy
+			b.savePersistedDataAsync()
+			b.mu.Unlock()
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("unknown debounce strategy %s", export.DebounceStrategy))
+		}
+	}
+
+	for _, pattern := range export.PatternSubscriptions {
+		if _, ok := channelTypes[pattern.TextChannelId]; !ok {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("text channel %s of pattern %s not found", pattern.TextChannelId, pattern.Pattern))
+			continue
+		}
+		pattern.GuildId = guildID
+		b.mu.Lock()
+		if slices.Contains(b.patternSubscriptions[guildID], pattern) {
+			result.AlreadyPresent++
+		} else {
+			b.patternSubscriptions[guildID] = append(b.patternSubscriptions[guildID], pattern)
+			b.savePersistedDataAsync()
+			result.Imported++
+		}
+		b.mu.Unlock()
+	}
+
+	for textChannelID, window := range export.GroupWindows {
+		duration, err := time.ParseDuration(window)
+		if _, ok := channelTypes[textChannelID]; !ok || err != nil || duration < minGroupWindow || duration > maxGroupWindow {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("notification grouping of text channel %s", textChannelID))
+			continue
+		}
+		b.mu.Lock()
+		if b.groupWindows[guildID] == nil {
+			b.groupWindows[guildID] = make(map[string]string)
+		}
+		b.groupWindows[guildID][textChannelID] = window
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+	}
+
+	if export.AdminChannelId != "" {
+		if _, ok := channelTypes[export.AdminChannelId]; ok {
+			b.setAdminChannel(guildID, export.AdminChannelId)
+			result.AdminChannelSet = true
+		} else {
+			result.AdminChannelError = fmt.Sprintf("admin channel %s not found", export.AdminChannelId)
+		}
+	}
+
+	return result, nil
+}
+
+// loadImportFile reads the guild exports to import on startup.
+// The file may contain a single export or a list of exports.
+func loadImportFile(path string) []*GuildExport {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("Error reading IMPORT_FILE", "path", path, "error", err)
+		return nil
+	}
+
+	var exports []*GuildExport
+	if err := json.Unmarshal(file, &exports); err != nil {
+		var export GuildExport
+		if err := json.Unmarshal(file, &export); err != nil {
+			slog.Error("Error parsing IMPORT_FILE", "path", path, "error", err)
+			return nil
+		}
+		exports = []*GuildExport{&export}
+	}
+	return exports
+}
+
+// importPending imports any IMPORT_FILE exports that target the given guild.
+// Each export is applied once per process.
+func (b *Bot) importPending(s DiscordSession, guildID string) {
+	b.mu.Lock()
+	var exports []*GuildExport
+	remaining := b.pendingImports[:0]
+	for _, export := range b.pendingImports {
+		if export.GuildId == guildID {
+			exports = append(exports, export)
+		} else {
+			remaining = append(remaining, export)
+		}
+	}
+	b.pendingImports = remaining
+	b.mu.Unlock()
+
+	for _, export := range exports {
+		result, err := b.importGuild(s, guildID, export)
+		if err != nil {
+			slog.Error("Error importing configuration", "guild_id", guildID, "error", err)
+			continue
+		}
+		slog.Info("Imported configuration", "guild_id", guildID, "new", result.Imported, "existing", result.AlreadyPresent, "skipped", len(result.Skipped))
+	}
+}
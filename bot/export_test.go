@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGuildExportIsEmpty(t *testing.T) {
+	tests := []struct {
+		name   string
+		export GuildExport
+		want   bool
+	}{
+		{name: "nothing configured", export: GuildExport{Version: guildExportVersion, GuildId: "1", ExportedAt: time.Now(), Subscriptions: []subscription{}}, want: true},
+		{name: "empty sections", export: GuildExport{Templates: map[string]string{}, Watchlist: []string{}, GroupWindows: map[string]string{}}, want: true},
+		{name: "subscription", export: GuildExport{Subscriptions: []subscription{{VoiceChannelId: "11", TextChannelId: "12"}}}},
+		{name: "minimal style only", export: GuildExport{Minimal: true}},
+		{name: "plain text only", export: GuildExport{PlainText: true}},
+		{name: "group window only", export: GuildExport{GroupWindows: map[string]string{"12": "1m"}}},
+		{name: "language only", export: GuildExport{Language: "de"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.export.isEmpty(); got != tt.want {
+				t.Errorf("isEmpty = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveGuildMinimalStyle(t *testing.T) {
+	b := newTestBot(t)
+	b.persistence = NewPersistence(filepath.Join(t.TempDir(), "subscriptions.json"))
+	b.archiveDir = t.TempDir()
+	b.mu.Lock()
+	b.minimalGuilds["1"] = true
+	b.mu.Unlock()
+
+	if err := b.archiveGuild("1"); err != nil {
+		t.Fatalf("archiveGuild: %v", err)
+	}
+	if entries, _ := os.ReadDir(b.archiveDir); len(entries) != 1 {
+		t.Errorf("%d archives for a guild with only the minimal style, want 1", len(entries))
+	}
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxImportSize caps the size of an uploaded export
+const maxImportSize = 1 << 20
+
+// exportImportCommands returns the /export-subscriptions and /import-subscriptions command definitions
+func exportImportCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "export-subscriptions",
+			DefaultMemberPermissions: &manageServerPermission,
+		},
+		{
+			Name:                     "import-subscriptions",
+			DefaultMemberPermissions: &manageServerPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:     discordgo.ApplicationCommandOptionAttachment,
+					Name:     "file",
+					Required: true,
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleExportSubscriptions(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !hasPermission(i, discordgo.PermissionManageServer) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "export.manage-server"))
+		return
+	}
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	export := b.exportGuild(i.GuildID)
+	jsonData, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		slog.Error("Error encoding export", "guild_id", i.GuildID, "error", err)
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "export.failed"))
+		return
+	}
+
+	slog.Info("Exported configuration", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "subscriptions", len(export.Subscriptions))
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.t(i.GuildID, "export.done", len(export.Subscriptions)),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files: []*discordgo.File{
+				{
+					Name:        fmt.Sprintf("voiceactivitybot-%s-%s.json", i.GuildID, export.ExportedAt.Format("20060102-1504")),
+					ContentType: "application/json",
+					Reader:      bytes.NewReader(jsonData),
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to interaction", "guild_id", i.GuildID, "channel_id", i.ChannelID, "error", err)
+	}
+}
+
+func (b *Bot) handleImportSubscriptions(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !hasPermission(i, discordgo.PermissionManageServer) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "import.manage-server"))
+		return
+	}
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	attachmentID, _ := data.Options[0].Value.(string)
+	attachment, ok := data.Resolved.Attachments[attachmentID]
+	if !ok {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "import.no-file"))
+		return
+	}
+	if attachment.Size > maxImportSize {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "import.too-large"))
+		return
+	}
+
+	// Downloading and checking channels can take longer than the interaction deadline
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	content := b.importAttachment(s, i, attachment.URL)
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+}
+
+// importAttachment downloads an export and imports it, returning the response text
+func (b *Bot) importAttachment(s DiscordSession, i *discordgo.InteractionCreate, url string) string {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		slog.Error("Error downloading import", "guild_id", i.GuildID, "error", err)
+		return b.t(i.GuildID, "import.download-failed")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		slog.Error("Error downloading import", "guild_id", i.GuildID, "status", resp.StatusCode, "error", err)
+		return b.t(i.GuildID, "import.download-failed")
+	}
+
+	var export GuildExport
+	if err := json.Unmarshal(body, &export); err != nil {
+		return b.t(i.GuildID, "import.invalid")
+	}
+
+	// Exports from another server can be imported; only channels that exist here are recreated
+	fromOtherGuild := export.GuildId != "" && export.GuildId != i.GuildID
+	export.GuildId = ""
+
+	result, err := b.importGuild(s, i.GuildID, &export)
+	if err != nil {
+		return b.t(i.GuildID, "import.failed", b.errorText(i.GuildID, err))
+	}
+	slog.Info("Imported configuration", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "new", result.Imported, "existing", result.AlreadyPresent, "skipped", len(result.Skipped))
+
+	lines := []string{b.t(i.GuildID, "import.done", result.Imported, result.AlreadyPresent)}
+	if fromOtherGuild {
+		lines = append(lines, b.t(i.GuildID, "import.other-guild"))
+	}
+	if result.AdminChannelError != "" {
+		lines = append(lines, "⚠️ "+result.AdminChannelError)
+	}
+	if len(result.Skipped) > 0 {
+		lines = append(lines, b.t(i.GuildID, "import.skipped", len(result.Skipped)))
+		for _, skipped := range result.Skipped {
+			lines = append(lines, "• "+skipped)
+		}
+	}
+	return truncateMessage(strings.Join(lines, "\n"), maxMessageLength)
+}
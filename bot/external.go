@@ -0,0 +1,398 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	externalSlack    = "slack"
+	externalTelegram = "telegram"
+
+	// externalTimeout bounds one request to an external service
+	externalTimeout = 10 * time.Second
+	// externalQueueSize is how many forwards may wait per provider before new
+	// ones are dropped
+	externalQueueSize = 100
+)
+
+// externalIntervals space out requests per provider, below the documented
+// limits of one message per second for Slack webhooks and Telegram chats
+var externalIntervals = map[string]time.Duration{
+	externalSlack:    time.Second,
+	externalTelegram: time.Second,
+}
+
+var (
+	discordMentionPattern   = regexp.MustCompile(`<(@!?|@&|#)(\d+)>`)
+	discordTimestampPattern = regexp.MustCompile(`<t:(-?\d+)(?::[tTdDfFR])?>`)
+)
+
+type (
+	// externalTarget is a destination outside Discord that a subscription
+	// also forwards its notifications to
+	externalTarget struct {
+		Provider string `json:"provider"` // externalSlack or externalTelegram
+		Target   string `json:"target"`   // Slack incoming webhook URL or Telegram chat ID
+	}
+
+	// externalProvider delivers text to one kind of external service
+	externalProvider interface {
+		validate(target string) error
+		format(text string) string
+		send(ctx context.Context, target, text string) error
+	}
+
+	// slackProvider posts to Slack incoming webhooks
+	slackProvider struct {
+		client *http.Client
+	}
+
+	// telegramProvider sends messages with a Telegram bot
+	telegramProvider struct {
+		client *http.Client
+		apiURL string // https://api.telegram.org/bot<token>
+	}
+
+	// externalBridge queues forwards for one provider and sends them no faster
+	// than its interval
+	externalBridge struct {
+		name     string
+		provider externalProvider
+		interval time.Duration
+		queue    chan externalForward
+	}
+
+	externalForward struct {
+		guildID string
+		target  string
+		text    string
+	}
+
+	// externalRateLimitError is returned when a service asks to slow down
+	externalRateLimitError struct {
+		retryAfter time.Duration
+	}
+)
+
+func (err *externalRateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", err.retryAfter)
+}
+
+// newExternalBridges returns the Slack bridge and, if a Telegram bot token is
+// set, the Telegram bridge
+func newExternalBridges(telegramToken string) map[string]*externalBridge {
+	client := &http.Client{Timeout: externalTimeout}
+	bridges := map[string]*externalBridge{
+		externalSlack: newExternalBridge(externalSlack, &slackProvider{client: client}),
+	}
+	if telegramToken != "" {
+		bridges[externalTelegram] = newExternalBridge(externalTelegram, &telegramProvider{client: client, apiURL: "https://api.telegram.org/bot" + telegramToken})
+	}
+	return bridges
+}
+
+func newExternalBridge(name string, provider externalProvider) *externalBridge {
+	return &externalBridge{
+		name:     name,
+		provider: provider,
+		interval: externalIntervals[name],
+		queue:    make(chan externalForward, externalQueueSize),
+	}
+}
+
+// run sends queued forwards until ctx is done
+func (bridge *externalBridge) run(ctx context.Context) {
+	for {
+		var forward externalForward
+		select {
+		case <-ctx.Done():
+			return
+		case forward = <-bridge.queue:
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, externalTimeout)
+		err := bridge.provider.send(sendCtx, forward.target, forward.text)
+		cancel()
+
+		wait := bridge.interval
+		var rateLimitErr *externalRateLimitError
+		if errors.As(err, &rateLimitErr) {
+			wait = max(wait, rateLimitErr.retryAfter)
+		}
+		if err != nil {
+			slog.Warn("Error forwarding notification", "guild_id", forward.guildID, "provider", bridge.name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// enqueue queues a forward, dropping it if the queue is full
+func (bridge *externalBridge) enqueue(forward externalForward) {
+	select {
+	case bridge.queue <- forward:
+	default:
+		slog.Warn("External forward queue full, dropping notification", "guild_id", forward.guildID, "provider", bridge.name)
+	}
+}
+
+// forwardExternal queues a delivered notification for the subscription's
+// external targets
+func (b *Bot) forwardExternal(sub subscription, message *discordgo.MessageSend) {
+	if len(sub.Externals) == 0 {
+		return
+	}
+	text := b.plainDiscordText(sub.GuildId, notificationText(message))
+	for _, target := range sub.Externals {
+		bridge, ok := b.externals[target.Provider]
+		if !ok {
+			slog.Warn("External provider not configured", "guild_id", sub.GuildId, "channel_id", sub.VoiceChannelId, "provider", target.Provider)
+			continue
+		}
+		bridge.enqueue(externalForward{guildID: sub.GuildId, target: target.Target, text: bridge.provider.format(text)})
+	}
+}
+
+// plainDiscordText replaces Discord mentions and timestamps, which other
+// services can't render, with names from the state cache and UTC times
+func (b *Bot) plainDiscordText(guildID, text string) string {
+	text = discordMentionPattern.ReplaceAllStringFunc(text, func(mention string) string {
+		parts := discordMentionPattern.FindStringSubmatch(mention)
+		id := parts[2]
+		switch parts[1] {
+		case "#":
+			if channel, err := b.session.State.Channel(id); err == nil {
+				return "#" + channel.Name
+			}
+		case "@&":
+			if role, err := b.session.State.Role(guildID, id); err == nil {
+				return "@" + role.Name
+			}
+		default:
+			if member, err := b.session.State.Member(guildID, id); err == nil && member.User != nil {
+				return "@" + getUsername(member)
+			}
+		}
+		return "@" + id
+	})
+	return discordTimestampPattern.ReplaceAllStringFunc(text, func(timestamp string) string {
+		unix, err := strconv.ParseInt(discordTimestampPattern.FindStringSubmatch(timestamp)[1], 10, 64)
+		if err != nil {
+			return timestamp
+		}
+		return time.Unix(unix, 0).UTC().Format("2006-01-02 15:04 UTC")
+	})
+}
+
+func (p *slackProvider) validate(target string) error {
+	if !strings.HasPrefix(target, "https://hooks.slack.com/") {
+		return newUserError("external.invalid-slack")
+	}
+	return nil
+}
+
+// format converts Discord markdown to Slack mrkdwn
+func (p *slackProvider) format(text string) string {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	return strings.ReplaceAll(text, "**", "*")
+}
+
+func (p *slackProvider) send(ctx context.Context, target, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return postExternal(ctx, p.client, target, body)
+}
+
+func (p *telegramProvider) validate(target string) error {
+	if _, err := strconv.ParseInt(target, 10, 64); err == nil || (strings.HasPrefix(target, "@") && len(target) > 1) {
+		return nil
+	}
+	return newUserError("external.invalid-telegram")
+}
+
+// format sends plain text, Telegram's Markdown would need escaping
+func (p *telegramProvider) format(text string) string {
+	return strings.ReplaceAll(text, "**", "")
+}
+
+func (p *telegramProvider) send(ctx context.Context, target, text string) error {
+	body, err := json.Marshal(map[string]any{"chat_id": target, "text": text, "disable_web_page_preview": true})
+	if err != nil {
+		return err
+	}
+	return postExternal(ctx, p.client, p.apiURL+"/sendMessage", body)
+}
+
+// postExternal posts JSON and turns error statuses into errors
+func postExternal(ctx context.Context, client *http.Client, endpoint string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may hold a token, keep it out of logs and replies
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &externalRateLimitError{retryAfter: time.Duration(max(retryAfter, 1)) * time.Second}
+	}
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// externalCommand returns the /subscribe-external command definition
+func externalCommand() *discordgo.ApplicationCommand {
+	voiceChannelOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionChannel,
+		Name:         "voice-channel",
+		Required:     true,
+		ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+	}
+	providerOption := &discordgo.ApplicationCommandOption{
+		Type:     discordgo.ApplicationCommandOptionString,
+		Name:     "provider",
+		Required: true,
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Value: externalSlack},
+			{Value: externalTelegram},
+		},
+	}
+	return &discordgo.ApplicationCommand{
+		Name: "subscribe-external",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "add",
+				Options: []*discordgo.ApplicationCommandOption{
+					voiceChannelOption,
+					providerOption,
+					{
+						Type:     discordgo.ApplicationCommandOptionString,
+						Name:     "target",
+						Required: true,
+					},
+				},
+			},
+			{
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Name:    "remove",
+				Options: []*discordgo.ApplicationCommandOption{voiceChannelOption, providerOption},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleSubscribeExternal(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subcommand.Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	provider := options["provider"].StringValue()
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	sub, ok := b.getSubscription(voiceChannelID, i.ChannelID)
+	if !ok || sub.GuildId != i.GuildID || sub.isDM() {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.not-subscribed-here", channelName))
+		return
+	}
+
+	if subcommand.Name == "remove" {
+		removed := false
+		b.updateSubscription(voiceChannelID, i.ChannelID, func(existing *subscription) {
+			before := len(existing.Externals)
+			existing.Externals = slices.DeleteFunc(slices.Clone(existing.Externals), func(target externalTarget) bool { return target.Provider == provider })
+			removed = len(existing.Externals) < before
+		})
+		if !removed {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "external.not-forwarded", channelName, externalProviderName(provider)))
+			return
+		}
+		slog.Info("External forward removed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", voiceChannelID, "text_channel_id", i.ChannelID, "provider", provider)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "external.removed", channelName, externalProviderName(provider)))
+		return
+	}
+
+	bridge, ok := b.externals[provider]
+	if !ok {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "external.not-configured", externalProviderName(provider)))
+		return
+	}
+	target := strings.TrimSpace(options["target"].StringValue())
+	if err := bridge.provider.validate(target); err != nil {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "external.invalid-target", b.errorText(i.GuildID, err)))
+		return
+	}
+
+	// Sending the test message may take longer than an interaction allows
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalTimeout)
+	defer cancel()
+	test := b.t(i.GuildID, "external.test", channelName, b.getGuildName(s, i.GuildID))
+	content := b.t(i.GuildID, "external.added", channelName, externalProviderName(provider))
+	if err := bridge.provider.send(ctx, target, bridge.provider.format(test)); err != nil {
+		slog.Warn("Error sending external test message", "guild_id", i.GuildID, "provider", provider, "error", err)
+		content = b.t(i.GuildID, "external.test-failed", externalProviderName(provider), err)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	b.updateSubscription(voiceChannelID, i.ChannelID, func(existing *subscription) {
+		// One target per provider, a new one replaces the old
+		existing.Externals = append(slices.DeleteFunc(slices.Clone(existing.Externals), func(other externalTarget) bool { return other.Provider == provider }),
+			externalTarget{Provider: provider, Target: target})
+	})
+	slog.Info("External forward added", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", voiceChannelID, "text_channel_id", i.ChannelID, "provider", provider)
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+}
+
+// externalProviderName returns the display name of a provider
+func externalProviderName(provider string) string {
+	switch provider {
+	case externalSlack:
+		return "Slack"
+	case externalTelegram:
+		return "Telegram"
+	}
+	return provider
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// isUnknownChannelError reports whether a send failed because the channel no longer exists
+func isUnknownChannelError(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownChannel
+}
+
+// textChannelGone handles a deleted text channel: its subscriptions move to
+// the guild's fallback channel, or are removed if it has none, and the admin
+// channel is told what happened
+func (b *Bot) textChannelGone(s DiscordSession, guildID, textChannelID, channelName string) {
+	b.mu.Lock()
+	fallbackID := b.fallbackChannels[guildID]
+	if fallbackID == textChannelID {
+		// The fallback itself is gone
+		delete(b.fallbackChannels, guildID)
+		b.savePersistedDataAsync()
+		fallbackID = ""
+	}
+	adminChannelID, hasAdminChannel := b.adminChannels[guildID]
+	if _, grouped := b.groupWindows[guildID][textChannelID]; grouped {
+		delete(b.groupWindows[guildID], textChannelID)
+		b.savePersistedDataAsync()
+	}
+	b.mu.Unlock()
+
+	removed := b.subscriptions.RemoveFunc(func(sub subscription) bool {
+		return sub.TextChannelId == textChannelID && !sub.isDM()
+	})
+	moved, dropped := b.movePatternSubscriptions(guildID, textChannelID, fallbackID)
+	if len(removed) == 0 && len(moved) == 0 && len(dropped) == 0 {
+		return
+	}
+	for idx, pattern := range moved {
+		moved[idx] = b.t(guildID, "fallback.pattern", pattern)
+	}
+	for idx, pattern := range dropped {
+		dropped[idx] = b.t(guildID, "fallback.pattern", pattern)
+	}
+
+	for _, sub := range removed {
+		name := fmt.Sprintf("**%s**", b.getChannelName(s, sub.VoiceChannelId))
+		if fallbackID == "" {
+			dropped = append(dropped, name)
+			continue
+		}
+
+		sub.TextChannelId = fallbackID
+		sub.StatusMessageId = ""
+		sub.Broken = ""
+		if b.subscriptions.Add(sub) {
+			moved = append(moved, name)
+		} else {
+			// The fallback channel is already subscribed to this voice channel
+			dropped = append(dropped, name)
+		}
+	}
+	slog.Info("Text channel gone, moved subscriptions", "guild_id", guildID, "channel_id", textChannelID, "fallback_channel_id", fallbackID, "moved", len(moved), "removed", len(dropped))
+
+	if !hasAdminChannel || adminChannelID == textChannelID {
+		return
+	}
+	lines := []string{b.t(guildID, "fallback.gone", channelName)}
+	if len(moved) > 0 {
+		lines = append(lines, b.t(guildID, "fallback.moved", b.joinLimited(guildID, moved, ", ", 800), fallbackID))
+	}
+	if len(dropped) > 0 {
+		line := b.t(guildID, "fallback.removed", b.joinLimited(guildID, dropped, ", ", 800))
+		if fallbackID == "" {
+			line += " " + b.t(guildID, "fallback.hint")
+		}
+		lines = append(lines, line)
+	}
+	if _, err := s.ChannelMessageSend(adminChannelID, truncateMessage(strings.Join(lines, "\n"), maxMessageLength)); err != nil {
+		slog.Error("Error notifying admin channel", "guild_id", guildID, "channel_id", adminChannelID, "error", err)
+	}
+}
+
+// handleConfigFallbackChannel sets the channel that takes over subscriptions
+// of deleted text channels
+func (b *Bot) handleConfigFallbackChannel(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	channelOpt, hasChannel := options["channel"]
+	clearOpt, hasClear := options["clear"]
+
+	b.mu.RLock()
+	current, isSet := b.fallbackChannels[i.GuildID]
+	b.mu.RUnlock()
+
+	switch {
+	case hasClear && clearOpt.BoolValue():
+		b.mu.Lock()
+		delete(b.fallbackChannels, i.GuildID)
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+		slog.Info("Fallback channel cleared", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i))
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "fallback.cleared"))
+
+	case hasChannel:
+		channelID := channelOpt.ChannelValue(nil).ID
+		b.mu.Lock()
+		b.fallbackChannels[i.GuildID] = channelID
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+		slog.Info("Fallback channel set", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", channelID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "fallback.set", channelID))
+
+	case isSet:
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "fallback.current", current))
+
+	default:
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "fallback.none"))
+	}
+}
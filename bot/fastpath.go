@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// fastPathWindow is how far back events count towards a guild's event rate
+const fastPathWindow = time.Minute
+
+// eventRates tracks recent voice events per guild, to decide which guilds are
+// quiet enough to be notified without debouncing
+type eventRates struct {
+	threshold int                    // events per window below which the fast path is taken, 0 disables it
+	events    map[string][]time.Time // guildID -> recent event times, oldest first
+	mu        sync.Mutex
+}
+
+func newEventRates(threshold int) *eventRates {
+	return &eventRates{threshold: threshold, events: make(map[string][]time.Time)}
+}
+
+// fast records an event and reports whether the guild had at most threshold
+// events in the last fastPathWindow, including this one
+func (r *eventRates) fast(guildID string, now time.Time) bool {
+	if r.threshold <= 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events[guildID]
+	for len(events) > 0 && now.Sub(events[0]) >= fastPathWindow {
+		events = events[1:]
+	}
+	events = append(events, now)
+	// Only the last threshold+1 events matter for the decision
+	if len(events) > r.threshold+1 {
+		events = events[len(events)-r.threshold-1:]
+	}
+	r.events[guildID] = events
+	return len(events) <= r.threshold
+}
+
+// forget drops a guild's event history
+func (r *eventRates) forget(guildID string) {
+	r.mu.Lock()
+	delete(r.events, guildID)
+	r.mu.Unlock()
+}
@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"log/slog"
+	"time"
+)
+
+// discordgo keeps the gateway session ID and sequence private, so a restarted
+// bot can't resume the previous session. Fast start shortens the gap instead:
+// commands stay registered across restarts and are refreshed in the
+// background, while voice states are seeded from GUILD_CREATE right away.
+
+// fastStartDelay is how long command registration waits after startup in
+// fast-start mode, leaving the gateway and rate limits to voice events
+const fastStartDelay = 30 * time.Second
+
+// registerCommandsDeferred refreshes a guild's commands once fastStartDelay
+// has passed since startup. Guilds are refreshed one at a time with a single
+// bulk overwrite each, which also keeps the commands in place meanwhile.
+func (b *Bot) registerCommandsDeferred(s DiscordSession, guildID string) {
+	select {
+	case <-time.After(time.Until(b.startedAt.Add(fastStartDelay))):
+	case <-b.ctx.Done():
+		return
+	}
+
+	b.registerMu.Lock()
+	defer b.registerMu.Unlock()
+	if b.ctx.Err() != nil {
+		return
+	}
+
+	commands, err := s.ApplicationCommandBulkOverwrite(b.session.State.User.ID, guildID, b.localizeCommands(commandDefinitions()))
+	if err != nil {
+		slog.Error("Cannot refresh commands", "guild_id", guildID, "error", err)
+		return
+	}
+	b.mu.Lock()
+	b.registeredCmdIds[guildID] = commands
+	b.mu.Unlock()
+	slog.Debug("Refreshed commands", "guild_id", guildID, "count", len(commands))
+}
@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// federationEventsPerMinute caps how many events one peer may push
+	federationEventsPerMinute = 120
+	// maxFederationName caps server, user and channel names pushed by peers
+	maxFederationName = 100
+)
+
+type (
+	// FederationEvent is a voice event pushed by a federated instance
+	FederationEvent struct {
+		Server      string    `json:"server"`                 // display name of the community
+		Type        string    `json:"type"`                   // join, leave or move
+		User        string    `json:"user"`                   // display name of the user
+		Channel     string    `json:"channel"`                // voice channel name, the new one for moves
+		FromChannel string    `json:"from_channel,omitempty"` // previous voice channel for moves
+		Count       int       `json:"count,omitempty"`        // users in the channel after the event
+		Time        time.Time `json:"time,omitzero"`
+	}
+
+	// federationReceiver accepts events from trusted peers and posts them to
+	// the hub channel
+	federationReceiver struct {
+		bot       *Bot
+		peers     map[string]string // token -> peer name
+		channelID string
+		mu        sync.Mutex
+		windows   map[string]*federationWindow // peer name -> current rate window
+	}
+
+	federationWindow struct {
+		start time.Time
+		count int
+	}
+)
+
+// newFederationReceiver returns the receiver of federated events, or nil
+// when federation is disabled
+func newFederationReceiver(b *Bot, cfg config.Federation) *federationReceiver {
+	if len(cfg.Peers) == 0 {
+		return nil
+	}
+
+	peers := make(map[string]string, len(cfg.Peers))
+	for name, token := range cfg.Peers {
+		peers[token] = name
+	}
+
+	return &federationReceiver{
+		bot:       b,
+		peers:     peers,
+		channelID: cfg.Channel,
+		windows:   make(map[string]*federationWindow),
+	}
+}
+
+// peer returns the name of the peer a request authenticates as
+func (f *federationReceiver) peer(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	for peerToken, name := range f.peers {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(peerToken)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// allow reports whether a peer is within its event rate
+func (f *federationReceiver) allow(peer string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	window, ok := f.windows[peer]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &federationWindow{start: now}
+		f.windows[peer] = window
+	}
+	window.count++
+	return window.count <= federationEventsPerMinute
+}
+
+// handleEvent receives POST /api/federation/events
+func (f *federationReceiver) handleEvent(w http.ResponseWriter, r *http.Request) {
+	peer, ok := f.peer(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, apiError{Error: "unauthorized"})
+		return
+	}
+	if !f.allow(peer) {
+		writeJSON(w, http.StatusTooManyRequests, apiError{Error: "too many events"})
+		return
+	}
+
+	var event FederationEvent
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&event); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid JSON body"})
+		return
+	}
+	if err := event.validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	if event.Server == "" {
+		event.Server = peer
+	}
+
+	// Announce in the hub guild's language, English when the channel isn't cached
+	hubGuildID := ""
+	if channel, err := f.bot.session.State.Channel(f.channelID); err == nil {
+		hubGuildID = channel.GuildID
+	}
+	_, err := f.bot.rest.ChannelMessageSendComplex(f.channelID, &discordgo.MessageSend{
+		Content: event.format(f.bot, hubGuildID),
+		Flags:   discordgo.MessageFlagsSuppressNotifications,
+		// Peers are trusted to report activity, not to ping anyone here
+		AllowedMentions: &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{}},
+	})
+	if err != nil {
+		slog.Error("Error posting federated event", "channel_id", f.channelID, "peer", peer, "event_type", "federation", "error", err)
+		writeJSON(w, http.StatusBadGateway, apiError{Error: "could not post event"})
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validate checks a pushed event before it is posted
+func (event *FederationEvent) validate() error {
+	switch event.Type {
+	case "join", "leave":
+	case "move":
+		if event.FromChannel == "" {
+			return fmt.Errorf("move events need from_channel")
+		}
+	default:
+		return fmt.Errorf("type must be join, leave or move")
+	}
+	if event.User == "" || event.Channel == "" {
+		return fmt.Errorf("user and channel are required")
+	}
+	for _, name := range []string{event.Server, event.User, event.Channel, event.FromChannel} {
+		if len(name) > maxFederationName {
+			return fmt.Errorf("names must be at most %d bytes", maxFederationName)
+		}
+	}
+	return nil
+}
+
+// format renders a federated event for the hub channel
+func (event *FederationEvent) format(b *Bot, guildID string) string {
+	server, user, channel := escapeMarkdown(event.Server), escapeMarkdown(event.User), escapeMarkdown(event.Channel)
+
+	var text string
+	switch event.Type {
+	case "join":
+		text = b.t(guildID, "federation.joined", server, user, channel)
+	case "leave":
+		text = b.t(guildID, "federation.left", server, user, channel)
+	case "move":
+		text = b.t(guildID, "federation.moved", server, user, escapeMarkdown(event.FromChannel), channel)
+	}
+	if event.Count > 0 {
+		text += " " + b.t(guildID, "federation.count", event.Count)
+	}
+	return text
+}
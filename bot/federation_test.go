@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+const testHubChannelID = "100000000000000004"
+
+type (
+	// postingSession records the messages a bot sends. Other calls panic.
+	postingSession struct {
+		DiscordSession
+		mu   sync.Mutex
+		sent map[string][]string // channelID -> contents
+	}
+)
+
+func (s *postingSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sent == nil {
+		s.sent = make(map[string][]string)
+	}
+	s.sent[channelID] = append(s.sent[channelID], data.Content)
+	return &discordgo.Message{ChannelID: channelID, Content: data.Content}, nil
+}
+
+func (s *postingSession) messages(channelID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent[channelID]
+}
+
+func TestFederationReceiver(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		body       string
+		wantStatus int
+		wantPosted string // empty when nothing is posted
+	}{
+		{
+			name:       "move",
+			token:      "s3cret",
+			body:       `{"server": "Gaming Community", "type": "move", "user": "Alice", "channel": "Raid", "from_channel": "Lobby", "count": 1}`,
+			wantStatus: http.StatusAccepted,
+			wantPosted: "🌐 **Gaming Community** · **Alice** moved from **Lobby** to **Raid** (1 here)",
+		},
+		{
+			name:       "server defaults to the peer",
+			token:      "s3cret",
+			body:       `{"type": "join", "user": "Bob_", "channel": "Raid"}`,
+			wantStatus: http.StatusAccepted,
+			wantPosted: "🌐 **gaming** · **Bob\\_** joined **Raid**",
+		},
+		{
+			name:       "unknown token",
+			token:      "wrong",
+			body:       `{"type": "join", "user": "Alice", "channel": "Raid"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "move without from_channel",
+			token:      "s3cret",
+			body:       `{"type": "move", "user": "Alice", "channel": "Raid"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "name too long",
+			token:      "s3cret",
+			body:       `{"type": "join", "user": "` + strings.Repeat("a", maxFederationName+1) + `", "channel": "Raid"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newTestBot(t)
+			hubSession := &postingSession{}
+			hub.UseSession(hubSession)
+			receiver := newFederationReceiver(hub, config.Federation{
+				Peers:   map[string]string{"gaming": "s3cret"},
+				Channel: testHubChannelID,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/federation/events", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			receiver.handleEvent(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			messages := hubSession.messages(testHubChannelID)
+			switch {
+			case tt.wantPosted == "" && len(messages) != 0:
+				t.Errorf("hub posted %q, want nothing", messages)
+			case tt.wantPosted != "" && (len(messages) != 1 || messages[0] != tt.wantPosted):
+				t.Errorf("hub posted %q, want [%q]", messages, tt.wantPosted)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxFollowDuration caps how long a follow lasts
+	maxFollowDuration = 24 * time.Hour
+	// maxFollowsPerUser caps active follows per member and guild
+	maxFollowsPerUser = 10
+	// followRenotifyAfter is the minimum time between two pings for the same follow
+	followRenotifyAfter = 10 * time.Minute
+)
+
+type (
+	// follow pings a member by DM when another user joins voice, until it expires
+	follow struct {
+		GuildId      string    `json:"guild_id"`
+		FollowerId   string    `json:"follower_id"`
+		TargetId     string    `json:"target_id"`
+		Expires      time.Time `json:"expires"`
+		LastNotified time.Time `json:"last_notified,omitzero"`
+	}
+)
+
+// followCommands returns the /follow and /unfollow command definitions
+func followCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name: "follow",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:     discordgo.ApplicationCommandOptionUser,
+					Name:     "user",
+					Required: true,
+				},
+				{
+					Type: discordgo.ApplicationCommandOptionString,
+					Name: "duration",
+				},
+			},
+		},
+		{
+			Name: "unfollow",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:     discordgo.ApplicationCommandOptionUser,
+					Name:     "user",
+					Required: true,
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleFollow(s DiscordSession, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	target := optionUser(i, options["user"])
+	followerID := interactionUserID(i)
+
+	duration := 3 * time.Hour
+	if opt, ok := options["duration"]; ok {
+		parsed, err := time.ParseDuration(opt.StringValue())
+		if err != nil || parsed < time.Minute || parsed > maxFollowDuration {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "follow.invalid-duration"))
+			return
+		}
+		duration = parsed
+	}
+
+	if target.ID == followerID {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "follow.self"))
+		return
+	}
+	if target.Bot {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "follow.bot"))
+		return
+	}
+
+	expires := time.Now().Add(duration)
+
+	b.mu.Lock()
+	b.follows = slices.DeleteFunc(b.follows, func(f follow) bool { return time.Now().After(f.Expires) })
+	count := 0
+	existing := -1
+	for idx, f := range b.follows {
+		if f.GuildId == i.GuildID && f.FollowerId == followerID {
+			count++
+			if f.TargetId == target.ID {
+				existing = idx
+			}
+		}
+	}
+	if existing < 0 && count >= maxFollowsPerUser {
+		b.mu.Unlock()
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "follow.limit", maxFollowsPerUser))
+		return
+	}
+	if existing >= 0 {
+		b.follows[existing].Expires = expires
+	} else {
+		b.follows = append(b.follows, follow{GuildId: i.GuildID, FollowerId: followerID, TargetId: target.ID, Expires: expires})
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "follow.added", target.ID, expires.Unix()))
+}
+
+func (b *Bot) handleUnfollow(s DiscordSession, i *discordgo.InteractionCreate) {
+	target := optionUser(i, i.ApplicationCommandData().Options[0])
+	followerID := interactionUserID(i)
+
+	b.mu.Lock()
+	before := len(b.follows)
+	b.follows = slices.DeleteFunc(b.follows, func(f follow) bool {
+		return f.GuildId == i.GuildID && f.FollowerId == followerID && f.TargetId == target.ID
+	})
+	removed := len(b.follows) < before
+	if removed {
+		b.savePersistedDataAsync()
+	}
+	b.mu.Unlock()
+
+	if !removed {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "follow.not-following", target.ID))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "follow.removed", target.ID))
+}
+
+// notifyFollowers DMs everyone following a user who just joined a voice
+// channel and drops expired follows
+func (b *Bot) notifyFollowers(s DiscordSession, guildID, userID, voiceChannelID string) {
+	now := time.Now()
+
+	b.mu.Lock()
+	before := len(b.follows)
+	b.follows = slices.DeleteFunc(b.follows, func(f follow) bool { return now.After(f.Expires) })
+	changed := len(b.follows) < before
+
+	var followers []string
+	for idx := range b.follows {
+		f := &b.follows[idx]
+		if f.GuildId != guildID || f.TargetId != userID || now.Sub(f.LastNotified) < followRenotifyAfter {
+			continue
+		}
+		f.LastNotified = now
+		followers = append(followers, f.FollowerId)
+		changed = true
+	}
+	if changed {
+		b.savePersistedDataAsync()
+	}
+	b.mu.Unlock()
+
+	if len(followers) == 0 {
+		return
+	}
+
+	content := b.t(guildID, "follow.joined", userID, voiceChannelID, b.getGuildName(s, guildID))
+	for _, followerID := range followers {
+		channel, err := s.UserChannelCreate(followerID)
+		if err == nil {
+			_, err = s.ChannelMessageSend(channel.ID, content)
+		}
+		if err != nil {
+			slog.Warn("Could not DM follower", "guild_id", guildID, "user_id", followerID, "event_type", "follow", "error", err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+// configureGateway sets the gateway intents and state caches, so hosts only
+// receive the data enabled features need
+func configureGateway(dg *discordgo.Session, gateway config.Gateway) {
+	intents := discordgo.IntentsGuilds | discordgo.IntentsGuildVoiceStates
+	if gateway.MemberIntent {
+		// Privileged intent, must also be enabled in the developer portal
+		intents |= discordgo.IntentsGuildMembers
+	}
+	dg.Identify.Intents = intents
+
+	caches := gateway.StateCache
+	state := dg.State
+	tracks := map[string]*bool{
+		"channels":       &state.TrackChannels,
+		"threads":        &state.TrackThreads,
+		"members":        &state.TrackMembers,
+		"roles":          &state.TrackRoles,
+		"voice":          &state.TrackVoice,
+		"emojis":         &state.TrackEmojis,
+		"stickers":       &state.TrackStickers,
+		"presences":      &state.TrackPresences,
+		"thread_members": &state.TrackThreadMembers,
+	}
+	for name, track := range tracks {
+		*track = slices.Contains(caches, name)
+	}
+
+	slog.Info("Gateway configured", "intents", intents, "state_caches", strings.Join(caches, ","))
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	goalPeriodWeek  = "week"
+	goalPeriodMonth = "month"
+)
+
+type (
+	// voiceGoal is a community target for total voice hours per period
+	voiceGoal struct {
+		Hours          int    `json:"hours"`
+		Period         string `json:"period"`                    // week or month
+		ChannelId      string `json:"channel_id"`                // where the celebration is posted
+		AchievedPeriod string `json:"achieved_period,omitempty"` // period key the goal was last reached in
+	}
+)
+
+// goalCommand returns the /goal command definition
+func goalCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "goal",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "set",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:     discordgo.ApplicationCommandOptionInteger,
+						Name:     "hours",
+						Required: true,
+						MinValue: &[]float64{1}[0],
+					},
+					{
+						Type:     discordgo.ApplicationCommandOptionString,
+						Name:     "period",
+						Required: true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Value: goalPeriodWeek},
+							{Value: goalPeriodMonth},
+						},
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "status",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "clear",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleGoal(s DiscordSession, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subcommand.Options)
+
+	switch subcommand.Name {
+	case "set":
+		goal := &voiceGoal{
+			Hours:     int(options["hours"].IntValue()),
+			Period:    options["period"].StringValue(),
+			ChannelId: i.ChannelID,
+		}
+
+		b.mu.Lock()
+		b.goals[i.GuildID] = goal
+		b.mu.Unlock()
+		b.savePersistedDataAsync()
+
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "goal.set."+goal.Period, goal.Hours, b.goalProgress(i.GuildID, *goal, time.Now())))
+	case "status":
+		b.mu.RLock()
+		goal, exists := b.goals[i.GuildID]
+		b.mu.RUnlock()
+
+		if !exists {
+			respondEphemeral(s, i.Interaction, b.t(i.GuildID, "goal.none"))
+			return
+		}
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "goal.status."+goal.Period, goal.Hours, b.goalProgress(i.GuildID, *goal, time.Now())))
+	case "clear":
+		b.mu.Lock()
+		delete(b.goals, i.GuildID)
+		b.mu.Unlock()
+		b.savePersistedDataAsync()
+
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "goal.removed"))
+	}
+}
+
+// goalPeriodBounds returns the start and end of the period containing t and a key identifying it
+func goalPeriodBounds(period string, t time.Time) (time.Time, time.Time, string) {
+	t = t.UTC()
+	if period == goalPeriodWeek {
+		// Weeks start on Monday
+		offset := (int(t.Weekday()) + 6) % 7
+		start := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+		year, week := start.ISOWeek()
+		return start, start.AddDate(0, 0, 7), fmt.Sprintf("%d-W%02d", year, week)
+	}
+
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0), start.Format("2006-01")
+}
+
+// goalProgress renders the progress bar line for a goal
+func (b *Bot) goalProgress(guildID string, goal voiceGoal, now time.Time) string {
+	from, _, _ := goalPeriodBounds(goal.Period, now)
+	hours := b.sessions.totalVoiceTime(guildID, from, now).Hours()
+	return b.t(guildID, "goal.progress", progressBar(hours/float64(goal.Hours), 12), hours, goal.Hours)
+}
+
+// progressBar renders a fraction as a text bar like "▓▓▓▓░░░░ 50%"
+func progressBar(fraction float64, width int) string {
+	if fraction < 0 {
+		fraction = 0
+	}
+	percent := int(fraction * 100)
+	if fraction > 1 {
+		fraction = 1
+	}
+	filled := int(fraction * float64(width))
+	return fmt.Sprintf("%s%s %d%%", strings.Repeat("▓", filled), strings.Repeat("░", width-filled), percent)
+}
+
+// checkGoal posts a celebration the first time a guild's goal is reached in a period
+func (b *Bot) checkGoal(s DiscordSession, guildID string) {
+	now := time.Now()
+
+	b.mu.RLock()
+	goal, exists := b.goals[guildID]
+	var snapshot voiceGoal
+	if exists {
+		snapshot = *goal
+	}
+	b.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	from, _, periodKey := goalPeriodBounds(snapshot.Period, now)
+	if snapshot.AchievedPeriod == periodKey {
+		return
+	}
+	if b.sessions.totalVoiceTime(guildID, from, now).Hours() < float64(snapshot.Hours) {
+		return
+	}
+
+	b.mu.Lock()
+	goal, exists = b.goals[guildID]
+	if !exists || goal.AchievedPeriod == periodKey {
+		b.mu.Unlock()
+		return
+	}
+	goal.AchievedPeriod = periodKey
+	b.mu.Unlock()
+	b.savePersistedDataAsync()
+
+	message := b.t(guildID, "goal.reached."+snapshot.Period, snapshot.Hours)
+	if _, err := s.ChannelMessageSend(snapshot.ChannelId, b.presentText(guildID, message)); err != nil {
+		slog.Error("Error sending goal celebration", "guild_id", guildID, "channel_id", snapshot.ChannelId, "event_type", "goal", "error", err)
+	}
+}
@@ -0,0 +1,229 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	minGroupWindow = 30 * time.Second
+	maxGroupWindow = time.Hour
+
+	// maxGroupedNotifications caps how many lines one grouped message collects
+	maxGroupedNotifications = 100
+)
+
+type (
+	// notificationGroup collects the notifications of one text channel until
+	// its window ends, see holdForGrouping
+	notificationGroup struct {
+		subs  []subscription // one per voice channel, in order of first activity
+		lines map[string][]string
+		timer *time.Timer
+	}
+
+	// notificationGroups holds the open groups, keyed by text channel ID
+	notificationGroups struct {
+		mu     sync.Mutex
+		groups map[string]*notificationGroup
+	}
+)
+
+func newNotificationGroups() *notificationGroups {
+	return &notificationGroups{groups: make(map[string]*notificationGroup)}
+}
+
+// groupWindow returns how long a text channel collects notifications before
+// posting them as one message, 0 if it posts them one by one
+func (b *Bot) groupWindow(guildID, textChannelID string) time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	window, _ := time.ParseDuration(b.groupWindows[guildID][textChannelID])
+	return window
+}
+
+// holdForGrouping adds a notification to its text channel's group if the
+// channel groups notifications, starting the window on the first one
+func (b *Bot) holdForGrouping(s DiscordSession, sub subscription, message string) bool {
+	if sub.isDM() {
+		return false
+	}
+	window := b.groupWindow(sub.GuildId, sub.TextChannelId)
+	if window <= 0 {
+		return false
+	}
+
+	b.notificationGroups.mu.Lock()
+	defer b.notificationGroups.mu.Unlock()
+
+	group, exists := b.notificationGroups.groups[sub.TextChannelId]
+	if !exists {
+		group = &notificationGroup{lines: make(map[string][]string)}
+		b.notificationGroups.groups[sub.TextChannelId] = group
+		group.timer = time.AfterFunc(window, func() {
+			b.flushNotificationGroup(s, sub.TextChannelId)
+		})
+	}
+	if _, seen := group.lines[sub.VoiceChannelId]; !seen {
+		group.subs = append(group.subs, sub)
+	}
+	if group.count() < maxGroupedNotifications {
+		group.lines[sub.VoiceChannelId] = append(group.lines[sub.VoiceChannelId], message)
+	}
+	return true
+}
+
+// count returns the number of collected notifications
+func (group *notificationGroup) count() int {
+	count := 0
+	for _, lines := range group.lines {
+		count += len(lines)
+	}
+	return count
+}
+
+// flushNotificationGroup posts a text channel's collected notifications as one
+// message, grouped by voice channel
+func (b *Bot) flushNotificationGroup(s DiscordSession, textChannelID string) {
+	b.notificationGroups.mu.Lock()
+	group, exists := b.notificationGroups.groups[textChannelID]
+	delete(b.notificationGroups.groups, textChannelID)
+	b.notificationGroups.mu.Unlock()
+
+	if !exists || len(group.subs) == 0 {
+		return
+	}
+
+	var sections []string
+	for _, sub := range group.subs {
+		section := fmt.Sprintf("**🔊 %s**", b.getChannelName(s, sub.VoiceChannelId))
+		for _, line := range group.lines[sub.VoiceChannelId] {
+			section += "\n• " + line
+		}
+		sections = append(sections, section)
+	}
+	content := truncateMessage(b.t(group.subs[0].GuildId, "grouping.header")+"\n"+strings.Join(sections, "\n\n"), maxMessageLength)
+
+	// Webhook name and avatar come from the first subscription of the group
+	sub := group.subs[0]
+	message := &discordgo.MessageSend{Content: b.presentText(sub.GuildId, content)}
+	sent, err := b.deliver(s, sub, message)
+	if err != nil {
+		slog.Error("Error sending grouped notifications", "guild_id", sub.GuildId, "channel_id", textChannelID, "event_type", "grouped", "error", err)
+		b.deliveryFailed(s, sub, message, 1, err)
+		return
+	}
+	b.retries.delivered(sub)
+	if sent == nil {
+		return
+	}
+
+	var forwarded []externalTarget
+	for _, groupSub := range group.subs {
+		b.recordFired(groupSub)
+		// A Slack or Telegram target shared by several voice channels gets the message once
+		groupSub.Externals = slices.DeleteFunc(slices.Clone(groupSub.Externals), func(target externalTarget) bool {
+			return slices.Contains(forwarded, target)
+		})
+		forwarded = append(forwarded, groupSub.Externals...)
+		b.forwardExternal(groupSub, message)
+	}
+}
+
+// drainNotificationGroups posts all open groups while ctx allows
+func (b *Bot) drainNotificationGroups(ctx context.Context) {
+	b.notificationGroups.mu.Lock()
+	var textChannelIDs []string
+	for textChannelID, group := range b.notificationGroups.groups {
+		if group.timer.Stop() {
+			textChannelIDs = append(textChannelIDs, textChannelID)
+		}
+	}
+	b.notificationGroups.mu.Unlock()
+
+	for _, textChannelID := range textChannelIDs {
+		if ctx.Err() != nil {
+			slog.Warn("Dropped grouped notifications on shutdown", "channel_id", textChannelID)
+			continue
+		}
+		b.flushNotificationGroup(b.rest, textChannelID)
+	}
+}
+
+// groupNotificationsCommand returns the /group-notifications command definition
+func groupNotificationsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name: "group-notifications",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:     discordgo.ApplicationCommandOptionString,
+				Name:     "window",
+				Required: true,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleGroupNotifications(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	value := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+	if strings.EqualFold(value, "off") {
+		b.mu.Lock()
+		_, wasSet := b.groupWindows[i.GuildID][i.ChannelID]
+		delete(b.groupWindows[i.GuildID], i.ChannelID)
+		if len(b.groupWindows[i.GuildID]) == 0 {
+			delete(b.groupWindows, i.GuildID)
+		}
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+
+		if !wasSet {
+			respondEphemeral(s, i.Interaction, b.t(i.GuildID, "grouping.already-off"))
+			return
+		}
+		// Whatever was collected so far is posted now
+		b.flushNotificationGroup(s, i.ChannelID)
+		slog.Info("Notification grouping disabled", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", i.ChannelID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "grouping.off"))
+		return
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil || window < minGroupWindow || window > maxGroupWindow {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "grouping.invalid-window"))
+		return
+	}
+	subscribed := len(b.subscriptions.Filter(func(sub subscription) bool {
+		return sub.GuildId == i.GuildID && sub.TextChannelId == i.ChannelID
+	})) > 0
+	b.mu.RLock()
+	subscribed = subscribed || slices.ContainsFunc(b.patternSubscriptions[i.GuildID], func(pattern patternSubscription) bool {
+		return pattern.TextChannelId == i.ChannelID
+	})
+	b.mu.RUnlock()
+	if !subscribed {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.no-subscriptions-here"))
+		return
+	}
+
+	b.mu.Lock()
+	if b.groupWindows[i.GuildID] == nil {
+		b.groupWindows[i.GuildID] = make(map[string]string)
+	}
+	b.groupWindows[i.GuildID][i.ChannelID] = window.String()
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Notification grouping enabled", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", i.ChannelID, "window", window.String())
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "grouping.on", value))
+}
@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// guildCreate sets up a guild when it becomes available, either at startup or
+// when the bot is invited while running
+func (b *Bot) guildCreate(s DiscordSession, g *discordgo.GuildCreate) {
+	b.occupancy.seedGuild(g.Guild)
+	b.voiceChannels.seedGuild(g.Guild)
+	b.seedSessions(g.Guild)
+
+	b.mu.Lock()
+	_, registered := b.registeredCmdIds[g.ID]
+	if !registered {
+		// Claim the guild so a repeated GUILD_CREATE (e.g. after an outage) doesn't register twice
+		b.registeredCmdIds[g.ID] = nil
+	}
+	b.mu.Unlock()
+
+	if !registered {
+		slog.Info("Setting up guild", "guild_id", g.ID, "guild", g.Name)
+		if b.fastStart {
+			go b.registerCommandsDeferred(s, g.ID)
+		} else {
+			b.registerCommands(s, g.ID)
+		}
+		b.importPending(s, g.ID)
+	}
+}
+
+// guildDelete archives and removes a guild's configuration when the bot is
+// kicked or the guild is deleted. Outages (Unavailable) keep everything.
+func (b *Bot) guildDelete(s DiscordSession, g *discordgo.GuildDelete) {
+	if g.Unavailable {
+		slog.Warn("Guild is temporarily unavailable", "guild_id", g.ID)
+		return
+	}
+
+	if err := b.archiveGuild(g.ID); err != nil {
+		slog.Error("Error archiving guild configuration", "guild_id", g.ID, "error", err)
+	}
+
+	removed := b.removeGuild(g.ID)
+	b.eventRates.forget(g.ID)
+	b.voiceChannels.forgetGuild(g.ID)
+	slog.Info("Removed from guild", "guild_id", g.ID, "deleted_subscriptions", removed)
+}
+
+// archiveGuild writes the guild's export to the archive directory if one is
+// set, so it can be restored with IMPORT_FILE if the bot is invited again
+func (b *Bot) archiveGuild(guildID string) error {
+	dir := b.archiveDir
+	if dir == "" {
+		return nil
+	}
+	if _, inMemory := b.persistence.(*MemoryStore); inMemory {
+		slog.Info("Not archiving guild, in-memory storage never writes to disk", "guild_id", guildID)
+		return nil
+	}
+
+	export := b.exportGuild(guildID)
+	if export.isEmpty() {
+		return nil
+	}
+
+	jsonData, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", guildID, time.Now().UTC().Format("20060102-150405")))
+	if err := writeFileAtomic(path, jsonData); err != nil {
+		return err
+	}
+
+	slog.Info("Archived guild configuration", "guild_id", guildID, "path", path)
+	return nil
+}
+
+// removeGuild deletes all subscriptions and settings of a guild and returns
+// the number of removed subscriptions
+func (b *Bot) removeGuild(guildID string) int {
+	removed := b.deleteGuildSettings(guildID)
+
+	b.mu.Lock()
+	// Discord removes the guild's commands together with the bot
+	delete(b.registeredCmdIds, guildID)
+	// A reset keeps opt-outs, leaving the guild doesn't
+	delete(b.optOuts, guildID)
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+	return len(removed)
+}
+
+// deleteGuildSettings deletes all subscriptions and settings of a guild and
+// returns the removed subscriptions
+func (b *Bot) deleteGuildSettings(guildID string) []subscription {
+	removed := b.subscriptions.RemoveGuild(guildID)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.adminChannels, guildID)
+	delete(b.watchlist, guildID)
+	delete(b.templates, guildID)
+	delete(b.goals, guildID)
+	delete(b.ignored, guildID)
+	delete(b.logChannels, guildID)
+	delete(b.fallbackChannels, guildID)
+	delete(b.subscribeAccess, guildID)
+	delete(b.debounceStrategies, guildID)
+	delete(b.plainTextGuilds, guildID)
+	delete(b.minimalGuilds, guildID)
+	delete(b.timestampGuilds, guildID)
+	delete(b.tones, guildID)
+	delete(b.languages, guildID)
+	delete(b.afkSettings, guildID)
+	delete(b.pauses, guildID)
+	delete(b.patternSubscriptions, guildID)
+	delete(b.groupWindows, guildID)
+	delete(b.digests, guildID)
+	b.follows = slices.DeleteFunc(b.follows, func(f follow) bool { return f.GuildId == guildID })
+
+	b.savePersistedDataAsync()
+	return removed
+}
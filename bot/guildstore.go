@@ -0,0 +1,272 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+type (
+	// guildStore is implemented by stores that several instances share. They
+	// write only the guilds that changed, so instances never overwrite each
+	// other's guilds, and report the guilds other instances wrote.
+	guildStore interface {
+		Store
+		// saveGuilds writes the data of each guild, deleting guilds whose data is nil
+		saveGuilds(guilds map[string]*PersistentData) error
+		// loadGuilds reads the data of the given guilds
+		loadGuilds(guildIDs []string) (*PersistentData, error)
+		// watch calls changed with the guilds other instances saved until ctx
+		// is done. nil means any guild may have changed, e.g. after a
+		// reconnect that may have missed changes.
+		watch(ctx context.Context, changed func(guildIDs []string))
+	}
+
+	// storeChange is announced to the other instances after a save. Guilds is
+	// nil when too many changed to list.
+	storeChange struct {
+		Instance string   `json:"instance"`
+		Guilds   []string `json:"guilds"`
+	}
+)
+
+// newInstanceID returns a random ID that tells instances sharing a store apart
+func newInstanceID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// byGuild splits data into the data of each guild
+func (data *PersistentData) byGuild() map[string]*PersistentData {
+	guilds := make(map[string]*PersistentData)
+	guild := func(guildID string) *PersistentData {
+		g, ok := guilds[guildID]
+		if !ok {
+			g = &PersistentData{}
+			g.ensureMaps()
+			guilds[guildID] = g
+		}
+		return g
+	}
+
+	for voiceChannelID, subs := range data.Subscriptions {
+		for _, sub := range subs {
+			g := guild(sub.GuildId)
+			g.Subscriptions[voiceChannelID] = append(g.Subscriptions[voiceChannelID], sub)
+		}
+	}
+	splitGuildMap(data.AdminChannels, func(id string) map[string]string { return guild(id).AdminChannels })
+	splitGuildMap(data.Watchlist, func(id string) map[string][]string { return guild(id).Watchlist })
+	splitGuildMap(data.Templates, func(id string) map[string]map[string]string { return guild(id).Templates })
+	splitGuildMap(data.Goals, func(id string) map[string]*voiceGoal { return guild(id).Goals })
+	splitGuildMap(data.Ignored, func(id string) map[string][]string { return guild(id).Ignored })
+	splitGuildMap(data.OptOuts, func(id string) map[string][]string { return guild(id).OptOuts })
+	splitGuildMap(data.LogChannels, func(id string) map[string]string { return guild(id).LogChannels })
+	splitGuildMap(data.FallbackChannels, func(id string) map[string]string { return guild(id).FallbackChannels })
+	splitGuildMap(data.SubscribeAccess, func(id string) map[string]subscribeAccess { return guild(id).SubscribeAccess })
+	splitGuildMap(data.DebounceStrategies, func(id string) map[string]string { return guild(id).DebounceStrategies })
+	splitGuildMap(data.PlainText, func(id string) map[string]bool { return guild(id).PlainText })
+	splitGuildMap(data.Minimal, func(id string) map[string]bool { return guild(id).Minimal })
+	splitGuildMap(data.Timestamps, func(id string) map[string]bool { return guild(id).Timestamps })
+	splitGuildMap(data.Tones, func(id string) map[string]string { return guild(id).Tones })
+	splitGuildMap(data.Languages, func(id string) map[string]string { return guild(id).Languages })
+	splitGuildMap(data.AFK, func(id string) map[string]afkSetting { return guild(id).AFK })
+	splitGuildMap(data.Pauses, func(id string) map[string]notificationPause { return guild(id).Pauses })
+	splitGuildMap(data.PatternSubscriptions, func(id string) map[string][]patternSubscription { return guild(id).PatternSubscriptions })
+	splitGuildMap(data.GroupWindows, func(id string) map[string]map[string]string { return guild(id).GroupWindows })
+	splitGuildMap(data.Digests, func(id string) map[string]voiceDigest { return guild(id).Digests })
+	for _, f := range data.Follows {
+		g := guild(f.GuildId)
+		g.Follows = append(g.Follows, f)
+	}
+	for _, sent := range data.SentNotifications {
+		g := guild(sent.GuildId)
+		g.SentNotifications = append(g.SentNotifications, sent)
+	}
+	return guilds
+}
+
+func splitGuildMap[V any](values map[string]V, into func(guildID string) map[string]V) {
+	for guildID, value := range values {
+		into(guildID)[guildID] = value
+	}
+}
+
+// encodeGuilds returns the encoded data of each guild, which is compared to
+// find the guilds that changed
+func encodeGuilds(guilds map[string]*PersistentData) (map[string]string, error) {
+	encoded := make(map[string]string, len(guilds))
+	for guildID, guild := range guilds {
+		data, err := json.Marshal(guild)
+		if err != nil {
+			return nil, err
+		}
+		encoded[guildID] = string(data)
+	}
+	return encoded, nil
+}
+
+// rememberSavedGuilds records data as what the store holds. Caller must not
+// hold b.saver.mu.
+func (b *Bot) rememberSavedGuilds(data *PersistentData) error {
+	encoded, err := encodeGuilds(data.byGuild())
+	if err != nil {
+		return err
+	}
+	b.saver.mu.Lock()
+	b.saver.guilds = encoded
+	b.saver.mu.Unlock()
+	return nil
+}
+
+// saveChangedGuilds writes the guilds whose data differs from what was last
+// written or loaded, and deletes the guilds that are gone. Guilds this
+// instance never knew are left alone. Caller must hold b.saver.mu.
+func (b *Bot) saveChangedGuilds(store guildStore, data *PersistentData) error {
+	guilds := data.byGuild()
+	encoded, err := encodeGuilds(guilds)
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[string]*PersistentData)
+	for guildID, guild := range guilds {
+		if b.saver.guilds[guildID] != encoded[guildID] {
+			changed[guildID] = guild
+		}
+	}
+	for guildID := range b.saver.guilds {
+		if _, ok := encoded[guildID]; !ok {
+			changed[guildID] = nil
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if err := store.saveGuilds(changed); err != nil {
+		return err
+	}
+	b.saver.guilds = encoded
+	slog.Debug("Saved changed guilds", "count", len(changed))
+	return nil
+}
+
+// reloadGuilds replaces the in-memory state of guilds another instance
+// changed with what the store now holds. A guild with changes of its own that
+// aren't saved yet keeps them; the next save writes them over the store's.
+func (b *Bot) reloadGuilds(guildIDs []string) {
+	store, ok := b.persistence.(guildStore)
+	if !ok {
+		return
+	}
+
+	// Saves compare against b.saver.guilds, so none may run in between
+	b.saver.mu.Lock()
+	defer b.saver.mu.Unlock()
+
+	var stored *PersistentData
+	var err error
+	if guildIDs == nil {
+		stored, err = store.Load()
+		if err == nil {
+			guildIDs = slices.AppendSeq(slices.Collect(maps.Keys(b.saver.guilds)), maps.Keys(stored.byGuild()))
+		}
+	} else {
+		stored, err = store.loadGuilds(guildIDs)
+	}
+	if err != nil {
+		slog.Error("Error reloading guilds changed by another instance", "error", err)
+		return
+	}
+	storedGuilds := stored.byGuild()
+	encodedStored, err := encodeGuilds(storedGuilds)
+	if err != nil {
+		slog.Error("Error reloading guilds changed by another instance", "error", err)
+		return
+	}
+
+	current, err := b.persistedData()
+	if err != nil {
+		slog.Error("Error reloading guilds changed by another instance", "error", err)
+		return
+	}
+	encodedCurrent, err := encodeGuilds(current.byGuild())
+	if err != nil {
+		slog.Error("Error reloading guilds changed by another instance", "error", err)
+		return
+	}
+
+	reloaded := 0
+	for _, guildID := range guildIDs {
+		if b.shard != nil && !b.shard.owns(guildID) {
+			continue
+		}
+		if encodedCurrent[guildID] != b.saver.guilds[guildID] {
+			slog.Info("Keeping unsaved changes over another instance's", "guild_id", guildID)
+			continue
+		}
+		if encodedStored[guildID] == b.saver.guilds[guildID] {
+			continue
+		}
+
+		b.replaceGuild(guildID, storedGuilds[guildID])
+		if encoded, ok := encodedStored[guildID]; ok {
+			b.saver.guilds[guildID] = encoded
+		} else {
+			delete(b.saver.guilds, guildID)
+		}
+		reloaded++
+	}
+	if reloaded > 0 {
+		slog.Info("Reloaded guilds changed by another instance", "count", reloaded)
+	}
+}
+
+// replaceGuild swaps in a guild's persisted state, removing it when guild is nil
+func (b *Bot) replaceGuild(guildID string, guild *PersistentData) {
+	if guild == nil {
+		guild = &PersistentData{}
+		guild.ensureMaps()
+	}
+	b.subscriptions.ReplaceGuild(guildID, guild.Subscriptions)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replaceGuildValue(b.adminChannels, guild.AdminChannels, guildID)
+	replaceGuildValue(b.watchlist, guild.Watchlist, guildID)
+	replaceGuildValue(b.templates, guild.Templates, guildID)
+	replaceGuildValue(b.goals, guild.Goals, guildID)
+	replaceGuildValue(b.ignored, guild.Ignored, guildID)
+	replaceGuildValue(b.optOuts, guild.OptOuts, guildID)
+	replaceGuildValue(b.logChannels, guild.LogChannels, guildID)
+	replaceGuildValue(b.fallbackChannels, guild.FallbackChannels, guildID)
+	replaceGuildValue(b.subscribeAccess, guild.SubscribeAccess, guildID)
+	replaceGuildValue(b.debounceStrategies, guild.DebounceStrategies, guildID)
+	replaceGuildValue(b.plainTextGuilds, guild.PlainText, guildID)
+	replaceGuildValue(b.minimalGuilds, guild.Minimal, guildID)
+	replaceGuildValue(b.timestampGuilds, guild.Timestamps, guildID)
+	replaceGuildValue(b.tones, guild.Tones, guildID)
+	replaceGuildValue(b.languages, guild.Languages, guildID)
+	replaceGuildValue(b.afkSettings, guild.AFK, guildID)
+	replaceGuildValue(b.pauses, guild.Pauses, guildID)
+	replaceGuildValue(b.patternSubscriptions, guild.PatternSubscriptions, guildID)
+	replaceGuildValue(b.groupWindows, guild.GroupWindows, guildID)
+	replaceGuildValue(b.digests, guild.Digests, guildID)
+	b.follows = append(slices.DeleteFunc(b.follows, func(f follow) bool { return f.GuildId == guildID }), guild.Follows...)
+	b.sentNotifications = append(slices.DeleteFunc(b.sentNotifications, func(sent sentNotification) bool { return sent.GuildId == guildID }), guild.SentNotifications...)
+}
+
+func replaceGuildValue[V any](dst, src map[string]V, guildID string) {
+	if value, ok := src[guildID]; ok {
+		dst[guildID] = value
+	} else {
+		delete(dst, guildID)
+	}
+}
@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+// fakeGuildStore is a guild store in memory that several test bots share
+type fakeGuildStore struct {
+	guilds map[string]string // guildID -> encoded data
+	saves  int
+	mu     sync.Mutex
+}
+
+func newFakeGuildStore() *fakeGuildStore {
+	return &fakeGuildStore{guilds: make(map[string]string)}
+}
+
+func (f *fakeGuildStore) Load() (*PersistentData, error) {
+	f.mu.Lock()
+	var guildIDs []string
+	for guildID := range f.guilds {
+		guildIDs = append(guildIDs, guildID)
+	}
+	f.mu.Unlock()
+	return f.loadGuilds(guildIDs)
+}
+
+func (f *fakeGuildStore) Save(data *PersistentData) error {
+	f.mu.Lock()
+	f.guilds = make(map[string]string)
+	f.mu.Unlock()
+	return f.saveGuilds(data.byGuild())
+}
+
+func (f *fakeGuildStore) saveGuilds(guilds map[string]*PersistentData) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.saves++
+	for guildID, guild := range guilds {
+		if guild == nil {
+			delete(f.guilds, guildID)
+			continue
+		}
+		encoded, err := json.Marshal(guild)
+		if err != nil {
+			return err
+		}
+		f.guilds[guildID] = string(encoded)
+	}
+	return nil
+}
+
+func (f *fakeGuildStore) loadGuilds(guildIDs []string) (*PersistentData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var encoded []string
+	for _, guildID := range guildIDs {
+		if value, ok := f.guilds[guildID]; ok {
+			encoded = append(encoded, value)
+		}
+	}
+	return decodeRedisGuilds(encoded)
+}
+
+func (f *fakeGuildStore) watch(ctx context.Context, changed func(guildIDs []string)) {}
+
+// newSharedTestBot creates a test bot using store and loads its state
+func newSharedTestBot(t *testing.T, store guildStore) *Bot {
+	t.Helper()
+	b := newTestBot(t)
+	b.persistence = store
+	if err := b.loadPersistedData(); err != nil {
+		t.Fatalf("loadPersistedData: %v", err)
+	}
+	return b
+}
+
+func adminChannelOf(b *Bot, guildID string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.adminChannels[guildID]
+}
+
+func flush(t *testing.T, b *Bot) {
+	t.Helper()
+	b.savePersistedDataAsync()
+	if err := b.flushSaves(); err != nil {
+		t.Fatalf("flushSaves: %v", err)
+	}
+}
+
+func TestInstancesKeepEachOthersGuilds(t *testing.T) {
+	store := newFakeGuildStore()
+	first := newSharedTestBot(t, store)
+	second := newSharedTestBot(t, store)
+
+	first.setAdminChannel("guild-a", "admin-a")
+	first.addSubscription("voice-a", "text-a", "guild-a")
+	flush(t, first)
+	second.setAdminChannel("guild-b", "admin-b")
+	flush(t, second)
+
+	data, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if data.AdminChannels["guild-a"] != "admin-a" || data.AdminChannels["guild-b"] != "admin-b" {
+		t.Errorf("stored admin channels %v, want both instances' guilds", data.AdminChannels)
+	}
+	if len(data.Subscriptions["voice-a"]) != 1 {
+		t.Errorf("the second instance's save dropped the first's subscription: %v", data.Subscriptions)
+	}
+
+	// Unchanged guilds aren't written again
+	saves := store.saves
+	flush(t, first)
+	if store.saves != saves {
+		t.Errorf("a save without changes wrote to the store")
+	}
+}
+
+func TestReloadGuilds(t *testing.T) {
+	tests := []struct {
+		name      string
+		unsaved   bool // the reloading instance changed the guild itself
+		wantAdmin string
+		wantSubs  int
+	}{
+		{name: "takes the other instance's change", wantAdmin: "from-first", wantSubs: 1},
+		{name: "keeps unsaved changes", unsaved: true, wantAdmin: "unsaved", wantSubs: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeGuildStore()
+			first := newSharedTestBot(t, store)
+			first.setAdminChannel("guild", "original")
+			flush(t, first)
+			second := newSharedTestBot(t, store)
+
+			first.setAdminChannel("guild", "from-first")
+			first.addSubscription("voice", "text", "guild")
+			flush(t, first)
+			if tt.unsaved {
+				second.setAdminChannel("guild", "unsaved")
+			}
+			second.reloadGuilds([]string{"guild"})
+
+			if got := adminChannelOf(second, "guild"); got != tt.wantAdmin {
+				t.Errorf("admin channel %q, want %q", got, tt.wantAdmin)
+			}
+			if got := len(second.subscriptions.Guild("guild")); got != tt.wantSubs {
+				t.Errorf("%d subscriptions after reloading, want %d", got, tt.wantSubs)
+			}
+		})
+	}
+}
+
+func TestReloadRemovedGuild(t *testing.T) {
+	store := newFakeGuildStore()
+	first := newSharedTestBot(t, store)
+	first.setAdminChannel("guild", "admin")
+	flush(t, first)
+	second := newSharedTestBot(t, store)
+
+	first.removeGuild("guild")
+	flush(t, first)
+	second.reloadGuilds(nil)
+
+	if got := adminChannelOf(second, "guild"); got != "" {
+		t.Errorf("admin channel of a removed guild is still %q", got)
+	}
+	// The removal is already in the store, so there is nothing to write
+	saves := store.saves
+	flush(t, second)
+	if store.saves != saves {
+		t.Errorf("saving after the reload wrote to the store")
+	}
+}
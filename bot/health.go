@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+// maxHeartbeatAge is how old the last heartbeat ack may be before the gateway
+// connection is considered dead (Discord's heartbeat interval is ~41s)
+const maxHeartbeatAge = 2 * time.Minute
+
+type (
+	// HealthStatus is the /healthz response
+	HealthStatus struct {
+		Healthy          bool         `json:"healthy"`
+		GatewayConnected bool         `json:"gateway_connected"`
+		HeartbeatAge     float64      `json:"heartbeat_age_seconds"`
+		PersistenceOK    bool         `json:"persistence_ok"`
+		PersistenceError string       `json:"persistence_error,omitempty"`
+		LastSave         string       `json:"last_save,omitempty"`
+		Shard            *ShardStatus `json:"shard,omitempty"` // only when sharded
+	}
+
+	// ProcessHealth is the /healthz response of a process running several
+	// bots. It is healthy only when every bot is.
+	ProcessHealth struct {
+		Healthy bool                    `json:"healthy"`
+		Bots    map[string]HealthStatus `json:"bots"`
+	}
+)
+
+// recordSave remembers the outcome of the last persistence write
+func (b *Bot) recordSave(err error) {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+
+	b.lastSaveErr = err
+	if err == nil {
+		b.lastSave = time.Now()
+	}
+}
+
+// health reports the gateway connection and persistence state
+func (b *Bot) health() HealthStatus {
+	b.session.RLock()
+	connected := b.session.DataReady
+	lastAck := b.session.LastHeartbeatAck
+	b.session.RUnlock()
+
+	status := HealthStatus{GatewayConnected: connected}
+	if !lastAck.IsZero() {
+		status.HeartbeatAge = time.Since(lastAck).Seconds()
+	}
+
+	b.healthMu.Lock()
+	status.PersistenceOK = b.lastSaveErr == nil
+	if b.lastSaveErr != nil {
+		status.PersistenceError = b.lastSaveErr.Error()
+	}
+	if !b.lastSave.IsZero() {
+		status.LastSave = b.lastSave.UTC().Format(time.RFC3339)
+	}
+	b.healthMu.Unlock()
+
+	if b.shard != nil {
+		shard := b.shardStatus()
+		status.Shard = &shard
+	}
+
+	status.Healthy = status.GatewayConnected && !lastAck.IsZero() &&
+		time.Since(lastAck) < maxHeartbeatAge && status.PersistenceOK
+	return status
+}
+
+// handleHealthz responds 200 when healthy and 503 otherwise
+func (b *Bot) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	status := b.health()
+	code := http.StatusOK
+	if !status.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, status)
+}
+
+// handleProcessHealthz responds 200 when every bot is healthy and 503
+// otherwise. A single bot's status is served as is.
+func handleProcessHealthz(bots []*Bot) http.HandlerFunc {
+	if len(bots) == 1 {
+		return bots[0].handleHealthz
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := ProcessHealth{Healthy: true, Bots: make(map[string]HealthStatus, len(bots))}
+		for _, b := range bots {
+			botStatus := b.health()
+			status.Bots[b.name] = botStatus
+			status.Healthy = status.Healthy && botStatus.Healthy
+		}
+		code := http.StatusOK
+		if !status.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, status)
+	}
+}
+
+// CheckHealth queries the local /healthz endpoint for container health checks
+// and returns an error if the bot is unhealthy
+func CheckHealth(cfg config.Health) error {
+	if cfg.Port == 0 {
+		return errors.New("the health port is not set")
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/healthz", cfg.Port))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unhealthy: %s", resp.Status)
+	}
+	return nil
+}
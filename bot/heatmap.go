@@ -0,0 +1,248 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// defaultHeatmapWeeks is how much history /activity-heatmap covers by default
+	defaultHeatmapWeeks = 4
+
+	// heatmapCellSize is the size of one hour in the PNG, in pixels
+	heatmapCellSize = 24
+)
+
+// heatmapShades are the table characters from idle to busiest
+var heatmapShades = []rune{'·', '░', '▒', '▓', '█'}
+
+// heatmapDays are the table rows, Monday first
+var heatmapDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+type (
+	// activityHeatmap holds the average number of members in a voice channel
+	// per weekday and hour, indexed [time.Weekday][hour]
+	activityHeatmap [7][24]float64
+)
+
+// heatmapCommand returns the /activity-heatmap command definition
+func heatmapCommand() *discordgo.ApplicationCommand {
+	minWeeks := float64(1)
+	return &discordgo.ApplicationCommand{
+		Name:                     "activity-heatmap",
+		DefaultMemberPermissions: &manageEventsPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type:     discordgo.ApplicationCommandOptionInteger,
+				Name:     "weeks",
+				MinValue: &minWeeks,
+				MaxValue: 52,
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionString,
+				Name: "timezone",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "image",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleActivityHeatmap(s DiscordSession, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	weeks := defaultHeatmapWeeks
+	if opt, ok := options["weeks"]; ok {
+		weeks = int(opt.IntValue())
+	}
+
+	loc := time.UTC
+	if opt, ok := options["timezone"]; ok {
+		var err error
+		if loc, err = time.LoadLocation(opt.StringValue()); err != nil {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "heatmap.unknown-timezone", opt.StringValue()))
+			return
+		}
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -7*weeks)
+	heatmap, busiest := b.activityHeatmap(i.GuildID, voiceChannelID, from, now, loc)
+	if busiest == 0 {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "heatmap.empty", channelName, weeks))
+		return
+	}
+
+	day, hour := heatmap.peak()
+	embed := &discordgo.MessageEmbed{
+		Title:       b.t(i.GuildID, "heatmap.title", channelName),
+		Description: "```\n" + heatmap.table(busiest, func(day time.Weekday) string { return b.t(i.GuildID, fmt.Sprintf("weekday.short.%d", day)) }) + "```",
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  b.t(i.GuildID, "heatmap.busiest-title"),
+				Value: b.t(i.GuildID, "heatmap.busiest", b.t(i.GuildID, fmt.Sprintf("weekday.%d", day)), hour, (hour+1)%24, busiest),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: b.t(i.GuildID, "heatmap.footer", weeks, loc, heatmapShades[0], heatmapShades[len(heatmapShades)-1]),
+		},
+	}
+
+	var files []*discordgo.File
+	if opt, ok := options["image"]; ok && opt.BoolValue() {
+		pngData, err := heatmap.png(busiest)
+		if err != nil {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "heatmap.image-failed"))
+			return
+		}
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://heatmap.png"}
+		files = append(files, &discordgo.File{Name: "heatmap.png", ContentType: "image/png", Reader: bytes.NewReader(pngData)})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{b.presentEmbed(i.GuildID, embed)},
+			Files:  files,
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// activityHeatmap averages how many members were in a voice channel in each
+// hour of the week during [from, to), in loc. It also returns the busiest
+// hour's average.
+func (b *Bot) activityHeatmap(guildID, voiceChannelID string, from, to time.Time, loc *time.Location) (heatmap activityHeatmap, busiest float64) {
+	weeks := to.Sub(from).Hours() / (24 * 7)
+	for _, session := range b.sessions.sessions(guildID, from, to) {
+		if session.ChannelId != voiceChannelID {
+			continue
+		}
+
+		start, end := session.Start, session.End
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		// Split the session at hour boundaries of loc, which aren't always
+		// whole hours of UTC
+		for start.Before(end) {
+			local := start.In(loc)
+			next := time.Date(local.Year(), local.Month(), local.Day(), local.Hour()+1, 0, 0, 0, loc)
+			if next.After(end) {
+				next = end
+			}
+			heatmap[local.Weekday()][local.Hour()] += next.Sub(start).Hours() / weeks
+			start = next
+		}
+	}
+
+	for day := range heatmap {
+		for hour := range heatmap[day] {
+			busiest = max(busiest, heatmap[day][hour])
+		}
+	}
+	return heatmap, busiest
+}
+
+// peak returns the busiest weekday and hour, the earliest in the week on ties
+func (heatmap *activityHeatmap) peak() (time.Weekday, int) {
+	peakDay, peakHour := heatmapDays[0], 0
+	for _, day := range heatmapDays {
+		for hour := range 24 {
+			if heatmap[day][hour] > heatmap[peakDay][peakHour] {
+				peakDay, peakHour = day, hour
+			}
+		}
+	}
+	return peakDay, peakHour
+}
+
+// level returns how busy an hour is relative to the busiest one, from 0 (idle) to 1
+func (heatmap *activityHeatmap) level(day time.Weekday, hour int, busiest float64) float64 {
+	if busiest <= 0 {
+		return 0
+	}
+	return heatmap[day][hour] / busiest
+}
+
+// table renders the heatmap as one row of shades per weekday, labelled with
+// dayName
+func (heatmap *activityHeatmap) table(busiest float64, dayName func(time.Weekday) string) string {
+	var sb strings.Builder
+	sb.WriteString("    ")
+	for hour := 0; hour < 24; hour += 6 {
+		fmt.Fprintf(&sb, "%-6s", fmt.Sprintf("%02d", hour))
+	}
+	sb.WriteString("\n")
+
+	for _, day := range heatmapDays {
+		fmt.Fprintf(&sb, "%-3s ", dayName(day))
+		for hour := range 24 {
+			sb.WriteRune(heatmapShade(heatmap.level(day, hour, busiest)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// heatmapShade returns the table character of a level. Any activity at all
+// gets at least the lightest shade.
+func heatmapShade(level float64) rune {
+	if level <= 0 {
+		return heatmapShades[0]
+	}
+	idx := 1 + int(level*float64(len(heatmapShades)-1)-0.001)
+	return heatmapShades[min(max(idx, 1), len(heatmapShades)-1)]
+}
+
+// png renders the heatmap as an image with a row per weekday, Monday on top,
+// and a column per hour
+func (heatmap *activityHeatmap) png(busiest float64) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 24*heatmapCellSize, len(heatmapDays)*heatmapCellSize))
+	idle := color.RGBA{0x2B, 0x2D, 0x31, 0xFF}
+	busy := color.RGBA{0x58, 0x65, 0xF2, 0xFF}
+
+	for row, day := range heatmapDays {
+		for hour := range 24 {
+			cell := blend(idle, busy, heatmap.level(day, hour, busiest))
+			for y := row * heatmapCellSize; y < (row+1)*heatmapCellSize-1; y++ {
+				for x := hour * heatmapCellSize; x < (hour+1)*heatmapCellSize-1; x++ {
+					img.SetRGBA(x, y, cell)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blend mixes two colors, weight 0 being from and 1 being to
+func blend(from, to color.RGBA, weight float64) color.RGBA {
+	mix := func(a, c uint8) uint8 { return uint8(float64(a) + (float64(c)-float64(a))*weight) }
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xFF}
+}
@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// helpTopic groups related commands on a page of /help. Its label is
+	// the catalog's "help.topic.<key>".
+	helpTopic struct {
+		key      string
+		emoji    string
+		commands []string
+	}
+)
+
+// helpTopics lists the /help pages in menu order, after the overview. Commands missing here are
+// shown under "Other", so new commands always appear somewhere.
+var helpTopics = []helpTopic{
+	{"subscriptions", "🔔", []string{"help", "subscribe", "unsubscribe", "unsubscribe-all", "subscribe-pattern", "unsubscribe-pattern", "subscribe-dm", "unsubscribe-dm", "subscribe-external", "follow", "unfollow"}},
+	{"notifications", "⚙️", []string{"subscription-settings", "session-events", "quiet-hours", "min-users", "mentions", "auto-delete", "group-notifications", "status-board", "template", "preview-formats"}},
+	{"activity", "📊", []string{"who-is-in", "voice-leaderboard", "activity-heatmap", "attendance", "digest", "goal", "log-channel"}},
+	{"privacy", "🔒", []string{"announce", "privacy", "ignore-user", "unignore-user"}},
+	{"admin", "🛠️", []string{"set-admin-channel", "config", "list-subscriptions", "subscription-health", "pause-notifications", "resume-notifications", "event-mode", "watch", "unwatch", "watchlist", "debounce-stats", "status", "refresh-commands", "export-subscriptions", "import-subscriptions", "reset-bot"}},
+}
+
+// helpCommand returns the /help command definition
+func helpCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name: "help",
+	}
+}
+
+// handleHelp shows the /help overview
+func (b *Bot) handleHelp(s DiscordSession, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{b.helpOverviewEmbed(s, i.GuildID, i.ChannelID)},
+			Components: b.helpComponents(i.GuildID, ""),
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleHelpTopicSelect switches the /help message to a topic or back to the
+// overview
+func (b *Bot) handleHelpTopicSelect(s DiscordSession, i *discordgo.InteractionCreate) {
+	values := i.MessageComponentData().Values
+	key := ""
+	if len(values) > 0 {
+		key = values[0]
+	}
+
+	embed := b.helpTopicEmbed(i.GuildID, key)
+	if embed == nil {
+		embed = b.helpOverviewEmbed(s, i.GuildID, i.ChannelID)
+		key = ""
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: b.helpComponents(i.GuildID, key),
+		},
+	})
+}
+
+// handleHelpButton starts the subscribe or unsubscribe flow from /help, as if
+// the command was run without arguments
+func (b *Bot) handleHelpButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	switch i.MessageComponentData().CustomID {
+	case "help_subscribe":
+		b.respondSubscribeDialog(s, i, discordgo.InteractionResponseChannelMessageWithSource, 0)
+	case "help_unsubscribe":
+		b.handleUnsubscribeWithoutChannel(s, i, i.ChannelID, i.GuildID)
+	}
+}
+
+// helpOverviewEmbed describes the bot and the guild's current configuration
+func (b *Bot) helpOverviewEmbed(s DiscordSession, guildID, channelID string) *discordgo.MessageEmbed {
+	b.mu.RLock()
+	adminChannelID := b.adminChannels[guildID]
+	b.mu.RUnlock()
+
+	adminChannel := b.t(guildID, "help.admin-channel.unset")
+	if adminChannelID != "" {
+		adminChannel = fmt.Sprintf("<#%s>", adminChannelID)
+	}
+
+	debounce := b.t(guildID, "help.debounce.off")
+	if b.debounceInterval > 0 {
+		debounce = fmt.Sprintf("%s, %s", b.debounceInterval, b.debounceStrategyName(guildID))
+	}
+
+	here := len(b.subscriptions.Filter(func(sub subscription) bool {
+		return sub.GuildId == guildID && sub.TextChannelId == channelID
+	}))
+	total := len(b.subscriptions.Guild(guildID))
+
+	events := make([]string, 0, len(eventInfos))
+	for _, info := range eventInfos {
+		events = append(events, fmt.Sprintf("%s **%s**: %s", info.emoji, b.t(guildID, "event."+info.name), b.t(guildID, "event."+info.name+".description")))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       b.t(guildID, "help.title"),
+		Description: b.t(guildID, "help.intro"),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: b.t(guildID, "help.field.admin-channel"), Value: adminChannel, Inline: true},
+			{Name: b.t(guildID, "help.field.debounce"), Value: debounce, Inline: true},
+			{Name: b.t(guildID, "help.field.subscriptions"), Value: b.t(guildID, "help.subscriptions", here, total), Inline: true},
+			{Name: b.t(guildID, "help.field.default-events"), Value: b.eventLabels(guildID, defaultEvents)},
+			{Name: b.t(guildID, "help.field.event-types"), Value: truncateMessage(strings.Join(events, "\n"), 1024) + "\n" + b.t(guildID, "help.event-types.note")},
+		},
+	}
+}
+
+// helpTopicEmbed lists a topic's commands with their descriptions and
+// subcommands. Returns nil for unknown topics.
+func (b *Bot) helpTopicEmbed(guildID, key string) *discordgo.MessageEmbed {
+	definitions := commandDefinitions()
+	var topic helpTopic
+	switch key {
+	case "other":
+		topic = helpTopic{key: "other", emoji: "📦"}
+		for _, cmd := range definitions {
+			if !slices.ContainsFunc(helpTopics, func(t helpTopic) bool { return slices.Contains(t.commands, cmd.Name) }) {
+				topic.commands = append(topic.commands, cmd.Name)
+			}
+		}
+	default:
+		index := slices.IndexFunc(helpTopics, func(t helpTopic) bool { return t.key == key })
+		if index < 0 {
+			return nil
+		}
+		topic = helpTopics[index]
+	}
+
+	lines := make([]string, 0, len(topic.commands))
+	for _, cmd := range definitions {
+		if !slices.Contains(topic.commands, cmd.Name) {
+			continue
+		}
+		line := fmt.Sprintf("`/%s` %s", cmd.Name, b.t(guildID, "command."+cmd.Name))
+		var subcommands []string
+		for _, opt := range cmd.Options {
+			if opt.Type == discordgo.ApplicationCommandOptionSubCommand {
+				subcommands = append(subcommands, opt.Name)
+			}
+		}
+		if len(subcommands) > 0 {
+			line += fmt.Sprintf("\n-# %s", strings.Join(subcommands, ", "))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, b.t(guildID, "help.no-commands"))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", topic.emoji, b.t(guildID, "help.topic."+topic.key)),
+		Description: truncateMessage(strings.Join(lines, "\n"), 4096),
+		Color:       0x5865F2,
+	}
+}
+
+// helpComponents returns the topic menu, with the current topic preselected,
+// and the subscribe and unsubscribe buttons
+func (b *Bot) helpComponents(guildID, current string) []discordgo.MessageComponent {
+	options := []discordgo.SelectMenuOption{
+		{Label: b.t(guildID, "help.topic.overview"), Value: "overview", Emoji: &discordgo.ComponentEmoji{Name: "❓"}, Default: current == ""},
+	}
+	for _, topic := range helpTopics {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:   b.t(guildID, "help.topic."+topic.key),
+			Value:   topic.key,
+			Emoji:   &discordgo.ComponentEmoji{Name: topic.emoji},
+			Default: current == topic.key,
+		})
+	}
+	if helpHasOtherCommands() {
+		options = append(options, discordgo.SelectMenuOption{Label: b.t(guildID, "help.topic.other"), Value: "other", Emoji: &discordgo.ComponentEmoji{Name: "📦"}, Default: current == "other"})
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: "help_topic", Placeholder: b.t(guildID, "help.placeholder"), Options: options},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: b.t(guildID, "help.button.subscribe"), Style: discordgo.PrimaryButton, CustomID: "help_subscribe", Emoji: &discordgo.ComponentEmoji{Name: "🔔"}},
+				discordgo.Button{Label: b.t(guildID, "help.button.unsubscribe"), Style: discordgo.SecondaryButton, CustomID: "help_unsubscribe", Emoji: &discordgo.ComponentEmoji{Name: "🔕"}},
+			},
+		},
+	}
+}
+
+// helpHasOtherCommands reports whether some command is in no help topic
+func helpHasOtherCommands() bool {
+	for _, cmd := range commandDefinitions() {
+		if !slices.ContainsFunc(helpTopics, func(t helpTopic) bool { return slices.Contains(t.commands, cmd.Name) }) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,318 @@
+package bot
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultLocale is the language of all built-in strings
+const defaultLocale = "en"
+
+// supportedLocales are the languages /config language offers, in menu order
+var supportedLocales = []string{"en", "de", "fr", "es", "pt-BR"}
+
+// discordLocales maps our locales to the Discord client locales used for
+// command descriptions
+var discordLocales = map[string][]discordgo.Locale{
+	"de":    {discordgo.German},
+	"fr":    {discordgo.French},
+	"es":    {discordgo.SpanishES, discordgo.SpanishLATAM},
+	"pt-BR": {discordgo.PortugueseBR},
+}
+
+//go:embed locales/*.json
+var localeBundles embed.FS
+
+type (
+	// messageCatalog looks up translated strings. Strings with fmt verbs are
+	// formatted by the caller; notification strings are Go templates.
+	messageCatalog interface {
+		message(locale, key string) (string, bool)
+	}
+
+	// bundleCatalog is a catalog loaded from JSON files, one flat object of
+	// key -> text per locale
+	bundleCatalog map[string]map[string]string
+
+	// layeredCatalog asks its catalogs in order, so later layers can override
+	// single strings of earlier ones
+	layeredCatalog []messageCatalog
+
+	// userError is an error shown to Discord users, translated with the
+	// catalog's key. Error returns the built-in English text, for logs and
+	// the HTTP API.
+	userError struct {
+		key  string
+		args []any
+	}
+)
+
+// builtinCatalog returns the embedded bundles
+var builtinCatalog = sync.OnceValue(func() bundleCatalog {
+	entries, _ := localeBundles.ReadDir("locales")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, "locales/"+entry.Name())
+	}
+	builtin, err := loadBundles(localeBundles.ReadFile, names)
+	if err != nil {
+		// The bundles are embedded, so this is a build problem
+		panic(err)
+	}
+	return builtin
+})
+
+// newUserError returns an error with the message of a catalog key
+func newUserError(key string, args ...any) error {
+	return &userError{key: key, args: args}
+}
+
+func (err *userError) Error() string {
+	text, ok := builtinCatalog().message(defaultLocale, err.key)
+	if !ok {
+		return err.key
+	}
+	return fmt.Sprintf(text, err.args...)
+}
+
+func (c bundleCatalog) message(locale, key string) (string, bool) {
+	text, ok := c[locale][key]
+	return text, ok
+}
+
+func (c layeredCatalog) message(locale, key string) (string, bool) {
+	for idx := len(c) - 1; idx >= 0; idx-- {
+		if text, ok := c[idx].message(locale, key); ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// loadBundles reads <locale>.json files from a directory
+func loadBundles(read func(name string) ([]byte, error), names []string) (bundleCatalog, error) {
+	catalog := make(bundleCatalog)
+	for _, name := range names {
+		if filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := read(name)
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(name), err)
+		}
+		catalog[strings.TrimSuffix(filepath.Base(name), ".json")] = messages
+	}
+	return catalog, nil
+}
+
+// loadCatalog returns the built-in bundles, overridden by the JSON files in
+// dir if it is set
+func loadCatalog(dir string) messageCatalog {
+	catalog := layeredCatalog{builtinCatalog()}
+
+	if dir == "" {
+		return catalog
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err == nil {
+		var custom bundleCatalog
+		if custom, err = loadBundles(os.ReadFile, files); err == nil {
+			slog.Info("Loaded message catalogs", "dir", dir, "locales", len(custom))
+			return append(catalog, custom)
+		}
+	}
+	slog.Warn("Invalid locales directory, using built-in messages", "dir", dir, "error", err)
+	return catalog
+}
+
+// guildLanguage returns the locale of a guild's built-in messages
+func (b *Bot) guildLanguage(guildID string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if locale, ok := b.languages[guildID]; ok {
+		return locale
+	}
+	return defaultLocale
+}
+
+// lookup returns a string of a locale, falling back to English
+func (b *Bot) lookup(locale, key string) (string, bool) {
+	if text, ok := b.catalog.message(locale, key); ok {
+		return text, true
+	}
+	return b.catalog.message(defaultLocale, key)
+}
+
+// t translates a message into the guild's language and formats it with args
+func (b *Bot) t(guildID, key string, args ...any) string {
+	return b.translate(b.guildLanguage(guildID), key, args...)
+}
+
+// translate translates a message into a locale and formats it with args
+func (b *Bot) translate(locale, key string, args ...any) string {
+	text, ok := b.lookup(locale, key)
+	if !ok {
+		slog.Warn("Missing message", "key", key)
+		return key
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// errorText returns the message of an error in the guild's language
+func (b *Bot) errorText(guildID string, err error) string {
+	var userErr *userError
+	if errors.As(err, &userErr) {
+		return b.t(guildID, userErr.key, userErr.args...)
+	}
+	var limitErr *subscriptionLimitError
+	if errors.As(err, &limitErr) {
+		if limitErr.voiceChannel {
+			return b.t(guildID, "limits.voice-channel", limitErr.limit)
+		}
+		return b.t(guildID, "limits.guild", limitErr.limit)
+	}
+	return err.Error()
+}
+
+// brokenReason returns why a subscription is paused in the guild's language.
+// Reasons are stored in English, so unknown ones are shown as they are.
+func (b *Bot) brokenReason(guildID, reason string) string {
+	switch reason {
+	case brokenDMsClosed:
+		return b.t(guildID, "broken.dms-closed")
+	case brokenMissingPermissions:
+		return b.t(guildID, "broken.missing-permissions")
+	case brokenDeliveryFailures:
+		return b.t(guildID, "broken.delivery-failures")
+	}
+	return reason
+}
+
+// onOff returns "on" or "off" in the guild's language
+func (b *Bot) onOff(guildID string, on bool) string {
+	if on {
+		return b.t(guildID, "common.on")
+	}
+	return b.t(guildID, "common.off")
+}
+
+// languageName returns the name of a locale in that language, e.g. "Deutsch"
+func (b *Bot) languageName(locale string) string {
+	if name, ok := b.catalog.message(locale, "language.name"); ok {
+		return name
+	}
+	return locale
+}
+
+// localizeCommands fills in the descriptions of commands and options and the
+// names of choices from the catalog, in English and translated. They are keyed
+// "command.<command>[.<subcommand>][.<option>]", choices
+// "<option key>.choice.<value>".
+func (b *Bot) localizeCommands(commands []*discordgo.ApplicationCommand) []*discordgo.ApplicationCommand {
+	for _, cmd := range commands {
+		key := "command." + cmd.Name
+		cmd.Description, cmd.DescriptionLocalizations = b.commandText(key)
+		b.localizeOptions(key, cmd.Options)
+	}
+	return commands
+}
+
+func (b *Bot) localizeOptions(prefix string, options []*discordgo.ApplicationCommandOption) {
+	for _, opt := range options {
+		key := prefix + "." + opt.Name
+		var localizations *map[discordgo.Locale]string
+		opt.Description, localizations = b.commandText(key)
+		if localizations != nil {
+			opt.DescriptionLocalizations = *localizations
+		}
+		for _, choice := range opt.Choices {
+			choice.Name, localizations = b.commandText(fmt.Sprintf("%s.choice.%v", key, choice.Value))
+			if localizations != nil {
+				choice.NameLocalizations = *localizations
+			}
+		}
+		b.localizeOptions(key, opt.Options)
+	}
+}
+
+// commandText returns the English text of a catalog key and its Discord
+// localizations, or nil if no locale translates it. Discord allows at most
+// 100 characters.
+func (b *Bot) commandText(key string) (string, *map[discordgo.Locale]string) {
+	text, ok := b.catalog.message(defaultLocale, key)
+	if !ok {
+		slog.Warn("Missing message", "key", key)
+		text = key
+	}
+
+	localizations := make(map[discordgo.Locale]string)
+	for locale, discordLocales := range discordLocales {
+		text, ok := b.catalog.message(locale, key)
+		if !ok {
+			continue
+		}
+		for _, discordLocale := range discordLocales {
+			localizations[discordLocale] = truncateMessage(text, 100)
+		}
+	}
+	if len(localizations) == 0 {
+		return truncateMessage(text, 100), nil
+	}
+	return truncateMessage(text, 100), &localizations
+}
+
+// handleConfigLanguage sets the language of the guild's built-in messages
+func (b *Bot) handleConfigLanguage(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	opt, ok := options["locale"]
+	if !ok {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "language.current", b.languageName(b.guildLanguage(i.GuildID))))
+		return
+	}
+
+	locale := opt.StringValue()
+	if !slices.Contains(supportedLocales, locale) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "language.unknown", locale))
+		return
+	}
+
+	b.mu.Lock()
+	if locale == defaultLocale {
+		delete(b.languages, i.GuildID)
+	} else {
+		b.languages[i.GuildID] = locale
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Language changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "locale", locale)
+
+	response := b.t(i.GuildID, "language.changed", b.languageName(locale))
+	if b.hasTemplates(i.GuildID) {
+		response += "\n" + b.t(i.GuildID, "language.templates")
+	}
+	respondEphemeral(s, i.Interaction, response)
+}
+
+// languageChoices returns the choices of /config language
+func languageChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(supportedLocales))
+	for _, locale := range supportedLocales {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Value: locale})
+	}
+	return choices
+}
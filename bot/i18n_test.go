@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// fmtVerb matches fmt verbs, with an optional explicit argument index
+	fmtVerb = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0]*\d*(?:\.\d+)?([a-zA-Z%])`)
+
+	// templateAction matches the fields and functions of template actions
+	templateAction = regexp.MustCompile(`{{[^}]*}}`)
+)
+
+// verbsOf returns the verb of each argument of a format string
+func verbsOf(text string) map[int]byte {
+	verbs := make(map[int]byte)
+	arg := 0
+	for _, match := range fmtVerb.FindAllStringSubmatch(text, -1) {
+		if match[2] == "%" {
+			continue
+		}
+		if match[1] != "" {
+			fmt.Sscan(match[1], &arg)
+			arg--
+		}
+		verbs[arg] = match[2][0]
+		arg++
+	}
+	return verbs
+}
+
+// TestLocalesComplete checks that every built-in locale translates exactly
+// the English keys, with the same arguments and template actions
+func TestLocalesComplete(t *testing.T) {
+	catalog := builtinCatalog()
+	english := catalog[defaultLocale]
+	for _, locale := range supportedLocales {
+		messages, ok := catalog[locale]
+		if !ok {
+			t.Errorf("no bundle for %s", locale)
+			continue
+		}
+		for key, text := range english {
+			translated, ok := messages[key]
+			if !ok {
+				t.Errorf("%s: missing %s", locale, key)
+				continue
+			}
+			if want, got := verbsOf(text), verbsOf(translated); !maps.Equal(want, got) {
+				t.Errorf("%s: %s has verbs %v, want %v", locale, key, got, want)
+			}
+			want := templateAction.FindAllString(text, -1)
+			got := templateAction.FindAllString(translated, -1)
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(want, got) {
+				t.Errorf("%s: %s has template actions %v, want %v", locale, key, got, want)
+			}
+		}
+		for key := range messages {
+			if _, ok := english[key]; !ok {
+				t.Errorf("%s: unknown key %s", locale, key)
+			}
+		}
+	}
+}
+
+// TestCommandsLocalized checks that every command, option and choice has a
+// description in every locale that fits Discord's limit
+func TestCommandsLocalized(t *testing.T) {
+	catalog := builtinCatalog()
+
+	var check func(key string)
+	check = func(key string) {
+		for _, locale := range supportedLocales {
+			text, ok := catalog[locale][key]
+			if !ok {
+				t.Errorf("%s: missing %s", locale, key)
+				continue
+			}
+			if utf8.RuneCountInString(text) > 100 {
+				t.Errorf("%s: %s is longer than 100 characters", locale, key)
+			}
+		}
+	}
+	var checkOptions func(prefix string, options []*discordgo.ApplicationCommandOption)
+	checkOptions = func(prefix string, options []*discordgo.ApplicationCommandOption) {
+		for _, opt := range options {
+			key := prefix + "." + opt.Name
+			check(key)
+			for _, choice := range opt.Choices {
+				check(fmt.Sprintf("%s.choice.%v", key, choice.Value))
+			}
+			checkOptions(key, opt.Options)
+		}
+	}
+	for _, cmd := range commandDefinitions() {
+		key := "command." + cmd.Name
+		check(key)
+		checkOptions(key, cmd.Options)
+	}
+}
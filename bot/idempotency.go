@@ -0,0 +1,27 @@
+package bot
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// interactionReplayWindow is how long interaction IDs are remembered. Discord
+// only accepts responses for 15 minutes, so older redeliveries can't be answered.
+const interactionReplayWindow = 15 * time.Minute
+
+// firstDelivery reports whether an interaction is seen for the first time.
+// Discord occasionally redelivers interactions; handling one twice would
+// subscribe or unsubscribe twice. With a shared store the ID is claimed
+// across instances too.
+func (b *Bot) firstDelivery(i *discordgo.InteractionCreate) bool {
+	if i.ID == "" {
+		return true
+	}
+	if !b.seenInteractions.allow("interaction", i.ID) || !b.claim("interaction:"+i.ID, interactionReplayWindow) {
+		slog.Warn("Ignoring redelivered interaction", "guild_id", i.GuildID, "channel_id", i.ChannelID, "user_id", interactionUserID(i), "event_type", "interaction", "interaction_id", i.ID)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ignoreCommands returns the /ignore-user, /unignore-user, and /announce command definitions
+func ignoreCommands() []*discordgo.ApplicationCommand {
+	userOption := []*discordgo.ApplicationCommandOption{
+		{
+			Type:     discordgo.ApplicationCommandOptionUser,
+			Name:     "user",
+			Required: true,
+		},
+	}
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "ignore-user",
+			DefaultMemberPermissions: &manageServerPermission,
+			Options:                  userOption,
+		},
+		{
+			Name:                     "unignore-user",
+			DefaultMemberPermissions: &manageServerPermission,
+			Options:                  userOption,
+		},
+		{
+			Name: "announce",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:     discordgo.ApplicationCommandOptionString,
+					Name:     "state",
+					Required: true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Value: "on"},
+						{Value: "off"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleIgnoreUser adds or removes a user from the guild's ignore list
+func (b *Bot) handleIgnoreUser(s DiscordSession, i *discordgo.InteractionCreate, ignore bool) {
+	user := optionUser(i, i.ApplicationCommandData().Options[0])
+
+	if ignore {
+		if !b.ignoreUser(i.GuildID, user.ID) {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "ignore.already", user.ID))
+			return
+		}
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "ignore.added", user.ID))
+		return
+	}
+
+	if !b.unignoreUser(i.GuildID, user.ID) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "ignore.not-ignored", user.ID))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "ignore.removed", user.ID))
+}
+
+// handleAnnounce lets users opt themselves out of (or back into) announcements
+func (b *Bot) handleAnnounce(s DiscordSession, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	state := i.ApplicationCommandData().Options[0].StringValue()
+
+	if state == "off" {
+		b.ignoreUser(i.GuildID, userID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "ignore.self-added"))
+		return
+	}
+
+	b.unignoreUser(i.GuildID, userID)
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "ignore.self-removed"))
+}
+
+// ignoreUser adds a user to the guild's ignore list and returns whether they were added
+func (b *Bot) ignoreUser(guildID, userID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if slices.Contains(b.ignored[guildID], userID) {
+		return false
+	}
+	b.ignored[guildID] = append(b.ignored[guildID], userID)
+
+	b.savePersistedDataAsync()
+	return true
+}
+
+// unignoreUser removes a user from the guild's ignore list and returns whether they were ignored
+func (b *Bot) unignoreUser(guildID, userID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	idx := slices.Index(b.ignored[guildID], userID)
+	if idx < 0 {
+		return false
+	}
+
+	b.ignored[guildID] = slices.Delete(b.ignored[guildID], idx, idx+1)
+	if len(b.ignored[guildID]) == 0 {
+		delete(b.ignored, guildID)
+	}
+
+	b.savePersistedDataAsync()
+	return true
+}
+
+// isIgnored reports whether a user must not be announced in a guild
+func (b *Bot) isIgnored(guildID, userID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Contains(b.ignored[guildID], userID)
+}
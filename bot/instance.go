@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	duplicateActionAlert     = "alert"
+	duplicateActionStandDown = "stand-down"
+
+	// duplicateAlertInterval limits how often the owner is alerted
+	duplicateAlertInterval = time.Hour
+)
+
+type (
+	// duplicateTransport watches REST responses for interactions that another
+	// process already acknowledged, which means a second instance with the
+	// same token is handling the same events
+	duplicateTransport struct {
+		next        http.RoundTripper
+		onDuplicate func()
+	}
+)
+
+func (t *duplicateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusBadRequest || !strings.Contains(req.URL.Path, "/interactions/") {
+		return resp, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, nil
+	}
+
+	var apiErr struct {
+		Code int `json:"code"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Code == discordgo.ErrCodeInteractionHasAlreadyBeenAcknowledged {
+		go t.onDuplicate()
+	}
+	return resp, nil
+}
+
+// watchForDuplicates installs the duplicate detection on the session's HTTP client
+func (b *Bot) watchForDuplicates(s *discordgo.Session) {
+	next := s.Client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	s.Client.Transport = &duplicateTransport{
+		next:        next,
+		onDuplicate: func() { b.duplicateDetected(s) },
+	}
+}
+
+// duplicateDetected alerts the application owner and stands down if configured.
+// Only the instance that lost the race sees the error, so the other keeps running.
+func (b *Bot) duplicateDetected(s DiscordSession) {
+	if _, shared := b.persistence.(coordinator); shared {
+		// Several instances are expected when they coordinate through a shared store
+		return
+	}
+
+	b.duplicateMu.Lock()
+	if time.Since(b.lastDuplicateAlert) < duplicateAlertInterval {
+		b.duplicateMu.Unlock()
+		return
+	}
+	b.lastDuplicateAlert = time.Now()
+	b.duplicateMu.Unlock()
+
+	standDown := b.duplicateAction == duplicateActionStandDown
+	slog.Warn("Another instance of this bot is answering the same interactions", "stand_down", standDown)
+
+	// The alert goes to the application owner, not a guild, so it uses the default language
+	message := b.t("", "instance.duplicate")
+	if standDown {
+		b.standingDown.Store(true)
+		message += "\n" + b.t("", "instance.standing-down")
+	} else {
+		message += "\n" + b.t("", "instance.stand-down-hint")
+	}
+
+	ownerID, err := applicationOwnerID(s)
+	if err != nil {
+		slog.Error("Error looking up the application owner", "error", err)
+		return
+	}
+	channel, err := s.UserChannelCreate(ownerID)
+	if err != nil {
+		slog.Error("Error opening DM with the application owner", "error", err)
+		return
+	}
+	if _, err := s.ChannelMessageSend(channel.ID, message); err != nil {
+		slog.Error("Error alerting the application owner", "error", err)
+	}
+}
+
+// applicationOwnerID returns the owner of the bot's application (the team owner for team apps)
+func applicationOwnerID(s DiscordSession) (string, error) {
+	app, err := s.Application("@me")
+	if err != nil {
+		return "", err
+	}
+	if app.Team != nil {
+		return app.Team.OwnerID, nil
+	}
+	if app.Owner != nil {
+		return app.Owner.ID, nil
+	}
+	return "", errors.New("application has no owner")
+}
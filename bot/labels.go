@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxLabelLength is the longest note admins can attach to a subscription
+const maxLabelLength = 100
+
+// labelOption returns the label option of /subscribe
+func labelOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:      discordgo.ApplicationCommandOptionString,
+		Name:      "label",
+		MaxLength: maxLabelLength,
+	}
+}
+
+// cleanLabel puts a label on a single line, since it is shown inline
+func cleanLabel(label string) string {
+	return truncateMessage(strings.Join(strings.Fields(label), " "), maxLabelLength)
+}
+
+// labelText shows a subscription's label after its target, or nothing
+func (sub subscription) labelText() string {
+	if sub.Label == "" {
+		return ""
+	}
+	return fmt.Sprintf(" 🏷️ %s", sub.Label)
+}
+
+// setLabel changes the label of a subscription
+func (b *Bot) setLabel(voiceChannelID, textChannelID, label string) bool {
+	return b.updateSubscription(voiceChannelID, textChannelID, func(sub *subscription) {
+		sub.Label = label
+	})
+}
@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	leaderboardWeek  = "week"
+	leaderboardMonth = "month"
+	leaderboardAll   = "all"
+
+	// leaderboardPageSize is how many members one leaderboard page lists
+	leaderboardPageSize = 10
+)
+
+// leaderboardMedals decorate the first three ranks
+var leaderboardMedals = []string{"🥇", "🥈", "🥉"}
+
+type (
+	// leaderboardEntry is one member's rank by voice time
+	leaderboardEntry struct {
+		UserId    string
+		VoiceTime time.Duration
+	}
+)
+
+// leaderboardCommand returns the /voice-leaderboard command definition
+func leaderboardCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name: "voice-leaderboard",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionString,
+				Name: "period",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Value: leaderboardWeek},
+					{Value: leaderboardMonth},
+					{Value: leaderboardAll},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleVoiceLeaderboard(s DiscordSession, i *discordgo.InteractionCreate) {
+	period := leaderboardWeek
+	if opt, ok := optionMap(i.ApplicationCommandData().Options)["period"]; ok {
+		period = opt.StringValue()
+	}
+	b.respondLeaderboard(s, i, discordgo.InteractionResponseChannelMessageWithSource, period, 0)
+}
+
+// handleLeaderboardPageButton switches the leaderboard to another page
+func (b *Bot) handleLeaderboardPageButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	parts := strings.Split(strings.TrimPrefix(i.MessageComponentData().CustomID, "leaderboard_page:"), ":")
+	if len(parts) != 2 {
+		return
+	}
+	page, _ := strconv.Atoi(parts[1])
+	b.respondLeaderboard(s, i, discordgo.InteractionResponseUpdateMessage, parts[0], page)
+}
+
+// respondLeaderboard shows one page of the leaderboard and the invoking
+// member's own rank
+func (b *Bot) respondLeaderboard(s DiscordSession, i *discordgo.InteractionCreate, responseType discordgo.InteractionResponseType, period string, page int) {
+	now := time.Now()
+	from, period := leaderboardPeriod(period, now)
+	entries := b.leaderboard(i.GuildID, from, now)
+	if len(entries) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: responseType,
+			Data: &discordgo.InteractionResponseData{
+				Content: b.t(i.GuildID, "leaderboard.empty."+period),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	pages := (len(entries) + leaderboardPageSize - 1) / leaderboardPageSize
+	page = max(0, min(page, pages-1))
+	var lines []string
+	for idx := page * leaderboardPageSize; idx < min((page+1)*leaderboardPageSize, len(entries)); idx++ {
+		lines = append(lines, fmt.Sprintf("%s <@%s> — %s", leaderboardRank(idx), entries[idx].UserId, formatDuration(entries[idx].VoiceTime)))
+	}
+
+	userID := interactionUserID(i)
+	own := b.t(i.GuildID, "leaderboard.no-time")
+	if b.isIgnored(i.GuildID, userID) {
+		own = b.t(i.GuildID, "leaderboard.ignored")
+	} else if idx := slices.IndexFunc(entries, func(entry leaderboardEntry) bool { return entry.UserId == userID }); idx >= 0 {
+		own = b.t(i.GuildID, "leaderboard.rank", idx+1, len(entries), formatDuration(entries[idx].VoiceTime))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       b.t(i.GuildID, "leaderboard.title."+period),
+		Description: strings.Join(lines, "\n"),
+		Color:       0x5865F2,
+		Fields:      []*discordgo.MessageEmbedField{{Name: b.t(i.GuildID, "leaderboard.rank-title"), Value: own}},
+	}
+	var components []discordgo.MessageComponent
+	if pages > 1 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: b.t(i.GuildID, "common.page", page+1, pages)}
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    b.t(i.GuildID, "common.previous"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("leaderboard_page:%s:%d", period, page-1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    b.t(i.GuildID, "common.next"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("leaderboard_page:%s:%d", period, page+1),
+					Disabled: page == pages-1,
+				},
+			},
+		})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: responseType,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{b.presentEmbed(i.GuildID, embed)},
+			Components:      components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+			Flags:           discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// leaderboardPeriod returns the start of a ranking period and the period,
+// unknown ones counting as weeks. Weeks start on Monday, and weeks and months
+// in UTC.
+func leaderboardPeriod(period string, now time.Time) (time.Time, string) {
+	now = now.UTC()
+	switch period {
+	case leaderboardMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), period
+	case leaderboardAll:
+		return time.Time{}, period
+	default:
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		return time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC), leaderboardWeek
+	}
+}
+
+// leaderboardRank returns a medal for the first three ranks and the number
+// for the rest
+func leaderboardRank(idx int) string {
+	if idx < len(leaderboardMedals) {
+		return leaderboardMedals[idx]
+	}
+	return fmt.Sprintf("**#%d**", idx+1)
+}
+
+// leaderboard sums each member's voice time in a guild during [from, to),
+// most first. Members who aren't announced are left out.
+func (b *Bot) leaderboard(guildID string, from, to time.Time) []leaderboardEntry {
+	byUser := make(map[string]time.Duration)
+	for _, session := range b.sessions.sessions(guildID, from, to) {
+		byUser[session.UserId] += session.overlap(from, to)
+	}
+
+	entries := make([]leaderboardEntry, 0, len(byUser))
+	for userID, voiceTime := range byUser {
+		if voiceTime < time.Minute || b.isIgnored(guildID, userID) {
+			continue
+		}
+		entries = append(entries, leaderboardEntry{UserId: userID, VoiceTime: voiceTime})
+	}
+	slices.SortFunc(entries, func(x, y leaderboardEntry) int {
+		if x.VoiceTime != y.VoiceTime {
+			return int(y.VoiceTime - x.VoiceTime)
+		}
+		return strings.Compare(x.UserId, y.UserId)
+	})
+	return entries
+}
@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"fmt"
+)
+
+type (
+	// subscriptionLimits caps how many subscriptions may exist, so a single
+	// guild can't create unbounded state or notification fan-out. Zero means
+	// unlimited.
+	subscriptionLimits struct {
+		PerGuild        int
+		PerVoiceChannel int
+	}
+
+	// subscriptionLimitError reports which limit a new subscription hit
+	subscriptionLimitError struct {
+		limit        int
+		voiceChannel bool
+	}
+)
+
+func (err *subscriptionLimitError) Error() string {
+	if err.voiceChannel {
+		return fmt.Sprintf("this voice channel already has the maximum of %d subscriptions", err.limit)
+	}
+	return fmt.Sprintf("this server already has the maximum of %d subscriptions", err.limit)
+}
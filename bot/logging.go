@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+// SetupLogging installs the default slog logger with the configured level
+// (debug, info, warn, error) and format (text or json)
+func SetupLogging(logConfig config.Log) {
+	level := slog.LevelInfo
+	if err := level.UnmarshalText([]byte(logConfig.Level)); err != nil {
+		defer slog.Warn("Invalid log level, using default info", "value", logConfig.Level)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if logConfig.Format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, options)
+	}
+
+	slog.SetDefault(slog.New(handler))
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+type (
+	// MemoryStore keeps the bot state in memory only. Nothing is written to
+	// disk, so all configuration is lost when the process exits.
+	MemoryStore struct {
+		data []byte // last saved state as JSON, so callers never share maps
+		mu   sync.Mutex
+	}
+)
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Load returns the last saved state, or empty data if nothing was saved yet
+func (m *MemoryStore) Load() (*PersistentData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data := &PersistentData{}
+	if m.data != nil {
+		if err := json.Unmarshal(m.data, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Older data may be missing newer sections
+	data.ensureMaps()
+	return data, nil
+}
+
+// Save keeps a copy of the state in memory
+func (m *MemoryStore) Save(data *PersistentData) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.data = jsonData
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxMentions is how many roles and users one subscription may mention
+const maxMentions = 5
+
+// mentionsCommand returns the /mentions command definition
+func mentionsCommand() *discordgo.ApplicationCommand {
+	voiceChannelOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionChannel,
+		Name:         "voice-channel",
+		Required:     true,
+		ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+	}
+	return &discordgo.ApplicationCommand{
+		Name:                     "mentions",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "add",
+				Options: []*discordgo.ApplicationCommandOption{
+					voiceChannelOption,
+					{
+						Type:     discordgo.ApplicationCommandOptionMentionable,
+						Name:     "target",
+						Required: true,
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "remove",
+				Options: []*discordgo.ApplicationCommandOption{
+					voiceChannelOption,
+					{
+						Type:     discordgo.ApplicationCommandOptionMentionable,
+						Name:     "target",
+						Required: true,
+					},
+				},
+			},
+			{
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Name:    "clear",
+				Options: []*discordgo.ApplicationCommandOption{voiceChannelOption},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleMentions(s DiscordSession, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	subcommand := data.Options[0]
+	options := optionMap(subcommand.Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	sub, found := b.getSubscription(voiceChannelID, i.ChannelID)
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.not-subscribed", channelName))
+		return
+	}
+
+	if subcommand.Name == "clear" {
+		b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+			sub.MentionRoleIds = nil
+			sub.MentionUserIds = nil
+		})
+		slog.Info("Mentions cleared", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "voice_channel_id", voiceChannelID, "channel_id", i.ChannelID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "mentions.cleared", channelName))
+		return
+	}
+
+	targetID := options["target"].Value.(string)
+	_, isRole := data.Resolved.Roles[targetID]
+	if isRole && targetID == i.GuildID {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "mentions.everyone"))
+		return
+	}
+	mention := "<@" + targetID + ">"
+	if isRole {
+		mention = "<@&" + targetID + ">"
+	}
+
+	if subcommand.Name == "remove" {
+		b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+			sub.MentionRoleIds = slices.DeleteFunc(sub.MentionRoleIds, func(id string) bool { return id == targetID })
+			sub.MentionUserIds = slices.DeleteFunc(sub.MentionUserIds, func(id string) bool { return id == targetID })
+		})
+		slog.Info("Mention removed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "voice_channel_id", voiceChannelID, "channel_id", i.ChannelID, "target_id", targetID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "mentions.removed", channelName, mention))
+		return
+	}
+
+	if slices.Contains(sub.MentionRoleIds, targetID) || slices.Contains(sub.MentionUserIds, targetID) {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "mentions.already", channelName, mention))
+		return
+	}
+	if len(sub.MentionRoleIds)+len(sub.MentionUserIds) >= maxMentions {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "mentions.limit", maxMentions))
+		return
+	}
+
+	b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+		if isRole {
+			sub.MentionRoleIds = append(sub.MentionRoleIds, targetID)
+		} else {
+			sub.MentionUserIds = append(sub.MentionUserIds, targetID)
+		}
+	})
+	slog.Info("Mention added", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "voice_channel_id", voiceChannelID, "channel_id", i.ChannelID, "target_id", targetID)
+
+	response := b.t(i.GuildID, "mentions.added", channelName, mention)
+	if b.mentionCooldown.window > 0 {
+		response = b.t(i.GuildID, "mentions.added-cooldown", channelName, mention, formatDuration(b.mentionCooldown.window))
+	}
+	respondEphemeral(s, i.Interaction, response)
+}
+
+// withMentions returns the message with the subscription's mentions added in
+// front. Roles and users still in their cooldown for the text channel are left
+// out outside event mode, and only the listed roles and users are allowed to
+// be pinged.
+func (b *Bot) withMentions(sub subscription, message *discordgo.MessageSend) *discordgo.MessageSend {
+	if sub.isDM() || len(sub.MentionRoleIds)+len(sub.MentionUserIds) == 0 {
+		return message
+	}
+
+	event := sub.eventActive(time.Now())
+	var mentions []string
+	allowed := &discordgo.MessageAllowedMentions{}
+	for _, roleID := range sub.MentionRoleIds {
+		if event || b.mentionCooldown.allow(sub.TextChannelId, roleID) {
+			mentions = append(mentions, "<@&"+roleID+">")
+			allowed.Roles = append(allowed.Roles, roleID)
+		}
+	}
+	for _, userID := range sub.MentionUserIds {
+		if event || b.mentionCooldown.allow(sub.TextChannelId, userID) {
+			mentions = append(mentions, "<@"+userID+">")
+			allowed.Users = append(allowed.Users, userID)
+		}
+	}
+	if len(mentions) == 0 {
+		return message
+	}
+
+	mentioned := *message
+	mentioned.Content = truncateMessage(strings.TrimSpace(strings.Join(mentions, " ")+" "+message.Content), maxMessageLength)
+	mentioned.AllowedMentions = allowed
+	return &mentioned
+}
@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+type (
+	// MergeReport summarizes what merging two states changed
+	MergeReport struct {
+		Added      int      // entries copied from the source
+		Duplicates int      // entries already present and identical
+		Conflicts  []string // entries that differ; the target's value was kept
+	}
+)
+
+// OpenStore opens a store from a file path or a postgres:// or redis:// URL.
+// Redis keys use redisPrefix like the bot does.
+func OpenStore(location, redisPrefix string) (Store, error) {
+	switch {
+	case strings.HasPrefix(location, "postgres://"), strings.HasPrefix(location, "postgresql://"):
+		return NewPostgresStore(location)
+	case strings.HasPrefix(location, "redis://"), strings.HasPrefix(location, "rediss://"):
+		return NewRedisStore(location, redisPrefix)
+	}
+	if _, err := os.Stat(location); err != nil {
+		return nil, err
+	}
+	return NewPersistence(location), nil
+}
+
+// sameSubscriptionSettings reports whether two subscriptions are configured
+// the same, ignoring when they last fired
+func sameSubscriptionSettings(a, b subscription) bool {
+	a.LastFiredAt, b.LastFiredAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+// MergeData copies everything from src into dst that dst does not have yet.
+// Identical entries are counted as duplicates; where both sides configure the
+// same thing differently, dst wins and the difference is reported.
+func MergeData(dst, src *PersistentData) *MergeReport {
+	dst.ensureMaps()
+	src.ensureMaps()
+	report := &MergeReport{}
+
+	for voiceChannelID, subs := range src.Subscriptions {
+		for _, sub := range subs {
+			idx := slices.IndexFunc(dst.Subscriptions[voiceChannelID], func(existing subscription) bool {
+				return existing.TextChannelId == sub.TextChannelId
+			})
+			switch {
+			case idx < 0:
+				dst.Subscriptions[voiceChannelID] = append(dst.Subscriptions[voiceChannelID], sub)
+				report.Added++
+			case sameSubscriptionSettings(dst.Subscriptions[voiceChannelID][idx], sub):
+				// Activity timestamps differ between deployments; keep the latest
+				existing := &dst.Subscriptions[voiceChannelID][idx]
+				if sub.LastFiredAt.After(existing.LastFiredAt) {
+					existing.LastFiredAt = sub.LastFiredAt
+				}
+				report.Duplicates++
+			default:
+				report.conflict("subscription %s -> %s in guild %s has different settings", voiceChannelID, sub.TextChannelId, sub.GuildId)
+			}
+		}
+	}
+
+	mergeSetting(report, "admin channel", dst.AdminChannels, src.AdminChannels)
+	mergeSetting(report, "session log channel", dst.LogChannels, src.LogChannels)
+	mergeSetting(report, "fallback channel", dst.FallbackChannels, src.FallbackChannels)
+	mergeSetting(report, "goal", dst.Goals, src.Goals)
+	mergeSetting(report, "subscribe permission", dst.SubscribeAccess, src.SubscribeAccess)
+	mergeSetting(report, "debounce strategy", dst.DebounceStrategies, src.DebounceStrategies)
+	mergeSetting(report, "plain text mode", dst.PlainText, src.PlainText)
+	mergeSetting(report, "minimal style", dst.Minimal, src.Minimal)
+	mergeSetting(report, "notification timestamps", dst.Timestamps, src.Timestamps)
+	mergeSetting(report, "message tone", dst.Tones, src.Tones)
+	mergeSetting(report, "language", dst.Languages, src.Languages)
+	mergeSetting(report, "AFK channel handling", dst.AFK, src.AFK)
+	mergeSetting(report, "notification pause", dst.Pauses, src.Pauses)
+	mergeSetting(report, "digest", dst.Digests, src.Digests)
+	mergeList(report, dst.Watchlist, src.Watchlist)
+	mergeList(report, dst.Ignored, src.Ignored)
+	mergeList(report, dst.OptOuts, src.OptOuts)
+
+	for guildID, patterns := range src.PatternSubscriptions {
+		for _, pattern := range patterns {
+			if slices.Contains(dst.PatternSubscriptions[guildID], pattern) {
+				report.Duplicates++
+				continue
+			}
+			dst.PatternSubscriptions[guildID] = append(dst.PatternSubscriptions[guildID], pattern)
+			report.Added++
+		}
+	}
+
+	for guildID, windows := range src.GroupWindows {
+		if dst.GroupWindows[guildID] == nil {
+			dst.GroupWindows[guildID] = make(map[string]string)
+		}
+		mergeSetting(report, "notification grouping in guild "+guildID, dst.GroupWindows[guildID], windows)
+	}
+
+	for guildID, templates := range src.Templates {
+		if dst.Templates[guildID] == nil {
+			dst.Templates[guildID] = make(map[string]string)
+		}
+		mergeSetting(report, "template in guild "+guildID, dst.Templates[guildID], templates)
+	}
+
+	for _, f := range src.Follows {
+		idx := slices.IndexFunc(dst.Follows, func(existing follow) bool {
+			return existing.GuildId == f.GuildId && existing.FollowerId == f.FollowerId && existing.TargetId == f.TargetId
+		})
+		if idx < 0 {
+			dst.Follows = append(dst.Follows, f)
+			report.Added++
+			continue
+		}
+		// Keep whichever follow lasts longer
+		if f.Expires.After(dst.Follows[idx].Expires) {
+			dst.Follows[idx].Expires = f.Expires
+		}
+		report.Duplicates++
+	}
+
+	for _, sent := range src.SentNotifications {
+		if !slices.ContainsFunc(dst.SentNotifications, func(existing sentNotification) bool { return existing.MessageId == sent.MessageId }) {
+			dst.SentNotifications = append(dst.SentNotifications, sent)
+		}
+	}
+
+	return report
+}
+
+// mergeSetting merges a per-key setting, keeping dst's value on conflicts
+func mergeSetting[V any](report *MergeReport, name string, dst, src map[string]V) {
+	for key, value := range src {
+		existing, ok := dst[key]
+		switch {
+		case !ok:
+			dst[key] = value
+			report.Added++
+		case reflect.DeepEqual(existing, value):
+			report.Duplicates++
+		default:
+			report.conflict("%s %s differs", name, key)
+		}
+	}
+}
+
+// mergeList merges per-guild ID lists as a union
+func mergeList(report *MergeReport, dst, src map[string][]string) {
+	for guildID, values := range src {
+		for _, value := range values {
+			if slices.Contains(dst[guildID], value) {
+				report.Duplicates++
+				continue
+			}
+			dst[guildID] = append(dst[guildID], value)
+			report.Added++
+		}
+	}
+}
+
+func (report *MergeReport) conflict(format string, args ...any) {
+	report.Conflicts = append(report.Conflicts, fmt.Sprintf(format, args...))
+}
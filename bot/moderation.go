@@ -0,0 +1,189 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+// moderationTimeout bounds an external moderation check
+const moderationTimeout = 3 * time.Second
+
+type (
+	// contentFilter checks notification text before it is sent. It returns the
+	// text to send, which may be redacted, and false to veto the notification.
+	contentFilter interface {
+		filter(ctx context.Context, guildID, content string) (string, bool, error)
+	}
+
+	// wordListFilter redacts or vetoes text containing listed words
+	wordListFilter struct {
+		pattern *regexp.Regexp
+		veto    bool
+	}
+
+	// httpFilter asks an external service whether text may be sent
+	httpFilter struct {
+		url    string
+		client *http.Client
+	}
+
+	// moderationRequest is posted to MODERATION_URL
+	moderationRequest struct {
+		GuildId string `json:"guild_id"`
+		Content string `json:"content"`
+	}
+
+	// moderationResponse is expected back from MODERATION_URL
+	moderationResponse struct {
+		Allow   bool   `json:"allow"`
+		Content string `json:"content,omitempty"` // replacement text, empty keeps the original
+	}
+
+	// moderation runs the configured filters in order
+	moderation struct {
+		filters     []contentFilter
+		dropOnError bool
+	}
+)
+
+// newModeration builds the configured filters. The word list file is read
+// once, here.
+func newModeration(cfg config.Moderation) *moderation {
+	m := &moderation{dropOnError: cfg.OnError == "drop"}
+
+	words := slices.Clone(cfg.Words)
+	if path := cfg.WordsFile; path != "" {
+		file, err := os.ReadFile(path)
+		if err != nil {
+			slog.Error("Error reading the moderation words file", "path", path, "error", err)
+		}
+		for _, line := range strings.Split(string(file), "\n") {
+			if word := strings.TrimSpace(line); word != "" && !strings.HasPrefix(word, "#") {
+				words = append(words, word)
+			}
+		}
+	}
+	if len(words) > 0 {
+		quoted := make([]string, len(words))
+		for idx, word := range words {
+			quoted[idx] = regexp.QuoteMeta(word)
+		}
+		m.filters = append(m.filters, &wordListFilter{
+			pattern: regexp.MustCompile(`(?i)` + strings.Join(quoted, "|")),
+			veto:    cfg.Action == "veto",
+		})
+	}
+
+	if cfg.URL != "" {
+		m.filters = append(m.filters, &httpFilter{url: cfg.URL, client: &http.Client{Timeout: moderationTimeout}})
+	}
+	return m
+}
+
+func (f *wordListFilter) filter(_ context.Context, _ string, content string) (string, bool, error) {
+	if !f.pattern.MatchString(content) {
+		return content, true, nil
+	}
+	if f.veto {
+		return content, false, nil
+	}
+	return f.pattern.ReplaceAllStringFunc(content, func(word string) string {
+		return strings.Repeat("\\*", len([]rune(word)))
+	}), true, nil
+}
+
+func (f *httpFilter) filter(ctx context.Context, guildID, content string) (string, bool, error) {
+	body, err := json.Marshal(moderationRequest{GuildId: guildID, Content: content})
+	if err != nil {
+		return content, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return content, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return content, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return content, false, fmt.Errorf("moderation service returned %s", resp.Status)
+	}
+
+	var result moderationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return content, false, fmt.Errorf("decoding moderation response: %w", err)
+	}
+	if result.Content != "" {
+		content = result.Content
+	}
+	return content, result.Allow, nil
+}
+
+// check runs every filter over a text. A filter error vetoes the text only
+// when MODERATION_ON_ERROR=drop.
+func (m *moderation) check(ctx context.Context, guildID, content string) (string, bool) {
+	if content == "" {
+		return content, true
+	}
+	for _, f := range m.filters {
+		filtered, allowed, err := f.filter(ctx, guildID, content)
+		if err != nil {
+			slog.Warn("Moderation check failed", "guild_id", guildID, "event_type", "moderation", "error", err)
+			if m.dropOnError {
+				return content, false
+			}
+			continue
+		}
+		if !allowed {
+			return content, false
+		}
+		content = filtered
+	}
+	return content, true
+}
+
+// moderate returns the message with its text passed through the content
+// filters, or false if a filter vetoed it
+func (b *Bot) moderate(guildID string, message *discordgo.MessageSend) (*discordgo.MessageSend, bool) {
+	if len(b.moderation.filters) == 0 {
+		return message, true
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, moderationTimeout)
+	defer cancel()
+
+	moderated := *message
+	var allowed bool
+	if moderated.Content, allowed = b.moderation.check(ctx, guildID, message.Content); !allowed {
+		return nil, false
+	}
+
+	moderated.Embeds = make([]*discordgo.MessageEmbed, len(message.Embeds))
+	for idx, embed := range message.Embeds {
+		embedCopy := *embed
+		if embedCopy.Title, allowed = b.moderation.check(ctx, guildID, embed.Title); !allowed {
+			return nil, false
+		}
+		if embedCopy.Description, allowed = b.moderation.check(ctx, guildID, embed.Description); !allowed {
+			return nil, false
+		}
+		moderated.Embeds[idx] = &embedCopy
+	}
+	return &moderated, true
+}
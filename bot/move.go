@@ -0,0 +1,22 @@
+package bot
+
+// notificationSubscriptions returns the subscriptions a notification goes to.
+// Moves reach the subscribers of both channels, once per text channel.
+func (b *Bot) notificationSubscriptions(voiceChannelID string, n notification) []subscription {
+	subscriptions := b.channelSubscriptions(voiceChannelID)
+	if n.fromChannelID == "" {
+		return subscriptions
+	}
+
+	seen := make(map[string]bool, len(subscriptions))
+	for _, sub := range subscriptions {
+		seen[sub.TextChannelId] = true
+	}
+	for _, sub := range b.channelSubscriptions(n.fromChannelID) {
+		if !seen[sub.TextChannelId] {
+			seen[sub.TextChannelId] = true
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+	return subscriptions
+}
@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// occupancy tracks which users are currently in each voice channel
+	occupancy struct {
+		channels map[string]map[string]struct{} // voiceChannelID -> userIDs
+		since    map[string]time.Time           // voiceChannelID -> when it stopped being empty
+		mu       sync.RWMutex
+	}
+)
+
+func newOccupancy() *occupancy {
+	return &occupancy{
+		channels: make(map[string]map[string]struct{}),
+		since:    make(map[string]time.Time),
+	}
+}
+
+// move records a user leaving one channel and/or joining another and returns
+// how many users were in the joined channel before the user arrived
+func (o *occupancy) move(userID, leftChannelID, joinedChannelID string) (previousCount int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if leftChannelID != "" {
+		delete(o.channels[leftChannelID], userID)
+		if len(o.channels[leftChannelID]) == 0 {
+			delete(o.channels, leftChannelID)
+			delete(o.since, leftChannelID)
+		}
+	}
+
+	if joinedChannelID != "" {
+		users := o.channels[joinedChannelID]
+		if users == nil {
+			users = make(map[string]struct{})
+			o.channels[joinedChannelID] = users
+			o.since[joinedChannelID] = time.Now()
+		}
+		previousCount = len(users)
+		users[userID] = struct{}{}
+	}
+	return previousCount
+}
+
+// count returns the number of users in a voice channel
+func (o *occupancy) count(voiceChannelID string) int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.channels[voiceChannelID])
+}
+
+// activeSince returns when a voice channel stopped being empty, or the zero
+// time if it is empty
+func (o *occupancy) activeSince(voiceChannelID string) time.Time {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.since[voiceChannelID]
+}
+
+// users returns the IDs of users in a voice channel
+func (o *occupancy) users(voiceChannelID string) []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	users := make([]string, 0, len(o.channels[voiceChannelID]))
+	for userID := range o.channels[voiceChannelID] {
+		users = append(users, userID)
+	}
+	return users
+}
+
+// seedGuild replaces the occupancy of a guild's voice channels with the voice
+// states Discord sent in GUILD_CREATE. Bots are skipped when member data is
+// available.
+func (o *occupancy) seedGuild(g *discordgo.Guild) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, channel := range g.Channels {
+		delete(o.channels, channel.ID)
+		delete(o.since, channel.ID)
+	}
+
+	bots := make(map[string]bool)
+	for _, member := range g.Members {
+		if member.User != nil && member.User.Bot {
+			bots[member.User.ID] = true
+		}
+	}
+
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == "" || bots[vs.UserID] || (vs.Member != nil && vs.Member.User != nil && vs.Member.User.Bot) {
+			continue
+		}
+		users := o.channels[vs.ChannelID]
+		if users == nil {
+			users = make(map[string]struct{})
+			o.channels[vs.ChannelID] = users
+			o.since[vs.ChannelID] = time.Now() // the real start is unknown after a restart
+		}
+		users[vs.UserID] = struct{}{}
+	}
+}
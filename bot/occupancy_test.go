@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberlessSession fails every guild member lookup
+type memberlessSession struct {
+	DiscordSession
+}
+
+func (memberlessSession) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	return nil, errors.New("unknown member")
+}
+
+func TestVoiceStateUpdateWithoutMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels []string // the user's channel after each update, empty for none
+		want     map[string]int
+	}{
+		{name: "join", channels: []string{"11"}, want: map[string]int{"11": 1}},
+		{name: "join and leave", channels: []string{"11", ""}, want: map[string]int{"11": 0}},
+		{name: "move", channels: []string{"11", "12"}, want: map[string]int{"11": 0, "12": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			for _, channelID := range tt.channels {
+				b.voiceStateUpdate(memberlessSession{}, &discordgo.VoiceStateUpdate{VoiceState: &discordgo.VoiceState{GuildID: "1", UserID: "100", ChannelID: channelID}})
+			}
+			for channelID, want := range tt.want {
+				if got := b.occupancy.count(channelID); got != want {
+					t.Errorf("%d users in %s, want %d", got, channelID, want)
+				}
+			}
+		})
+	}
+}
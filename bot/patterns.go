@@ -0,0 +1,256 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxPatternLength matches Discord's channel name limit
+const maxPatternLength = 100
+
+type (
+	// patternSubscription subscribes a text channel to every voice channel
+	// whose name matches Pattern, for "join to create" channels that come and
+	// go with their own IDs. Matching happens at event time, see
+	// channelSubscriptions.
+	patternSubscription struct {
+		Pattern       string `json:"pattern"` // * matches any text, ? one character, case-insensitive
+		TextChannelId string `json:"text_channel_id"`
+		GuildId       string `json:"guild_id"`
+	}
+)
+
+// matchChannelPattern reports whether a channel name matches a pattern,
+// ignoring case
+func matchChannelPattern(pattern, name string) bool {
+	p := []rune(strings.ToLower(pattern))
+	n := []rune(strings.ToLower(name))
+
+	// Backtrack to the last * when a literal doesn't match
+	pi, ni := 0, 0
+	star, starNi := -1, 0
+	for ni < len(n) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == n[ni]):
+			pi++
+			ni++
+		case pi < len(p) && p[pi] == '*':
+			star, starNi = pi, ni
+			pi++
+		case star >= 0:
+			pi = star + 1
+			starNi++
+			ni = starNi
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// subscription returns the subscription a pattern stands for in a matching
+// voice channel
+func (p patternSubscription) subscription(voiceChannelID string) subscription {
+	return subscription{
+		VoiceChannelId: voiceChannelID,
+		TextChannelId:  p.TextChannelId,
+		GuildId:        p.GuildId,
+		Pattern:        p.Pattern,
+	}
+}
+
+// channelSubscriptions returns the subscriptions of a voice channel, including
+// pattern subscriptions its current name matches. A text channel subscribed
+// both ways is notified once.
+func (b *Bot) channelSubscriptions(voiceChannelID string) []subscription {
+	subs := b.subscriptions.Channel(voiceChannelID)
+
+	channel, err := b.session.State.Channel(voiceChannelID)
+	if err != nil || (channel.Type != discordgo.ChannelTypeGuildVoice && channel.Type != discordgo.ChannelTypeGuildStageVoice) {
+		return subs
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, pattern := range b.patternSubscriptions[channel.GuildID] {
+		if !matchChannelPattern(pattern.Pattern, channel.Name) {
+			continue
+		}
+		if slices.ContainsFunc(subs, func(sub subscription) bool { return sub.TextChannelId == pattern.TextChannelId }) {
+			continue
+		}
+		subs = append(subs, pattern.subscription(voiceChannelID))
+	}
+	return subs
+}
+
+// hasPatternSubscription reports whether a pattern subscription still exists
+func (b *Bot) hasPatternSubscription(guildID, pattern, textChannelID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Contains(b.patternSubscriptions[guildID], patternSubscription{Pattern: pattern, TextChannelId: textChannelID, GuildId: guildID})
+}
+
+// movePatternSubscriptions moves the pattern subscriptions of a deleted text
+// channel to fallbackID, or removes them if it is empty. It returns the moved
+// and removed patterns.
+func (b *Bot) movePatternSubscriptions(guildID, textChannelID, fallbackID string) (moved, dropped []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var kept []patternSubscription
+	for _, pattern := range b.patternSubscriptions[guildID] {
+		if pattern.TextChannelId != textChannelID {
+			kept = append(kept, pattern)
+			continue
+		}
+		name := pattern.Pattern
+		pattern.TextChannelId = fallbackID
+		if fallbackID == "" || slices.Contains(kept, pattern) || slices.Contains(b.patternSubscriptions[guildID], pattern) {
+			dropped = append(dropped, name)
+			continue
+		}
+		kept = append(kept, pattern)
+		moved = append(moved, name)
+	}
+	if len(moved) == 0 && len(dropped) == 0 {
+		return nil, nil
+	}
+	if len(kept) == 0 {
+		delete(b.patternSubscriptions, guildID)
+	} else {
+		b.patternSubscriptions[guildID] = kept
+	}
+	b.savePersistedDataAsync()
+	return moved, dropped
+}
+
+// patternListText lists a guild's pattern subscriptions for the subscription
+// list, or returns an empty string if it has none
+func (b *Bot) patternListText(guildID string) string {
+	b.mu.RLock()
+	patterns := b.patternSubscriptions[guildID]
+	lines := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		lines = append(lines, fmt.Sprintf("`%s` → <#%s>", pattern.Pattern, pattern.TextChannelId))
+	}
+	b.mu.RUnlock()
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n\n" + b.t(guildID, "pattern.list") + "\n" + b.joinLimited(guildID, lines, "\n", 1000)
+}
+
+// patternCommands returns the /subscribe-pattern and /unsubscribe-pattern
+// command definitions
+func patternCommands() []*discordgo.ApplicationCommand {
+	patternOption := func() *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:      discordgo.ApplicationCommandOptionString,
+			Name:      "pattern",
+			Required:  true,
+			MaxLength: maxPatternLength,
+		}
+	}
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:    "subscribe-pattern",
+			Options: []*discordgo.ApplicationCommandOption{patternOption()},
+		},
+		{
+			Name:    "unsubscribe-pattern",
+			Options: []*discordgo.ApplicationCommandOption{patternOption()},
+		},
+	}
+}
+
+func (b *Bot) handleSubscribePattern(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	pattern := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+	if pattern == "" || utf8.RuneCountInString(pattern) > maxPatternLength {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "pattern.length", maxPatternLength))
+		return
+	}
+	if strings.Trim(pattern, "*?") == "" {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "pattern.wildcards-only"))
+		return
+	}
+
+	entry := patternSubscription{Pattern: pattern, TextChannelId: i.ChannelID, GuildId: i.GuildID}
+
+	b.mu.Lock()
+	patterns := b.patternSubscriptions[i.GuildID]
+	if slices.ContainsFunc(patterns, func(existing patternSubscription) bool {
+		return existing.TextChannelId == i.ChannelID && strings.EqualFold(existing.Pattern, pattern)
+	}) {
+		b.mu.Unlock()
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "pattern.already", pattern))
+		return
+	}
+	// Patterns count toward the server's subscription limit
+	if limit := b.subscriptionLimits.PerGuild; limit > 0 && len(patterns)+len(b.subscriptions.Guild(i.GuildID)) >= limit {
+		b.mu.Unlock()
+		respondWithError(s, i.Interaction, "❌ "+b.errorText(i.GuildID, &subscriptionLimitError{limit: limit}))
+		return
+	}
+	b.patternSubscriptions[i.GuildID] = append(patterns, entry)
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Pattern subscription added", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", i.ChannelID, "pattern", pattern)
+
+	var matching []string
+	for _, channel := range b.stateVoiceChannels(i.GuildID) {
+		if matchChannelPattern(pattern, channel.Name) {
+			matching = append(matching, fmt.Sprintf("**%s**", channel.Name))
+		}
+	}
+	message := b.t(i.GuildID, "pattern.added", pattern)
+	if len(matching) > 0 {
+		message += "\n" + b.t(i.GuildID, "pattern.matching", b.joinLimited(i.GuildID, matching, ", ", 1000))
+	}
+	respondEphemeral(s, i.Interaction, message)
+}
+
+func (b *Bot) handleUnsubscribePattern(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	pattern := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	b.mu.Lock()
+	patterns := b.patternSubscriptions[i.GuildID]
+	idx := slices.IndexFunc(patterns, func(existing patternSubscription) bool {
+		return existing.TextChannelId == i.ChannelID && strings.EqualFold(existing.Pattern, pattern)
+	})
+	if idx < 0 {
+		b.mu.Unlock()
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "pattern.not-subscribed", pattern))
+		return
+	}
+	pattern = patterns[idx].Pattern
+	patterns = slices.Delete(slices.Clone(patterns), idx, idx+1)
+	if len(patterns) == 0 {
+		delete(b.patternSubscriptions, i.GuildID)
+	} else {
+		b.patternSubscriptions[i.GuildID] = patterns
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Pattern subscription removed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", i.ChannelID, "pattern", pattern)
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "pattern.removed", pattern))
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMatchChannelPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "Squad *", name: "Squad Alice", want: true},
+		{pattern: "Squad *", name: "Squad ", want: true},
+		{pattern: "Squad *", name: "Squad", want: false},
+		{pattern: "squad *", name: "SQUAD Bob", want: true},
+		{pattern: "Duo ?", name: "Duo 7", want: true},
+		{pattern: "Duo ?", name: "Duo 17", want: false},
+		{pattern: "Duo ?", name: "Duo 🎮", want: true},
+		{pattern: "*'s channel", name: "Alice's channel", want: true},
+		{pattern: "*'s channel", name: "Alice's channel 2", want: false},
+		{pattern: "*-*-*", name: "eu-raid-1", want: true},
+		{pattern: "*a*b", name: "xaxbxab", want: true},
+		{pattern: "*a*b", name: "xaxbxa", want: false},
+		{pattern: "**", name: "", want: true},
+		{pattern: "Lobby", name: "Lobby", want: true},
+		{pattern: "Lobby", name: "Lobby 2", want: false},
+		{pattern: "Lobby", name: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchChannelPattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchChannelPattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChannelSubscriptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		channel  *discordgo.Channel
+		direct   []string // text channels subscribed to the voice channel by ID
+		patterns []string // text channel of a "Squad *" pattern subscription
+		want     []string
+	}{
+		{
+			name:     "pattern matches",
+			channel:  &discordgo.Channel{ID: "11", GuildID: "1", Name: "Squad Alice", Type: discordgo.ChannelTypeGuildVoice},
+			patterns: []string{"lfg"},
+			want:     []string{"lfg"},
+		},
+		{
+			name:     "stage channel",
+			channel:  &discordgo.Channel{ID: "11", GuildID: "1", Name: "Squad Stage", Type: discordgo.ChannelTypeGuildStageVoice},
+			patterns: []string{"lfg"},
+			want:     []string{"lfg"},
+		},
+		{
+			name:     "no match",
+			channel:  &discordgo.Channel{ID: "11", GuildID: "1", Name: "Lobby", Type: discordgo.ChannelTypeGuildVoice},
+			direct:   []string{"lobby"},
+			patterns: []string{"lfg"},
+			want:     []string{"lobby"},
+		},
+		{
+			name:     "text channel notified once",
+			channel:  &discordgo.Channel{ID: "11", GuildID: "1", Name: "Squad Alice", Type: discordgo.ChannelTypeGuildVoice},
+			direct:   []string{"lfg"},
+			patterns: []string{"lfg", "squads"},
+			want:     []string{"lfg", "squads"},
+		},
+		{
+			name:     "other guild's pattern",
+			channel:  &discordgo.Channel{ID: "11", GuildID: "2", Name: "Squad Alice", Type: discordgo.ChannelTypeGuildVoice},
+			patterns: []string{"lfg"},
+		},
+		{
+			name:     "not a voice channel",
+			channel:  &discordgo.Channel{ID: "11", GuildID: "1", Name: "Squad Alice", Type: discordgo.ChannelTypeGuildText},
+			patterns: []string{"lfg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			if err := b.session.State.GuildAdd(&discordgo.Guild{ID: tt.channel.GuildID}); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.session.State.ChannelAdd(tt.channel); err != nil {
+				t.Fatal(err)
+			}
+			for _, textChannelID := range tt.direct {
+				b.subscriptions.Add(subscription{VoiceChannelId: "11", TextChannelId: textChannelID, GuildId: tt.channel.GuildID})
+			}
+			for _, textChannelID := range tt.patterns {
+				b.patternSubscriptions["1"] = append(b.patternSubscriptions["1"], patternSubscription{Pattern: "Squad *", TextChannelId: textChannelID, GuildId: "1"})
+			}
+
+			var got []string
+			for _, sub := range b.channelSubscriptions("11") {
+				got = append(got, sub.TextChannelId)
+				if sub.VoiceChannelId != "11" {
+					t.Errorf("subscription of %s is for voice channel %s", sub.TextChannelId, sub.VoiceChannelId)
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("notified %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovePatternSubscriptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		fallback    string
+		patterns    []patternSubscription
+		wantMoved   []string
+		wantDropped []string
+		wantLeft    []patternSubscription
+	}{
+		{
+			name:      "moved to the fallback",
+			fallback:  "general",
+			patterns:  []patternSubscription{{Pattern: "Squad *", TextChannelId: "lfg", GuildId: "1"}, {Pattern: "Duo ?", TextChannelId: "duos", GuildId: "1"}},
+			wantMoved: []string{"Squad *"},
+			wantLeft:  []patternSubscription{{Pattern: "Squad *", TextChannelId: "general", GuildId: "1"}, {Pattern: "Duo ?", TextChannelId: "duos", GuildId: "1"}},
+		},
+		{
+			name:        "removed without a fallback",
+			patterns:    []patternSubscription{{Pattern: "Squad *", TextChannelId: "lfg", GuildId: "1"}},
+			wantDropped: []string{"Squad *"},
+		},
+		{
+			name:        "fallback already subscribed",
+			fallback:    "general",
+			patterns:    []patternSubscription{{Pattern: "Squad *", TextChannelId: "lfg", GuildId: "1"}, {Pattern: "Squad *", TextChannelId: "general", GuildId: "1"}},
+			wantDropped: []string{"Squad *"},
+			wantLeft:    []patternSubscription{{Pattern: "Squad *", TextChannelId: "general", GuildId: "1"}},
+		},
+		{
+			name:     "other text channel",
+			fallback: "general",
+			patterns: []patternSubscription{{Pattern: "Duo ?", TextChannelId: "duos", GuildId: "1"}},
+			wantLeft: []patternSubscription{{Pattern: "Duo ?", TextChannelId: "duos", GuildId: "1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			b.patternSubscriptions["1"] = slices.Clone(tt.patterns)
+
+			moved, dropped := b.movePatternSubscriptions("1", "lfg", tt.fallback)
+			if !slices.Equal(moved, tt.wantMoved) || !slices.Equal(dropped, tt.wantDropped) {
+				t.Errorf("moved %v and dropped %v, want %v and %v", moved, dropped, tt.wantMoved, tt.wantDropped)
+			}
+			if left := b.patternSubscriptions["1"]; !slices.Equal(left, tt.wantLeft) {
+				t.Errorf("pattern subscriptions %v, want %v", left, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// pauseCheckInterval is how often expired pauses are lifted
+const pauseCheckInterval = time.Minute
+
+type (
+	// notificationPause silences all notifications of a guild
+	notificationPause struct {
+		Until  time.Time `json:"until,omitzero"` // zero until resumed by hand
+		UserId string    `json:"user_id"`        // who paused
+	}
+)
+
+// pauseCommands returns the /pause-notifications and /resume-notifications command definitions
+func pauseCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "pause-notifications",
+			DefaultMemberPermissions: &manageServerPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type: discordgo.ApplicationCommandOptionString,
+					Name: "duration",
+				},
+			},
+		},
+		{
+			Name:                     "resume-notifications",
+			DefaultMemberPermissions: &manageServerPermission,
+		},
+	}
+}
+
+// active reports whether the pause still silences notifications
+func (pause notificationPause) active(now time.Time) bool {
+	return pause.Until.IsZero() || pause.Until.After(now)
+}
+
+// notificationsPaused reports whether a guild's notifications are paused
+func (b *Bot) notificationsPaused(guildID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	pause, ok := b.pauses[guildID]
+	return ok && pause.active(time.Now())
+}
+
+func (b *Bot) handlePauseNotifications(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	pause := notificationPause{UserId: interactionUserID(i)}
+	if opt, ok := optionMap(i.ApplicationCommandData().Options)["duration"]; ok {
+		duration, err := time.ParseDuration(opt.StringValue())
+		if err != nil || duration < time.Minute {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "pause.invalid-duration"))
+			return
+		}
+		pause.Until = time.Now().Add(duration)
+	}
+
+	b.mu.Lock()
+	b.pauses[i.GuildID] = pause
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Notifications paused", "audit", true, "guild_id", i.GuildID, "user_id", pause.UserId, "until", pause.Until)
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "pause.paused", b.describePause(i.GuildID, pause)))
+}
+
+func (b *Bot) handleResumeNotifications(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	b.mu.Lock()
+	pause, paused := b.pauses[i.GuildID]
+	delete(b.pauses, i.GuildID)
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	if !paused || !pause.active(time.Now()) {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "pause.not-paused"))
+		return
+	}
+	slog.Info("Notifications resumed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i))
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "pause.resumed"))
+}
+
+// describePause returns how long a pause lasts, for messages
+func (b *Bot) describePause(guildID string, pause notificationPause) string {
+	if pause.Until.IsZero() {
+		return b.t(guildID, "pause.until-resumed")
+	}
+	return b.t(guildID, "pause.until", pause.Until.Unix(), pause.Until.Unix())
+}
+
+// runPauses lifts expired pauses and tells the admin channel
+func (b *Bot) runPauses(ctx context.Context) {
+	ticker := time.NewTicker(pauseCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		resumed := make(map[string]string) // guildID -> admin channelID
+		b.mu.Lock()
+		for guildID, pause := range b.pauses {
+			if pause.active(now) {
+				continue
+			}
+			delete(b.pauses, guildID)
+			b.savePersistedDataAsync()
+			resumed[guildID] = b.adminChannels[guildID]
+		}
+		b.mu.Unlock()
+
+		for guildID, adminChannelID := range resumed {
+			slog.Info("Notification pause ended", "guild_id", guildID)
+			if adminChannelID == "" || !b.claim("pause_end:"+guildID, pauseCheckInterval) {
+				continue
+			}
+			if _, err := b.rest.ChannelMessageSend(adminChannelID, b.presentText(guildID, b.t(guildID, "pause.ended"))); err != nil {
+				slog.Error("Error notifying admin channel", "guild_id", guildID, "channel_id", adminChannelID, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// brokenMissingPermissions marks a subscription paused after a permission error
+const brokenMissingPermissions = "missing permissions"
+
+// isPermissionError reports whether a REST error means the bot lacks access
+// to the target channel
+func isPermissionError(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return false
+	}
+	return restErr.Message.Code == discordgo.ErrCodeMissingPermissions || restErr.Message.Code == discordgo.ErrCodeMissingAccess
+}
+
+// markBroken pauses a subscription until its permissions are restored
+func (b *Bot) markBroken(sub subscription, reason string) {
+	b.updateSubscription(sub.VoiceChannelId, sub.TextChannelId, func(existing *subscription) {
+		existing.Broken = reason
+	})
+	slog.Warn("Paused subscription", "guild_id", sub.GuildId, "voice_channel_id", sub.VoiceChannelId, "channel_id", sub.TextChannelId, "reason", reason)
+}
+
+// requiredPermissions returns the permissions the bot needs to deliver notifications
+func (b *Bot) requiredPermissions() int64 {
+	required := int64(discordgo.PermissionViewChannel | discordgo.PermissionSendMessages)
+	if b.webhookDelivery {
+		required |= discordgo.PermissionManageWebhooks
+	}
+	return required
+}
+
+// recheckBrokenSubscriptions periodically re-enables paused subscriptions once
+// the bot can post in their text channel again
+func (b *Bot) recheckBrokenSubscriptions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// DM subscriptions resume when the user runs /subscribe-dm again
+		broken := b.subscriptions.Filter(func(sub subscription) bool {
+			return sub.Broken != "" && !sub.isDM()
+		})
+
+		required := b.requiredPermissions()
+		for _, sub := range broken {
+			permissions, err := b.rest.UserChannelPermissions(b.session.State.User.ID, sub.TextChannelId)
+			if err != nil || permissions&required != required {
+				continue
+			}
+
+			b.updateSubscription(sub.VoiceChannelId, sub.TextChannelId, func(existing *subscription) {
+				existing.Broken = ""
+			})
+			slog.Info("Permissions restored, resumed subscription", "guild_id", sub.GuildId, "voice_channel_id", sub.VoiceChannelId, "channel_id", sub.TextChannelId)
+		}
+	}
+}
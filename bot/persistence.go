@@ -2,24 +2,152 @@ package bot
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
+)
+
+const (
+	// defaultPersistenceBackups is how many previous versions of the
+	// persistence file NewPersistence keeps
+	defaultPersistenceBackups = 3
+
+	// persistenceBackupInterval is how often the backups are rotated. Saves
+	// in between only replace the file, so the backups span a few hours
+	// rather than a few save intervals.
+	persistenceBackupInterval = time.Hour
 )
 
 type (
 	// PersistentData represents the data structure to be saved to disk
 	PersistentData struct {
-		Subscriptions map[string][]subscription `json:"subscriptions"`
+		Subscriptions        map[string][]subscription        `json:"subscriptions"`
+		AdminChannels        map[string]string                `json:"admin_channels,omitempty"`     // guildID -> channelID
+		Watchlist            map[string][]string              `json:"watchlist,omitempty"`          // guildID -> voiceChannelIDs
+		Templates            map[string]map[string]string     `json:"templates,omitempty"`          // guildID -> event -> template
+		Goals                map[string]*voiceGoal            `json:"goals,omitempty"`              // guildID -> goal
+		Ignored              map[string][]string              `json:"ignored,omitempty"`            // guildID -> userIDs
+		OptOuts              map[string][]string              `json:"opt_outs,omitempty"`           // guildID -> userIDs that are neither announced nor recorded
+		LogChannels          map[string]string                `json:"log_channels,omitempty"`       // guildID -> channelID
+		SentNotifications    []sentNotification               `json:"sent_notifications,omitempty"` // awaiting auto-delete
+		Follows              []follow                         `json:"follows,omitempty"`
+		SubscribeAccess      map[string]subscribeAccess       `json:"subscribe_access,omitempty"`      // guildID -> who may subscribe
+		DebounceStrategies   map[string]string                `json:"debounce_strategies,omitempty"`   // guildID -> strategy
+		PlainText            map[string]bool                  `json:"plain_text,omitempty"`            // guildIDs without emoji
+		Minimal              map[string]bool                  `json:"minimal,omitempty"`               // guildIDs without emoji and markdown, always also in PlainText
+		Timestamps           map[string]bool                  `json:"timestamps,omitempty"`            // guildIDs with Discord timestamps in notifications
+		Tones                map[string]string                `json:"tones,omitempty"`                 // guildID -> tone of built-in messages
+		Languages            map[string]string                `json:"languages,omitempty"`             // guildID -> locale of built-in messages
+		Digests              map[string]voiceDigest           `json:"digests,omitempty"`               // guildID -> digest
+		AFK                  map[string]afkSetting            `json:"afk,omitempty"`                   // guildID -> AFK channel handling
+		FallbackChannels     map[string]string                `json:"fallback_channels,omitempty"`     // guildID -> channelID for subscriptions of deleted channels
+		Pauses               map[string]notificationPause     `json:"pauses,omitempty"`                // guildID -> paused notifications
+		PatternSubscriptions map[string][]patternSubscription `json:"pattern_subscriptions,omitempty"` // guildID -> voice channel name patterns
+		GroupWindows         map[string]map[string]string     `json:"group_windows,omitempty"`         // guildID -> textChannelID -> grouping window
+	}
+
+	// Store loads and saves the bot's persistent state
+	Store interface {
+		Load() (*PersistentData, error)
+		Save(data *PersistentData) error
 	}
 
 	// Persistence handles reading and writing bot state to disk
 	Persistence struct {
 		filePath string
+		backups  int       // previous versions kept as <file>.1 (newest) to <file>.<backups>
+		rotated  time.Time // last rotation, zero until the first save
 		mu       sync.Mutex
 	}
 )
 
+// ensureMaps replaces missing sections with empty maps
+func (data *PersistentData) ensureMaps() {
+	if data.Subscriptions == nil {
+		data.Subscriptions = make(map[string][]subscription)
+	}
+	if data.AdminChannels == nil {
+		data.AdminChannels = make(map[string]string)
+	}
+	if data.Watchlist == nil {
+		data.Watchlist = make(map[string][]string)
+	}
+	if data.Templates == nil {
+		data.Templates = make(map[string]map[string]string)
+	}
+	if data.Goals == nil {
+		data.Goals = make(map[string]*voiceGoal)
+	}
+	if data.OptOuts == nil {
+		data.OptOuts = make(map[string][]string)
+	}
+	if data.Ignored == nil {
+		data.Ignored = make(map[string][]string)
+	}
+	if data.LogChannels == nil {
+		data.LogChannels = make(map[string]string)
+	}
+	if data.SubscribeAccess == nil {
+		data.SubscribeAccess = make(map[string]subscribeAccess)
+	}
+	if data.DebounceStrategies == nil {
+		data.DebounceStrategies = make(map[string]string)
+	}
+	if data.PlainText == nil {
+		data.PlainText = make(map[string]bool)
+	}
+	if data.Minimal == nil {
+		data.Minimal = make(map[string]bool)
+	}
+	if data.Timestamps == nil {
+		data.Timestamps = make(map[string]bool)
+	}
+	if data.Tones == nil {
+		data.Tones = make(map[string]string)
+	}
+	if data.Languages == nil {
+		data.Languages = make(map[string]string)
+	}
+	if data.FallbackChannels == nil {
+		data.FallbackChannels = make(map[string]string)
+	}
+	if data.AFK == nil {
+		data.AFK = make(map[string]afkSetting)
+	}
+	if data.Pauses == nil {
+		data.Pauses = make(map[string]notificationPause)
+	}
+	if data.PatternSubscriptions == nil {
+		data.PatternSubscriptions = make(map[string][]patternSubscription)
+	}
+	if data.GroupWindows == nil {
+		data.GroupWindows = make(map[string]map[string]string)
+	}
+	if data.Digests == nil {
+		data.Digests = make(map[string]voiceDigest)
+	}
+}
+
+// clone returns a deep copy of the data. The bot's sections are live maps
+// and slices guarded by b.mu, so they are copied while it is held and the
+// copy is encoded by the store without it.
+func (data *PersistentData) clone() (*PersistentData, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	copied := &PersistentData{}
+	if err := json.Unmarshal(encoded, copied); err != nil {
+		return nil, err
+	}
+	copied.ensureMaps()
+	return copied, nil
+}
+
 // NewPersistence creates a new persistence handler
 func NewPersistence(filePath string) *Persistence {
 	if filePath == "" {
@@ -27,36 +155,85 @@ func NewPersistence(filePath string) *Persistence {
 	}
 	return &Persistence{
 		filePath: filePath,
+		backups:  defaultPersistenceBackups,
 	}
 }
 
-// Load reads the persistent data from disk
+// backupPath returns the path of the n-th most recent backup
+func (p *Persistence) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", p.filePath, n)
+}
+
+// Load reads the persistent data from disk. If the file is unreadable or
+// corrupt, it is moved aside to <file>.corrupt and the most recent valid
+// backup is loaded instead.
 func (p *Persistence) Load() (*PersistentData, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	data := &PersistentData{
-		Subscriptions: make(map[string][]subscription),
+	data, err := readPersistentData(p.filePath)
+	if err == nil {
+		return data, nil
 	}
-
-	file, err := os.ReadFile(p.filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist yet, return empty data
+	if errors.Is(err, os.ErrNotExist) {
+		// No file yet; a missing primary with backups means a crash mid-rotation
+		if data, backup, ok := p.loadBackup(); ok {
+			slog.Warn("Persistence file is missing, restored the latest backup", "path", p.filePath, "backup", backup)
 			return data, nil
 		}
+		data = &PersistentData{}
+		data.ensureMaps()
+		return data, nil
+	}
+
+	data, backup, ok := p.loadBackup()
+	if !ok {
 		return nil, err
 	}
+	corruptPath := p.filePath + ".corrupt"
+	if renameErr := os.Rename(p.filePath, corruptPath); renameErr != nil {
+		slog.Error("Error moving corrupt persistence file aside", "path", p.filePath, "error", renameErr)
+	}
+	slog.Error("Persistence file is corrupt, restored the latest valid backup", "path", p.filePath, "backup", backup, "corrupt_copy", corruptPath, "error", err)
+	return data, nil
+}
 
-	err = json.Unmarshal(file, data)
+// loadBackup returns the most recent backup that can be read
+func (p *Persistence) loadBackup() (*PersistentData, string, bool) {
+	for n := 1; n <= p.backups; n++ {
+		path := p.backupPath(n)
+		data, err := readPersistentData(path)
+		if err == nil {
+			return data, path, true
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Skipping unreadable persistence backup", "path", path, "error", err)
+		}
+	}
+	return nil, "", false
+}
+
+// readPersistentData reads and decodes a persistence file
+func readPersistentData(path string) (*PersistentData, error) {
+	file, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	data := &PersistentData{}
+	if err := json.Unmarshal(file, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Older data may be missing newer sections
+	data.ensureMaps()
 	return data, nil
 }
 
-// Save writes the persistent data to disk
+// Save writes the persistent data to disk. The data is written to a temporary
+// file that replaces the old one in a single rename, so a crash leaves either
+// the old or the new file. At most once per persistenceBackupInterval, the
+// previous file is kept as the newest backup.
 func (p *Persistence) Save(data *PersistentData) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -66,11 +243,83 @@ func (p *Persistence) Save(data *PersistentData) error {
 		return err
 	}
 
-	err = os.WriteFile(p.filePath, jsonData, 0644)
+	if _, err := os.Stat(p.filePath); err == nil && time.Since(p.rotated) >= persistenceBackupInterval {
+		if err := p.rotateBackups(); err != nil {
+			slog.Warn("Error rotating persistence backups", "path", p.filePath, "error", err)
+		} else {
+			p.rotated = time.Now()
+		}
+	}
+	if err := writeFileAtomic(p.filePath, jsonData); err != nil {
+		return err
+	}
+
+	slog.Debug("Saved subscriptions", "count", len(data.Subscriptions), "path", p.filePath)
+	return nil
+}
+
+// rotateBackups shifts <file>.1 … <file>.N-1 up by one, dropping the oldest,
+// and links the current file as <file>.1. The current file stays in place
+// until the new one replaces it.
+func (p *Persistence) rotateBackups() error {
+	if p.backups == 0 {
+		return nil
+	}
+	if _, err := os.Stat(p.filePath); err != nil {
+		return nil
+	}
+
+	for n := p.backups - 1; n >= 1; n-- {
+		if err := os.Rename(p.backupPath(n), p.backupPath(n+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	newest := p.backupPath(1)
+	os.Remove(newest)
+	if err := os.Link(p.filePath, newest); err == nil {
+		return nil
+	}
+	// Hard links are not supported everywhere, copy instead
+	current, err := os.ReadFile(p.filePath)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(newest, current)
+}
+
+// writeFileAtomic replaces path with data through a synced temporary file in
+// the same directory and a rename
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
 	if err != nil {
 		return err
 	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
 
-	log.Printf("Saved %d subscriptions to %s", len(data.Subscriptions), p.filePath)
+	// Make the rename itself durable; not every platform can sync a directory
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
 	return nil
 }
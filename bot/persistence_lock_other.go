@@ -0,0 +1,9 @@
+//go:build !unix
+
+package bot
+
+// lock is a no-op where flock isn't available. Shards on such platforms need
+// their own PERSISTENCE_FILE each.
+func (p *Persistence) lock() (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package bot
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive lock on <file>.lock, waiting while another process
+// holds it. The lock is released when the process exits, even on a crash.
+func (p *Persistence) lock() (func(), error) {
+	f, err := os.OpenFile(p.filePath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
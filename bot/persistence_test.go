@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func savedAdminChannel(t *testing.T, p *Persistence, channelID string) {
+	t.Helper()
+	if err := p.Save(&PersistentData{AdminChannels: map[string]string{"guild": channelID}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+}
+
+func TestSaveRotatesBackupsHourly(t *testing.T) {
+	p := NewPersistence(filepath.Join(t.TempDir(), "subscriptions.json"))
+
+	savedAdminChannel(t, p, "first")
+	savedAdminChannel(t, p, "second")
+	savedAdminChannel(t, p, "third")
+	if _, err := os.Stat(p.backupPath(2)); !os.IsNotExist(err) {
+		t.Fatalf("saves within an hour rotated the backups twice, %s: %v", p.backupPath(2), err)
+	}
+	backup, err := readPersistentData(p.backupPath(1))
+	if err != nil || backup.AdminChannels["guild"] != "first" {
+		t.Fatalf("newest backup = %v, %v, want the first save", backup, err)
+	}
+
+	p.rotated = time.Now().Add(-persistenceBackupInterval)
+	savedAdminChannel(t, p, "fourth")
+	for n, want := range map[int]string{1: "third", 2: "first"} {
+		backup, err := readPersistentData(p.backupPath(n))
+		if err != nil || backup.AdminChannels["guild"] != want {
+			t.Errorf("backup %d = %v, %v, want %q", n, backup, err, want)
+		}
+	}
+}
+
+func TestLoadFallsBackToBackup(t *testing.T) {
+	p := NewPersistence(filepath.Join(t.TempDir(), "subscriptions.json"))
+	savedAdminChannel(t, p, "first")
+	savedAdminChannel(t, p, "second")
+
+	if err := os.WriteFile(p.filePath, []byte(`{"subscriptions": {`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := data.AdminChannels["guild"]; got != "first" {
+		t.Errorf("loaded admin channel %q, want %q from the backup", got, "first")
+	}
+	if _, err := os.Stat(p.filePath + ".corrupt"); err != nil {
+		t.Errorf("corrupt file was not moved aside: %v", err)
+	}
+}
+
+// TestShardsShareFile saves two shards concurrently to one persistence file,
+// each through its own Persistence like separate processes would
+func TestShardsShareFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	guilds := []string{"4194304", "8388608"} // routed to shard 1 and 0 of 2
+
+	var wg sync.WaitGroup
+	for _, guildID := range guilds {
+		b := newTestBot(t)
+		b.persistence = NewPersistence(path)
+		b.shard = &shardInfo{id: shardFor(guildID, 2), count: 2}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				b.mu.Lock()
+				b.adminChannels[guildID] = strconv.Itoa(i)
+				b.mu.Unlock()
+				if err := b.savePersistedData(); err != nil {
+					t.Errorf("save: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := NewPersistence(path).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, guildID := range guilds {
+		if got := data.AdminChannels[guildID]; got != "49" {
+			t.Errorf("admin channel of guild %s = %q, want the last save's 49", guildID, got)
+		}
+	}
+}
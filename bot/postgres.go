@@ -0,0 +1,943 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// postgresTimeout bounds every load and save against the database
+	postgresTimeout = 30 * time.Second
+
+	// postgresChanges is the notification channel saves are announced on
+	postgresChanges = "voiceactivitybot_changes"
+
+	// postgresRelisten is how long to wait before listening again after the
+	// notification connection failed
+	postgresRelisten = 5 * time.Second
+
+	// postgresMaxPayload keeps notifications below PostgreSQL's 8000 byte limit
+	postgresMaxPayload = 7000
+)
+
+type (
+	// PostgresStore persists bot state in PostgreSQL. Saves upsert and delete
+	// the rows of the guilds that changed and announce them with NOTIFY, so
+	// instances sharing the database reload those guilds.
+	PostgresStore struct {
+		pool     *pgxpool.Pool
+		instance string // tells this instance's changes apart from the others'
+	}
+)
+
+// postgresMigrations are applied in order; never edit an entry once released
+var postgresMigrations = []string{
+	`CREATE TABLE subscriptions (
+		voice_channel_id TEXT NOT NULL,
+		text_channel_id  TEXT NOT NULL,
+		guild_id         TEXT NOT NULL,
+		data             JSONB NOT NULL,
+		PRIMARY KEY (voice_channel_id, text_channel_id)
+	);
+	CREATE INDEX subscriptions_guild_id_idx ON subscriptions (guild_id);
+	CREATE TABLE admin_channels (
+		guild_id   TEXT PRIMARY KEY,
+		channel_id TEXT NOT NULL
+	);
+	CREATE TABLE settings (
+		key   TEXT PRIMARY KEY,
+		value JSONB NOT NULL
+	);`,
+	// Subscriptions and settings get real columns; the JSON tables are moved
+	// aside and copied over by migrateJSONTables
+	`ALTER TABLE subscriptions RENAME TO legacy_subscriptions;
+	ALTER TABLE legacy_subscriptions RENAME CONSTRAINT subscriptions_pkey TO legacy_subscriptions_pkey;
+	ALTER INDEX subscriptions_guild_id_idx RENAME TO legacy_subscriptions_guild_id_idx;
+	ALTER TABLE settings RENAME TO legacy_settings;
+	ALTER TABLE legacy_settings RENAME CONSTRAINT settings_pkey TO legacy_settings_pkey;
+	CREATE TABLE subscriptions (
+		voice_channel_id   TEXT NOT NULL,
+		text_channel_id    TEXT NOT NULL,
+		guild_id           TEXT NOT NULL,
+		user_id            TEXT NOT NULL DEFAULT '',
+		quiet_start        TEXT,
+		quiet_end          TEXT,
+		quiet_timezone     TEXT,
+		quiet_mode         TEXT,
+		webhook_name       TEXT NOT NULL DEFAULT '',
+		webhook_avatar_url TEXT NOT NULL DEFAULT '',
+		broken             TEXT NOT NULL DEFAULT '',
+		status_board       BOOLEAN NOT NULL DEFAULT false,
+		status_message_id  TEXT NOT NULL DEFAULT '',
+		min_users          INTEGER NOT NULL DEFAULT 0 CHECK (min_users >= 0),
+		delete_after       TEXT NOT NULL DEFAULT '',
+		delete_on_leave    BOOLEAN NOT NULL DEFAULT false,
+		style              TEXT NOT NULL DEFAULT '',
+		mention_role_ids   TEXT[] NOT NULL DEFAULT '{}',
+		mention_user_ids   TEXT[] NOT NULL DEFAULT '{}',
+		event_until        TIMESTAMPTZ,
+		events             INTEGER NOT NULL DEFAULT 0,
+		max_length         INTEGER NOT NULL DEFAULT 0 CHECK (max_length >= 0),
+		last_fired_at      TIMESTAMPTZ,
+		label              TEXT NOT NULL DEFAULT '',
+		debounce_strategy  TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (voice_channel_id, text_channel_id),
+		CHECK ((quiet_start IS NULL) = (quiet_end IS NULL))
+	);
+	CREATE INDEX subscriptions_guild_id_idx ON subscriptions (guild_id);
+	CREATE TABLE subscription_externals (
+		voice_channel_id TEXT NOT NULL,
+		text_channel_id  TEXT NOT NULL,
+		guild_id         TEXT NOT NULL,
+		provider         TEXT NOT NULL,
+		target           TEXT NOT NULL,
+		PRIMARY KEY (voice_channel_id, text_channel_id, provider, target),
+		FOREIGN KEY (voice_channel_id, text_channel_id) REFERENCES subscriptions ON DELETE CASCADE
+	);
+	CREATE INDEX subscription_externals_guild_id_idx ON subscription_externals (guild_id);
+	CREATE TABLE guild_settings (
+		guild_id             TEXT PRIMARY KEY,
+		log_channel_id       TEXT,
+		fallback_channel_id  TEXT,
+		subscribe_role_id    TEXT,
+		subscribe_permission TEXT,
+		debounce_strategy    TEXT,
+		plain_text           BOOLEAN,
+		minimal              BOOLEAN,
+		timestamps           BOOLEAN,
+		tone                 TEXT,
+		language             TEXT,
+		afk_notify           BOOLEAN,
+		afk_went_afk         BOOLEAN,
+		paused_by            TEXT,
+		paused_until         TIMESTAMPTZ,
+		goal_hours           INTEGER,
+		goal_period          TEXT CHECK (goal_period IN ('week', 'month')),
+		goal_channel_id      TEXT,
+		goal_achieved_period TEXT,
+		digest_channel_id    TEXT,
+		digest_period        TEXT CHECK (digest_period IN ('daily', 'weekly')),
+		digest_hour          INTEGER CHECK (digest_hour BETWEEN 0 AND 23),
+		digest_last_sent     TIMESTAMPTZ
+	);
+	CREATE TABLE watchlist (
+		guild_id         TEXT NOT NULL,
+		voice_channel_id TEXT NOT NULL,
+		PRIMARY KEY (guild_id, voice_channel_id)
+	);
+	CREATE TABLE ignored_users (
+		guild_id TEXT NOT NULL,
+		user_id  TEXT NOT NULL,
+		PRIMARY KEY (guild_id, user_id)
+	);
+	CREATE TABLE opt_outs (
+		guild_id TEXT NOT NULL,
+		user_id  TEXT NOT NULL,
+		PRIMARY KEY (guild_id, user_id)
+	);
+	CREATE TABLE templates (
+		guild_id TEXT NOT NULL,
+		event    TEXT NOT NULL,
+		template TEXT NOT NULL,
+		PRIMARY KEY (guild_id, event)
+	);
+	CREATE TABLE pattern_subscriptions (
+		guild_id        TEXT NOT NULL,
+		pattern         TEXT NOT NULL,
+		text_channel_id TEXT NOT NULL,
+		PRIMARY KEY (guild_id, pattern, text_channel_id)
+	);
+	CREATE TABLE group_windows (
+		guild_id        TEXT NOT NULL,
+		text_channel_id TEXT NOT NULL,
+		duration        TEXT NOT NULL,
+		PRIMARY KEY (guild_id, text_channel_id)
+	);
+	CREATE TABLE follows (
+		guild_id      TEXT NOT NULL,
+		follower_id   TEXT NOT NULL,
+		target_id     TEXT NOT NULL,
+		expires       TIMESTAMPTZ NOT NULL,
+		last_notified TIMESTAMPTZ,
+		PRIMARY KEY (guild_id, follower_id, target_id)
+	);
+	CREATE TABLE sent_notifications (
+		message_id       TEXT PRIMARY KEY,
+		guild_id         TEXT NOT NULL,
+		text_channel_id  TEXT NOT NULL,
+		voice_channel_id TEXT NOT NULL,
+		user_id          TEXT NOT NULL DEFAULT '',
+		delete_at        TIMESTAMPTZ
+	);
+	CREATE INDEX sent_notifications_guild_id_idx ON sent_notifications (guild_id);`,
+}
+
+// postgresDataMigrations run right after the migration of the same version
+var postgresDataMigrations = map[int]func(ctx context.Context, tx pgx.Tx) error{
+	2: migrateJSONTables,
+}
+
+// postgresGuildTables are the tables holding a guild's rows, children first
+var postgresGuildTables = []string{
+	"subscription_externals", "subscriptions", "admin_channels", "guild_settings", "watchlist", "ignored_users",
+	"opt_outs", "templates", "pattern_subscriptions", "group_windows", "follows", "sent_notifications",
+}
+
+// NewPostgresStore connects to PostgreSQL and applies pending migrations
+func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to database: %w", err)
+	}
+
+	store := &PostgresStore{pool: pool, instance: newInstanceID()}
+	if err := store.migrate(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
+	return store, nil
+}
+
+// migrate applies all migrations newer than the recorded schema version
+func (p *PostgresStore) migrate(ctx context.Context) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize migrations across instances starting at the same time
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(7461626173)`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TIMESTAMPTZ NOT NULL DEFAULT now())`); err != nil {
+		return err
+	}
+
+	var version int
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+
+	for idx := version; idx < len(postgresMigrations); idx++ {
+		if _, err := tx.Exec(ctx, postgresMigrations[idx]); err != nil {
+			return fmt.Errorf("migration %d: %w", idx+1, err)
+		}
+		if migrateData, ok := postgresDataMigrations[idx+1]; ok {
+			if err := migrateData(ctx, tx); err != nil {
+				return fmt.Errorf("migration %d: %w", idx+1, err)
+			}
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, idx+1); err != nil {
+			return err
+		}
+		slog.Info("Applied database migration", "version", idx+1)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// migrateJSONTables copies the JSON subscriptions and settings of the first
+// schema into the tables of the second and drops them
+func migrateJSONTables(ctx context.Context, tx pgx.Tx) error {
+	sections := make(map[string]json.RawMessage)
+	rows, err := tx.Query(ctx, `SELECT key, value FROM legacy_settings`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		sections[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	data := &PersistentData{}
+	if len(sections) > 0 {
+		raw, err := json.Marshal(sections)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, data); err != nil {
+			return err
+		}
+	}
+	data.Subscriptions = make(map[string][]subscription)
+	data.AdminChannels = make(map[string]string)
+	data.ensureMaps()
+
+	rows, err = tx.Query(ctx, `SELECT data FROM legacy_subscriptions`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var sub subscription
+		if err := rows.Scan(&sub); err != nil {
+			rows.Close()
+			return err
+		}
+		data.Subscriptions[sub.VoiceChannelId] = append(data.Subscriptions[sub.VoiceChannelId], sub)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// admin_channels keeps its schema, so its rows are written back unchanged
+	rows, err = tx.Query(ctx, `SELECT guild_id, channel_id FROM admin_channels`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var guildID, channelID string
+		if err := rows.Scan(&guildID, &channelID); err != nil {
+			rows.Close()
+			return err
+		}
+		data.AdminChannels[guildID] = channelID
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	guilds := data.byGuild()
+	if err := writeGuilds(ctx, tx, guilds); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DROP TABLE legacy_subscriptions; DROP TABLE legacy_settings`); err != nil {
+		return err
+	}
+	slog.Info("Moved JSON subscriptions and settings into tables", "guilds", len(guilds))
+	return nil
+}
+
+// Load reads the persistent data of every guild from the database
+func (p *PostgresStore) Load() (*PersistentData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+	return loadPostgresGuilds(ctx, p.pool, nil)
+}
+
+// loadGuilds reads the persistent data of the given guilds
+func (p *PostgresStore) loadGuilds(guildIDs []string) (*PersistentData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+	return loadPostgresGuilds(ctx, p.pool, append([]string{}, guildIDs...))
+}
+
+// postgresQuerier is a pool, connection, or transaction
+type postgresQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// queryGuildRows calls scan for every row of a query over the given guilds,
+// all guilds when guildIDs is nil. The query's only parameter is the guilds.
+func queryGuildRows(ctx context.Context, db postgresQuerier, query string, guildIDs []string, scan func(rows pgx.Rows) error) error {
+	rows, err := db.Query(ctx, query, guildIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// loadPostgresGuilds reads the given guilds, every guild when guildIDs is nil
+func loadPostgresGuilds(ctx context.Context, db postgresQuerier, guildIDs []string) (*PersistentData, error) {
+	data := &PersistentData{}
+	data.ensureMaps()
+	const inGuilds = ` WHERE $1::text[] IS NULL OR guild_id = ANY($1)`
+
+	externals := make(map[[2]string][]externalTarget)
+	err := queryGuildRows(ctx, db, `SELECT voice_channel_id, text_channel_id, provider, target FROM subscription_externals`+inGuilds+` ORDER BY provider, target`, guildIDs, func(rows pgx.Rows) error {
+		var voiceChannelID, textChannelID string
+		var target externalTarget
+		if err := rows.Scan(&voiceChannelID, &textChannelID, &target.Provider, &target.Target); err != nil {
+			return err
+		}
+		key := [2]string{voiceChannelID, textChannelID}
+		externals[key] = append(externals[key], target)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT voice_channel_id, text_channel_id, guild_id, user_id, quiet_start, quiet_end, quiet_timezone, quiet_mode,
+		webhook_name, webhook_avatar_url, broken, status_board, status_message_id, min_users, delete_after, delete_on_leave, style,
+		mention_role_ids, mention_user_ids, event_until, events, max_length, last_fired_at, label, debounce_strategy
+		FROM subscriptions`+inGuilds+` ORDER BY voice_channel_id, text_channel_id`, guildIDs, func(rows pgx.Rows) error {
+		var sub subscription
+		var quietStart, quietEnd, quietTimezone, quietMode *string
+		var eventUntil, lastFiredAt *time.Time
+		var events int
+		err := rows.Scan(&sub.VoiceChannelId, &sub.TextChannelId, &sub.GuildId, &sub.UserId, &quietStart, &quietEnd, &quietTimezone, &quietMode,
+			&sub.WebhookName, &sub.WebhookAvatarURL, &sub.Broken, &sub.StatusBoard, &sub.StatusMessageId, &sub.MinUsers, &sub.DeleteAfter, &sub.DeleteOnLeave, &sub.Style,
+			&sub.MentionRoleIds, &sub.MentionUserIds, &eventUntil, &events, &sub.MaxLength, &lastFiredAt, &sub.Label, &sub.DebounceStrategy)
+		if err != nil {
+			return err
+		}
+		if quietStart != nil && quietEnd != nil {
+			sub.QuietHours = &quietHours{Start: *quietStart, End: *quietEnd, Timezone: deref(quietTimezone), Mode: deref(quietMode)}
+		}
+		sub.Events = eventMask(events)
+		sub.EventUntil = derefTime(eventUntil)
+		sub.LastFiredAt = derefTime(lastFiredAt)
+		sub.Externals = externals[[2]string{sub.VoiceChannelId, sub.TextChannelId}]
+		if len(sub.MentionRoleIds) == 0 {
+			sub.MentionRoleIds = nil
+		}
+		if len(sub.MentionUserIds) == 0 {
+			sub.MentionUserIds = nil
+		}
+		data.Subscriptions[sub.VoiceChannelId] = append(data.Subscriptions[sub.VoiceChannelId], sub)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT guild_id, channel_id FROM admin_channels`+inGuilds, guildIDs, func(rows pgx.Rows) error {
+		var guildID, channelID string
+		if err := rows.Scan(&guildID, &channelID); err != nil {
+			return err
+		}
+		data.AdminChannels[guildID] = channelID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT guild_id, log_channel_id, fallback_channel_id, subscribe_role_id, subscribe_permission, debounce_strategy,
+		plain_text, minimal, timestamps, tone, language, afk_notify, afk_went_afk, paused_by, paused_until,
+		goal_hours, goal_period, goal_channel_id, goal_achieved_period, digest_channel_id, digest_period, digest_hour, digest_last_sent
+		FROM guild_settings`+inGuilds, guildIDs, func(rows pgx.Rows) error {
+		var guildID string
+		var logChannelID, fallbackChannelID, subscribeRoleID, subscribePermission, debounceStrategy, tone, language *string
+		var plainText, minimal, timestamps, afkNotify, afkWentAFK *bool
+		var pausedBy, goalPeriod, goalChannelID, goalAchievedPeriod, digestChannelID, digestPeriod *string
+		var pausedUntil, digestLastSent *time.Time
+		var goalHours, digestHour *int
+		err := rows.Scan(&guildID, &logChannelID, &fallbackChannelID, &subscribeRoleID, &subscribePermission, &debounceStrategy,
+			&plainText, &minimal, &timestamps, &tone, &language, &afkNotify, &afkWentAFK, &pausedBy, &pausedUntil,
+			&goalHours, &goalPeriod, &goalChannelID, &goalAchievedPeriod, &digestChannelID, &digestPeriod, &digestHour, &digestLastSent)
+		if err != nil {
+			return err
+		}
+
+		setIfPresent(data.LogChannels, guildID, logChannelID)
+		setIfPresent(data.FallbackChannels, guildID, fallbackChannelID)
+		setIfPresent(data.DebounceStrategies, guildID, debounceStrategy)
+		setIfPresent(data.PlainText, guildID, plainText)
+		setIfPresent(data.Minimal, guildID, minimal)
+		setIfPresent(data.Timestamps, guildID, timestamps)
+		setIfPresent(data.Tones, guildID, tone)
+		setIfPresent(data.Languages, guildID, language)
+		if subscribePermission != nil {
+			data.SubscribeAccess[guildID] = subscribeAccess{RoleId: deref(subscribeRoleID), Permission: *subscribePermission}
+		}
+		if afkNotify != nil {
+			data.AFK[guildID] = afkSetting{Notify: *afkNotify, WentAFK: deref(afkWentAFK)}
+		}
+		if pausedBy != nil {
+			data.Pauses[guildID] = notificationPause{UserId: *pausedBy, Until: derefTime(pausedUntil)}
+		}
+		if goalHours != nil {
+			data.Goals[guildID] = &voiceGoal{Hours: *goalHours, Period: deref(goalPeriod), ChannelId: deref(goalChannelID), AchievedPeriod: deref(goalAchievedPeriod)}
+		}
+		if digestChannelID != nil {
+			data.Digests[guildID] = voiceDigest{ChannelId: *digestChannelID, Period: deref(digestPeriod), Hour: deref(digestHour), LastSent: derefTime(digestLastSent)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lists := []struct {
+		table, column string
+		into          map[string][]string
+	}{
+		{"watchlist", "voice_channel_id", data.Watchlist},
+		{"ignored_users", "user_id", data.Ignored},
+		{"opt_outs", "user_id", data.OptOuts},
+	}
+	for _, list := range lists {
+		err = queryGuildRows(ctx, db, `SELECT guild_id, `+list.column+` FROM `+list.table+inGuilds+` ORDER BY `+list.column, guildIDs, func(rows pgx.Rows) error {
+			var guildID, value string
+			if err := rows.Scan(&guildID, &value); err != nil {
+				return err
+			}
+			list.into[guildID] = append(list.into[guildID], value)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT guild_id, event, template FROM templates`+inGuilds, guildIDs, func(rows pgx.Rows) error {
+		var guildID, event, template string
+		if err := rows.Scan(&guildID, &event, &template); err != nil {
+			return err
+		}
+		if data.Templates[guildID] == nil {
+			data.Templates[guildID] = make(map[string]string)
+		}
+		data.Templates[guildID][event] = template
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT guild_id, pattern, text_channel_id FROM pattern_subscriptions`+inGuilds+` ORDER BY pattern, text_channel_id`, guildIDs, func(rows pgx.Rows) error {
+		var pattern patternSubscription
+		if err := rows.Scan(&pattern.GuildId, &pattern.Pattern, &pattern.TextChannelId); err != nil {
+			return err
+		}
+		data.PatternSubscriptions[pattern.GuildId] = append(data.PatternSubscriptions[pattern.GuildId], pattern)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT guild_id, text_channel_id, duration FROM group_windows`+inGuilds, guildIDs, func(rows pgx.Rows) error {
+		var guildID, textChannelID, window string
+		if err := rows.Scan(&guildID, &textChannelID, &window); err != nil {
+			return err
+		}
+		if data.GroupWindows[guildID] == nil {
+			data.GroupWindows[guildID] = make(map[string]string)
+		}
+		data.GroupWindows[guildID][textChannelID] = window
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT guild_id, follower_id, target_id, expires, last_notified FROM follows`+inGuilds+` ORDER BY expires`, guildIDs, func(rows pgx.Rows) error {
+		var f follow
+		var lastNotified *time.Time
+		if err := rows.Scan(&f.GuildId, &f.FollowerId, &f.TargetId, &f.Expires, &lastNotified); err != nil {
+			return err
+		}
+		f.Expires = f.Expires.UTC()
+		f.LastNotified = derefTime(lastNotified)
+		data.Follows = append(data.Follows, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryGuildRows(ctx, db, `SELECT message_id, guild_id, text_channel_id, voice_channel_id, user_id, delete_at FROM sent_notifications`+inGuilds+` ORDER BY delete_at`, guildIDs, func(rows pgx.Rows) error {
+		var sent sentNotification
+		var deleteAt *time.Time
+		if err := rows.Scan(&sent.MessageId, &sent.GuildId, &sent.TextChannelId, &sent.VoiceChannelId, &sent.UserId, &deleteAt); err != nil {
+			return err
+		}
+		sent.DeleteAt = derefTime(deleteAt)
+		data.SentNotifications = append(data.SentNotifications, sent)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// saveGuilds writes the rows of each guild in one transaction and announces
+// the guilds to the other instances when it commits
+func (p *PostgresStore) saveGuilds(guilds map[string]*PersistentData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := writeGuilds(ctx, tx, guilds); err != nil {
+		return err
+	}
+
+	change := storeChange{Instance: p.instance, Guilds: slices.Collect(maps.Keys(guilds))}
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	if len(payload) > postgresMaxPayload {
+		// Too many to list; the others reload everything
+		change.Guilds = nil
+		if payload, err = json.Marshal(change); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, postgresChanges, string(payload)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	slog.Debug("Saved guilds to PostgreSQL", "count", len(guilds))
+	return nil
+}
+
+// Save writes the guilds of data and deletes every other guild
+func (p *PostgresStore) Save(data *PersistentData) error {
+	stored, err := p.Load()
+	if err != nil {
+		return err
+	}
+	guilds := data.byGuild()
+	for guildID := range stored.byGuild() {
+		if _, ok := guilds[guildID]; !ok {
+			guilds[guildID] = nil
+		}
+	}
+	return p.saveGuilds(guilds)
+}
+
+// writeGuilds upserts the rows of each guild and deletes its rows that are
+// gone, or all of its rows when its data is nil
+func writeGuilds(ctx context.Context, tx pgx.Tx, guilds map[string]*PersistentData) error {
+	batch := &pgx.Batch{}
+	for guildID, guild := range guilds {
+		if guild == nil {
+			for _, table := range postgresGuildTables {
+				batch.Queue(`DELETE FROM `+table+` WHERE guild_id = $1`, guildID)
+			}
+			continue
+		}
+		queueGuild(batch, guildID, guild)
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// queueGuild queues the upserts and deletes that make a guild's rows match guild
+func queueGuild(batch *pgx.Batch, guildID string, guild *PersistentData) {
+	// Subscriptions, deleting their externals with them
+	var subs []subscription
+	for _, channelSubs := range guild.Subscriptions {
+		subs = append(subs, channelSubs...)
+	}
+	voiceChannelIDs, textChannelIDs := []string{}, []string{}
+	for _, sub := range subs {
+		voiceChannelIDs = append(voiceChannelIDs, sub.VoiceChannelId)
+		textChannelIDs = append(textChannelIDs, sub.TextChannelId)
+	}
+	queueDeleteGone(batch, "subscriptions", guildID, []string{"voice_channel_id", "text_channel_id"}, voiceChannelIDs, textChannelIDs)
+	externalKeys := [][]string{{}, {}, {}, {}}
+	for _, sub := range subs {
+		var quietStart, quietEnd, quietTimezone, quietMode *string
+		if sub.QuietHours != nil {
+			quietStart, quietEnd, quietTimezone, quietMode = &sub.QuietHours.Start, &sub.QuietHours.End, &sub.QuietHours.Timezone, &sub.QuietHours.Mode
+		}
+		batch.Queue(`INSERT INTO subscriptions (voice_channel_id, text_channel_id, guild_id, user_id, quiet_start, quiet_end, quiet_timezone, quiet_mode,
+			webhook_name, webhook_avatar_url, broken, status_board, status_message_id, min_users, delete_after, delete_on_leave, style,
+			mention_role_ids, mention_user_ids, event_until, events, max_length, last_fired_at, label, debounce_strategy)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
+			ON CONFLICT (voice_channel_id, text_channel_id) DO UPDATE SET guild_id = $3, user_id = $4, quiet_start = $5, quiet_end = $6,
+			quiet_timezone = $7, quiet_mode = $8, webhook_name = $9, webhook_avatar_url = $10, broken = $11, status_board = $12,
+			status_message_id = $13, min_users = $14, delete_after = $15, delete_on_leave = $16, style = $17, mention_role_ids = $18,
+			mention_user_ids = $19, event_until = $20, events = $21, max_length = $22, last_fired_at = $23, label = $24,
+			debounce_strategy = $25`,
+			sub.VoiceChannelId, sub.TextChannelId, guildID, sub.UserId, quietStart, quietEnd, quietTimezone, quietMode,
+			sub.WebhookName, sub.WebhookAvatarURL, sub.Broken, sub.StatusBoard, sub.StatusMessageId, sub.MinUsers, sub.DeleteAfter, sub.DeleteOnLeave, sub.Style,
+			nonNil(sub.MentionRoleIds), nonNil(sub.MentionUserIds), nullTime(sub.EventUntil), int(sub.Events), sub.MaxLength, nullTime(sub.LastFiredAt), sub.Label, sub.DebounceStrategy)
+		for _, target := range sub.Externals {
+			externalKeys[0] = append(externalKeys[0], sub.VoiceChannelId)
+			externalKeys[1] = append(externalKeys[1], sub.TextChannelId)
+			externalKeys[2] = append(externalKeys[2], target.Provider)
+			externalKeys[3] = append(externalKeys[3], target.Target)
+		}
+	}
+	queueDeleteGone(batch, "subscription_externals", guildID, []string{"voice_channel_id", "text_channel_id", "provider", "target"}, externalKeys...)
+	for idx := range externalKeys[0] {
+		batch.Queue(`INSERT INTO subscription_externals (voice_channel_id, text_channel_id, guild_id, provider, target) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING`, externalKeys[0][idx], externalKeys[1][idx], guildID, externalKeys[2][idx], externalKeys[3][idx])
+	}
+
+	if channelID, ok := guild.AdminChannels[guildID]; ok {
+		batch.Queue(`INSERT INTO admin_channels (guild_id, channel_id) VALUES ($1, $2) ON CONFLICT (guild_id) DO UPDATE SET channel_id = $2`, guildID, channelID)
+	} else {
+		batch.Queue(`DELETE FROM admin_channels WHERE guild_id = $1`, guildID)
+	}
+
+	queueGuildSettings(batch, guildID, guild)
+
+	lists := []struct {
+		table, column string
+		values        []string
+	}{
+		{"watchlist", "voice_channel_id", guild.Watchlist[guildID]},
+		{"ignored_users", "user_id", guild.Ignored[guildID]},
+		{"opt_outs", "user_id", guild.OptOuts[guildID]},
+	}
+	for _, list := range lists {
+		queueDeleteGone(batch, list.table, guildID, []string{list.column}, nonNil(list.values))
+		for _, value := range list.values {
+			batch.Queue(`INSERT INTO `+list.table+` (guild_id, `+list.column+`) VALUES ($1, $2) ON CONFLICT DO NOTHING`, guildID, value)
+		}
+	}
+
+	events := slices.Collect(maps.Keys(guild.Templates[guildID]))
+	queueDeleteGone(batch, "templates", guildID, []string{"event"}, nonNil(events))
+	for event, template := range guild.Templates[guildID] {
+		batch.Queue(`INSERT INTO templates (guild_id, event, template) VALUES ($1, $2, $3) ON CONFLICT (guild_id, event) DO UPDATE SET template = $3`, guildID, event, template)
+	}
+
+	patterns, patternChannels := []string{}, []string{}
+	for _, pattern := range guild.PatternSubscriptions[guildID] {
+		patterns = append(patterns, pattern.Pattern)
+		patternChannels = append(patternChannels, pattern.TextChannelId)
+	}
+	queueDeleteGone(batch, "pattern_subscriptions", guildID, []string{"pattern", "text_channel_id"}, patterns, patternChannels)
+	for idx := range patterns {
+		batch.Queue(`INSERT INTO pattern_subscriptions (guild_id, pattern, text_channel_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, guildID, patterns[idx], patternChannels[idx])
+	}
+
+	windowChannels := slices.Collect(maps.Keys(guild.GroupWindows[guildID]))
+	queueDeleteGone(batch, "group_windows", guildID, []string{"text_channel_id"}, nonNil(windowChannels))
+	for textChannelID, window := range guild.GroupWindows[guildID] {
+		batch.Queue(`INSERT INTO group_windows (guild_id, text_channel_id, duration) VALUES ($1, $2, $3) ON CONFLICT (guild_id, text_channel_id) DO UPDATE SET duration = $3`, guildID, textChannelID, window)
+	}
+
+	followers, targets := []string{}, []string{}
+	for _, f := range guild.Follows {
+		followers = append(followers, f.FollowerId)
+		targets = append(targets, f.TargetId)
+	}
+	queueDeleteGone(batch, "follows", guildID, []string{"follower_id", "target_id"}, followers, targets)
+	for _, f := range guild.Follows {
+		batch.Queue(`INSERT INTO follows (guild_id, follower_id, target_id, expires, last_notified) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (guild_id, follower_id, target_id) DO UPDATE SET expires = $4, last_notified = $5`,
+			guildID, f.FollowerId, f.TargetId, f.Expires, nullTime(f.LastNotified))
+	}
+
+	messageIDs := []string{}
+	for _, sent := range guild.SentNotifications {
+		messageIDs = append(messageIDs, sent.MessageId)
+	}
+	queueDeleteGone(batch, "sent_notifications", guildID, []string{"message_id"}, messageIDs)
+	for _, sent := range guild.SentNotifications {
+		batch.Queue(`INSERT INTO sent_notifications (message_id, guild_id, text_channel_id, voice_channel_id, user_id, delete_at) VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (message_id) DO UPDATE SET delete_at = $6`,
+			sent.MessageId, guildID, sent.TextChannelId, sent.VoiceChannelId, sent.UserId, nullTime(sent.DeleteAt))
+	}
+}
+
+// queueGuildSettings upserts the guild's settings row, or deletes it when the
+// guild has no settings
+func queueGuildSettings(batch *pgx.Batch, guildID string, guild *PersistentData) {
+	var subscribeRoleID, subscribePermission *string
+	if access, ok := guild.SubscribeAccess[guildID]; ok {
+		subscribeRoleID, subscribePermission = &access.RoleId, &access.Permission
+	}
+	var afkNotify, afkWentAFK *bool
+	if afk, ok := guild.AFK[guildID]; ok {
+		afkNotify, afkWentAFK = &afk.Notify, &afk.WentAFK
+	}
+	var pausedBy *string
+	var pausedUntil *time.Time
+	if pause, ok := guild.Pauses[guildID]; ok {
+		pausedBy, pausedUntil = &pause.UserId, nullTime(pause.Until)
+	}
+	var goalHours *int
+	var goalPeriod, goalChannelID, goalAchievedPeriod *string
+	if goal := guild.Goals[guildID]; goal != nil {
+		goalHours, goalPeriod, goalChannelID, goalAchievedPeriod = &goal.Hours, &goal.Period, &goal.ChannelId, &goal.AchievedPeriod
+	}
+	var digestChannelID, digestPeriod *string
+	var digestHour *int
+	var digestLastSent *time.Time
+	if digest, ok := guild.Digests[guildID]; ok {
+		digestChannelID, digestPeriod, digestHour, digestLastSent = &digest.ChannelId, &digest.Period, &digest.Hour, nullTime(digest.LastSent)
+	}
+
+	values := []any{
+		guildID, present(guild.LogChannels, guildID), present(guild.FallbackChannels, guildID), subscribeRoleID, subscribePermission,
+		present(guild.DebounceStrategies, guildID), present(guild.PlainText, guildID), present(guild.Minimal, guildID),
+		present(guild.Timestamps, guildID), present(guild.Tones, guildID), present(guild.Languages, guildID), afkNotify, afkWentAFK,
+		pausedBy, pausedUntil, goalHours, goalPeriod, goalChannelID, goalAchievedPeriod, digestChannelID, digestPeriod, digestHour, digestLastSent,
+	}
+	if !slices.ContainsFunc(values[1:], func(value any) bool { return !isNilValue(value) }) {
+		batch.Queue(`DELETE FROM guild_settings WHERE guild_id = $1`, guildID)
+		return
+	}
+
+	columns := []string{
+		"guild_id", "log_channel_id", "fallback_channel_id", "subscribe_role_id", "subscribe_permission", "debounce_strategy",
+		"plain_text", "minimal", "timestamps", "tone", "language", "afk_notify", "afk_went_afk", "paused_by", "paused_until",
+		"goal_hours", "goal_period", "goal_channel_id", "goal_achieved_period", "digest_channel_id", "digest_period", "digest_hour", "digest_last_sent",
+	}
+	placeholders := make([]string, len(columns))
+	updates := make([]string, 0, len(columns)-1)
+	for idx, column := range columns {
+		placeholders[idx] = fmt.Sprintf("$%d", idx+1)
+		if idx > 0 {
+			updates = append(updates, fmt.Sprintf("%s = $%d", column, idx+1))
+		}
+	}
+	batch.Queue(`INSERT INTO guild_settings (`+strings.Join(columns, ", ")+`) VALUES (`+strings.Join(placeholders, ", ")+`)
+		ON CONFLICT (guild_id) DO UPDATE SET `+strings.Join(updates, ", "), values...)
+}
+
+// queueDeleteGone deletes the guild's rows of table whose key columns aren't
+// among keys, which hold one slice of values per column
+func queueDeleteGone(batch *pgx.Batch, table, guildID string, columns []string, keys ...[]string) {
+	params := make([]string, len(keys))
+	args := []any{guildID}
+	for idx, values := range keys {
+		params[idx] = fmt.Sprintf("$%d::text[]", idx+2)
+		args = append(args, nonNil(values))
+	}
+	batch.Queue(`DELETE FROM `+table+` WHERE guild_id = $1 AND (`+strings.Join(columns, ", ")+`) NOT IN (SELECT * FROM unnest(`+strings.Join(params, ", ")+`))`, args...)
+}
+
+// watch calls changed with the guilds other instances save, until ctx is
+// done. Notifications sent while disconnected are lost, so every reconnect
+// reports that any guild may have changed.
+func (p *PostgresStore) watch(ctx context.Context, changed func(guildIDs []string)) {
+	for reconnect := false; ; reconnect = true {
+		err := p.listen(ctx, reconnect, changed)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("Lost PostgreSQL change notifications, listening again", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(postgresRelisten):
+		}
+	}
+}
+
+// listen waits for change notifications on a dedicated connection
+func (p *PostgresStore) listen(ctx context.Context, reconnect bool, changed func(guildIDs []string)) error {
+	pooled, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection keeps listening, so it never goes back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, `LISTEN `+postgresChanges); err != nil {
+		return err
+	}
+	if reconnect {
+		changed(nil)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change storeChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			slog.Warn("Ignoring malformed PostgreSQL change", "error", err)
+			continue
+		}
+		if change.Instance != p.instance {
+			changed(change.Guilds)
+		}
+	}
+}
+
+// Close releases the database connections
+func (p *PostgresStore) Close() {
+	p.pool.Close()
+}
+
+// present returns a pointer to a guild's value, nil when it has none
+func present[V any](values map[string]V, guildID string) *V {
+	if value, ok := values[guildID]; ok {
+		return &value
+	}
+	return nil
+}
+
+// setIfPresent stores a nullable column's value
+func setIfPresent[V any](values map[string]V, guildID string, value *V) {
+	if value != nil {
+		values[guildID] = *value
+	}
+}
+
+func deref[V any](value *V) V {
+	var zero V
+	if value == nil {
+		return zero
+	}
+	return *value
+}
+
+func derefTime(value *time.Time) time.Time {
+	if value == nil {
+		return time.Time{}
+	}
+	return value.UTC()
+}
+
+// nullTime stores zero times as NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// nonNil returns an empty slice for nil, which pgx would send as NULL
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// isNilValue reports whether a column value is a nil pointer
+func isNilValue(value any) bool {
+	switch value := value.(type) {
+	case *string:
+		return value == nil
+	case *bool:
+		return value == nil
+	case *int:
+		return value == nil
+	case *time.Time:
+		return value == nil
+	}
+	return value == nil
+}
+
+var _ guildStore = (*PostgresStore)(nil)
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestQueueGuildSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(data *PersistentData)
+		want string // start of the queued statement
+	}{
+		{name: "no settings", set: func(data *PersistentData) {}, want: "DELETE FROM guild_settings"},
+		{name: "false is a setting", set: func(data *PersistentData) { data.PlainText["guild"] = false }, want: "INSERT INTO guild_settings"},
+		{name: "pause", set: func(data *PersistentData) { data.Pauses["guild"] = notificationPause{UserId: "user"} }, want: "INSERT INTO guild_settings"},
+		{name: "other guild's setting", set: func(data *PersistentData) { data.Tones["other"] = "casual" }, want: "DELETE FROM guild_settings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &PersistentData{}
+			data.ensureMaps()
+			tt.set(data)
+
+			batch := &pgx.Batch{}
+			queueGuildSettings(batch, "guild", data)
+			if len(batch.QueuedQueries) != 1 {
+				t.Fatalf("queued %d statements, want 1", len(batch.QueuedQueries))
+			}
+			if got := batch.QueuedQueries[0].SQL; !strings.HasPrefix(got, tt.want) {
+				t.Errorf("queued %q, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueDeleteGone(t *testing.T) {
+	batch := &pgx.Batch{}
+	queueDeleteGone(batch, "follows", "guild", []string{"follower_id", "target_id"}, nil, []string{"target"})
+
+	query := batch.QueuedQueries[0]
+	want := `DELETE FROM follows WHERE guild_id = $1 AND (follower_id, target_id) NOT IN (SELECT * FROM unnest($2::text[], $3::text[]))`
+	if query.SQL != want {
+		t.Errorf("queued %q, want %q", query.SQL, want)
+	}
+	// A nil key list must be an empty array, not NULL, or nothing is deleted
+	if keys, ok := query.Arguments[1].([]string); !ok || keys == nil {
+		t.Errorf("first key list is %#v, want an empty array", query.Arguments[1])
+	}
+}
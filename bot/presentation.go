@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Presentation styles of /config style
+const (
+	presentationEmoji   = "emoji"   // the default
+	presentationPlain   = "plain"   // without emoji
+	presentationMinimal = "minimal" // without emoji and markdown
+)
+
+var (
+	// customEmojiPattern matches Discord custom emoji like <:name:id> and <a:name:id>
+	customEmojiPattern = regexp.MustCompile(`<a?:\w+:\d+>`)
+	// markdownReplacer removes Discord's inline markdown
+	markdownReplacer = strings.NewReplacer("**", "", "__", "", "~~", "", "||", "", "`", "")
+	// markdownLinePattern matches headings, subtext, and quotes at line starts
+	markdownLinePattern = regexp.MustCompile(`(?m)^(#{1,3}|-#|>>>|>) `)
+	// markdownEscaper escapes Discord markdown in names users choose
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "~", `\~`, "|", `\|`, ">", `\>`, "#", `\#`, "\n", " ",
+	)
+)
+
+// stripEmoji removes emoji and pictographic symbols from text and tidies the
+// spacing they leave behind, for screen readers and strict formatting norms
+func stripEmoji(text string) string {
+	text = customEmojiPattern.ReplaceAllString(text, "")
+
+	stripped := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.So, r), unicode.Is(unicode.Sk, r) && r >= 0x1F3FB && r <= 0x1F3FF:
+			return -1
+		case r >= 0x2190 && r <= 0x21FF: // arrows like ↔
+			return -1
+		case r == 0x200D, r == 0x20E3, r >= 0xFE00 && r <= 0xFE0F: // joiners, keycaps, variation selectors
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(stripped, "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripMarkdown removes bold, italics by underscores, strikethrough,
+// spoilers, code, headings, and quotes, leaving mentions and timestamps intact
+func stripMarkdown(text string) string {
+	return markdownLinePattern.ReplaceAllString(markdownReplacer.Replace(text), "")
+}
+
+// escapeMarkdown makes a user's or peer's name show as written instead of
+// formatting the message around it
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// plainText reports whether a guild uses the emoji-free presentation
+func (b *Bot) plainText(guildID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.plainTextGuilds[guildID]
+}
+
+// presentation returns a guild's presentation style
+func (b *Bot) presentation(guildID string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	switch {
+	case b.minimalGuilds[guildID]:
+		return presentationMinimal
+	case b.plainTextGuilds[guildID]:
+		return presentationPlain
+	}
+	return presentationEmoji
+}
+
+// setPresentation changes a guild's presentation style. Minimal implies plain
+// text, so everything that only checks plainText leaves out emoji too.
+// Callers must hold b.mu.
+func (b *Bot) setPresentation(guildID, style string) {
+	delete(b.plainTextGuilds, guildID)
+	delete(b.minimalGuilds, guildID)
+	switch style {
+	case presentationMinimal:
+		b.minimalGuilds[guildID] = true
+		b.plainTextGuilds[guildID] = true
+	case presentationPlain:
+		b.plainTextGuilds[guildID] = true
+	}
+	b.savePersistedDataAsync()
+}
+
+// presenter returns the function that applies a guild's presentation style
+// to text, or nil for the default style
+func (b *Bot) presenter(guildID string) func(string) string {
+	switch b.presentation(guildID) {
+	case presentationMinimal:
+		return func(text string) string { return stripMarkdown(stripEmoji(text)) }
+	case presentationPlain:
+		return stripEmoji
+	}
+	return nil
+}
+
+// presentText applies the guild's presentation mode to message text
+func (b *Bot) presentText(guildID, text string) string {
+	present := b.presenter(guildID)
+	if present == nil {
+		return text
+	}
+	return present(text)
+}
+
+// presentEmbed applies the guild's presentation mode to an embed in place
+func (b *Bot) presentEmbed(guildID string, embed *discordgo.MessageEmbed) *discordgo.MessageEmbed {
+	present := b.presenter(guildID)
+	if embed == nil || present == nil {
+		return embed
+	}
+
+	embed.Title = present(embed.Title)
+	embed.Description = present(embed.Description)
+	for _, field := range embed.Fields {
+		field.Name = present(field.Name)
+		field.Value = present(field.Value)
+	}
+	if embed.Footer != nil {
+		embed.Footer.Text = present(embed.Footer.Text)
+	}
+	return embed
+}
+
+// presentMessage returns a copy of a message in the guild's presentation mode
+func (b *Bot) presentMessage(guildID string, message *discordgo.MessageSend) *discordgo.MessageSend {
+	present := b.presenter(guildID)
+	if present == nil {
+		return message
+	}
+
+	plain := *message
+	plain.Content = present(message.Content)
+	plain.Embeds = make([]*discordgo.MessageEmbed, len(message.Embeds))
+	for idx, embed := range message.Embeds {
+		embedCopy := *embed
+		if embed.Footer != nil {
+			footerCopy := *embed.Footer
+			embedCopy.Footer = &footerCopy
+		}
+		embedCopy.Fields = make([]*discordgo.MessageEmbedField, len(embed.Fields))
+		for fieldIdx, field := range embed.Fields {
+			fieldCopy := *field
+			embedCopy.Fields[fieldIdx] = &fieldCopy
+		}
+		plain.Embeds[idx] = b.presentEmbed(guildID, &embedCopy)
+	}
+	return &plain
+}
+
+// handleConfigEmoji turns the guild's emoji-free presentation on or off
+func (b *Bot) handleConfigEmoji(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	opt, ok := options["enabled"]
+	if !ok {
+		state := b.t(i.GuildID, "style.emoji")
+		if b.plainText(i.GuildID) {
+			state = b.t(i.GuildID, "style.plain")
+		}
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "style.current", state))
+		return
+	}
+
+	enabled := opt.BoolValue()
+	b.mu.Lock()
+	if enabled {
+		b.setPresentation(i.GuildID, presentationEmoji)
+	} else if !b.plainTextGuilds[i.GuildID] {
+		b.setPresentation(i.GuildID, presentationPlain)
+	}
+	b.mu.Unlock()
+
+	slog.Info("Emoji presentation changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "emoji", enabled)
+	if enabled {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "style.emoji-on"))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "style.emoji-off"))
+}
+
+// presentationStyles are the styles of /config style, described by the
+// style.<name> messages
+var presentationStyles = []string{presentationEmoji, presentationPlain, presentationMinimal}
+
+// handleConfigStyle sets the guild's presentation style
+func (b *Bot) handleConfigStyle(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	opt, ok := options["preset"]
+	if !ok {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "style.current", b.t(i.GuildID, "style."+b.presentation(i.GuildID))))
+		return
+	}
+
+	style := opt.StringValue()
+	if !slices.Contains(presentationStyles, style) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "style.unknown"))
+		return
+	}
+	b.mu.Lock()
+	b.setPresentation(i.GuildID, style)
+	b.mu.Unlock()
+
+	slog.Info("Presentation style changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "style", style)
+	// The reply is shown in the new style, as a sample
+	respondEphemeral(s, i.Interaction, b.presentText(i.GuildID, b.t(i.GuildID, "style.set", b.t(i.GuildID, "style."+style))))
+}
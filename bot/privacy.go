@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// privacyCommand returns the /privacy command definition
+func privacyCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name: "privacy",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "opt-out",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "opt-in",
+			},
+		},
+	}
+}
+
+func (b *Bot) handlePrivacy(s DiscordSession, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+
+	if i.ApplicationCommandData().Options[0].Name == "opt-in" {
+		if !b.optIn(i.GuildID, userID) {
+			respondEphemeral(s, i.Interaction, b.t(i.GuildID, "privacy.not-opted-out"))
+			return
+		}
+		slog.Info("User opted in", "audit", true, "guild_id", i.GuildID, "user_id", userID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "privacy.opted-in"))
+		return
+	}
+
+	alreadyOptedOut := !b.optOut(i.GuildID, userID)
+
+	// Delete what was collected before, also when repeating the command
+	sessions, err := b.sessions.forget(func(session voiceSession) bool {
+		return session.GuildId == i.GuildID && session.UserId == userID
+	})
+	if err != nil {
+		slog.Error("Error rewriting session history", "guild_id", i.GuildID, "user_id", userID, "error", err)
+	}
+	follows := b.removeFollowsOf(i.GuildID, userID)
+	slog.Info("User opted out", "audit", true, "guild_id", i.GuildID, "user_id", userID, "deleted_sessions", sessions, "deleted_follows", follows)
+
+	message := b.t(i.GuildID, "privacy.opted-out")
+	if alreadyOptedOut {
+		message = b.t(i.GuildID, "privacy.already-opted-out")
+	}
+	deleted := b.t(i.GuildID, "privacy.deleted", sessions)
+	if follows > 0 {
+		deleted = b.t(i.GuildID, "privacy.deleted-follows", sessions, follows)
+	}
+	respondEphemeral(s, i.Interaction, message+" "+deleted)
+}
+
+// optOut adds a user to the guild's opt-out list and returns whether they were added
+func (b *Bot) optOut(guildID, userID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if slices.Contains(b.optOuts[guildID], userID) {
+		return false
+	}
+	b.optOuts[guildID] = append(b.optOuts[guildID], userID)
+
+	b.savePersistedDataAsync()
+	return true
+}
+
+// optIn removes a user from the guild's opt-out list and returns whether they had opted out
+func (b *Bot) optIn(guildID, userID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	idx := slices.Index(b.optOuts[guildID], userID)
+	if idx < 0 {
+		return false
+	}
+
+	b.optOuts[guildID] = slices.Delete(b.optOuts[guildID], idx, idx+1)
+	if len(b.optOuts[guildID]) == 0 {
+		delete(b.optOuts, guildID)
+	}
+
+	b.savePersistedDataAsync()
+	return true
+}
+
+// isOptedOut reports whether a user's voice activity must be neither
+// announced nor recorded in a guild
+func (b *Bot) isOptedOut(guildID, userID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Contains(b.optOuts[guildID], userID)
+}
+
+// removeFollowsOf deletes the follows whose target is a user and returns how many
+func (b *Bot) removeFollowsOf(guildID, userID string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	before := len(b.follows)
+	b.follows = slices.DeleteFunc(b.follows, func(f follow) bool { return f.GuildId == guildID && f.TargetId == userID })
+	if len(b.follows) == before {
+		return 0
+	}
+	b.savePersistedDataAsync()
+	return before - len(b.follows)
+}
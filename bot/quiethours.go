@@ -0,0 +1,325 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	quietModeSuppress = "suppress"
+	quietModeQueue    = "queue"
+	quietModeSilent   = "silent"
+
+	// maxQueuedNotifications caps how many messages are held per subscription
+	maxQueuedNotifications = 50
+)
+
+type (
+	// quietHours is a daily window during which notifications are held back
+	quietHours struct {
+		Start    string `json:"start"`    // HH:MM
+		End      string `json:"end"`      // HH:MM
+		Timezone string `json:"timezone"` // IANA name, e.g. Europe/Berlin
+		Mode     string `json:"mode"`     // suppress, queue, or silent
+	}
+
+	// quietQueue holds notifications queued during quiet hours for one subscription
+	quietQueue struct {
+		messages []string
+		timer    *time.Timer
+	}
+)
+
+// quietHoursCommand returns the /quiet-hours command definition
+func quietHoursCommand() *discordgo.ApplicationCommand {
+	voiceChannelOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionChannel,
+		Name:         "voice-channel",
+		Required:     true,
+		ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:                     "quiet-hours",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "set",
+				Options: []*discordgo.ApplicationCommandOption{
+					voiceChannelOption,
+					{
+						Type:     discordgo.ApplicationCommandOptionString,
+						Name:     "start",
+						Required: true,
+					},
+					{
+						Type:     discordgo.ApplicationCommandOptionString,
+						Name:     "end",
+						Required: true,
+					},
+					{
+						Type: discordgo.ApplicationCommandOptionString,
+						Name: "timezone",
+					},
+					{
+						Type: discordgo.ApplicationCommandOptionString,
+						Name: "mode",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Value: quietModeSuppress},
+							{Value: quietModeQueue},
+							{Value: quietModeSilent},
+						},
+					},
+				},
+			},
+			{
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Name:    "clear",
+				Options: []*discordgo.ApplicationCommandOption{voiceChannelOption},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleQuietHours(s DiscordSession, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subcommand.Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	var qh *quietHours
+	if subcommand.Name == "set" {
+		qh = &quietHours{
+			Start:    options["start"].StringValue(),
+			End:      options["end"].StringValue(),
+			Timezone: "UTC",
+			Mode:     quietModeSuppress,
+		}
+		if opt, ok := options["timezone"]; ok {
+			qh.Timezone = opt.StringValue()
+		}
+		if opt, ok := options["mode"]; ok {
+			qh.Mode = opt.StringValue()
+		}
+
+		if err := qh.validate(); err != nil {
+			respondWithError(s, i.Interaction, "❌ "+b.errorText(i.GuildID, err))
+			return
+		}
+	}
+
+	found := b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+		sub.QuietHours = qh
+	})
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.not-subscribed", channelName))
+		return
+	}
+
+	responseText := b.t(i.GuildID, "quiethours.removed", channelName)
+	if qh != nil {
+		responseText = b.t(i.GuildID, "quiethours.set", channelName, qh)
+	}
+	respondEphemeral(s, i.Interaction, responseText)
+}
+
+// validate checks the times, timezone, and mode
+func (qh *quietHours) validate() error {
+	if _, err := parseClock(qh.Start); err != nil {
+		return newUserError("quiethours.invalid-start", qh.Start)
+	}
+	if _, err := parseClock(qh.End); err != nil {
+		return newUserError("quiethours.invalid-end", qh.End)
+	}
+	if qh.Start == qh.End {
+		return newUserError("quiethours.same-times")
+	}
+	if _, err := time.LoadLocation(qh.Timezone); err != nil {
+		return newUserError("quiethours.unknown-timezone", qh.Timezone)
+	}
+	if qh.Mode != quietModeSuppress && qh.Mode != quietModeQueue && qh.Mode != quietModeSilent {
+		return newUserError("quiethours.unknown-mode", qh.Mode)
+	}
+	return nil
+}
+
+// String renders the window, e.g. "23:00–08:00 Europe/Berlin (queue)"
+func (qh *quietHours) String() string {
+	return fmt.Sprintf("%s–%s %s (%s)", qh.Start, qh.End, qh.Timezone, qh.Mode)
+}
+
+// activeUntil reports whether the window contains t and, if so, when it ends
+func (qh *quietHours) activeUntil(t time.Time) (bool, time.Time) {
+	loc, err := time.LoadLocation(qh.Timezone)
+	if err != nil {
+		return false, time.Time{}
+	}
+	start, errStart := parseClock(qh.Start)
+	end, errEnd := parseClock(qh.End)
+	if errStart != nil || errEnd != nil {
+		return false, time.Time{}
+	}
+
+	// Compare wall clock times, so days with a DST change work like any other
+	local := t.In(loc)
+	now := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	today := clockOn(local, 0, end)
+	tomorrow := clockOn(local, 1, end)
+
+	if start < end {
+		// Same-day window, e.g. 13:00–15:00
+		if now >= start && now < end {
+			return true, today
+		}
+		return false, time.Time{}
+	}
+
+	// Window wraps midnight, e.g. 23:00–08:00
+	if now >= start {
+		return true, tomorrow
+	}
+	if now < end {
+		return true, today
+	}
+	return false, time.Time{}
+}
+
+// clockOn returns the wall clock time clock on the day days after t's, in t's
+// location
+func clockOn(t time.Time, days int, clock time.Duration) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+days, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+// parseClock parses HH:MM into a duration since midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// holdForQuietHours returns true if the subscription is in quiet hours and the
+// message must not be sent now. Queued messages are posted when the window ends.
+func (b *Bot) holdForQuietHours(s DiscordSession, sub subscription, message string) bool {
+	if sub.QuietHours == nil {
+		return false
+	}
+
+	if sub.QuietHours.Mode == quietModeSilent {
+		return false
+	}
+
+	active, until := sub.QuietHours.activeUntil(time.Now())
+	if !active {
+		return false
+	}
+	if sub.QuietHours.Mode != quietModeQueue {
+		return true
+	}
+
+	key := sub.VoiceChannelId + ":" + sub.TextChannelId
+
+	b.quietMu.Lock()
+	defer b.quietMu.Unlock()
+
+	queue, exists := b.quietQueues[key]
+	if !exists {
+		queue = &quietQueue{}
+		b.quietQueues[key] = queue
+		queue.timer = time.AfterFunc(time.Until(until), func() {
+			b.flushQuietQueue(s, key, false)
+		})
+	}
+	if len(queue.messages) < maxQueuedNotifications {
+		queue.messages = append(queue.messages, message)
+	}
+	return true
+}
+
+// flushQuietQueue posts all notifications queued during quiet hours as one
+// message. The subscription is looked up again, so queues of subscriptions
+// removed, broken or paused in the meantime are dropped. silent sends the
+// message without a notification, for flushing before the window ends.
+func (b *Bot) flushQuietQueue(s DiscordSession, key string, silent bool) {
+	b.quietMu.Lock()
+	queue, exists := b.quietQueues[key]
+	delete(b.quietQueues, key)
+	b.quietMu.Unlock()
+
+	if !exists || len(queue.messages) == 0 {
+		return
+	}
+
+	voiceChannelID, textChannelID, _ := strings.Cut(key, ":")
+	sub, ok := b.subscriptions.Get(voiceChannelID, textChannelID)
+	switch {
+	case !ok:
+		slog.Info("Dropping notifications queued for a removed subscription", "channel_id", textChannelID, "count", len(queue.messages), "event_type", "quiet_hours")
+		return
+	case sub.Broken != "":
+		slog.Info("Dropping notifications queued for a broken subscription", "guild_id", sub.GuildId, "channel_id", textChannelID, "count", len(queue.messages), "event_type", "quiet_hours")
+		return
+	case b.notificationsPaused(sub.GuildId):
+		slog.Info("Dropping notifications queued for a paused guild", "guild_id", sub.GuildId, "channel_id", textChannelID, "count", len(queue.messages), "event_type", "quiet_hours")
+		return
+	}
+
+	message := &discordgo.MessageSend{Content: truncateMessage(b.t(sub.GuildId, "quiethours.queued")+"\n"+strings.Join(queue.messages, "\n"), maxMessageLength)}
+	if silent {
+		message.Flags |= discordgo.MessageFlagsSuppressNotifications
+	}
+	if _, err := b.deliver(s, sub, message); err != nil {
+		slog.Error("Error sending queued notifications", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "event_type", "quiet_hours", "error", err)
+	}
+}
+
+// drainQuietQueues stops the quiet hours timers and posts the queued
+// notifications silently while ctx allows, so a restart doesn't lose them
+func (b *Bot) drainQuietQueues(ctx context.Context) {
+	b.quietMu.Lock()
+	var keys []string
+	for key, queue := range b.quietQueues {
+		if queue.timer.Stop() {
+			keys = append(keys, key)
+		}
+	}
+	b.quietMu.Unlock()
+
+	dropped := 0
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			dropped++
+			continue
+		}
+		b.flushQuietQueue(b.rest, key, true)
+	}
+	if dropped > 0 {
+		slog.Warn("Dropped notifications queued for quiet hours on shutdown", "count", dropped)
+	}
+}
+
+// silentMessage returns the message to post during silent quiet hours: sent
+// with the silent flag and without role or everyone mentions. Outside the
+// window the message is returned unchanged.
+func silentMessage(sub subscription, message *discordgo.MessageSend) *discordgo.MessageSend {
+	if sub.QuietHours == nil || sub.QuietHours.Mode != quietModeSilent {
+		return message
+	}
+	if active, _ := sub.QuietHours.activeUntil(time.Now()); !active {
+		return message
+	}
+
+	silent := *message
+	silent.Flags |= discordgo.MessageFlagsSuppressNotifications
+	silent.AllowedMentions = &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+	}
+	return &silent
+}
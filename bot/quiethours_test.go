@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursActiveUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, berlin)
+		if err != nil {
+			t.Fatalf("parse %s: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name       string
+		start, end string
+		now        time.Time
+		wantActive bool
+		wantUntil  time.Time
+	}{
+		{name: "inside same-day window", start: "13:00", end: "15:00", now: at("2026-06-10 14:00"), wantActive: true, wantUntil: at("2026-06-10 15:00")},
+		{name: "before same-day window", start: "13:00", end: "15:00", now: at("2026-06-10 12:59"), wantActive: false},
+		{name: "end is exclusive", start: "13:00", end: "15:00", now: at("2026-06-10 15:00"), wantActive: false},
+		{name: "wrapping window before midnight", start: "23:00", end: "08:00", now: at("2026-06-10 23:30"), wantActive: true, wantUntil: at("2026-06-11 08:00")},
+		{name: "wrapping window after midnight", start: "23:00", end: "08:00", now: at("2026-06-11 07:59"), wantActive: true, wantUntil: at("2026-06-11 08:00")},
+		{name: "outside wrapping window", start: "23:00", end: "08:00", now: at("2026-06-11 12:00"), wantActive: false},
+		{name: "wrapping into the spring DST change", start: "23:00", end: "08:00", now: at("2026-03-29 01:00"), wantActive: true, wantUntil: at("2026-03-29 08:00")},
+		{name: "after the spring DST change", start: "23:00", end: "08:00", now: at("2026-03-29 07:30"), wantActive: true, wantUntil: at("2026-03-29 08:00")},
+		{name: "same-day window on the autumn DST change", start: "13:00", end: "15:00", now: at("2026-10-25 14:00"), wantActive: true, wantUntil: at("2026-10-25 15:00")},
+		{name: "before the window on the autumn DST change", start: "13:00", end: "15:00", now: at("2026-10-25 12:30"), wantActive: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qh := &quietHours{Start: tt.start, End: tt.end, Timezone: "Europe/Berlin", Mode: quietModeQueue}
+			active, until := qh.activeUntil(tt.now)
+			if active != tt.wantActive {
+				t.Fatalf("active at %s = %v, want %v", tt.now, active, tt.wantActive)
+			}
+			if active && !until.Equal(tt.wantUntil) {
+				t.Errorf("until %s, want %s", until, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestFlushQuietQueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(b *Bot)
+		wantSent bool
+	}{
+		{name: "subscription unchanged", change: func(b *Bot) {}, wantSent: true},
+		{name: "subscription removed", change: func(b *Bot) { b.subscriptions.Remove("voice", "text") }},
+		{name: "subscription broken", change: func(b *Bot) {
+			b.subscriptions.Update("voice", "text", func(sub *subscription) { sub.Broken = "missing permissions" })
+		}},
+		{name: "guild paused", change: func(b *Bot) {
+			b.mu.Lock()
+			b.pauses["guild"] = notificationPause{UserId: "admin"}
+			b.mu.Unlock()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			session := &postingSession{}
+			b.subscriptions.Add(subscription{VoiceChannelId: "voice", TextChannelId: "text", GuildId: "guild"})
+			b.quietQueues["voice:text"] = &quietQueue{messages: []string{"Alice joined"}, timer: time.NewTimer(time.Hour)}
+
+			tt.change(b)
+			b.flushQuietQueue(session, "voice:text", false)
+
+			if sent := len(session.messages("text")) > 0; sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+			if _, ok := b.quietQueues["voice:text"]; ok {
+				t.Error("the queue is still there after flushing")
+			}
+		})
+	}
+}
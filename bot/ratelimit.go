@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// rateLimitWindow is the sliding window the notification limit applies to
+const rateLimitWindow = time.Minute
+
+type (
+	// rateLimiter caps notifications per text channel and merges the overflow
+	// into a single combined message once the window allows another send
+	rateLimiter struct {
+		limit    int
+		channels map[string]*channelLimit // key: textChannelID
+		mu       sync.Mutex
+	}
+
+	channelLimit struct {
+		sent     []time.Time // send times within the window
+		overflow []string
+		sub      subscription // used to deliver the combined message
+		timer    *time.Timer
+	}
+)
+
+func newRateLimiter(limit int) *rateLimiter {
+	return &rateLimiter{
+		limit:    limit,
+		channels: make(map[string]*channelLimit),
+	}
+}
+
+// allow records a send to the subscription's text channel and returns true if
+// it may go out now. Otherwise the message is held and flush is called with
+// all held messages when the window has room again.
+func (r *rateLimiter) allow(sub subscription, message string, flush func(sub subscription, messages []string)) bool {
+	if r.limit <= 0 {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	cl, exists := r.channels[sub.TextChannelId]
+	if !exists {
+		cl = &channelLimit{}
+		r.channels[sub.TextChannelId] = cl
+	}
+	cl.prune(now)
+
+	if len(cl.sent) < r.limit && len(cl.overflow) == 0 {
+		cl.sent = append(cl.sent, now)
+		return true
+	}
+
+	cl.overflow = append(cl.overflow, message)
+	cl.sub = sub
+	if cl.timer == nil {
+		wait := rateLimitWindow
+		if len(cl.sent) > 0 {
+			wait = cl.sent[0].Add(rateLimitWindow).Sub(now)
+		}
+		cl.timer = time.AfterFunc(wait, func() {
+			r.flush(sub.TextChannelId, flush)
+		})
+	}
+	return false
+}
+
+// flush hands the held messages of a channel to be sent as one message
+func (r *rateLimiter) flush(textChannelID string, flush func(sub subscription, messages []string)) {
+	r.mu.Lock()
+	cl, exists := r.channels[textChannelID]
+	if !exists || len(cl.overflow) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	overflow, sub := cl.overflow, cl.sub
+	cl.overflow = nil
+	cl.timer = nil
+	cl.sent = append(cl.sent, time.Now())
+	r.mu.Unlock()
+
+	flush(sub, overflow)
+}
+
+// prune drops send times that have left the window
+func (cl *channelLimit) prune(now time.Time) {
+	idx := 0
+	for idx < len(cl.sent) && now.Sub(cl.sent[idx]) >= rateLimitWindow {
+		idx++
+	}
+	cl.sent = cl.sent[idx:]
+}
+
+// formatOverflow combines held notifications into a single message
+func (b *Bot) formatOverflow(guildID string, messages []string) string {
+	header := b.t(guildID, "ratelimit.overflow", len(messages)) + "\n"
+	return truncateMessage(header+strings.Join(messages, "\n"), maxMessageLength)
+}
+
+// sendOverflow delivers a combined overflow message
+func (b *Bot) sendOverflow(s DiscordSession) func(sub subscription, messages []string) {
+	return func(sub subscription, messages []string) {
+		content := b.formatOverflow(sub.GuildId, messages)
+		if _, err := b.deliver(s, sub, &discordgo.MessageSend{Content: content}); err != nil {
+			slog.Error("Error sending combined notification", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "event_type", "rate_limit_overflow", "error", err)
+		}
+	}
+}
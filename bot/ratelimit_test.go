@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		channels    []string // the text channel of each send
+		aged        bool     // the earlier sends left the window
+		wantAllowed int
+		wantHeld    []string // handed to flush, in order
+	}{
+		{name: "unlimited", channels: []string{"a", "a", "a", "a"}, wantAllowed: 4},
+		{name: "within the limit", limit: 3, channels: []string{"a", "a", "a"}, wantAllowed: 3},
+		{name: "overflow is held", limit: 2, channels: []string{"a", "a", "a", "a", "a"}, wantAllowed: 2, wantHeld: []string{"2", "3", "4"}},
+		{name: "limit per channel", limit: 1, channels: []string{"a", "b", "a"}, wantAllowed: 2, wantHeld: []string{"2"}},
+		{name: "window passed", limit: 2, channels: []string{"a", "a", "a"}, aged: true, wantAllowed: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRateLimiter(tt.limit)
+			var held []string
+			flush := func(sub subscription, messages []string) { held = append(held, messages...) }
+
+			allowed := 0
+			for idx, channel := range tt.channels {
+				if tt.aged {
+					r.mu.Lock()
+					for _, cl := range r.channels {
+						for i := range cl.sent {
+							cl.sent[i] = cl.sent[i].Add(-rateLimitWindow)
+						}
+					}
+					r.mu.Unlock()
+				}
+				if r.allow(subscription{TextChannelId: channel}, string(rune('0'+idx)), flush) {
+					allowed++
+				}
+			}
+			for channel, cl := range r.channels {
+				if cl.timer != nil {
+					cl.timer.Stop()
+				}
+				r.flush(channel, flush)
+			}
+
+			if allowed != tt.wantAllowed {
+				t.Errorf("%d sends allowed, want %d", allowed, tt.wantAllowed)
+			}
+			if !slices.Equal(held, tt.wantHeld) {
+				t.Errorf("held %q, want %q", held, tt.wantHeld)
+			}
+		})
+	}
+}
+
+func TestRateLimiterFlushUsesWindow(t *testing.T) {
+	r := newRateLimiter(1)
+	flush := func(sub subscription, messages []string) {}
+	r.allow(subscription{TextChannelId: "a"}, "first", flush)
+	r.allow(subscription{TextChannelId: "a"}, "second", flush)
+
+	r.mu.Lock()
+	cl := r.channels["a"]
+	// The combined message goes out once the first send leaves the window
+	if cl.timer == nil || !cl.timer.Stop() {
+		t.Error("no flush scheduled for the held message")
+	}
+	r.mu.Unlock()
+
+	r.flush("a", flush)
+	if r.allow(subscription{TextChannelId: "a"}, "third", flush) {
+		t.Error("send allowed right after the combined message, which counts toward the limit")
+	}
+	if timer := r.channels["a"].timer; timer != nil {
+		timer.Stop()
+	}
+}
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// deletedChannel remembers the subscriptions of a deleted voice channel
+	// so they can move to a recreated channel of the same name
+	deletedChannel struct {
+		guildID       string
+		name          string
+		parentID      string
+		subscriptions []subscription
+		watched       bool
+		deletedAt     time.Time
+	}
+)
+
+// rememberDeletedChannel keeps a deleted voice channel's subscriptions for the
+// grace period
+func (b *Bot) rememberDeletedChannel(c *discordgo.Channel, subs []subscription, watched bool) {
+	if b.channelRecreateGrace == 0 || (len(subs) == 0 && !watched) {
+		return
+	}
+
+	b.deletedMu.Lock()
+	defer b.deletedMu.Unlock()
+
+	b.pruneDeletedChannels()
+	b.deletedChannels[c.GuildID] = append(b.deletedChannels[c.GuildID], deletedChannel{
+		guildID:       c.GuildID,
+		name:          c.Name,
+		parentID:      c.ParentID,
+		subscriptions: subs,
+		watched:       watched,
+		deletedAt:     time.Now(),
+	})
+}
+
+// pruneDeletedChannels forgets deleted channels whose grace period is over.
+// Caller must hold deletedMu.
+func (b *Bot) pruneDeletedChannels() {
+	cutoff := time.Now().Add(-b.channelRecreateGrace)
+	for guildID, deleted := range b.deletedChannels {
+		deleted = slices.DeleteFunc(deleted, func(d deletedChannel) bool {
+			return d.deletedAt.Before(cutoff)
+		})
+		if len(deleted) == 0 {
+			delete(b.deletedChannels, guildID)
+		} else {
+			b.deletedChannels[guildID] = deleted
+		}
+	}
+}
+
+// takeDeletedChannel removes and returns the most recently deleted channel
+// with the same name and category as c
+func (b *Bot) takeDeletedChannel(c *discordgo.Channel) (deletedChannel, bool) {
+	b.deletedMu.Lock()
+	defer b.deletedMu.Unlock()
+
+	b.pruneDeletedChannels()
+	deleted := b.deletedChannels[c.GuildID]
+	for idx := len(deleted) - 1; idx >= 0; idx-- {
+		if deleted[idx].name == c.Name && deleted[idx].parentID == c.ParentID {
+			match := deleted[idx]
+			b.deletedChannels[c.GuildID] = slices.Delete(deleted, idx, idx+1)
+			return match, true
+		}
+	}
+	return deletedChannel{}, false
+}
+
+// channelCreate moves the subscriptions of a recently deleted voice channel
+// to a new channel with the same name and category
+func (b *Bot) channelCreate(s DiscordSession, c *discordgo.ChannelCreate) {
+	if b.channelRecreateGrace == 0 {
+		return
+	}
+	if c.Type != discordgo.ChannelTypeGuildVoice && c.Type != discordgo.ChannelTypeGuildStageVoice {
+		return
+	}
+
+	deleted, found := b.takeDeletedChannel(c.Channel)
+	if !found {
+		return
+	}
+
+	var moved []subscription
+	for _, sub := range deleted.subscriptions {
+		sub.VoiceChannelId = c.ID
+		sub.StatusMessageId = "" // the old board was unpinned, a new one is posted on the next update
+		if b.subscriptions.Add(sub) {
+			moved = append(moved, sub)
+		}
+	}
+
+	if deleted.watched {
+		b.addWatch(c.GuildID, c.ID)
+	}
+	slog.Info("Voice channel recreated, moved subscriptions", "audit", true, "guild_id", c.GuildID, "channel_id", c.ID, "subscriptions", len(moved), "watched", deleted.watched)
+
+	message := b.presentText(c.GuildID, b.t(c.GuildID, "recreate.resubscribed", c.Name, c.ID))
+	for _, sub := range moved {
+		if sub.Broken != "" || sub.isDM() {
+			continue
+		}
+		if _, err := s.ChannelMessageSend(sub.TextChannelId, message); err != nil {
+			slog.Error("Error announcing re-subscription", "guild_id", c.GuildID, "channel_id", sub.TextChannelId, "error", err)
+		}
+	}
+}
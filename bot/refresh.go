@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// refreshCommandsCommand returns the /refresh-commands command definition
+func refreshCommandsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "refresh-commands",
+		DefaultMemberPermissions: &manageServerPermission,
+	}
+}
+
+// handleRefreshCommands overwrites the guild's commands with the current
+// definitions, e.g. after an upgrade, without restarting the bot
+func (b *Bot) handleRefreshCommands(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !hasPermission(i, discordgo.PermissionManageServer) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "refresh.manage-server"))
+		return
+	}
+
+	// Bulk overwrite can take longer than the interaction deadline
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	commands, err := s.ApplicationCommandBulkOverwrite(b.session.State.User.ID, i.GuildID, b.localizeCommands(commandDefinitions()))
+	content := b.t(i.GuildID, "refresh.done", len(commands))
+	if err != nil {
+		slog.Error("Error refreshing commands", "guild_id", i.GuildID, "error", err)
+		content = b.t(i.GuildID, "refresh.failed")
+	} else {
+		b.mu.Lock()
+		b.registeredCmdIds[i.GuildID] = commands
+		b.mu.Unlock()
+		slog.Info("Refreshed commands", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "count", len(commands))
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// reminderCheckInterval is how often due reminders are sent
+	reminderCheckInterval = 15 * time.Second
+	// maxRemindersPerUser limits pending reminders per user
+	maxRemindersPerUser = 5
+)
+
+type (
+	// reminder is a pending "remind me" request for a voice session
+	reminder struct {
+		userID         string
+		guildID        string
+		voiceChannelID string
+		due            time.Time
+	}
+
+	// reminderScheduler holds pending reminders until they are due
+	reminderScheduler struct {
+		pending []reminder
+		mu      sync.Mutex
+	}
+)
+
+// add schedules a reminder. It returns false if the user already has one for
+// the channel or too many pending overall.
+func (rs *reminderScheduler) add(r reminder) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	count := 0
+	for _, pending := range rs.pending {
+		if pending.userID != r.userID {
+			continue
+		}
+		if pending.voiceChannelID == r.voiceChannelID {
+			return false
+		}
+		count++
+	}
+	if count >= maxRemindersPerUser {
+		return false
+	}
+	rs.pending = append(rs.pending, r)
+	return true
+}
+
+// takeDue removes and returns the reminders due at now
+func (rs *reminderScheduler) takeDue(now time.Time) []reminder {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var due []reminder
+	rs.pending = slices.DeleteFunc(rs.pending, func(r reminder) bool {
+		if r.due.After(now) {
+			return false
+		}
+		due = append(due, r)
+		return true
+	})
+	return due
+}
+
+// sessionComponents returns the buttons attached to session-start notifications
+func (b *Bot) sessionComponents(guildID, voiceChannelID string) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	if b.threadButton {
+		buttons = append(buttons, b.sessionThreadButton(guildID, voiceChannelID))
+	}
+	if b.reminderDelay > 0 {
+		buttons = append(buttons, b.reminderButton(guildID, voiceChannelID))
+	}
+	if len(buttons) == 0 {
+		return nil
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// reminderButton returns the "Remind me" button of session-start notifications
+func (b *Bot) reminderButton(guildID, voiceChannelID string) discordgo.Button {
+	return discordgo.Button{
+		Label:    b.t(guildID, "reminders.button", formatDuration(b.reminderDelay)),
+		Style:    discordgo.SecondaryButton,
+		CustomID: "remind_me:" + voiceChannelID,
+		Emoji:    &discordgo.ComponentEmoji{Name: "⏰"},
+	}
+}
+
+// handleRemindMeButton schedules a DM for the clicking user
+func (b *Bot) handleRemindMeButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	voiceChannelID := strings.TrimPrefix(i.MessageComponentData().CustomID, "remind_me:")
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	if b.reminderDelay == 0 {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "reminders.off"))
+		return
+	}
+
+	added := b.reminders.add(reminder{
+		userID:         interactionUserID(i),
+		guildID:        i.GuildID,
+		voiceChannelID: voiceChannelID,
+		due:            time.Now().Add(b.reminderDelay),
+	})
+	if !added {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "reminders.already", channelName))
+		return
+	}
+
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "reminders.added", formatDuration(b.reminderDelay), channelName))
+}
+
+// runReminders sends due reminders for sessions that are still going
+func (b *Bot) runReminders(ctx context.Context) {
+	ticker := time.NewTicker(reminderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, r := range b.reminders.takeDue(time.Now()) {
+			b.sendReminder(b.rest, r)
+		}
+	}
+}
+
+// sendReminder DMs a reminder unless the session ended or the user already joined
+func (b *Bot) sendReminder(s DiscordSession, r reminder) {
+	users := b.occupancy.users(r.voiceChannelID)
+	if len(users) == 0 || slices.Contains(users, r.userID) {
+		slog.Debug("Reminder dropped, session over or user present", "guild_id", r.guildID, "channel_id", r.voiceChannelID, "user_id", r.userID)
+		return
+	}
+
+	content := b.presentText(r.guildID, b.t(r.guildID, "reminders.due", r.voiceChannelID, b.getGuildName(s, r.guildID), len(users)))
+	channel, err := s.UserChannelCreate(r.userID)
+	if err == nil {
+		_, err = s.ChannelMessageSend(channel.ID, content)
+	}
+	if err != nil {
+		slog.Warn("Could not DM reminder", "guild_id", r.guildID, "user_id", r.userID, "event_type", "reminder", "error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// voiceChannels remembers the voice channel each user was last seen in,
+	// independent of discordgo's state cache. After a reconnect the cache is
+	// rebuilt and Discord may replay voice states, so BeforeUpdate is missing
+	// or stale; comparing with the last known channel keeps users who never
+	// left from being announced again.
+	voiceChannels struct {
+		guilds map[string]map[string]string // guildID -> userID -> voice channel ID
+		mu     sync.Mutex
+	}
+)
+
+func newVoiceChannels() *voiceChannels {
+	return &voiceChannels{guilds: make(map[string]map[string]string)}
+}
+
+// swap records the channel a user is in now, empty when they left voice, and
+// returns the channel they were in before
+func (v *voiceChannels) swap(guildID, userID, channelID string) (previous string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	users := v.guilds[guildID]
+	previous = users[userID]
+	if channelID == "" {
+		delete(users, userID)
+		return previous
+	}
+	if users == nil {
+		users = make(map[string]string)
+		v.guilds[guildID] = users
+	}
+	users[userID] = channelID
+	return previous
+}
+
+// seedGuild replaces what is known about a guild with the voice states
+// Discord sent in GUILD_CREATE, which arrives again after every reconnect
+// that couldn't resume the session
+func (v *voiceChannels) seedGuild(g *discordgo.Guild) {
+	users := make(map[string]string, len(g.VoiceStates))
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID != "" {
+			users[vs.UserID] = vs.ChannelID
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.guilds[g.ID] = users
+}
+
+// forgetGuild drops a guild the bot was removed from
+func (v *voiceChannels) forgetGuild(guildID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.guilds, guildID)
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// resetBotCommand returns the /reset-bot command definition
+func resetBotCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "reset-bot",
+		DefaultMemberPermissions: &manageServerPermission,
+	}
+}
+
+// handleResetBot asks for confirmation before wiping the guild's data
+func (b *Bot) handleResetBot(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !hasPermission(i, discordgo.PermissionManageServer) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "reset.manage-server"))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.t(i.GuildID, "reset.confirm"),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: b.t(i.GuildID, "reset.confirm-button"), Style: discordgo.DangerButton, CustomID: "reset_bot_confirm"},
+						discordgo.Button{Label: b.t(i.GuildID, "common.cancel"), Style: discordgo.SecondaryButton, CustomID: "reset_bot_cancel"},
+					},
+				},
+			},
+		},
+	})
+}
+
+// handleResetBotButton wipes the guild's data or cancels the reset
+func (b *Bot) handleResetBotButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	content := b.t(i.GuildID, "reset.canceled")
+	if i.MessageComponentData().CustomID == "reset_bot_confirm" {
+		// The confirmation may have been forwarded, check again
+		if !hasPermission(i, discordgo.PermissionManageServer) {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "reset.manage-server"))
+			return
+		}
+		subs, sessions := b.resetGuild(i.GuildID)
+		slog.Info("Guild data reset", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "deleted_subscriptions", subs, "deleted_sessions", sessions)
+		content = b.t(i.GuildID, "reset.done", subs, sessions)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// resetGuild deletes a guild's subscriptions, settings, session history, and
+// statistics, keeping its registered commands. It returns the number of
+// deleted subscriptions and sessions.
+func (b *Bot) resetGuild(guildID string) (int, int) {
+	removed := b.deleteGuildSettings(guildID)
+	b.deliveryStats.forget(removed)
+	b.debounceStats.forget(guildID)
+	b.eventRates.forget(guildID)
+
+	sessions, err := b.sessions.forget(func(session voiceSession) bool { return session.GuildId == guildID })
+	if err != nil {
+		slog.Error("Error rewriting session history", "guild_id", guildID, "error", err)
+	}
+	return len(removed), sessions
+}
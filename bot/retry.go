@@ -0,0 +1,239 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// retryBaseDelay is the wait before the first retry, doubled for each further one
+	retryBaseDelay = 2 * time.Second
+	// maxPendingRetries bounds the retry queue; further failures are dead-lettered at once
+	maxPendingRetries = 1000
+
+	// brokenDeliveryFailures marks a subscription paused after repeated permanent failures
+	brokenDeliveryFailures = "repeated delivery failures"
+)
+
+type (
+	// retryQueue retries notifications that failed with transient errors and
+	// counts permanent failures per subscription
+	retryQueue struct {
+		attempts       int    // deliveries per notification, including the first
+		failureLimit   int    // consecutive permanent failures before a subscription is paused
+		deadLetterFile string // JSON lines file for notifications that were given up, optional
+		baseDelay      time.Duration
+		pending        int
+		failures       map[string]int // voiceChannelID:textChannelID -> consecutive permanent failures
+		mu             sync.Mutex
+		fileMu         sync.Mutex
+	}
+
+	// deadLetter is a notification that could not be delivered
+	deadLetter struct {
+		Time           time.Time `json:"time"`
+		GuildId        string    `json:"guild_id"`
+		VoiceChannelId string    `json:"voice_channel_id"`
+		TextChannelId  string    `json:"text_channel_id"`
+		Attempts       int       `json:"attempts"`
+		Error          string    `json:"error"`
+		Content        string    `json:"content"`
+	}
+)
+
+func newRetryQueue(cfg config.Notifications) *retryQueue {
+	return &retryQueue{
+		attempts:       cfg.Retries + 1,
+		failureLimit:   cfg.FailureLimit,
+		deadLetterFile: cfg.DeadLetterFile,
+		baseDelay:      retryBaseDelay,
+		failures:       make(map[string]int),
+	}
+}
+
+// isTransientError reports whether a failed send may succeed when retried:
+// rate limits, Discord server errors, and network errors
+func isTransientError(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return true
+	}
+	if restErr.Response == nil {
+		return true
+	}
+	return restErr.Response.StatusCode == 429 || restErr.Response.StatusCode >= 500
+}
+
+// backoff returns the wait before the attempt-th delivery, doubling from
+// baseDelay for the second one
+func (q *retryQueue) backoff(attempt int) time.Duration {
+	return q.baseDelay << (attempt - 2)
+}
+
+// delivered resets a subscription's permanent failure count
+func (q *retryQueue) delivered(sub subscription) {
+	q.mu.Lock()
+	delete(q.failures, sub.VoiceChannelId+":"+sub.TextChannelId)
+	q.mu.Unlock()
+}
+
+// failedPermanently counts a permanent failure and reports whether the
+// subscription reached the limit
+func (q *retryQueue) failedPermanently(sub subscription) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := sub.VoiceChannelId + ":" + sub.TextChannelId
+	q.failures[key]++
+	if q.failures[key] < q.failureLimit {
+		return false
+	}
+	delete(q.failures, key)
+	return true
+}
+
+// reserve takes a slot in the retry queue
+func (q *retryQueue) reserve() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.pending >= maxPendingRetries {
+		return false
+	}
+	q.pending++
+	return true
+}
+
+func (q *retryQueue) release() {
+	q.mu.Lock()
+	q.pending--
+	q.mu.Unlock()
+}
+
+// deliveryFailed handles a failed delivery of message, the attempt-th one:
+// transient errors are retried with exponential backoff, everything else is
+// dead-lettered, and repeated permanent failures pause the subscription
+func (b *Bot) deliveryFailed(s DiscordSession, sub subscription, message *discordgo.MessageSend, attempt int, err error) {
+	if isTransientError(err) {
+		if attempt < b.retries.attempts && b.retries.reserve() {
+			delay := b.retries.backoff(attempt + 1)
+			slog.Warn("Retrying notification", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "attempt", attempt, "delay", delay, "error", err)
+			time.AfterFunc(delay, func() {
+				defer b.retries.release()
+				b.retryDelivery(s, sub, message, attempt+1)
+			})
+			return
+		}
+		b.deadLetter(sub, message, attempt, err)
+		return
+	}
+
+	b.deadLetter(sub, message, attempt, err)
+	if isUnknownChannelError(err) && !sub.isDM() {
+		name := sub.TextChannelId
+		if channel, stateErr := b.session.State.Channel(sub.TextChannelId); stateErr == nil {
+			name = channel.Name
+		}
+		b.textChannelGone(s, sub.GuildId, sub.TextChannelId, name)
+		return
+	}
+	if b.retries.failedPermanently(sub) {
+		b.disableSubscription(s, sub, err)
+	}
+}
+
+// retryDelivery sends a notification again unless the bot is stopping or the
+// subscription was removed or paused meanwhile
+func (b *Bot) retryDelivery(s DiscordSession, sub subscription, message *discordgo.MessageSend, attempt int) {
+	if b.ctx.Err() != nil {
+		b.deadLetter(sub, message, attempt-1, errors.New("bot stopped before the retry"))
+		return
+	}
+	current, ok := b.getSubscription(sub.VoiceChannelId, sub.TextChannelId)
+	if !ok && sub.Pattern != "" {
+		current, ok = sub, b.hasPatternSubscription(sub.GuildId, sub.Pattern, sub.TextChannelId)
+	}
+	if !ok || current.Broken != "" {
+		return
+	}
+
+	sent, err := b.deliver(s, current, message)
+	if err != nil {
+		b.deliveryFailed(s, current, message, attempt, err)
+		return
+	}
+	b.retries.delivered(current)
+	if sent != nil {
+		slog.Info("Notification delivered after retrying", "guild_id", current.GuildId, "channel_id", current.TextChannelId, "attempt", attempt)
+		b.recordFired(current)
+		b.trackSent(current, sent, "")
+	}
+}
+
+// deadLetter logs a notification that was given up, and appends it to
+// DEAD_LETTER_FILE if set
+func (b *Bot) deadLetter(sub subscription, message *discordgo.MessageSend, attempts int, err error) {
+	letter := deadLetter{
+		Time:           time.Now().UTC(),
+		GuildId:        sub.GuildId,
+		VoiceChannelId: sub.VoiceChannelId,
+		TextChannelId:  sub.TextChannelId,
+		Attempts:       attempts,
+		Error:          err.Error(),
+		Content:        notificationText(message),
+	}
+	slog.Error("Notification dead-lettered", "dead_letter", true, "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "attempts", attempts, "error", err)
+
+	if b.retries.deadLetterFile == "" {
+		return
+	}
+	line, _ := json.Marshal(letter)
+
+	b.retries.fileMu.Lock()
+	defer b.retries.fileMu.Unlock()
+	f, fileErr := os.OpenFile(b.retries.deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if fileErr != nil {
+		slog.Error("Error opening dead letter file", "path", b.retries.deadLetterFile, "error", fileErr)
+		return
+	}
+	defer f.Close()
+	if _, fileErr := f.Write(append(line, '\n')); fileErr != nil {
+		slog.Error("Error writing dead letter file", "path", b.retries.deadLetterFile, "error", fileErr)
+	}
+}
+
+// disableSubscription pauses a subscription after repeated permanent failures
+// and tells the guild's admin channel
+func (b *Bot) disableSubscription(s DiscordSession, sub subscription, err error) {
+	reason := brokenDeliveryFailures
+	switch {
+	case isPermissionError(err):
+		reason = brokenMissingPermissions
+	case sub.isDM() && isDMClosedError(err):
+		reason = brokenDMsClosed
+	}
+	b.markBroken(sub, reason)
+
+	b.mu.RLock()
+	adminChannelID, hasAdminChannel := b.adminChannels[sub.GuildId]
+	b.mu.RUnlock()
+	if !hasAdminChannel || adminChannelID == sub.TextChannelId {
+		return
+	}
+
+	key := "retry.paused"
+	if sub.isDM() {
+		key = "retry.paused-dm"
+	}
+	content := b.t(sub.GuildId, key, b.getChannelName(s, sub.VoiceChannelId), sub.targetName(s, b), b.retries.failureLimit, err)
+	if _, sendErr := s.ChannelMessageSend(adminChannelID, truncateMessage(content, maxMessageLength)); sendErr != nil {
+		slog.Error("Error notifying admin channel", "guild_id", sub.GuildId, "channel_id", adminChannelID, "error", sendErr)
+	}
+}
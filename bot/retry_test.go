@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// failingSession fails sends with errs in turn, then posts them
+type failingSession struct {
+	postingSession
+	failMu sync.Mutex
+	errs   []error
+}
+
+func (s *failingSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.failMu.Lock()
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		s.failMu.Unlock()
+		return nil, err
+	}
+	s.failMu.Unlock()
+	return s.postingSession.ChannelMessageSendComplex(channelID, data, options...)
+}
+
+func restError(status, code int) error {
+	return &discordgo.RESTError{
+		Response: &http.Response{StatusCode: status},
+		Message:  &discordgo.APIErrorMessage{Code: code},
+	}
+}
+
+// readDeadLetters returns the notifications in a dead letter file
+func readDeadLetters(t *testing.T, path string) []deadLetter {
+	t.Helper()
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var letters []deadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var letter deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			t.Fatalf("dead letter %q: %v", scanner.Text(), err)
+		}
+		letters = append(letters, letter)
+	}
+	return letters
+}
+
+func TestRetryBackoff(t *testing.T) {
+	q := &retryQueue{baseDelay: retryBaseDelay}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 6, want: 32 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := q.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryFailed(t *testing.T) {
+	serverError := restError(http.StatusInternalServerError, 0)
+	tests := []struct {
+		name         string
+		errs         []error
+		retries      int
+		failureLimit int
+		wantSent     int
+		wantAttempts int    // of the dead letter, 0 when none is written
+		wantBroken   string // the subscription's pause reason
+	}{
+		{name: "server error is retried", errs: []error{serverError, serverError}, retries: 2, failureLimit: 3, wantSent: 1},
+		{name: "rate limit is retried", errs: []error{restError(http.StatusTooManyRequests, 0)}, retries: 1, failureLimit: 3, wantSent: 1},
+		{name: "network error is retried", errs: []error{errors.New("connection reset")}, retries: 1, failureLimit: 3, wantSent: 1},
+		{name: "gives up after the retries", errs: []error{serverError, serverError, serverError}, retries: 2, failureLimit: 3, wantAttempts: 3},
+		{name: "no retries", errs: []error{serverError}, failureLimit: 3, wantAttempts: 1},
+		{name: "permanent error is not retried", errs: []error{restError(http.StatusForbidden, discordgo.ErrCodeMissingAccess)}, retries: 4, failureLimit: 3, wantAttempts: 1},
+		{
+			name:         "permanent errors pause the subscription",
+			errs:         []error{restError(http.StatusForbidden, discordgo.ErrCodeMissingAccess)},
+			retries:      4,
+			failureLimit: 1,
+			wantAttempts: 1,
+			wantBroken:   brokenMissingPermissions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			b.retries.attempts = tt.retries + 1
+			b.retries.failureLimit = tt.failureLimit
+			b.retries.baseDelay = time.Millisecond
+			b.retries.deadLetterFile = filepath.Join(t.TempDir(), "dead-letters.jsonl")
+			sub := subscription{VoiceChannelId: "11", TextChannelId: "lobby", GuildId: "1"}
+			b.subscriptions.Add(sub)
+			session := &failingSession{errs: tt.errs}
+
+			message := &discordgo.MessageSend{Content: "Alice joined"}
+			if _, err := b.deliver(session, sub, message); err != nil {
+				b.deliveryFailed(session, sub, message, 1, err)
+			}
+
+			waitFor(t, time.Second, func() bool {
+				return len(session.messages("lobby")) == tt.wantSent && len(readDeadLetters(t, b.retries.deadLetterFile)) == min(tt.wantAttempts, 1)
+			})
+			if letters := readDeadLetters(t, b.retries.deadLetterFile); len(letters) > 0 {
+				letter := letters[0]
+				if letter.Attempts != tt.wantAttempts || letter.Content != "Alice joined" || letter.TextChannelId != "lobby" || letter.Error == "" {
+					t.Errorf("dead letter %+v, want %d attempts of the notification", letter, tt.wantAttempts)
+				}
+			}
+			if current, _ := b.getSubscription("11", "lobby"); current.Broken != tt.wantBroken {
+				t.Errorf("subscription paused for %q, want %q", current.Broken, tt.wantBroken)
+			}
+		})
+	}
+}
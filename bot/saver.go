@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// saver writes the persisted data in the background. Changes only mark
+	// the data dirty; a single writer saves it at most once per interval, so
+	// bursts of changes cost one write and writes never overlap.
+	saver struct {
+		interval time.Duration
+		dirty    atomic.Bool
+		mu       sync.Mutex        // serializes writes, so an older snapshot never overwrites a newer one
+		guilds   map[string]string // guildID -> encoded data as last written or loaded, for guild stores
+	}
+)
+
+func newSaver(interval time.Duration) *saver {
+	return &saver{interval: interval}
+}
+
+// runSaver writes changed data every interval until ctx is done. Stop
+// flushes whatever changed after the last write.
+func (b *Bot) runSaver(ctx context.Context) {
+	ticker := time.NewTicker(b.saver.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.flushSaves(); err != nil {
+				slog.Error("Error saving persisted data", "error", err)
+			}
+		}
+	}
+}
+
+// flushSaves writes the persisted data if it changed since the last write.
+// A failed write leaves the data dirty so the next flush retries it.
+func (b *Bot) flushSaves() error {
+	b.saver.mu.Lock()
+	defer b.saver.mu.Unlock()
+
+	if !b.saver.dirty.Swap(false) {
+		return nil
+	}
+	if err := b.savePersistedData(); err != nil {
+		b.saver.dirty.Store(true)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+// newTestBot creates a bot with in-memory storage that is never connected
+func newTestBot(t *testing.T) *Bot {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Token = "test"
+	cfg.Storage.Backend = storageBackendMemory
+	b, err := NewBot(cfg)
+	if err != nil {
+		t.Fatalf("NewBot: %v", err)
+	}
+	return b
+}
+
+// TestSaveWhileHandlersWrite runs the background writer alongside handlers
+// changing persisted settings. Run with -race.
+func TestSaveWhileHandlersWrite(t *testing.T) {
+	b := newTestBot(t)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			b.savePersistedDataAsync()
+			if err := b.flushSaves(); err != nil {
+				t.Errorf("flushSaves: %v", err)
+				return
+			}
+		}
+	}()
+
+	for n := range 2000 {
+		guildID := fmt.Sprintf("guild-%d", n%50)
+		b.setAdminChannel(guildID, fmt.Sprintf("channel-%d", n))
+		b.removeAdminChannel(guildID)
+		b.setAdminChannel(guildID, "channel")
+	}
+	close(done)
+	wg.Wait()
+
+	if err := b.flushSaves(); err != nil {
+		t.Fatalf("flushSaves: %v", err)
+	}
+	data, err := b.persistence.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(data.AdminChannels) != 50 {
+		t.Errorf("saved %d admin channels, want 50", len(data.AdminChannels))
+	}
+}
@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+type (
+	// Servers are the health endpoint and the HTTP API of a process. All bots
+	// of a multi-bot process are served on the same ports: /healthz and
+	// /metrics cover every bot, and each bot's API is mounted under
+	// /bots/<name>. Dashboards log in through their bot's application, so
+	// each bot serves its own.
+	Servers struct {
+		servers []namedServer
+	}
+
+	namedServer struct {
+		name   string
+		server *http.Server
+	}
+)
+
+// NewServers creates the configured servers for bots
+func NewServers(cfg *config.Config, bots []*Bot) *Servers {
+	s := &Servers{}
+	if cfg.Health.Port != 0 {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /healthz", handleProcessHealthz(bots))
+		mux.HandleFunc("GET /metrics", handleMetrics(bots))
+		s.add("Health endpoint", cfg.Health.Port, mux)
+	}
+
+	if cfg.API.Port != 0 {
+		if len(bots) == 1 {
+			s.add("HTTP API", cfg.API.Port, bots[0].api.handler)
+		} else {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /healthz", handleProcessHealthz(bots))
+			for _, b := range bots {
+				prefix := "/bots/" + b.name
+				mux.Handle(prefix+"/", http.StripPrefix(prefix, b.api.handler))
+			}
+			s.add("HTTP API", cfg.API.Port, mux)
+		}
+	}
+	return s
+}
+
+func (s *Servers) add(name string, port int, handler http.Handler) {
+	s.servers = append(s.servers, namedServer{
+		name: name,
+		server: &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	})
+}
+
+// Start serves in the background
+func (s *Servers) Start() {
+	for _, ns := range s.servers {
+		go func() {
+			slog.Info(ns.name+" listening", "addr", ns.server.Addr)
+			if err := ns.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error(ns.name+" stopped", "error", err)
+			}
+		}()
+	}
+}
+
+// Stop shuts the servers down, waiting for requests in progress until ctx is
+// done
+func (s *Servers) Stop(ctx context.Context) {
+	for _, ns := range s.servers {
+		if err := ns.server.Shutdown(ctx); err != nil {
+			slog.Error("Error shutting down "+ns.name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+// newTestInstances returns a healthy bot alpha and a bot beta failing to save
+func newTestInstances(t *testing.T) (*config.Config, []*Bot) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Health.Port = 8081
+	cfg.API.Port = 8082
+	cfg.API.Token = "secret"
+
+	var bots []*Bot
+	for _, name := range []string{"alpha", "beta"} {
+		instance := *cfg
+		instance.Name = name
+		instance.Token = "test"
+		instance.Storage.Backend = storageBackendMemory
+		b, err := NewBot(&instance)
+		if err != nil {
+			t.Fatalf("NewBot: %v", err)
+		}
+		b.session.DataReady = true
+		b.session.LastHeartbeatAck = time.Now()
+		bots = append(bots, b)
+	}
+	bots[1].lastSaveErr = errors.New("disk full")
+	return cfg, bots
+}
+
+func TestServersReportEveryBot(t *testing.T) {
+	cfg, bots := newTestInstances(t)
+	servers := NewServers(cfg, bots)
+
+	for _, ns := range servers.servers {
+		rec := httptest.NewRecorder()
+		ns.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s /healthz = %d, want %d", ns.name, rec.Code, http.StatusServiceUnavailable)
+		}
+		var status ProcessHealth
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !status.Bots["alpha"].Healthy || status.Bots["beta"].PersistenceError != "disk full" {
+			t.Errorf("%s /healthz = %+v, want alpha healthy and beta failing", ns.name, status)
+		}
+	}
+}
+
+func TestServersRouteAPIByBot(t *testing.T) {
+	cfg, bots := newTestInstances(t)
+	servers := NewServers(cfg, bots)
+	api := servers.servers[1].server.Handler
+
+	tests := []struct {
+		path  string
+		token string
+		want  int
+	}{
+		{"/bots/beta/api/subscriptions", "secret", http.StatusOK},
+		{"/bots/beta/api/subscriptions", "", http.StatusUnauthorized},
+		{"/bots/gamma/api/subscriptions", "secret", http.StatusNotFound},
+		{"/api/subscriptions", "secret", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sessionEventsCommand returns the /session-events command definition
+func sessionEventsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "session-events",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "active",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "empty",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "joins",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "leaves",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "capacity",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "moves",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "mute",
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionBoolean,
+				Name: "stream",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleSessionEvents(s DiscordSession, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	sub, found := b.getSubscription(voiceChannelID, i.ChannelID)
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "subscription.not-found", channelName))
+		return
+	}
+
+	// Without toggles, show the event menu
+	if len(options) == 1 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:    b.t(i.GuildID, "events.question", channelName),
+				Components: b.eventSelectComponents(i.GuildID, i.ChannelID, []string{voiceChannelID}, sub.Events),
+				Flags:      discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	mask := sub.Events.effective()
+	for name, event := range sessionEventOptions {
+		if opt, ok := options[name]; ok {
+			if opt.BoolValue() {
+				mask |= event
+			} else {
+				mask &^= event
+			}
+		}
+	}
+	if mask == 0 {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "events.keep-one"))
+		return
+	}
+
+	var updated subscription
+	b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+		sub.Events = mask
+		updated = *sub
+	})
+
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "events.updated", channelName, strings.Join(b.sessionEventLines(i.GuildID, updated), "\n")))
+}
+
+// sessionEventOptions maps the /session-events options to events
+var sessionEventOptions = map[string]eventMask{
+	"joins":    eventJoin,
+	"leaves":   eventLeave,
+	"moves":    eventMove,
+	"mute":     eventMute,
+	"stream":   eventStream,
+	"active":   eventActive,
+	"empty":    eventEmpty,
+	"capacity": eventFull,
+}
+
+// sessionEventLines describes which events a subscription is notified about
+func (b *Bot) sessionEventLines(guildID string, sub subscription) []string {
+	lines := make([]string, 0, len(eventInfos))
+	for _, info := range eventInfos {
+		lines = append(lines, fmt.Sprintf("%s %s: **%s**", info.emoji, b.t(guildID, "event."+info.name), b.onOff(guildID, sub.Events.has(info.mask))))
+	}
+	return lines
+}
+
+// notifyChannelActive notifies subscriptions that asked to hear when the
+// first person joins an empty voice channel
+func (b *Bot) notifyChannelActive(s DiscordSession, guildID, voiceChannelID, userID, username string) {
+	subs := b.sessionEventSubscriptions(s, voiceChannelID, userID, func(sub subscription) bool { return sub.Events.has(eventActive) })
+	if len(subs) == 0 || !b.claim(fmt.Sprintf("active:%s:%s", voiceChannelID, userID), b.debounceInterval) {
+		return
+	}
+
+	channelName := b.getChannelName(s, voiceChannelID)
+	content := b.renderMessage(guildID, TemplateEvent{
+		Type:      TemplateEventActive,
+		User:      username,
+		UserID:    userID,
+		Channel:   channelName,
+		ChannelID: voiceChannelID,
+		Guild:     b.getGuildName(s, guildID),
+		Count:     1,
+		Time:      time.Now(),
+	})
+
+	for _, sub := range subs {
+		message := &discordgo.MessageSend{Content: content}
+		if !sub.isDM() {
+			message.Components = b.sessionComponents(sub.GuildId, voiceChannelID)
+		}
+		sent := b.deliverNotification(s, sub, message)
+		b.trackSent(sub, sent, "")
+	}
+}
+
+// notifyChannelEmpty notifies subscriptions that asked to hear when the last
+// person leaves a voice channel. since is when the channel became active.
+func (b *Bot) notifyChannelEmpty(s DiscordSession, guildID, voiceChannelID, userID, username string, since time.Time) {
+	subs := b.sessionEventSubscriptions(s, voiceChannelID, userID, func(sub subscription) bool { return sub.Events.has(eventEmpty) })
+	if len(subs) == 0 || !b.claim(fmt.Sprintf("empty:%s:%d", voiceChannelID, since.Unix()), b.debounceInterval) {
+		return
+	}
+
+	now := time.Now()
+	var duration time.Duration
+	if !since.IsZero() {
+		duration = now.Sub(since)
+	}
+
+	channelName := b.getChannelName(s, voiceChannelID)
+	content := b.renderMessage(guildID, TemplateEvent{
+		Type:      TemplateEventEmpty,
+		User:      username,
+		UserID:    userID,
+		Channel:   channelName,
+		ChannelID: voiceChannelID,
+		Guild:     b.getGuildName(s, guildID),
+		Count:     0,
+		Time:      now,
+		Duration:  duration,
+	})
+
+	for _, sub := range subs {
+		sent := b.deliverNotification(s, sub, &discordgo.MessageSend{Content: content})
+		b.trackSent(sub, sent, "")
+	}
+}
+
+// notifyLeave announces a user leaving a voice channel, with how long they
+// stayed, to subscriptions that asked for leaves
+func (b *Bot) notifyLeave(s DiscordSession, guildID, voiceChannelID, userID, username string, stayed time.Duration) {
+	subs := b.sessionEventSubscriptions(s, voiceChannelID, userID, func(sub subscription) bool { return sub.Events.has(eventLeave) })
+	if len(subs) == 0 || !b.claim(fmt.Sprintf("leave:%s:%s", voiceChannelID, userID), b.debounceInterval) {
+		return
+	}
+
+	content := b.renderMessage(guildID, TemplateEvent{
+		Type:      TemplateEventLeave,
+		User:      username,
+		UserID:    userID,
+		Channel:   b.getChannelName(s, voiceChannelID),
+		ChannelID: voiceChannelID,
+		Guild:     b.getGuildName(s, guildID),
+		Count:     b.occupancy.count(voiceChannelID),
+		Time:      time.Now(),
+		Duration:  stayed,
+	})
+
+	for _, sub := range subs {
+		sent := b.deliverNotification(s, sub, &discordgo.MessageSend{Content: content})
+		b.trackSent(sub, sent, "")
+	}
+}
+
+// notifyCapacity notifies subscriptions that asked for user limit alerts when
+// a voice channel's occupancy crosses its user limit, either way
+func (b *Bot) notifyCapacity(s DiscordSession, guildID, voiceChannelID, userID string, previousCount, count int) {
+	if previousCount == count {
+		return
+	}
+	channel, err := b.channel(s, voiceChannelID)
+	if err != nil || channel.UserLimit <= 0 {
+		return
+	}
+
+	var eventType string
+	switch {
+	case previousCount < channel.UserLimit && count >= channel.UserLimit:
+		eventType = TemplateEventFull
+	case previousCount >= channel.UserLimit && count < channel.UserLimit:
+		eventType = TemplateEventFree
+	default:
+		return
+	}
+
+	subs := b.sessionEventSubscriptions(s, voiceChannelID, "", func(sub subscription) bool { return sub.Events.has(eventFull) })
+	if len(subs) == 0 || !b.claim(fmt.Sprintf("%s:%s:%s", eventType, voiceChannelID, userID), b.debounceInterval) {
+		return
+	}
+
+	content := b.renderMessage(guildID, TemplateEvent{
+		Type:      eventType,
+		Channel:   channel.Name,
+		ChannelID: voiceChannelID,
+		Guild:     b.getGuildName(s, guildID),
+		Count:     count,
+		Limit:     channel.UserLimit,
+		Time:      time.Now(),
+	})
+
+	for _, sub := range subs {
+		sent := b.deliverNotification(s, sub, &discordgo.MessageSend{Content: content})
+		b.trackSent(sub, sent, "")
+	}
+}
+
+// sessionEventSubscriptions returns the healthy subscriptions of a voice
+// channel that want an event. DM subscribers are not told about themselves.
+func (b *Bot) sessionEventSubscriptions(s DiscordSession, voiceChannelID, userID string, wants func(subscription) bool) []subscription {
+	var subs []subscription
+	for _, sub := range b.channelSubscriptions(voiceChannelID) {
+		if !wants(sub) || sub.Broken != "" || sub.StatusBoard {
+			continue
+		}
+		if sub.isDM() && (sub.UserId == userID || !b.userCanView(s, sub.UserId, sub.VoiceChannelId)) {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return subs
+}
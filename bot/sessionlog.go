@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// logChannelCommand returns the /log-channel command definition
+func logChannelCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "log-channel",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "set",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "clear",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleLogChannel(s DiscordSession, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	if subcommand.Name == "clear" {
+		b.mu.Lock()
+		_, exists := b.logChannels[i.GuildID]
+		delete(b.logChannels, i.GuildID)
+		b.savePersistedDataAsync()
+		b.mu.Unlock()
+
+		if !exists {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "sessionlog.none"))
+			return
+		}
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "sessionlog.off"))
+		return
+	}
+
+	channelID := i.ChannelID
+	if options := optionMap(subcommand.Options); options["channel"] != nil {
+		channelID = options["channel"].ChannelValue(nil).ID
+	}
+
+	b.mu.Lock()
+	b.logChannels[i.GuildID] = channelID
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Session log channel set", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", channelID)
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "sessionlog.on", channelID))
+}
+
+// postSessionLog appends a completed session to the guild's log channel as one compact line
+func (b *Bot) postSessionLog(s DiscordSession, session voiceSession) {
+	b.mu.RLock()
+	channelID, ok := b.logChannels[session.GuildId]
+	b.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	line := fmt.Sprintf("🎙️ <@%s> · <#%s> · <t:%d:t>–<t:%d:t> · %s",
+		session.UserId, session.ChannelId, session.Start.Unix(), session.End.Unix(), formatDuration(session.End.Sub(session.Start)))
+
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         b.presentText(session.GuildId, line),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Flags:           discordgo.MessageFlagsSuppressNotifications,
+	})
+	if err != nil {
+		slog.Error("Error posting session log", "guild_id", session.GuildId, "channel_id", channelID, "user_id", session.UserId, "event_type", "session_log", "error", err)
+	}
+}
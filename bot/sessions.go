@@ -0,0 +1,342 @@
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// voiceSession is one stay of a user in a voice channel
+	voiceSession struct {
+		GuildId   string    `json:"guild_id"`
+		UserId    string    `json:"user_id"`
+		ChannelId string    `json:"channel_id"`
+		Start     time.Time `json:"start"`
+		End       time.Time `json:"end,omitzero"` // zero while the session is active
+	}
+
+	// sessionStore records voice sessions. Completed sessions are appended to a
+	// JSON lines file so history survives restarts; active sessions are kept in
+	// a file next to it so a restart doesn't reset how long users have been in voice.
+	sessionStore struct {
+		filePath  string
+		active    map[string]*voiceSession // key: userID:channelID
+		completed []voiceSession
+		mu        sync.RWMutex
+		activeMu  sync.Mutex // serializes writes of the active sessions file
+		historyMu sync.Mutex // serializes writes of the history file
+	}
+)
+
+// newSessionStore creates the session store at the configured sessions file
+// and loads its history. With in-memory storage, sessions are never written
+// to disk.
+func newSessionStore(storage config.Storage) *sessionStore {
+	filePath := storage.SessionsFile
+	if storage.Backend == storageBackendMemory {
+		filePath = ""
+	}
+
+	store := &sessionStore{
+		filePath: filePath,
+		active:   make(map[string]*voiceSession),
+	}
+	if err := store.load(); err != nil {
+		slog.Warn("Failed to load session history", "error", err)
+	}
+	if err := store.loadActive(); err != nil {
+		slog.Warn("Failed to load active voice sessions", "error", err)
+	}
+	return store
+}
+
+// activeFilePath returns the file active sessions are kept in, e.g.
+// sessions-active.json next to sessions.jsonl
+func (st *sessionStore) activeFilePath() string {
+	return strings.TrimSuffix(st.filePath, filepath.Ext(st.filePath)) + "-active.json"
+}
+
+// loadActive restores the sessions that were active when the bot stopped.
+// Sessions of users who left in the meantime are dropped by retain.
+func (st *sessionStore) loadActive() error {
+	if st.filePath == "" {
+		return nil
+	}
+
+	file, err := os.ReadFile(st.activeFilePath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var sessions []voiceSession
+	if err := json.Unmarshal(file, &sessions); err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		st.active[session.UserId+":"+session.ChannelId] = &session
+	}
+	return nil
+}
+
+// saveActive writes the active sessions file
+func (st *sessionStore) saveActive() {
+	if st.filePath == "" {
+		return
+	}
+
+	st.activeMu.Lock()
+	defer st.activeMu.Unlock()
+
+	st.mu.RLock()
+	sessions := make([]voiceSession, 0, len(st.active))
+	for _, session := range st.active {
+		sessions = append(sessions, *session)
+	}
+	st.mu.RUnlock()
+
+	data, err := json.Marshal(sessions)
+	if err == nil {
+		err = writeFileAtomic(st.activeFilePath(), data)
+	}
+	if err != nil {
+		slog.Error("Error saving active voice sessions", "error", err)
+	}
+}
+
+// retain drops the restored active sessions of a guild whose user is no
+// longer in that channel. present holds userID:channelID keys.
+func (st *sessionStore) retain(guildID string, present map[string]bool) {
+	st.mu.Lock()
+	dropped := 0
+	for key, session := range st.active {
+		if session.GuildId == guildID && !present[key] {
+			delete(st.active, key)
+			dropped++
+		}
+	}
+	st.mu.Unlock()
+
+	if dropped > 0 {
+		st.saveActive()
+	}
+}
+
+// load reads completed sessions from disk, skipping corrupt lines
+func (st *sessionStore) load() error {
+	if st.filePath == "" {
+		return nil
+	}
+
+	file, err := os.Open(st.filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var session voiceSession
+		if err := json.Unmarshal(scanner.Bytes(), &session); err != nil {
+			continue
+		}
+		st.completed = append(st.completed, session)
+	}
+
+	slog.Info("Loaded voice sessions", "count", len(st.completed))
+	return scanner.Err()
+}
+
+// start begins a session for a user in a channel
+func (st *sessionStore) start(guildID, userID, channelID string, at time.Time) {
+	st.mu.Lock()
+
+	key := userID + ":" + channelID
+	if _, exists := st.active[key]; exists {
+		st.mu.Unlock()
+		return
+	}
+	st.active[key] = &voiceSession{GuildId: guildID, UserId: userID, ChannelId: channelID, Start: at}
+	st.mu.Unlock()
+
+	st.saveActive()
+}
+
+// end completes a user's session in a channel and returns it
+func (st *sessionStore) end(userID, channelID string, at time.Time) (voiceSession, bool) {
+	st.mu.Lock()
+	key := userID + ":" + channelID
+	active, exists := st.active[key]
+	if !exists {
+		st.mu.Unlock()
+		return voiceSession{}, false
+	}
+	delete(st.active, key)
+
+	session := *active
+	session.End = at
+	st.completed = append(st.completed, session)
+	st.mu.Unlock()
+
+	if err := st.append(session); err != nil {
+		slog.Error("Error saving voice session", "guild_id", session.GuildId, "channel_id", session.ChannelId, "user_id", session.UserId, "error", err)
+	}
+	st.saveActive()
+	return session, true
+}
+
+// activeSince returns when a user's active session in a channel started
+func (st *sessionStore) activeSince(userID, channelID string) (time.Time, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	active, exists := st.active[userID+":"+channelID]
+	if !exists {
+		return time.Time{}, false
+	}
+	return active.Start, true
+}
+
+// append writes a completed session to the history file
+func (st *sessionStore) append(session voiceSession) error {
+	if st.filePath == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	st.historyMu.Lock()
+	defer st.historyMu.Unlock()
+
+	file, err := os.OpenFile(st.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// forget deletes the completed and active sessions match accepts and
+// rewrites the history file without them. It returns the number of deleted
+// sessions.
+func (st *sessionStore) forget(match func(session voiceSession) bool) (int, error) {
+	st.historyMu.Lock()
+	defer st.historyMu.Unlock()
+
+	st.mu.Lock()
+	before := len(st.completed) + len(st.active)
+	st.completed = slices.DeleteFunc(st.completed, match)
+	maps.DeleteFunc(st.active, func(_ string, session *voiceSession) bool { return match(*session) })
+	removed := before - len(st.completed) - len(st.active)
+	var history []byte
+	for _, session := range st.completed {
+		if line, err := json.Marshal(session); err == nil {
+			history = append(append(history, line...), '\n')
+		}
+	}
+	st.mu.Unlock()
+
+	if removed == 0 || st.filePath == "" {
+		return removed, nil
+	}
+	st.saveActive()
+	return removed, writeFileAtomic(st.filePath, history)
+}
+
+// sessions returns the completed and active sessions of a guild that overlap
+// [from, to). Active sessions are returned with End set to to.
+func (st *sessionStore) sessions(guildID string, from, to time.Time) []voiceSession {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	var result []voiceSession
+	for _, session := range st.completed {
+		if session.GuildId == guildID && session.Start.Before(to) && session.End.After(from) {
+			result = append(result, session)
+		}
+	}
+	for _, active := range st.active {
+		if active.GuildId == guildID && active.Start.Before(to) {
+			session := *active
+			session.End = to
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+// overlap returns how much of the session falls into [from, to)
+func (session voiceSession) overlap(from, to time.Time) time.Duration {
+	start, end := session.Start, session.End
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// totalVoiceTime sums the voice time of a guild within [from, to)
+func (st *sessionStore) totalVoiceTime(guildID string, from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, session := range st.sessions(guildID, from, to) {
+		total += session.overlap(from, to)
+	}
+	return total
+}
+
+// trackSession ends the session in the channel the user left and starts one in
+// the channel they joined
+func (b *Bot) trackSession(s DiscordSession, event VoiceEvent) {
+	if leftChannelID := event.Left(); leftChannelID != "" {
+		if ended, ok := b.sessions.end(event.UserId, leftChannelID, event.At); ok {
+			b.postSessionLog(s, ended)
+			b.checkGoal(s, event.GuildId)
+		}
+	}
+	if joinedChannelID := event.Joined(); joinedChannelID != "" {
+		b.sessions.start(event.GuildId, event.UserId, joinedChannelID, event.At)
+	}
+}
+
+// seedSessions starts sessions for users already in voice when a guild becomes
+// available. Sessions restored from before a restart keep their start time.
+func (b *Bot) seedSessions(g *discordgo.Guild) {
+	now := time.Now()
+	present := make(map[string]bool)
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == "" || (vs.Member != nil && vs.Member.User != nil && vs.Member.User.Bot) || b.isOptedOut(g.ID, vs.UserID) {
+			continue
+		}
+		present[vs.UserID+":"+vs.ChannelID] = true
+		b.sessions.start(g.ID, vs.UserID, vs.ChannelID, now)
+	}
+	b.sessions.retain(g.ID, present)
+}
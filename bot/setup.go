@@ -0,0 +1,148 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminChannelPermissions are the permissions the bot needs in the admin
+// channel, named by the permission.<name> messages
+var adminChannelPermissions = []struct {
+	name       string
+	permission int64
+}{
+	{"view-channel", discordgo.PermissionViewChannel},
+	{"send-messages", discordgo.PermissionSendMessages},
+	{"embed-links", discordgo.PermissionEmbedLinks},
+}
+
+// guildHasSettings reports whether anything has been configured for a guild yet
+func (b *Bot) guildHasSettings(guildID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if _, ok := b.adminChannels[guildID]; ok {
+		return true
+	}
+	if len(b.watchlist[guildID]) > 0 || len(b.templates[guildID]) > 0 || len(b.ignored[guildID]) > 0 {
+		return true
+	}
+	if _, ok := b.goals[guildID]; ok {
+		return true
+	}
+	if _, ok := b.logChannels[guildID]; ok {
+		return true
+	}
+	if _, ok := b.fallbackChannels[guildID]; ok {
+		return true
+	}
+	if _, ok := b.subscribeAccess[guildID]; ok {
+		return true
+	}
+	if _, ok := b.debounceStrategies[guildID]; ok {
+		return true
+	}
+	if b.plainTextGuilds[guildID] {
+		return true
+	}
+	if b.timestampGuilds[guildID] {
+		return true
+	}
+	if _, ok := b.tones[guildID]; ok {
+		return true
+	}
+	if _, ok := b.languages[guildID]; ok {
+		return true
+	}
+	if _, ok := b.afkSettings[guildID]; ok {
+		return true
+	}
+	if _, ok := b.digests[guildID]; ok {
+		return true
+	}
+	if len(b.patternSubscriptions[guildID]) > 0 {
+		return true
+	}
+	if len(b.groupWindows[guildID]) > 0 {
+		return true
+	}
+	return len(b.subscriptions.Guild(guildID)) > 0
+}
+
+// respondWithSetup starts the first-run setup, asking for the admin channel
+func (b *Bot) respondWithSetup(s DiscordSession, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.t(i.GuildID, "setup.welcome"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.SelectMenu{
+							MenuType:     discordgo.ChannelSelectMenu,
+							CustomID:     "setup_admin_channel",
+							Placeholder:  b.t(i.GuildID, "setup.placeholder"),
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    b.t(i.GuildID, "setup.use-current"),
+							Style:    discordgo.PrimaryButton,
+							CustomID: "setup_use_current",
+						},
+					},
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleSetupAdminChannel stores the admin channel picked in the setup flow
+// and reports whether the bot has the permissions it needs there
+func (b *Bot) handleSetupAdminChannel(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !hasPermission(i, discordgo.PermissionManageServer) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "setup.manage-server"))
+		return
+	}
+
+	channelID := i.ChannelID
+	if values := i.MessageComponentData().Values; len(values) > 0 {
+		channelID = values[0]
+	}
+
+	b.setAdminChannel(i.GuildID, channelID)
+	slog.Info("Admin channel set", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "channel_id", channelID, "setup", true)
+
+	var checklist []string
+	missing := false
+	permissions, err := s.UserChannelPermissions(b.session.State.User.ID, channelID)
+	for _, required := range adminChannelPermissions {
+		name := b.t(i.GuildID, "permission."+required.name)
+		if err == nil && permissions&required.permission == required.permission {
+			checklist = append(checklist, "✅ "+name)
+		} else {
+			checklist = append(checklist, b.t(i.GuildID, "setup.permission-missing", name))
+			missing = true
+		}
+	}
+
+	next := b.t(i.GuildID, "setup.next", channelID)
+	if missing {
+		next = b.t(i.GuildID, "setup.next-missing", channelID)
+	}
+	content := b.t(i.GuildID, "setup.done", channelID, strings.Join(checklist, "\n"), next)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// shardInfo describes the gateway shard this process runs. Each shard
+	// only handles the guilds Discord routes to it and only loads and saves
+	// their part of the shared state.
+	shardInfo struct {
+		id    int
+		count int
+		mu    sync.Mutex // serializes read-modify-write saves
+	}
+
+	// lockingStore is a Store that several processes may share. lock keeps
+	// the other processes' read-modify-write saves out until unlock is called.
+	lockingStore interface {
+		lock() (unlock func(), err error)
+	}
+)
+
+// configureSharding sets up the session's shard. A shard count of auto uses
+// the count Discord recommends. Returns nil when the bot runs unsharded.
+func configureSharding(dg *discordgo.Session, gateway config.Gateway) (*shardInfo, error) {
+	if gateway.ShardCount == "" || gateway.ShardCount == "1" {
+		return nil, nil
+	}
+
+	id := gateway.ShardId
+	count, err := strconv.Atoi(gateway.ShardCount) // validated by config
+	if gateway.ShardCount == "auto" {
+		recommended, err := dg.GatewayBot()
+		if err != nil {
+			return nil, fmt.Errorf("fetching recommended shard count: %w", err)
+		}
+		count = recommended.Shards
+	} else if err != nil {
+		return nil, err
+	}
+	if id >= count {
+		return nil, fmt.Errorf("shard ID %d is out of range, Discord recommends %d shards", id, count)
+	}
+	if count == 1 {
+		return nil, nil
+	}
+
+	dg.ShardID = id
+	dg.ShardCount = count
+	slog.Info("Running as gateway shard", "shard_id", id, "shard_count", count)
+	return &shardInfo{id: id, count: count}, nil
+}
+
+// owns reports whether a guild is routed to this shard
+func (shard *shardInfo) owns(guildID string) bool {
+	return shardFor(guildID, shard.count) == shard.id
+}
+
+// shardFor returns the shard Discord routes a guild to when running count
+// shards. Data without a guild belongs to shard 0.
+func shardFor(guildID string, count int) int {
+	snowflake, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil || count < 1 {
+		return 0
+	}
+	return int((snowflake >> 22) % uint64(count))
+}
+
+// ShardDistribution counts the configured guilds in data per shard when
+// running count shards
+func ShardDistribution(data *PersistentData, count int) []int {
+	guilds := make(map[string]bool)
+	data.filterGuilds(func(guildID string) bool {
+		if guildID != "" {
+			guilds[guildID] = true
+		}
+		return false
+	})
+
+	distribution := make([]int, count)
+	for guildID := range guilds {
+		distribution[shardFor(guildID, count)]++
+	}
+	return distribution
+}
+
+// filterGuilds returns a copy of data with only the guilds keep accepts
+func (data *PersistentData) filterGuilds(keep func(guildID string) bool) *PersistentData {
+	filtered := &PersistentData{
+		AdminChannels:        filterGuildMap(data.AdminChannels, keep),
+		Watchlist:            filterGuildMap(data.Watchlist, keep),
+		Templates:            filterGuildMap(data.Templates, keep),
+		Goals:                filterGuildMap(data.Goals, keep),
+		Ignored:              filterGuildMap(data.Ignored, keep),
+		OptOuts:              filterGuildMap(data.OptOuts, keep),
+		LogChannels:          filterGuildMap(data.LogChannels, keep),
+		FallbackChannels:     filterGuildMap(data.FallbackChannels, keep),
+		SubscribeAccess:      filterGuildMap(data.SubscribeAccess, keep),
+		DebounceStrategies:   filterGuildMap(data.DebounceStrategies, keep),
+		PlainText:            filterGuildMap(data.PlainText, keep),
+		Minimal:              filterGuildMap(data.Minimal, keep),
+		Timestamps:           filterGuildMap(data.Timestamps, keep),
+		Tones:                filterGuildMap(data.Tones, keep),
+		Languages:            filterGuildMap(data.Languages, keep),
+		AFK:                  filterGuildMap(data.AFK, keep),
+		Pauses:               filterGuildMap(data.Pauses, keep),
+		PatternSubscriptions: filterGuildMap(data.PatternSubscriptions, keep),
+		GroupWindows:         filterGuildMap(data.GroupWindows, keep),
+		Digests:              filterGuildMap(data.Digests, keep),
+		Subscriptions:        make(map[string][]subscription),
+	}
+
+	for voiceChannelID, subs := range data.Subscriptions {
+		for _, sub := range subs {
+			if keep(sub.GuildId) {
+				filtered.Subscriptions[voiceChannelID] = append(filtered.Subscriptions[voiceChannelID], sub)
+			}
+		}
+	}
+	for _, f := range data.Follows {
+		if keep(f.GuildId) {
+			filtered.Follows = append(filtered.Follows, f)
+		}
+	}
+	for _, sent := range data.SentNotifications {
+		if keep(sent.GuildId) {
+			filtered.SentNotifications = append(filtered.SentNotifications, sent)
+		}
+	}
+	return filtered
+}
+
+func filterGuildMap[V any](values map[string]V, keep func(guildID string) bool) map[string]V {
+	filtered := make(map[string]V)
+	for guildID, value := range values {
+		if keep(guildID) {
+			filtered[guildID] = value
+		}
+	}
+	return filtered
+}
+
+// mergeForSave combines this shard's guilds with the other shards' guilds
+// currently in the store, so a save never drops another shard's data. The
+// caller holds the store's lock until the merged data is saved.
+func (shard *shardInfo) mergeForSave(store Store, own *PersistentData) (*PersistentData, error) {
+	current, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	merged := current.filterGuilds(func(guildID string) bool { return !shard.owns(guildID) })
+	MergeData(merged, own)
+	return merged, nil
+}
@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// ShardStatus describes the gateway shard this process runs
+	ShardStatus struct {
+		Id      int     `json:"id"`
+		Count   int     `json:"count"`
+		Guilds  int     `json:"guilds"`
+		Latency float64 `json:"latency_ms"`
+	}
+)
+
+// shardStatus reports the guilds and gateway latency of this process. The
+// shard is 0 of 1 when running unsharded.
+func (b *Bot) shardStatus() ShardStatus {
+	status := ShardStatus{Id: 0, Count: 1}
+	if b.shard != nil {
+		status.Id, status.Count = b.shard.id, b.shard.count
+	}
+
+	b.session.State.RLock()
+	status.Guilds = len(b.session.State.Guilds)
+	b.session.State.RUnlock()
+
+	status.Latency = float64(b.session.HeartbeatLatency()) / float64(time.Millisecond)
+	return status
+}
+
+// statusCommand returns the /status command definition
+func statusCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "status",
+		DefaultMemberPermissions: &manageServerPermission,
+	}
+}
+
+func (b *Bot) handleStatus(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	status := b.shardStatus()
+	fields := []*discordgo.MessageEmbedField{
+		{Name: b.t(i.GuildID, "status.shard"), Value: b.t(i.GuildID, "status.shard-of", status.Id, status.Count), Inline: true},
+		{Name: b.t(i.GuildID, "status.servers"), Value: fmt.Sprintf("%d", status.Guilds), Inline: true},
+		{Name: b.t(i.GuildID, "status.latency"), Value: fmt.Sprintf("%.0f ms", status.Latency), Inline: true},
+	}
+
+	// Other shards run in other processes, so the distribution comes from the shared store
+	if b.shard != nil {
+		data, err := b.persistence.Load()
+		if err != nil {
+			slog.Error("Error loading shared state for shard distribution", "guild_id", i.GuildID, "error", err)
+		} else {
+			var lines []string
+			for id, guilds := range ShardDistribution(data, status.Count) {
+				lines = append(lines, b.t(i.GuildID, "status.shard-guilds", id, guilds))
+			}
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:  b.t(i.GuildID, "status.distribution"),
+				Value: truncateMessage(strings.Join(lines, "\n"), 1024),
+			})
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:  b.t(i.GuildID, "status.title"),
+				Color:  0x5865F2,
+				Fields: fields,
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleMetrics serves shard gauges in the Prometheus text format. Each
+// shard process exposes its own values, labelled with its shard ID, and with
+// the bot's name when the process runs several bots.
+func handleMetrics(bots []*Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type botMetrics struct {
+			name      string // label set of the bot, empty for a single bot
+			labels    string // label set of the bot's shard
+			status    ShardStatus
+			connected int
+		}
+		metrics := make([]botMetrics, 0, len(bots))
+		for _, b := range bots {
+			m := botMetrics{status: b.shardStatus()}
+			m.labels = fmt.Sprintf("shard=\"%d\"", m.status.Id)
+			if b.name != "" {
+				m.name = fmt.Sprintf("{bot=%q}", b.name)
+				m.labels = fmt.Sprintf("bot=%q,%s", b.name, m.labels)
+			}
+			if b.health().GatewayConnected {
+				m.connected = 1
+			}
+			metrics = append(metrics, m)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintf(w, "# HELP voiceactivitybot_shard_count Number of gateway shards the bot runs.\n")
+		fmt.Fprintf(w, "# TYPE voiceactivitybot_shard_count gauge\n")
+		for _, m := range metrics {
+			fmt.Fprintf(w, "voiceactivitybot_shard_count%s %d\n", m.name, m.status.Count)
+		}
+		fmt.Fprintf(w, "# HELP voiceactivitybot_shard_guilds Servers handled by this shard.\n")
+		fmt.Fprintf(w, "# TYPE voiceactivitybot_shard_guilds gauge\n")
+		for _, m := range metrics {
+			fmt.Fprintf(w, "voiceactivitybot_shard_guilds{%s} %d\n", m.labels, m.status.Guilds)
+		}
+		fmt.Fprintf(w, "# HELP voiceactivitybot_gateway_latency_seconds Latency of the last gateway heartbeat.\n")
+		fmt.Fprintf(w, "# TYPE voiceactivitybot_gateway_latency_seconds gauge\n")
+		for _, m := range metrics {
+			fmt.Fprintf(w, "voiceactivitybot_gateway_latency_seconds{%s} %g\n", m.labels, m.status.Latency/1000)
+		}
+		fmt.Fprintf(w, "# HELP voiceactivitybot_gateway_connected Whether the shard's gateway connection is ready.\n")
+		fmt.Fprintf(w, "# TYPE voiceactivitybot_gateway_connected gauge\n")
+		for _, m := range metrics {
+			fmt.Fprintf(w, "voiceactivitybot_gateway_connected{%s} %d\n", m.labels, m.connected)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// statusBoardDelay coalesces rapid voice changes into one board edit
+const statusBoardDelay = 2 * time.Second
+
+// statusBoardCommand returns the /status-board command definition
+func statusBoardCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "status-board",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type:     discordgo.ApplicationCommandOptionBoolean,
+				Name:     "enabled",
+				Required: true,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleStatusBoard(s DiscordSession, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	enabled := options["enabled"].BoolValue()
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	sub, found := b.getSubscription(voiceChannelID, i.ChannelID)
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.not-subscribed", channelName))
+		return
+	}
+
+	if !enabled {
+		b.updateSubscription(voiceChannelID, i.ChannelID, func(existing *subscription) {
+			existing.StatusBoard = false
+			existing.StatusMessageId = ""
+		})
+		if sub.StatusMessageId != "" {
+			s.ChannelMessageDelete(sub.TextChannelId, sub.StatusMessageId)
+		}
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "statusboard.off", channelName))
+		return
+	}
+
+	b.updateSubscription(voiceChannelID, i.ChannelID, func(existing *subscription) {
+		existing.StatusBoard = true
+	})
+	sub.StatusBoard = true
+
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "statusboard.on", channelName))
+	b.refreshStatusBoard(s, sub)
+}
+
+// scheduleStatusBoards updates the status boards of a voice channel after a short delay
+func (b *Bot) scheduleStatusBoards(s DiscordSession, voiceChannelID string) {
+	if voiceChannelID == "" {
+		return
+	}
+
+	b.statusBoardMu.Lock()
+	defer b.statusBoardMu.Unlock()
+
+	if _, pending := b.statusBoardTimers[voiceChannelID]; pending {
+		return
+	}
+	b.statusBoardTimers[voiceChannelID] = time.AfterFunc(statusBoardDelay, func() {
+		b.statusBoardMu.Lock()
+		delete(b.statusBoardTimers, voiceChannelID)
+		b.statusBoardMu.Unlock()
+
+		var boards []subscription
+		for _, sub := range b.subscriptions.Channel(voiceChannelID) {
+			if sub.StatusBoard && sub.Broken == "" {
+				boards = append(boards, sub)
+			}
+		}
+
+		for _, sub := range boards {
+			b.refreshStatusBoard(s, sub)
+		}
+	})
+}
+
+// refreshStatusBoard edits the board message, posting and pinning a new one if it is missing
+func (b *Bot) refreshStatusBoard(s DiscordSession, sub subscription) {
+	embed := b.presentEmbed(sub.GuildId, b.statusBoardEmbed(s, sub.GuildId, sub.VoiceChannelId))
+
+	if sub.StatusMessageId != "" {
+		_, err := s.ChannelMessageEditEmbed(sub.TextChannelId, sub.StatusMessageId, embed)
+		if err == nil {
+			return
+		}
+
+		var restErr *discordgo.RESTError
+		if !errors.As(err, &restErr) || restErr.Message == nil || restErr.Message.Code != discordgo.ErrCodeUnknownMessage {
+			slog.Error("Error updating status board", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "event_type", "status_board", "error", err)
+			if isPermissionError(err) {
+				b.markBroken(sub, brokenMissingPermissions)
+			}
+			return
+		}
+		// The board was deleted, post a new one
+	}
+
+	message, err := s.ChannelMessageSendEmbed(sub.TextChannelId, embed)
+	if err != nil {
+		slog.Error("Error posting status board", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "event_type", "status_board", "error", err)
+		if isPermissionError(err) {
+			b.markBroken(sub, brokenMissingPermissions)
+		}
+		return
+	}
+
+	if err := s.ChannelMessagePin(sub.TextChannelId, message.ID); err != nil {
+		slog.Warn("Could not pin status board", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "event_type", "status_board", "error", err)
+	}
+
+	b.updateSubscription(sub.VoiceChannelId, sub.TextChannelId, func(existing *subscription) {
+		existing.StatusMessageId = message.ID
+	})
+}
+
+// statusBoardEmbed renders the current occupants of a voice channel
+func (b *Bot) statusBoardEmbed(s DiscordSession, guildID, voiceChannelID string) *discordgo.MessageEmbed {
+	users := b.occupancy.users(voiceChannelID)
+
+	description := b.t(guildID, "statusboard.empty")
+	if len(users) > 0 {
+		mentions := make([]string, len(users))
+		for idx, userID := range users {
+			mentions[idx] = fmt.Sprintf("• <@%s>", userID)
+		}
+		description = strings.Join(mentions, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🔊 %s", b.getChannelName(s, voiceChannelID)),
+		Description: description,
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: b.t(guildID, "statusboard.footer", len(users)),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
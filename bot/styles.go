@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Notification styles a subscription can use
+const (
+	stylePlain   = "plain"   // a regular bot message (default)
+	styleEmbed   = "embed"   // the message inside an embed
+	styleWebhook = "webhook" // a message posted through the channel webhook
+	styleRoster  = "roster"  // the message followed by everyone currently in the channel
+)
+
+// notificationStyles lists the styles in the order they are previewed
+var notificationStyles = []string{stylePlain, styleEmbed, styleWebhook, styleRoster}
+
+// validStyle reports whether a style name is known; empty means the default
+func validStyle(style string) bool {
+	if style == "" {
+		return true
+	}
+	for _, known := range notificationStyles {
+		if style == known {
+			return true
+		}
+	}
+	return false
+}
+
+// styledMessage renders a notification in the subscription's style
+func (b *Bot) styledMessage(s DiscordSession, sub subscription, n notification) *discordgo.MessageSend {
+	message := &discordgo.MessageSend{Content: n.content}
+
+	style := sub.Style
+	if sub.eventActive(time.Now()) {
+		style = styleRoster
+	}
+	switch style {
+	case styleEmbed:
+		message.Content = ""
+		message.Embeds = []*discordgo.MessageEmbed{notificationEmbed(n.content)}
+	case styleRoster:
+		message.Content = n.content + "\n" + b.rosterLine(sub.GuildId, b.occupancy.users(sub.VoiceChannelId))
+	}
+	return message
+}
+
+// notificationEmbed wraps notification text in an embed
+func notificationEmbed(content string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Description: content,
+		Color:       0x5865F2,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+}
+
+// maxRosterLength keeps roster lines well below the message limit, so the
+// notification itself is never cut
+const maxRosterLength = 1000
+
+// rosterLine lists the users in a voice channel
+func (b *Bot) rosterLine(guildID string, users []string) string {
+	if len(users) == 0 {
+		return "-# " + b.t(guildID, "styles.roster.empty")
+	}
+	mentions := make([]string, len(users))
+	for idx, userID := range users {
+		mentions[idx] = fmt.Sprintf("<@%s>", userID)
+	}
+	return "-# " + b.t(guildID, "styles.roster.users", len(users), b.joinLimited(guildID, mentions, ", ", maxRosterLength))
+}
+
+// notificationText returns the text of a notification message for quiet hours
+// and rate limiting, whatever its style
+func notificationText(message *discordgo.MessageSend) string {
+	if message.Content == "" && len(message.Embeds) > 0 {
+		return message.Embeds[0].Description
+	}
+	return message.Content
+}
+
+// previewFormatsCommand returns the /preview-formats command definition
+func previewFormatsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "preview-formats",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+		},
+	}
+}
+
+func (b *Bot) handlePreviewFormats(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	voiceChannelID := ""
+	channelName := b.t(i.GuildID, "styles.sample-channel")
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		voiceChannelID = options[0].ChannelValue(nil).ID
+		channelName = b.getChannelName(s, voiceChannelID)
+	}
+
+	userID := interactionUserID(i)
+	username := b.t(i.GuildID, "styles.sample-user")
+	if i.Member != nil && i.Member.User != nil {
+		username = i.Member.User.Username
+		if i.Member.Nick != "" {
+			username = i.Member.Nick
+		}
+	}
+
+	// Use the guild's join template or tone, like a real notification
+	content := b.renderMessage(i.GuildID, TemplateEvent{
+		Type:      TemplateEventJoin,
+		User:      username,
+		UserID:    userID,
+		Channel:   channelName,
+		ChannelID: voiceChannelID,
+		Guild:     b.getGuildName(s, i.GuildID),
+		Count:     1,
+		Time:      time.Now(),
+	})
+
+	roster := []string{userID}
+	if voiceChannelID != "" {
+		if users := b.occupancy.users(voiceChannelID); len(users) > 0 {
+			roster = users
+		}
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	for _, style := range notificationStyles {
+		embed := &discordgo.MessageEmbed{
+			Title: fmt.Sprintf("%s — %s", style, b.t(i.GuildID, "styles."+style)),
+			Color: 0x2B2D31,
+		}
+		switch style {
+		case stylePlain:
+			embed.Description = content
+		case styleEmbed:
+			embed.Description = content
+			embed.Color = 0x5865F2
+		case styleWebhook:
+			embed.Author = &discordgo.MessageEmbedAuthor{Name: webhookName}
+			if b.session.State.User != nil {
+				embed.Author.IconURL = b.session.State.User.AvatarURL("64")
+			}
+			embed.Description = content
+		case styleRoster:
+			embed.Description = content + "\n" + b.rosterLine(i.GuildID, roster)
+		}
+		embeds = append(embeds, embed)
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.t(i.GuildID, "styles.preview"),
+			Embeds:  embeds,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to interaction", "guild_id", i.GuildID, "channel_id", i.ChannelID, "error", err)
+	}
+}
@@ -0,0 +1,375 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+type (
+	// subscriptions holds the subscriptions of every guild, grouped by voice
+	// channel. Every voice channel belongs to exactly one guild, so a voice
+	// channel's subscriptions never mix guilds.
+	//
+	// It is safe for concurrent use and has its own lock, which is never held
+	// while calling out, so it may be used with or without b.mu held. Stored
+	// slices are replaced rather than modified in place and every slice handed
+	// out is a copy, so callers may keep results after the call returns.
+	subscriptions struct {
+		byGuild  map[string]map[string][]subscription // guildID -> voiceChannelID -> subscriptions
+		guildOf  map[string]string                    // voiceChannelID -> guildID
+		onChange func()                               // called after every change, outside the lock
+		mu       sync.RWMutex
+	}
+
+	// crossGuildError reports a subscription whose guild isn't the guild of
+	// its voice channel
+	crossGuildError struct {
+		voiceChannelId string
+		guildId        string
+	}
+)
+
+func (err *crossGuildError) Error() string {
+	return fmt.Sprintf("voice channel %s belongs to guild %s", err.voiceChannelId, err.guildId)
+}
+
+// newSubscriptions creates an empty subscription store. onChange may be nil.
+func newSubscriptions(onChange func()) *subscriptions {
+	return &subscriptions{
+		byGuild:  make(map[string]map[string][]subscription),
+		guildOf:  make(map[string]string),
+		onChange: onChange,
+	}
+}
+
+// changed notifies the owner of a change. Caller must not hold mu.
+func (st *subscriptions) changed() {
+	if st.onChange != nil {
+		st.onChange()
+	}
+}
+
+// channel returns the stored subscriptions of a voice channel. Caller must hold mu.
+func (st *subscriptions) channel(voiceChannelID string) []subscription {
+	return st.byGuild[st.guildOf[voiceChannelID]][voiceChannelID]
+}
+
+// setChannel stores the subscriptions of a voice channel, dropping the voice
+// channel and, if it was the last, its guild when there are none. Caller must
+// hold mu for writing.
+func (st *subscriptions) setChannel(guildID, voiceChannelID string, subs []subscription) {
+	if len(subs) > 0 {
+		if st.byGuild[guildID] == nil {
+			st.byGuild[guildID] = make(map[string][]subscription)
+		}
+		st.byGuild[guildID][voiceChannelID] = subs
+		st.guildOf[voiceChannelID] = guildID
+		return
+	}
+
+	delete(st.byGuild[guildID], voiceChannelID)
+	if len(st.byGuild[guildID]) == 0 {
+		delete(st.byGuild, guildID)
+	}
+	delete(st.guildOf, voiceChannelID)
+}
+
+// Replace swaps in a whole set of subscriptions keyed by voice channel, e.g.
+// after loading from storage. Subscriptions whose guild differs from the
+// other subscriptions of their voice channel are dropped. It does not call
+// onChange.
+func (st *subscriptions) Replace(byVoiceChannel map[string][]subscription) {
+	byGuild := make(map[string]map[string][]subscription)
+	guildOf := make(map[string]string)
+	for voiceChannelID, subs := range byVoiceChannel {
+		if len(subs) == 0 {
+			continue
+		}
+		guildID := subs[0].GuildId
+		kept := slices.DeleteFunc(slices.Clone(subs), func(sub subscription) bool {
+			if sub.GuildId != guildID {
+				slog.Warn("Dropping subscription of a voice channel in another guild", "guild_id", sub.GuildId, "channel_id", voiceChannelID, "text_channel_id", sub.TextChannelId, "error", &crossGuildError{voiceChannelId: voiceChannelID, guildId: guildID})
+				return true
+			}
+			return false
+		})
+		if byGuild[guildID] == nil {
+			byGuild[guildID] = make(map[string][]subscription)
+		}
+		byGuild[guildID][voiceChannelID] = kept
+		guildOf[voiceChannelID] = guildID
+	}
+
+	st.mu.Lock()
+	st.byGuild = byGuild
+	st.guildOf = guildOf
+	st.mu.Unlock()
+}
+
+// ReplaceGuild swaps in the subscriptions of one guild, keyed by voice
+// channel, e.g. after another instance changed them. Subscriptions of other
+// guilds are dropped. It does not call onChange.
+func (st *subscriptions) ReplaceGuild(guildID string, byVoiceChannel map[string][]subscription) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for voiceChannelID := range st.byGuild[guildID] {
+		delete(st.guildOf, voiceChannelID)
+	}
+	delete(st.byGuild, guildID)
+	for voiceChannelID, subs := range byVoiceChannel {
+		kept := slices.DeleteFunc(slices.Clone(subs), func(sub subscription) bool { return sub.GuildId != guildID })
+		if other, ok := st.guildOf[voiceChannelID]; ok && other != guildID {
+			slog.Warn("Dropping subscriptions of a voice channel in another guild", "guild_id", guildID, "channel_id", voiceChannelID, "error", &crossGuildError{voiceChannelId: voiceChannelID, guildId: other})
+			continue
+		}
+		st.setChannel(guildID, voiceChannelID, kept)
+	}
+}
+
+// Snapshot returns a copy of all subscriptions keyed by voice channel
+func (st *subscriptions) Snapshot() map[string][]subscription {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	snapshot := make(map[string][]subscription, len(st.guildOf))
+	for _, channels := range st.byGuild {
+		for voiceChannelID, subs := range channels {
+			snapshot[voiceChannelID] = slices.Clone(subs)
+		}
+	}
+	return snapshot
+}
+
+// Channel returns the subscriptions of a voice channel
+func (st *subscriptions) Channel(voiceChannelID string) []subscription {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return slices.Clone(st.channel(voiceChannelID))
+}
+
+// ChannelIn returns the subscriptions of a voice channel if it belongs to the
+// guild, and none otherwise
+func (st *subscriptions) ChannelIn(guildID, voiceChannelID string) []subscription {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return slices.Clone(st.byGuild[guildID][voiceChannelID])
+}
+
+// Has reports whether a voice channel has any subscriptions
+func (st *subscriptions) Has(voiceChannelID string) bool {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	_, ok := st.guildOf[voiceChannelID]
+	return ok
+}
+
+// Filter returns every subscription for which keep returns true. keep must
+// not call back into the store.
+func (st *subscriptions) Filter(keep func(sub subscription) bool) []subscription {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	var matching []subscription
+	for _, channels := range st.byGuild {
+		for _, subs := range channels {
+			for _, sub := range subs {
+				if keep(sub) {
+					matching = append(matching, sub)
+				}
+			}
+		}
+	}
+	return matching
+}
+
+// Guild returns every subscription of a guild
+func (st *subscriptions) Guild(guildID string) []subscription {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	var subs []subscription
+	for _, channelSubs := range st.byGuild[guildID] {
+		subs = append(subs, channelSubs...)
+	}
+	return subs
+}
+
+// GuildChannels returns a copy of a guild's subscriptions keyed by voice channel
+func (st *subscriptions) GuildChannels(guildID string) map[string][]subscription {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	channels := make(map[string][]subscription, len(st.byGuild[guildID]))
+	for voiceChannelID, subs := range st.byGuild[guildID] {
+		channels[voiceChannelID] = slices.Clone(subs)
+	}
+	return channels
+}
+
+// Get returns a copy of a subscription and whether it exists
+func (st *subscriptions) Get(voiceChannelID, textChannelID string) (subscription, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	for _, sub := range st.channel(voiceChannelID) {
+		if sub.TextChannelId == textChannelID {
+			return sub, true
+		}
+	}
+	return subscription{}, false
+}
+
+// Add stores a subscription and returns whether it was added. An existing
+// subscription for the same voice and text channel is left untouched.
+func (st *subscriptions) Add(sub subscription) bool {
+	added, _ := st.AddWithin(sub, subscriptionLimits{})
+	return added
+}
+
+// AddWithin is Add, but fails with a *subscriptionLimitError instead of
+// adding a subscription beyond limits, and with a *crossGuildError if the
+// voice channel has subscriptions of another guild
+func (st *subscriptions) AddWithin(sub subscription, limits subscriptionLimits) (bool, error) {
+	st.mu.Lock()
+	if guildID, ok := st.guildOf[sub.VoiceChannelId]; ok && guildID != sub.GuildId {
+		st.mu.Unlock()
+		return false, &crossGuildError{voiceChannelId: sub.VoiceChannelId, guildId: guildID}
+	}
+	existing := st.byGuild[sub.GuildId][sub.VoiceChannelId]
+	if slices.ContainsFunc(existing, func(other subscription) bool { return other.TextChannelId == sub.TextChannelId }) {
+		st.mu.Unlock()
+		return false, nil
+	}
+	if limits.PerVoiceChannel > 0 && len(existing) >= limits.PerVoiceChannel {
+		st.mu.Unlock()
+		return false, &subscriptionLimitError{limit: limits.PerVoiceChannel, voiceChannel: true}
+	}
+	if limits.PerGuild > 0 {
+		count := 0
+		for _, subs := range st.byGuild[sub.GuildId] {
+			count += len(subs)
+		}
+		if count >= limits.PerGuild {
+			st.mu.Unlock()
+			return false, &subscriptionLimitError{limit: limits.PerGuild}
+		}
+	}
+	st.setChannel(sub.GuildId, sub.VoiceChannelId, append(slices.Clip(existing), sub))
+	st.mu.Unlock()
+
+	st.changed()
+	return true, nil
+}
+
+// Update applies fn to a subscription and returns whether it existed. fn must
+// not call back into the store and must not change the subscription's guild
+// or channels.
+func (st *subscriptions) Update(voiceChannelID, textChannelID string, fn func(sub *subscription)) bool {
+	st.mu.Lock()
+	guildID := st.guildOf[voiceChannelID]
+	subs := st.byGuild[guildID][voiceChannelID]
+	idx := slices.IndexFunc(subs, func(sub subscription) bool { return sub.TextChannelId == textChannelID })
+	if idx < 0 {
+		st.mu.Unlock()
+		return false
+	}
+	updated := slices.Clone(subs)
+	fn(&updated[idx])
+	updated[idx].GuildId, updated[idx].VoiceChannelId, updated[idx].TextChannelId = guildID, voiceChannelID, textChannelID
+	st.byGuild[guildID][voiceChannelID] = updated
+	st.mu.Unlock()
+
+	st.changed()
+	return true
+}
+
+// Remove deletes a subscription and returns whether it existed
+func (st *subscriptions) Remove(voiceChannelID, textChannelID string) bool {
+	st.mu.RLock()
+	guildID := st.guildOf[voiceChannelID]
+	st.mu.RUnlock()
+	return st.RemoveIn(guildID, voiceChannelID, textChannelID)
+}
+
+// RemoveIn deletes a subscription if its voice channel belongs to the guild
+// and returns whether it existed there
+func (st *subscriptions) RemoveIn(guildID, voiceChannelID, textChannelID string) bool {
+	st.mu.Lock()
+	subs := st.byGuild[guildID][voiceChannelID]
+	idx := slices.IndexFunc(subs, func(sub subscription) bool { return sub.TextChannelId == textChannelID })
+	if idx < 0 {
+		st.mu.Unlock()
+		return false
+	}
+	st.setChannel(guildID, voiceChannelID, slices.Delete(slices.Clone(subs), idx, idx+1))
+	st.mu.Unlock()
+
+	st.changed()
+	return true
+}
+
+// RemoveChannel deletes and returns all subscriptions of a voice channel
+func (st *subscriptions) RemoveChannel(voiceChannelID string) []subscription {
+	st.mu.Lock()
+	subs := st.channel(voiceChannelID)
+	st.setChannel(st.guildOf[voiceChannelID], voiceChannelID, nil)
+	st.mu.Unlock()
+
+	if len(subs) > 0 {
+		st.changed()
+	}
+	return subs
+}
+
+// RemoveGuild deletes and returns all subscriptions of a guild
+func (st *subscriptions) RemoveGuild(guildID string) []subscription {
+	st.mu.Lock()
+	var removed []subscription
+	for voiceChannelID, subs := range st.byGuild[guildID] {
+		removed = append(removed, subs...)
+		delete(st.guildOf, voiceChannelID)
+	}
+	delete(st.byGuild, guildID)
+	st.mu.Unlock()
+
+	if len(removed) > 0 {
+		st.changed()
+	}
+	return removed
+}
+
+// RemoveFunc deletes and returns every subscription for which remove returns
+// true. remove must not call back into the store.
+func (st *subscriptions) RemoveFunc(remove func(sub subscription) bool) []subscription {
+	st.mu.Lock()
+	var removed []subscription
+	for guildID, channels := range st.byGuild {
+		for voiceChannelID, subs := range channels {
+			if !slices.ContainsFunc(subs, remove) {
+				continue
+			}
+
+			var remaining []subscription
+			for _, sub := range subs {
+				if remove(sub) {
+					removed = append(removed, sub)
+				} else {
+					remaining = append(remaining, sub)
+				}
+			}
+			st.setChannel(guildID, voiceChannelID, remaining)
+		}
+	}
+	st.mu.Unlock()
+
+	if len(removed) > 0 {
+		st.changed()
+	}
+	return removed
+}
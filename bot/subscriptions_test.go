@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestSubscriptionsConcurrent changes and reads the store from several
+// goroutines at once. Run with -race.
+func TestSubscriptionsConcurrent(t *testing.T) {
+	changes := 0
+	var changesMu sync.Mutex
+	st := newSubscriptions(func() {
+		changesMu.Lock()
+		changes++
+		changesMu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			guildID := fmt.Sprintf("guild-%d", worker%2)
+			for n := range 200 {
+				voiceChannelID := fmt.Sprintf("voice-%d-%d", worker%2, n%10)
+				textChannelID := fmt.Sprintf("text-%d", worker)
+				sub := subscription{GuildId: guildID, VoiceChannelId: voiceChannelID, TextChannelId: textChannelID}
+
+				st.Add(sub)
+				st.Update(voiceChannelID, textChannelID, func(sub *subscription) { sub.Label = "updated" })
+				for voiceChannelID, subs := range st.Snapshot() {
+					if len(subs) > 0 {
+						// Snapshots are copies, changing them must not reach the store
+						subs[0].Label = voiceChannelID
+					}
+				}
+				st.Guild(guildID)
+				st.Channel(voiceChannelID)
+				if n%3 == 0 {
+					st.Remove(voiceChannelID, textChannelID)
+				}
+				if n%50 == 0 {
+					st.Replace(st.Snapshot())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Replace may bring back a subscription as it was before Update, but
+	// never with a label set on a snapshot
+	for _, subs := range st.Snapshot() {
+		for _, sub := range subs {
+			if sub.Label != "" && sub.Label != "updated" {
+				t.Errorf("subscription %s -> %s has label %q from a snapshot", sub.VoiceChannelId, sub.TextChannelId, sub.Label)
+			}
+		}
+	}
+	if changes == 0 {
+		t.Error("onChange was never called")
+	}
+}
+
+func TestSubscriptionsAddWithinRejectsOtherGuild(t *testing.T) {
+	st := newSubscriptions(nil)
+	if _, err := st.AddWithin(subscription{GuildId: "guild-a", VoiceChannelId: "voice", TextChannelId: "text-a"}, subscriptionLimits{}); err != nil {
+		t.Fatalf("AddWithin: %v", err)
+	}
+
+	added, err := st.AddWithin(subscription{GuildId: "guild-b", VoiceChannelId: "voice", TextChannelId: "text-b"}, subscriptionLimits{})
+	var crossGuild *crossGuildError
+	if added || !errors.As(err, &crossGuild) {
+		t.Fatalf("AddWithin from another guild = %v, %v, want a *crossGuildError", added, err)
+	}
+	if subs := st.ChannelIn("guild-b", "voice"); len(subs) != 0 {
+		t.Errorf("guild-b sees %d subscriptions of guild-a's voice channel", len(subs))
+	}
+	if st.RemoveIn("guild-b", "voice", "text-a") {
+		t.Error("RemoveIn removed a subscription of another guild")
+	}
+	if subs := st.Channel("voice"); len(subs) != 1 || subs[0].GuildId != "guild-a" {
+		t.Errorf("Channel(voice) = %+v, want guild-a's subscription only", subs)
+	}
+}
+
+func TestSubscriptionsAddWithinLimits(t *testing.T) {
+	st := newSubscriptions(nil)
+	limits := subscriptionLimits{PerGuild: 3, PerVoiceChannel: 2}
+	add := func(voiceChannelID, textChannelID string) error {
+		_, err := st.AddWithin(subscription{GuildId: "guild", VoiceChannelId: voiceChannelID, TextChannelId: textChannelID}, limits)
+		return err
+	}
+
+	if err := add("voice-1", "text-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := add("voice-1", "text-2"); err != nil {
+		t.Fatal(err)
+	}
+	var limitErr *subscriptionLimitError
+	if err := add("voice-1", "text-3"); !errors.As(err, &limitErr) || !limitErr.voiceChannel {
+		t.Errorf("third subscription of a voice channel: %v, want a voice channel limit error", err)
+	}
+	if err := add("voice-2", "text-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := add("voice-3", "text-1"); !errors.As(err, &limitErr) || limitErr.voiceChannel {
+		t.Errorf("fourth subscription of a guild: %v, want a guild limit error", err)
+	}
+	// Re-adding an existing subscription is not an error even at the limit
+	if added, err := st.AddWithin(subscription{GuildId: "guild", VoiceChannelId: "voice-1", TextChannelId: "text-1"}, limits); added || err != nil {
+		t.Errorf("re-adding an existing subscription = %v, %v, want false, nil", added, err)
+	}
+}
+
+func TestSubscriptionsReplaceDropsOtherGuild(t *testing.T) {
+	st := newSubscriptions(nil)
+	st.Replace(map[string][]subscription{
+		"voice-1": {
+			{GuildId: "guild-a", VoiceChannelId: "voice-1", TextChannelId: "text-a"},
+			{GuildId: "guild-b", VoiceChannelId: "voice-1", TextChannelId: "text-b"},
+		},
+		"voice-2": {
+			{GuildId: "guild-b", VoiceChannelId: "voice-2", TextChannelId: "text-b"},
+		},
+	})
+
+	if subs := st.Channel("voice-1"); len(subs) != 1 || subs[0].GuildId != "guild-a" {
+		t.Errorf("Channel(voice-1) = %+v, want guild-a's subscription only", subs)
+	}
+	if subs := st.Guild("guild-b"); len(subs) != 1 || subs[0].VoiceChannelId != "voice-2" {
+		t.Errorf("Guild(guild-b) = %+v, want the voice-2 subscription only", subs)
+	}
+	// The voice channel stays with the guild it was loaded for
+	if _, err := st.AddWithin(subscription{GuildId: "guild-b", VoiceChannelId: "voice-1", TextChannelId: "text-b"}, subscriptionLimits{}); err == nil {
+		t.Error("AddWithin after Replace accepted a subscription from another guild")
+	}
+}
@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	notificationModeIndividual = "individual"
+	notificationModeSummary    = "summary"
+)
+
+type (
+	// summaryBuffer aggregates activity in one voice channel over the summary window
+	summaryBuffer struct {
+		timer  *time.Timer
+		since  time.Time // when the window's first event happened
+		joined []string
+		left   []string
+		mu     sync.Mutex
+	}
+)
+
+// recordSummaryEvent adds a join or leave to the channel's summary buffer and
+// starts the window timer on the first event
+func (b *Bot) recordSummaryEvent(s DiscordSession, voiceChannelID, username string, joined bool) {
+	b.summaryMu.Lock()
+	buf, exists := b.summaries[voiceChannelID]
+	if !exists {
+		buf = &summaryBuffer{}
+		b.summaries[voiceChannelID] = buf
+	}
+	b.summaryMu.Unlock()
+
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+
+	if joined {
+		if !slices.Contains(buf.joined, username) {
+			buf.joined = append(buf.joined, username)
+		}
+	} else if !slices.Contains(buf.left, username) {
+		buf.left = append(buf.left, username)
+	}
+
+	if buf.timer != nil {
+		return
+	}
+
+	buf.since = time.Now()
+	buf.timer = time.AfterFunc(b.summaryWindow, func() {
+		b.summaryMu.Lock()
+		delete(b.summaries, voiceChannelID)
+		b.summaryMu.Unlock()
+		b.flushSummary(s, voiceChannelID, buf)
+	})
+}
+
+// flushSummary sends a channel's summary to its subscriptions. Each
+// subscription only gets the joins and leaves its events ask for.
+func (b *Bot) flushSummary(s DiscordSession, voiceChannelID string, buf *summaryBuffer) {
+	buf.mu.Lock()
+	joined, left, since := buf.joined, buf.left, buf.since
+	buf.mu.Unlock()
+
+	if !b.claim("summary:"+voiceChannelID, b.summaryWindow/2) {
+		return
+	}
+
+	channelName, guildID := voiceChannelID, ""
+	if channel, err := b.channel(s, voiceChannelID); err == nil {
+		channelName, guildID = channel.Name, channel.GuildID
+	}
+
+	now := time.Now()
+	contents := make(map[eventMask]string) // by the events included
+	for _, sub := range b.channelSubscriptions(voiceChannelID) {
+		active := sub.eventActive(now)
+		if sub.Broken != "" || sub.StatusBoard || (sub.MinUsers > 0 && !active) {
+			continue
+		}
+		var events eventMask
+		if len(joined) > 0 && (active || sub.Events.has(eventJoin)) {
+			events |= eventJoin
+		}
+		if len(left) > 0 && (active || sub.Events.has(eventLeave)) {
+			events |= eventLeave
+		}
+		if events == 0 || (sub.isDM() && !b.userCanView(s, sub.UserId, voiceChannelID)) {
+			continue
+		}
+
+		content, ok := contents[events]
+		if !ok {
+			var subJoined, subLeft []string
+			if events.has(eventJoin) {
+				subJoined = joined
+			}
+			if events.has(eventLeave) {
+				subLeft = left
+			}
+			content = b.formatSummary(guildID, channelName, subJoined, subLeft)
+			if guildID != "" && b.timestamps(guildID) {
+				content += " " + discordTimestamp(since, "t") + "–" + discordTimestamp(now, "t")
+			}
+			contents[events] = content
+		}
+
+		sent := b.deliverNotification(s, sub, b.styledMessage(s, sub, notification{content: content}))
+		b.trackSent(sub, sent, "")
+	}
+}
+
+// drainSummaries sends the summaries of running windows on shutdown, as long
+// as ctx allows
+func (b *Bot) drainSummaries(ctx context.Context) {
+	b.summaryMu.Lock()
+	pending := make(map[string]*summaryBuffer)
+	for voiceChannelID, buf := range b.summaries {
+		buf.mu.Lock()
+		if buf.timer != nil && buf.timer.Stop() {
+			pending[voiceChannelID] = buf
+			delete(b.summaries, voiceChannelID)
+		}
+		buf.mu.Unlock()
+	}
+	b.summaryMu.Unlock()
+
+	for voiceChannelID, buf := range pending {
+		if ctx.Err() != nil {
+			slog.Warn("Dropped activity summary on shutdown", "channel_id", voiceChannelID)
+			continue
+		}
+		b.flushSummary(b.rest, voiceChannelID, buf)
+	}
+}
+
+// formatSummary renders a summary like
+// "3 joined, 1 left: Alice, Bob, Carol joined; Dan left **General**" in the
+// guild's presentation style
+func (b *Bot) formatSummary(guildID, channelName string, joined, left []string) string {
+	var counts, details []string
+	if len(joined) > 0 {
+		counts = append(counts, b.t(guildID, "summary.joined-count", len(joined)))
+		details = append(details, b.t(guildID, "summary.joined", b.boldList(guildID, joined)))
+	}
+	if len(left) > 0 {
+		counts = append(counts, b.t(guildID, "summary.left-count", len(left)))
+		details = append(details, b.t(guildID, "summary.left", b.boldList(guildID, left)))
+	}
+
+	if b.presentation(guildID) != presentationMinimal {
+		channelName = escapeMarkdown(channelName)
+	}
+	return b.presentText(guildID, b.t(guildID, "summary.line", strings.Join(counts, ", "), strings.Join(details, "; "), channelName))
+}
+
+// maxSummaryListLength is the room for each name list of a summary, so busy
+// windows read "**Alice**, **Bob** and 12 others…" instead of being cut off
+const maxSummaryListLength = 700
+
+// boldList joins names as "**Alice**, **Bob**", escaping markdown in them.
+// The minimal style has no markdown, so the names are joined as they are.
+func (b *Bot) boldList(guildID string, names []string) string {
+	if b.presentation(guildID) == presentationMinimal {
+		return b.joinLimited(guildID, names, ", ", maxSummaryListLength)
+	}
+	bold := make([]string, len(names))
+	for idx, name := range names {
+		bold[idx] = "**" + escapeMarkdown(name) + "**"
+	}
+	return b.joinLimited(guildID, bold, ", ", maxSummaryListLength)
+}
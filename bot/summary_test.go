@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newSummaryTestBot returns a bot that knows voice channel 11 "General" of
+// guild 1
+func newSummaryTestBot(t *testing.T) *Bot {
+	t.Helper()
+	b := newTestBot(t)
+	guild := &discordgo.Guild{ID: "1", Channels: []*discordgo.Channel{
+		{ID: "11", GuildID: "1", Name: "General", Type: discordgo.ChannelTypeGuildVoice},
+	}}
+	if err := b.session.State.GuildAdd(guild); err != nil {
+		t.Fatalf("GuildAdd: %v", err)
+	}
+	return b
+}
+
+func TestFlushSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		events eventMask
+		joined []string
+		left   []string
+		want   string // empty when nothing is sent
+	}{
+		{name: "default events leave out leaves", joined: []string{"Alice"}, left: []string{"Dan"}, want: "📊 1 joined: **Alice** joined **General**"},
+		{name: "joins and leaves", events: eventJoin | eventLeave, joined: []string{"Alice"}, left: []string{"Dan"}, want: "📊 1 joined, 1 left: **Alice** joined; **Dan** left **General**"},
+		{name: "leaves only", events: eventLeave, joined: []string{"Alice"}, left: []string{"Dan"}, want: "📊 1 left: **Dan** left **General**"},
+		{name: "only unwanted entries", events: eventLeave, joined: []string{"Alice"}},
+		{name: "names are escaped", joined: []string{"*star*", "snake_case"}, want: `📊 2 joined: **\*star\***, **snake\_case** joined **General**`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newSummaryTestBot(t)
+			session := &postingSession{}
+			b.subscriptions.Add(subscription{VoiceChannelId: "11", TextChannelId: "12", GuildId: "1", Events: tt.events})
+
+			b.flushSummary(session, "11", &summaryBuffer{joined: tt.joined, left: tt.left, since: time.Now()})
+
+			sent := session.messages("12")
+			if tt.want == "" {
+				if len(sent) > 0 {
+					t.Errorf("sent %q, want nothing", sent)
+				}
+				return
+			}
+			if len(sent) != 1 || sent[0] != tt.want {
+				t.Errorf("sent %q, want %q", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSummaryPresentation(t *testing.T) {
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{style: presentationEmoji, want: `📊 2 joined: **Al\_ice**, **Bob** joined **Chill\_Room**`},
+		{style: presentationPlain, want: `2 joined: **Al\_ice**, **Bob** joined **Chill\_Room**`},
+		{style: presentationMinimal, want: "2 joined: Al_ice, Bob joined Chill_Room"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			b := newTestBot(t)
+			b.mu.Lock()
+			b.setPresentation("1", tt.style)
+			b.mu.Unlock()
+
+			if got := b.formatSummary("1", "Chill_Room", []string{"Al_ice", "Bob"}, nil); got != tt.want {
+				t.Errorf("formatSummary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrainSummaries(t *testing.T) {
+	b := newSummaryTestBot(t)
+	session := &postingSession{}
+	b.rest = session
+	b.summaryWindow = time.Hour
+	b.subscriptions.Add(subscription{VoiceChannelId: "11", TextChannelId: "12", GuildId: "1"})
+
+	b.recordSummaryEvent(session, "11", "Alice", true)
+	b.drainSummaries(context.Background())
+
+	if sent := session.messages("12"); len(sent) != 1 {
+		t.Fatalf("sent %q on shutdown, want the pending summary", sent)
+	}
+	if len(b.summaries) != 0 {
+		t.Error("the drained window is still running")
+	}
+}
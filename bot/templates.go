@@ -0,0 +1,277 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Template event types that can have a custom format
+const (
+	TemplateEventJoin   = "join"
+	TemplateEventMove   = "move"
+	TemplateEventActive = "active" // first person joined an empty channel
+	TemplateEventEmpty  = "empty"  // last person left
+	TemplateEventLeave  = "leave"
+	TemplateEventFull   = "full"   // channel reached its user limit
+	TemplateEventFree   = "free"   // a slot opened up in a full channel
+	TemplateEventMute   = "mute"   // user muted or unmuted, see .On
+	TemplateEventStream = "stream" // user started or stopped streaming, see .On
+	TemplateEventAFK    = "afk"    // user moved to the AFK channel from .Channel
+)
+
+// TemplateEvents lists the event types that support custom templates
+var TemplateEvents = []string{TemplateEventJoin, TemplateEventMove, TemplateEventActive, TemplateEventEmpty, TemplateEventLeave, TemplateEventFull, TemplateEventFree, TemplateEventMute, TemplateEventStream, TemplateEventAFK}
+
+type (
+	// TemplateEvent is the data available to notification templates
+	TemplateEvent struct {
+		Type          string        // Event type, e.g. "join"
+		User          string        // Display name of the user
+		UserID        string        // Discord user ID
+		Channel       string        // Voice channel name
+		ChannelID     string        // Voice channel ID
+		FromChannel   string        // Previous voice channel name, set for moves
+		FromChannelID string        // Previous voice channel ID, set for moves
+		Guild         string        // Server name
+		Count         int           // Users in the channel after the event
+		Limit         int           // User limit of the channel, 0 if unlimited
+		On            bool          // For "mute" and "stream": muted or started streaming, false when it ended
+		Time          time.Time     // When the event happened
+		Duration      time.Duration // How long the channel was occupied for "empty", how long the user stayed for "leave" and "afk"
+	}
+)
+
+// templateFuncs are the helper functions available in notification templates
+var templateFuncs = template.FuncMap{
+	// duration formats a time.Duration, or the time elapsed since a time.Time, as "1h 25m"
+	"duration": func(value any) (string, error) {
+		switch v := value.(type) {
+		case time.Duration:
+			return formatDuration(v), nil
+		case time.Time:
+			return formatDuration(time.Since(v)), nil
+		default:
+			return "", fmt.Errorf("duration expects a duration or time, got %T", value)
+		}
+	},
+	// timestamp renders a time as a Discord timestamp in each reader's
+	// timezone, in one of Discord's styles like "R" (relative) or "t" (time)
+	"timestamp": func(t time.Time, style string) (string, error) {
+		if !slices.Contains([]string{"t", "T", "d", "D", "f", "F", "R"}, style) {
+			return "", fmt.Errorf("timestamp style must be one of t, T, d, D, f, F, R, got %q", style)
+		}
+		return discordTimestamp(t, style), nil
+	},
+	// channelMention renders a clickable channel link from a channel ID
+	"channelMention": func(channelID string) string {
+		return "<#" + channelID + ">"
+	},
+	// userMention renders a user mention from a user ID
+	"userMention": func(userID string) string {
+		return "<@" + userID + ">"
+	},
+	// pluralize picks the singular or plural word for a count
+	"pluralize": func(count int, singular, plural string) string {
+		if count == 1 {
+			return singular
+		}
+		return plural
+	},
+	// truncate shortens text to a number of characters, adding "…"
+	"truncate": func(limit int, text string) string {
+		if limit < 1 {
+			return ""
+		}
+		return truncateMessage(text, limit)
+	},
+}
+
+// ParseTemplate validates a notification template
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("notification").Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+}
+
+// RenderTemplate renders a notification template against an event
+func RenderTemplate(text string, event TemplateEvent) (string, error) {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", err
+	}
+
+	rendered := strings.TrimSpace(buf.String())
+	if rendered == "" {
+		return "", fmt.Errorf("template rendered an empty message")
+	}
+	return truncateMessage(rendered, maxMessageLength), nil
+}
+
+// SampleEvents returns synthetic events for previewing templates
+func SampleEvents(eventType string) []TemplateEvent {
+	now := time.Now()
+	samples := []TemplateEvent{
+		{Type: eventType, User: "Alice", UserID: "100000000000000001", Channel: "General", ChannelID: "200000000000000001", Guild: "Example Server", Count: 1, Time: now},
+		{Type: eventType, User: "Bob the Builder", UserID: "100000000000000002", Channel: "Gaming 🎮", ChannelID: "200000000000000002", Guild: "Example Server", Count: 4, Time: now.Add(-90 * time.Minute)},
+		{Type: eventType, User: "Carol", UserID: "100000000000000003", Channel: "Study Room", ChannelID: "200000000000000003", Guild: "Example Server", Count: 12, Time: now.Add(-26 * time.Hour)},
+	}
+	for idx := range samples {
+		switch eventType {
+		case TemplateEventActive:
+			samples[idx].Count = 1
+		case TemplateEventEmpty, TemplateEventLeave, TemplateEventAFK:
+			samples[idx].Count = 0
+			samples[idx].Duration = time.Duration(idx+1) * 47 * time.Minute
+		case TemplateEventFull:
+			samples[idx].Limit = samples[idx].Count
+		case TemplateEventFree:
+			samples[idx].Limit = samples[idx].Count + 1
+		case TemplateEventMute, TemplateEventStream:
+			samples[idx].On = idx%2 == 0
+		}
+	}
+	if eventType == TemplateEventMove {
+		for idx := range samples {
+			samples[idx].FromChannel = "Lobby"
+			samples[idx].FromChannelID = "200000000000000009"
+		}
+	}
+	return samples
+}
+
+// templateCommand returns the /template command definition
+func templateCommand() *discordgo.ApplicationCommand {
+	var eventChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, event := range TemplateEvents {
+		eventChoices = append(eventChoices, &discordgo.ApplicationCommandOptionChoice{Value: event})
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:                     "template",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "set",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:     discordgo.ApplicationCommandOptionString,
+						Name:     "event",
+						Required: true,
+						Choices:  eventChoices,
+					},
+					{
+						Type:      discordgo.ApplicationCommandOptionString,
+						Name:      "template",
+						Required:  true,
+						MaxLength: 1000,
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "clear",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:     discordgo.ApplicationCommandOptionString,
+						Name:     "event",
+						Required: true,
+						Choices:  eventChoices,
+					},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Name: "show",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleTemplate(s DiscordSession, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subcommand.Options)
+
+	switch subcommand.Name {
+	case "set":
+		event := options["event"].StringValue()
+		text := options["template"].StringValue()
+
+		preview, err := RenderTemplate(text, SampleEvents(event)[0])
+		if err != nil {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "template.invalid", err))
+			return
+		}
+
+		b.setTemplate(i.GuildID, event, text)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "template.saved", event, preview))
+	case "clear":
+		event := options["event"].StringValue()
+		b.setTemplate(i.GuildID, event, "")
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "template.cleared", event))
+	case "show":
+		defaultText := b.t(i.GuildID, "template.default")
+		b.mu.RLock()
+		templates := b.templates[i.GuildID]
+		var lines []string
+		for _, event := range TemplateEvents {
+			text, ok := templates[event]
+			if !ok {
+				text = defaultText
+			}
+			lines = append(lines, fmt.Sprintf("**%s**: `%s`", event, text))
+		}
+		b.mu.RUnlock()
+
+		respondEphemeral(s, i.Interaction, strings.Join(lines, "\n"))
+	}
+}
+
+// setTemplate stores a guild's template for an event; an empty text removes it
+func (b *Bot) setTemplate(guildID, event, text string) {
+	b.mu.Lock()
+	if text == "" {
+		delete(b.templates[guildID], event)
+		if len(b.templates[guildID]) == 0 {
+			delete(b.templates, guildID)
+		}
+	} else {
+		if b.templates[guildID] == nil {
+			b.templates[guildID] = make(map[string]string)
+		}
+		b.templates[guildID][event] = text
+	}
+	b.mu.Unlock()
+
+	b.savePersistedDataAsync()
+}
+
+// renderGuildTemplate renders the guild's custom template for an event.
+// Returns false if the guild has none or it fails to render.
+func (b *Bot) renderGuildTemplate(guildID string, event TemplateEvent) (string, bool) {
+	if !slices.Contains(TemplateEvents, event.Type) {
+		return "", false
+	}
+
+	b.mu.RLock()
+	text, ok := b.templates[guildID][event.Type]
+	b.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	rendered, err := RenderTemplate(text, event)
+	if err != nil {
+		return "", false
+	}
+	return rendered, true
+}
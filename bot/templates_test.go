@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	event := TemplateEvent{Type: TemplateEventMove, User: "Alice", Channel: "Raid", FromChannel: "Lobby", Count: 3, Limit: 5}
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "fields", text: "{{.User}} moved from {{.FromChannel}} to {{.Channel}} ({{.Count}}/{{.Limit}})", want: "Alice moved from Lobby to Raid (3/5)"},
+		{name: "conditionals", text: "{{.User}} joined{{if .Limit}}, {{.Count}} of {{.Limit}} slots taken{{end}}", want: "Alice joined, 3 of 5 slots taken"},
+		{name: "trims whitespace", text: "  {{.User}}\n", want: "Alice"},
+		{name: "syntax error", text: "{{.User", wantErr: true},
+		{name: "unknown field", text: "{{.Nickname}} joined", wantErr: true},
+		{name: "unknown function", text: "{{shout .User}}", wantErr: true},
+		{name: "empty message", text: "{{if .On}}{{.User}}{{end}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(tt.text, event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderTemplate(%q) error %v, want error %v", tt.text, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	event := TemplateEvent{
+		User:      "Alice",
+		UserID:    "100",
+		ChannelID: "200",
+		Count:     1,
+		Time:      time.Now().Add(-47 * time.Minute),
+		Duration:  85 * time.Minute,
+	}
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "duration", text: "{{duration .Duration}}", want: "1h 25m"},
+		{name: "duration of a time", text: "{{duration .Time}}", want: "47m"},
+		{name: "duration of a number", text: "{{duration 47}}", wantErr: true},
+		{name: "timestamp", text: `{{timestamp .Time "R"}}`, want: "<t:" + strconv.FormatInt(event.Time.Unix(), 10) + ":R>"},
+		{name: "timestamp style", text: `{{timestamp .Time "x"}}`, wantErr: true},
+		{name: "mentions", text: "{{userMention .UserID}} in {{channelMention .ChannelID}}", want: "<@100> in <#200>"},
+		{name: "singular", text: `{{.Count}} {{pluralize .Count "person" "people"}}`, want: "1 person"},
+		{name: "plural", text: `{{pluralize 0 "person" "people"}}`, want: "people"},
+		{name: "truncate", text: "{{truncate 3 .User}}", want: "Al…"},
+		{name: "truncate fits", text: "{{truncate 5 .User}}", want: "Alice"},
+		{name: "truncate to nothing", text: "{{truncate 0 .User}}.", want: "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(tt.text, event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderTemplate(%q) error %v, want error %v", tt.text, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateTruncates(t *testing.T) {
+	got, err := RenderTemplate("{{.User}}", TemplateEvent{User: strings.Repeat("é", maxMessageLength+1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(got); n != maxMessageLength || !strings.HasSuffix(got, "…") {
+		t.Errorf("rendered %d characters ending in %q, want %d ending in an ellipsis", n, got[len(got)-3:], maxMessageLength)
+	}
+}
+
+func TestSampleEventsRender(t *testing.T) {
+	for _, eventType := range TemplateEvents {
+		for idx, event := range SampleEvents(eventType) {
+			if event.Type != eventType {
+				t.Errorf("%s sample %d has type %q", eventType, idx, event.Type)
+			}
+			if _, err := RenderTemplate("{{.User}} {{.Channel}} {{.FromChannel}} {{.Count}} {{.Duration}}", event); err != nil {
+				t.Errorf("%s sample %d: %v", eventType, idx, err)
+			}
+		}
+	}
+}
+
+func TestRenderGuildTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates map[string]string // event type -> template of guild 1
+		event     TemplateEvent
+		want      string
+		wantOK    bool
+	}{
+		{name: "no template", event: TemplateEvent{Type: TemplateEventJoin, User: "Alice"}},
+		{
+			name:      "template",
+			templates: map[string]string{TemplateEventJoin: "👋 {{.User}}"},
+			event:     TemplateEvent{Type: TemplateEventJoin, User: "Alice"},
+			want:      "👋 Alice",
+			wantOK:    true,
+		},
+		{
+			name:      "other event",
+			templates: map[string]string{TemplateEventJoin: "👋 {{.User}}"},
+			event:     TemplateEvent{Type: TemplateEventLeave, User: "Alice"},
+		},
+		{
+			name:      "not a template event",
+			templates: map[string]string{"summary": "{{.User}}"},
+			event:     TemplateEvent{Type: "summary", User: "Alice"},
+		},
+		{
+			name:      "fails to render",
+			templates: map[string]string{TemplateEventJoin: "{{.Nickname}}"},
+			event:     TemplateEvent{Type: TemplateEventJoin, User: "Alice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			for eventType, text := range tt.templates {
+				b.setTemplate("1", eventType, text)
+			}
+
+			got, ok := b.renderGuildTemplate("1", tt.event)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("renderGuildTemplate = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if _, ok := b.renderGuildTemplate("2", tt.event); ok {
+				t.Error("another guild's template was used")
+			}
+		})
+	}
+}
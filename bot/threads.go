@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sessionThreadButton returns the "Open chat thread" button attached to
+// session-start notifications
+func (b *Bot) sessionThreadButton(guildID, voiceChannelID string) discordgo.Button {
+	return discordgo.Button{
+		Label:    b.t(guildID, "threads.button"),
+		Style:    discordgo.SecondaryButton,
+		CustomID: "open_thread:" + voiceChannelID,
+		Emoji:    &discordgo.ComponentEmoji{Name: "💬"},
+	}
+}
+
+// handleOpenThreadButton creates a thread on the notification message the
+// first time someone asks for one
+func (b *Bot) handleOpenThreadButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	voiceChannelID := strings.TrimPrefix(i.MessageComponentData().CustomID, "open_thread:")
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	if i.Message.Thread != nil {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "threads.exists", i.Message.Thread.ID))
+		return
+	}
+
+	thread, err := s.MessageThreadStartComplex(i.ChannelID, i.Message.ID, &discordgo.ThreadStart{
+		Name:                b.t(i.GuildID, "threads.name", channelName, time.Now().UTC().Format("2006-01-02 15:04")),
+		AutoArchiveDuration: 60,
+	})
+	if err != nil {
+		slog.Error("Error creating session thread", "guild_id", i.GuildID, "channel_id", i.ChannelID, "event_type", "open_thread", "error", err)
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "threads.failed"))
+		return
+	}
+
+	// Replace the button with a link to the new thread
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{
+			Label: b.t(i.GuildID, "threads.link"),
+			Style: discordgo.LinkButton,
+			URL:   fmt.Sprintf("https://discord.com/channels/%s/%s", i.GuildID, thread.ID),
+		},
+	}
+	if b.reminderDelay > 0 {
+		buttons = append(buttons, b.reminderButton(i.GuildID, voiceChannelID))
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    i.Message.Content,
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+		},
+	})
+}
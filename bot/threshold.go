@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// minUsersCommand returns the /min-users command definition
+func minUsersCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "min-users",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type:     discordgo.ApplicationCommandOptionInteger,
+				Name:     "threshold",
+				Required: true,
+				MinValue: &[]float64{0}[0],
+				MaxValue: 99,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleMinUsers(s DiscordSession, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	voiceChannelID := options["voice-channel"].ChannelValue(nil).ID
+	threshold := int(options["threshold"].IntValue())
+	channelName := b.getChannelName(s, voiceChannelID)
+
+	found := b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+		sub.MinUsers = threshold
+	})
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.not-subscribed", channelName))
+		return
+	}
+
+	if threshold == 0 {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "threshold.cleared", channelName))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "threshold.set", threshold, channelName))
+}
+
+// notifyThresholds notifies subscriptions whose minimum user count was reached
+// by the last join. A subscription fires again after the channel dropped below
+// its threshold.
+func (b *Bot) notifyThresholds(s DiscordSession, voiceChannelID string, previousCount, count int) {
+	if count <= previousCount {
+		return
+	}
+
+	var reached []subscription
+	for _, sub := range b.subscriptions.Channel(voiceChannelID) {
+		if sub.MinUsers > previousCount && sub.MinUsers <= count && sub.Broken == "" && !sub.StatusBoard {
+			reached = append(reached, sub)
+		}
+	}
+
+	if len(reached) == 0 || !b.claim(fmt.Sprintf("threshold:%s:%d", voiceChannelID, count), b.debounceInterval) {
+		return
+	}
+
+	channelName := b.getChannelName(s, voiceChannelID)
+	for _, sub := range reached {
+		content := b.t(sub.GuildId, "threshold.reached", count, channelName)
+		sent := b.deliverNotification(s, sub, &discordgo.MessageSend{Content: content})
+		b.trackSent(sub, sent, "")
+	}
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordTimestamp renders a time as a Discord timestamp, which every reader
+// sees in their own timezone. style is one of Discord's formats, e.g. "R" for
+// "5 minutes ago" or "t" for a short time.
+func discordTimestamp(t time.Time, style string) string {
+	return fmt.Sprintf("<t:%d:%s>", t.Unix(), style)
+}
+
+// timestamps reports whether a guild adds Discord timestamps to notifications
+func (b *Bot) timestamps(guildID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.timestampGuilds[guildID]
+}
+
+// eventTimestamp returns the timestamp appended to a built-in message: when
+// the event happened, or the span of the session for an emptied channel
+func eventTimestamp(event TemplateEvent) string {
+	if event.Type == TemplateEventEmpty && event.Duration > 0 {
+		return discordTimestamp(event.Time.Add(-event.Duration), "t") + "–" + discordTimestamp(event.Time, "t")
+	}
+	return discordTimestamp(event.Time, "R")
+}
+
+// handleConfigTimestamps turns Discord timestamps in notifications on or off
+func (b *Bot) handleConfigTimestamps(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	opt, ok := options["enabled"]
+	if !ok {
+		state := b.t(i.GuildID, "timestamps.without")
+		if b.timestamps(i.GuildID) {
+			state = b.t(i.GuildID, "timestamps.with")
+		}
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "style.current", state))
+		return
+	}
+
+	enabled := opt.BoolValue()
+	b.mu.Lock()
+	if enabled {
+		b.timestampGuilds[i.GuildID] = true
+	} else {
+		delete(b.timestampGuilds, i.GuildID)
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Notification timestamps changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "timestamps", enabled)
+	if enabled {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "timestamps.on", discordTimestamp(time.Now(), "R")))
+		return
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "timestamps.off"))
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Tone presets for the built-in messages
+const (
+	toneCasual = "casual" // the default
+	toneFormal = "formal"
+	toneMeme   = "meme"
+)
+
+// tonePresets lists the tones of the built-in messages. Catalogs hold each
+// tone's template for an event under "notify.<tone>.<event>".
+var tonePresets = []string{toneCasual, toneFormal, toneMeme}
+
+// guildTone returns the tone of a guild's built-in messages
+func (b *Bot) guildTone(guildID string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if tone, ok := b.tones[guildID]; ok {
+		return tone
+	}
+	return toneCasual
+}
+
+// renderMessage renders an event with the guild's custom template, or the
+// built-in message of the guild's tone if it has none. Built-in messages end
+// with a timestamp if the guild turned them on, custom templates place their
+// own with the timestamp function.
+func (b *Bot) renderMessage(guildID string, event TemplateEvent) string {
+	if message, ok := b.renderGuildTemplate(guildID, event); ok {
+		return message
+	}
+
+	message, err := RenderTemplate(b.tonePreset(guildID, event.Type), event)
+	if err != nil {
+		// The presets are static, so this only happens for an unknown event type
+		slog.Error("Error rendering built-in message", "guild_id", guildID, "event_type", event.Type, "error", err)
+		message = b.t(guildID, "notify.fallback", event.User, event.Channel)
+	}
+	if b.timestamps(guildID) && !event.Time.IsZero() {
+		stamp := eventTimestamp(event)
+		message = truncateMessage(message, maxMessageLength-len(stamp)-1) + " " + stamp
+	}
+	return message
+}
+
+// tonePreset returns the built-in template of an event in the guild's tone and
+// language
+func (b *Bot) tonePreset(guildID, eventType string) string {
+	return b.presetIn(b.guildLanguage(guildID), b.guildTone(guildID), eventType)
+}
+
+// presetIn returns the built-in template of an event in a tone and language,
+// or "" for an unknown event
+func (b *Bot) presetIn(locale, tone, eventType string) string {
+	text, _ := b.lookup(locale, "notify."+tone+"."+eventType)
+	return text
+}
+
+// handleConfigTone sets the tone of the guild's built-in messages
+func (b *Bot) handleConfigTone(s DiscordSession, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	opt, ok := options["preset"]
+	if !ok {
+		tone := b.guildTone(i.GuildID)
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "tone.current", b.t(i.GuildID, "tone."+tone), b.toneSample(i.GuildID, tone)))
+		return
+	}
+
+	tone := opt.StringValue()
+	if !slices.Contains(tonePresets, tone) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "tone.unknown", tone))
+		return
+	}
+
+	b.mu.Lock()
+	if tone == toneCasual {
+		delete(b.tones, i.GuildID)
+	} else {
+		b.tones[i.GuildID] = tone
+	}
+	b.savePersistedDataAsync()
+	b.mu.Unlock()
+
+	slog.Info("Message tone changed", "audit", true, "guild_id", i.GuildID, "user_id", interactionUserID(i), "tone", tone)
+
+	note := ""
+	if b.hasTemplates(i.GuildID) {
+		note = "\n" + b.t(i.GuildID, "tone.custom-templates")
+	}
+	respondEphemeral(s, i.Interaction, b.t(i.GuildID, "tone.set", b.t(i.GuildID, "tone."+tone), b.toneSample(i.GuildID, tone))+note)
+}
+
+// toneSample renders each event of a tone in the guild's language with a
+// sample event
+func (b *Bot) toneSample(guildID, tone string) string {
+	locale := b.guildLanguage(guildID)
+	var lines []string
+	for _, event := range TemplateEvents {
+		sample := SampleEvents(event)[0]
+		if event == TemplateEventMove {
+			sample = SampleEvents(event)[1]
+		}
+		if rendered, err := RenderTemplate(b.presetIn(locale, tone, event), sample); err == nil {
+			lines = append(lines, rendered)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hasTemplates reports whether a guild has any custom templates
+func (b *Bot) hasTemplates(guildID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.templates[guildID]) > 0
+}
@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord's embed limits
+const (
+	maxEmbedTitle       = 256
+	maxEmbedDescription = 4096
+	maxEmbedFields      = 25
+	maxEmbedFieldName   = 256
+	maxEmbedFieldValue  = 1024
+	maxEmbedTotal       = 6000 // all embeds of a message together
+
+	// minMessageLength is the smallest max length a subscription can set
+	minMessageLength = 100
+)
+
+// joinLimited joins items with sep, ending with "and N others…" when not all
+// of them fit into limit characters
+func (b *Bot) joinLimited(guildID string, items []string, sep string, limit int) string {
+	joined := strings.Join(items, sep)
+	if utf8.RuneCountInString(joined) <= limit {
+		return joined
+	}
+
+	var out strings.Builder
+	for idx, item := range items {
+		rest := " " + b.t(guildID, "truncation.others", len(items)-idx)
+		next := item
+		if idx > 0 {
+			next = sep + item
+		}
+		if utf8.RuneCountInString(out.String())+utf8.RuneCountInString(next)+utf8.RuneCountInString(rest) > limit {
+			if idx == 0 {
+				return strings.TrimSpace(rest)
+			}
+			return out.String() + rest
+		}
+		out.WriteString(next)
+	}
+	return out.String()
+}
+
+// truncateLines shortens content to limit characters at a line break when
+// possible, noting how many lines were cut
+func (b *Bot) truncateLines(guildID, content string, limit int) string {
+	if utf8.RuneCountInString(content) <= limit {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for keep := len(lines) - 1; keep > 0; keep-- {
+		kept := strings.Join(lines[:keep], "\n") + "\n" + b.t(guildID, "truncation.more-lines", len(lines)-keep)
+		if utf8.RuneCountInString(kept) <= limit {
+			return kept
+		}
+	}
+	return truncateMessage(content, limit)
+}
+
+// splitEmbedField splits a field whose value is too long into several fields
+// at line breaks, naming the continuations "name (cont.)"
+func (b *Bot) splitEmbedField(guildID string, original *discordgo.MessageEmbedField) []*discordgo.MessageEmbedField {
+	field := *original
+	field.Name = truncateMessage(field.Name, maxEmbedFieldName)
+	if utf8.RuneCountInString(field.Value) <= maxEmbedFieldValue {
+		return []*discordgo.MessageEmbedField{&field}
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	var value strings.Builder
+	continued := b.t(guildID, "truncation.continued", field.Name)
+	flush := func() {
+		name := field.Name
+		if len(fields) > 0 {
+			name = truncateMessage(continued, maxEmbedFieldName)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: value.String(), Inline: field.Inline})
+		value.Reset()
+	}
+	for _, line := range strings.Split(field.Value, "\n") {
+		line = truncateMessage(line, maxEmbedFieldValue)
+		if value.Len() > 0 && utf8.RuneCountInString(value.String())+1+utf8.RuneCountInString(line) > maxEmbedFieldValue {
+			flush()
+		}
+		if value.Len() > 0 {
+			value.WriteString("\n")
+		}
+		value.WriteString(line)
+	}
+	flush()
+	return fields
+}
+
+// fitEmbed makes an embed respect Discord's per-embed limits. Long fields are
+// split, and fields beyond the limit are replaced by an "and N more" field.
+func (b *Bot) fitEmbed(guildID string, embed *discordgo.MessageEmbed) {
+	embed.Title = truncateMessage(embed.Title, maxEmbedTitle)
+	embed.Description = b.truncateLines(guildID, embed.Description, maxEmbedDescription)
+
+	var fields []*discordgo.MessageEmbedField
+	for _, field := range embed.Fields {
+		fields = append(fields, b.splitEmbedField(guildID, field)...)
+	}
+	if len(fields) > maxEmbedFields {
+		dropped := len(fields) - maxEmbedFields + 1
+		fields = append(fields[:maxEmbedFields-1], &discordgo.MessageEmbedField{
+			Name:  "…",
+			Value: b.t(guildID, "truncation.more", dropped),
+		})
+	}
+	embed.Fields = fields
+}
+
+// embedLength counts the characters Discord includes in the 6000 limit
+func embedLength(embed *discordgo.MessageEmbed) int {
+	length := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	for _, field := range embed.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	if embed.Footer != nil {
+		length += utf8.RuneCountInString(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		length += utf8.RuneCountInString(embed.Author.Name)
+	}
+	return length
+}
+
+// fitMessage returns a copy of a message that respects limit characters of
+// content and Discord's embed limits, logging when anything had to be cut
+func (b *Bot) fitMessage(original *discordgo.MessageSend, limit int, guildID, channelID string) *discordgo.MessageSend {
+	if limit <= 0 || limit > maxMessageLength {
+		limit = maxMessageLength
+	}
+
+	message := *original
+	length := utf8.RuneCountInString(message.Content)
+	truncated := length > limit
+	message.Content = b.truncateLines(guildID, message.Content, limit)
+
+	if len(original.Embeds) > 0 {
+		message.Embeds = make([]*discordgo.MessageEmbed, len(original.Embeds))
+		for idx, embed := range original.Embeds {
+			embedCopy := *embed
+			message.Embeds[idx] = &embedCopy
+		}
+	}
+
+	total := 0
+	for idx, embed := range message.Embeds {
+		before := embedLength(embed)
+		b.fitEmbed(guildID, embed)
+		after := embedLength(embed)
+		truncated = truncated || after < before
+
+		// Drop trailing fields, then whole embeds, until the total fits
+		for total+after > maxEmbedTotal && len(embed.Fields) > 0 {
+			embed.Fields = embed.Fields[:len(embed.Fields)-1]
+			after = embedLength(embed)
+			truncated = true
+		}
+		if total+after > maxEmbedTotal {
+			message.Embeds = message.Embeds[:idx]
+			truncated = true
+			break
+		}
+		total += after
+	}
+
+	if truncated {
+		slog.Info("Notification shortened to fit length limits", "guild_id", guildID, "channel_id", channelID, "length", length, "limit", limit)
+	}
+	return &message
+}
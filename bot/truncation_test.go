@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestTruncateMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		want    string
+	}{
+		{name: "fits", content: "Alice joined", limit: 12, want: "Alice joined"},
+		{name: "ascii", content: "Alice joined", limit: 6, want: "Alice…"},
+		{name: "multibyte", content: "Zoë und Jürgen", limit: 6, want: "Zoë u…"},
+		{name: "emoji", content: "👋👋👋👋", limit: 3, want: "👋👋…"},
+		{name: "escape kept whole", content: `Bob\_ joined`, limit: 7, want: `Bob\_ …`},
+		{name: "escape not split", content: `Bob\_ joined`, limit: 5, want: "Bob…"},
+		{name: "escaped backslash", content: `a\\b joined`, limit: 4, want: `a\\…`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateMessage(tt.content, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateMessage(%q, %d) = %q, want %q", tt.content, tt.limit, got, tt.want)
+			}
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) > tt.limit {
+				t.Errorf("%q is not %d valid characters at most", got, tt.limit)
+			}
+		})
+	}
+}
+
+func TestJoinLimited(t *testing.T) {
+	names := []string{"**Alice**", "**Bob**", "**Zoë**"}
+	tests := []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{name: "fits", limit: 100, want: "**Alice**, **Bob**, **Zoë**"},
+		{name: "whole names only", limit: 25, want: "**Alice** and 2 others…"},
+		{name: "no name fits", limit: 15, want: "and 3 others…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			got := b.joinLimited("1", names, ", ", tt.limit)
+			if got != tt.want {
+				t.Errorf("joinLimited(%d) = %q, want %q", tt.limit, got, tt.want)
+			}
+			if utf8.RuneCountInString(got) > tt.limit {
+				t.Errorf("%q is longer than %d characters", got, tt.limit)
+			}
+		})
+	}
+}
+
+func TestTruncateLines(t *testing.T) {
+	content := "**Alice** joined\n**Bob** joined\n**Zoë** joined"
+	tests := []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{name: "fits", limit: 100, want: content},
+		{name: "cut at a line", limit: 40, want: "**Alice** joined\n… and 2 more lines"},
+		{name: "no line fits", limit: 12, want: "**Alice** j…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			got := b.truncateLines("1", content, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateLines(%d) = %q, want %q", tt.limit, got, tt.want)
+			}
+			if utf8.RuneCountInString(got) > tt.limit {
+				t.Errorf("%q is longer than %d characters", got, tt.limit)
+			}
+		})
+	}
+}
+
+func TestSplitEmbedField(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		lines int
+		want  []int // lines per field
+	}{
+		{name: "ascii", line: strings.Repeat("x", 99), lines: 15, want: []int{10, 5}},
+		{name: "counted in characters", line: strings.Repeat("é", 99), lines: 15, want: []int{10, 5}},
+		{name: "long lines", line: strings.Repeat("x", maxEmbedFieldValue+10), lines: 2, want: []int{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			value := strings.TrimSuffix(strings.Repeat(tt.line+"\n", tt.lines), "\n")
+			fields := b.splitEmbedField("1", &discordgo.MessageEmbedField{Name: "Who", Value: value})
+			if len(fields) != len(tt.want) {
+				t.Fatalf("%d fields, want %d", len(fields), len(tt.want))
+			}
+			for idx, field := range fields {
+				wantName := "Who"
+				if idx > 0 {
+					wantName = "Who (cont.)"
+				}
+				if field.Name != wantName {
+					t.Errorf("field %d named %q, want %q", idx, field.Name, wantName)
+				}
+				if n := utf8.RuneCountInString(field.Value); n > maxEmbedFieldValue {
+					t.Errorf("field %d has %d characters", idx, n)
+				}
+				if got := strings.Count(field.Value, "\n") + 1; got != tt.want[idx] {
+					t.Errorf("field %d has %d lines, want %d", idx, got, tt.want[idx])
+				}
+			}
+		})
+	}
+}
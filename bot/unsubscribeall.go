@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// unsubscribeAllCommand returns the /unsubscribe-all command definition
+func unsubscribeAllCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name: "unsubscribe-all",
+	}
+}
+
+// handleUnsubscribeAll lists what the text channel is subscribed to and asks
+// for confirmation before removing it all
+func (b *Bot) handleUnsubscribeAll(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireSubscribeAccess(s, i) {
+		return
+	}
+
+	subs := b.subscriptions.Filter(func(sub subscription) bool {
+		return sub.GuildId == i.GuildID && sub.TextChannelId == i.ChannelID
+	})
+	names := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		names = append(names, fmt.Sprintf("**%s**", b.getChannelName(s, sub.VoiceChannelId)))
+	}
+	var patterns []string
+	b.mu.RLock()
+	for _, pattern := range b.patternSubscriptions[i.GuildID] {
+		if pattern.TextChannelId == i.ChannelID {
+			patterns = append(patterns, pattern.Pattern)
+		}
+	}
+	b.mu.RUnlock()
+	for _, pattern := range patterns {
+		names = append(names, b.t(i.GuildID, "fallback.pattern", pattern))
+	}
+
+	if len(names) == 0 {
+		respondEphemeral(s, i.Interaction, b.t(i.GuildID, "unsubscribe.none"))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.t(i.GuildID, "unsubscribe-all.confirm", len(names), b.joinLimited(i.GuildID, names, ", ", 1500)),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: b.t(i.GuildID, "unsubscribe-all.confirm-button"), Style: discordgo.DangerButton, CustomID: "unsubscribe_all_confirm"},
+						discordgo.Button{Label: b.t(i.GuildID, "common.cancel"), Style: discordgo.SecondaryButton, CustomID: "unsubscribe_all_cancel"},
+					},
+				},
+			},
+		},
+	})
+}
+
+// handleUnsubscribeAllButton removes the text channel's subscriptions or
+// cancels. Subscriptions added since the confirmation was shown go too.
+func (b *Bot) handleUnsubscribeAllButton(s DiscordSession, i *discordgo.InteractionCreate) {
+	content := b.t(i.GuildID, "unsubscribe-all.canceled")
+	if i.MessageComponentData().CustomID == "unsubscribe_all_confirm" {
+		// Access may have changed since the confirmation was shown
+		if !b.requireSubscribeAccess(s, i) {
+			return
+		}
+		removed := b.subscriptions.RemoveFunc(func(sub subscription) bool {
+			return sub.GuildId == i.GuildID && sub.TextChannelId == i.ChannelID
+		})
+		_, patterns := b.movePatternSubscriptions(i.GuildID, i.ChannelID, "")
+		slog.Info("Text channel unsubscribed from everything", "audit", true, "guild_id", i.GuildID, "channel_id", i.ChannelID, "user_id", interactionUserID(i), "removed", len(removed), "removed_patterns", len(patterns))
+		content = b.t(i.GuildID, "unsubscribe-all.done", len(removed)+len(patterns))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Orders of /list-subscriptions
+const (
+	listSortName      = "name"
+	listSortLastFired = "last-fired"
+)
+
+// lastFiredResolution is how often a subscription's last fired time is saved.
+// The list shows days, so saving on every notification isn't worth the writes.
+const lastFiredResolution = time.Hour
+
+type (
+	// subscriptionListView selects and orders the subscriptions in /list-subscriptions
+	subscriptionListView struct {
+		sort      string        // listSortName or listSortLastFired
+		unusedFor time.Duration // only subscriptions that haven't fired for this long, 0 shows all
+		page      int           // which 25 voice channels are shown, see maxSelectOptions
+	}
+)
+
+// pageCustomID returns the custom ID of a button that shows another page of
+// the view: "list_page:<page>:<sort>:<unused days>"
+func (view subscriptionListView) pageCustomID(page int) string {
+	return fmt.Sprintf("list_page:%d:%s:%d", page, view.sort, int(view.unusedFor.Hours()/24))
+}
+
+// parseListPage reads the view of a page button's custom ID
+func parseListPage(customID string) (subscriptionListView, bool) {
+	parts := strings.Split(strings.TrimPrefix(customID, "list_page:"), ":")
+	if len(parts) != 3 {
+		return subscriptionListView{}, false
+	}
+	page, errPage := strconv.Atoi(parts[0])
+	days, errDays := strconv.Atoi(parts[2])
+	if errPage != nil || errDays != nil {
+		return subscriptionListView{}, false
+	}
+	return subscriptionListView{sort: parts[1], unusedFor: time.Duration(days) * 24 * time.Hour, page: page}, true
+}
+
+// recordFired remembers that a subscription delivered a notification
+func (b *Bot) recordFired(sub subscription) {
+	now := time.Now()
+	if now.Sub(sub.LastFiredAt) < lastFiredResolution {
+		return
+	}
+	b.updateSubscription(sub.VoiceChannelId, sub.TextChannelId, func(existing *subscription) {
+		existing.LastFiredAt = now
+	})
+}
+
+// lastFiredText describes when a subscription last fired, for the list view
+func (b *Bot) lastFiredText(sub subscription) string {
+	if sub.LastFiredAt.IsZero() {
+		return b.t(sub.GuildId, "usage.never-fired")
+	}
+	return b.t(sub.GuildId, "usage.last-fired", sub.LastFiredAt.Unix())
+}
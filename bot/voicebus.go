@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Normalized voice event types
+const (
+	VoiceJoin   VoiceEventType = "join"
+	VoiceLeave  VoiceEventType = "leave"
+	VoiceMove   VoiceEventType = "move"
+	VoiceMute   VoiceEventType = "mute"
+	VoiceStream VoiceEventType = "stream"
+)
+
+type (
+	// VoiceEventType names a normalized voice event
+	VoiceEventType string
+
+	// VoiceEvent is one thing a user did in voice, derived from a voice state
+	// update. Bots never produce events.
+	VoiceEvent struct {
+		Type          VoiceEventType
+		GuildId       string
+		UserId        string
+		ChannelId     string // the channel joined, left, or where the mute or stream changed
+		FromChannelId string // the channel left, for moves
+		Member        *discordgo.Member
+		State         *discordgo.VoiceState // the user's voice state after the event
+		Muted         bool                  // self-muted or self-deafened, for mute events
+		Streaming     bool                  // for stream events
+		Ignored       bool                  // the user must not be announced
+		At            time.Time
+
+		previousCount int       // users in the joined channel before the event
+		leftSince     time.Time // when the channel left last became occupied
+	}
+
+	// VoiceEventHandler consumes voice events. For each voice state update,
+	// handlers run one after another in order of registration on the goroutine
+	// discordgo dispatched it on, so a slow handler delays the ones after it
+	// but not the gateway. Handlers whose REST calls need not happen in event
+	// order, such as reports, make them in a goroutine.
+	VoiceEventHandler func(s DiscordSession, event VoiceEvent)
+
+	// voiceBus hands voice events to every registered handler
+	voiceBus struct {
+		handlers []namedVoiceHandler
+		mu       sync.RWMutex
+	}
+
+	namedVoiceHandler struct {
+		name    string
+		handler VoiceEventHandler
+	}
+)
+
+// Joined returns the channel the user is in after a join or move
+func (event VoiceEvent) Joined() string {
+	if event.Type == VoiceJoin || event.Type == VoiceMove {
+		return event.ChannelId
+	}
+	return ""
+}
+
+// Left returns the channel the user was in before a leave or move
+func (event VoiceEvent) Left() string {
+	switch event.Type {
+	case VoiceLeave:
+		return event.ChannelId
+	case VoiceMove:
+		return event.FromChannelId
+	}
+	return ""
+}
+
+// OnVoiceEvent registers a handler for every voice event. name identifies the
+// handler in logs. Register handlers before Start.
+func (b *Bot) OnVoiceEvent(name string, handler VoiceEventHandler) {
+	b.voiceBus.mu.Lock()
+	defer b.voiceBus.mu.Unlock()
+	b.voiceBus.handlers = append(b.voiceBus.handlers, namedVoiceHandler{name: name, handler: handler})
+}
+
+// publish hands events to the handlers in order of registration. A panicking
+// handler is logged and skipped, so it can't take down the others.
+func (bus *voiceBus) publish(s DiscordSession, events []VoiceEvent) {
+	bus.mu.RLock()
+	handlers := bus.handlers
+	bus.mu.RUnlock()
+
+	for _, event := range events {
+		for _, h := range handlers {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						slog.Error("Voice event handler panicked", "handler", h.name, "guild_id", event.GuildId, "user_id", event.UserId, "event_type", string(event.Type), "error", r)
+					}
+				}()
+				h.handler(s, event)
+			}()
+		}
+	}
+}
+
+// voiceEvents normalizes a voice state update into events: a join, leave, or
+// move when the channel changed, otherwise mute and stream changes
+func voiceEvents(vsu *discordgo.VoiceStateUpdate, member *discordgo.Member, leftChannelID, joinedChannelID string, ignored bool) []VoiceEvent {
+	base := VoiceEvent{
+		GuildId: vsu.GuildID,
+		UserId:  vsu.UserID,
+		Member:  member,
+		State:   vsu.VoiceState,
+		Ignored: ignored,
+		At:      time.Now(),
+	}
+
+	switch {
+	case leftChannelID != "" && joinedChannelID != "":
+		base.Type, base.ChannelId, base.FromChannelId = VoiceMove, joinedChannelID, leftChannelID
+		return []VoiceEvent{base}
+	case joinedChannelID != "":
+		base.Type, base.ChannelId = VoiceJoin, joinedChannelID
+		return []VoiceEvent{base}
+	case leftChannelID != "":
+		base.Type, base.ChannelId = VoiceLeave, leftChannelID
+		return []VoiceEvent{base}
+	case vsu.BeforeUpdate == nil || vsu.ChannelID == "":
+		return nil
+	}
+
+	base.ChannelId = vsu.ChannelID
+	before := vsu.BeforeUpdate
+	var events []VoiceEvent
+	if muted := vsu.SelfMute || vsu.SelfDeaf; muted != (before.SelfMute || before.SelfDeaf) {
+		event := base
+		event.Type, event.Muted = VoiceMute, muted
+		events = append(events, event)
+	}
+	if vsu.SelfStream != before.SelfStream {
+		event := base
+		event.Type, event.Streaming = VoiceStream, vsu.SelfStream
+		events = append(events, event)
+	}
+	return events
+}
+
+// logVoiceEvent writes every voice event to the debug log
+func logVoiceEvent(s DiscordSession, event VoiceEvent) {
+	slog.Debug("Voice event", "guild_id", event.GuildId, "user_id", event.UserId, "channel_id", event.ChannelId, "from_channel_id", event.FromChannelId, "event_type", string(event.Type))
+}
+
+// registerVoiceConsumers subscribes the built-in features to voice events.
+// Occupancy is updated before events are published, so every handler sees the
+// channels' users after the event. Notifications are sent in order of the
+// events, since debouncing pairs joins with later leaves, and before session
+// stats end the session they take the time stayed from.
+func (b *Bot) registerVoiceConsumers() {
+	b.OnVoiceEvent("log", logVoiceEvent)
+	b.OnVoiceEvent("notifications", b.notifyVoiceEvent)
+	b.OnVoiceEvent("session stats", b.trackSession)
+	b.OnVoiceEvent("status boards", func(s DiscordSession, event VoiceEvent) {
+		b.scheduleStatusBoards(s, event.Left())
+		b.scheduleStatusBoards(s, event.Joined())
+	})
+	b.OnVoiceEvent("auto-delete", func(s DiscordSession, event VoiceEvent) {
+		if left := event.Left(); left != "" {
+			b.deleteOnLeave(s, event.UserId, left)
+		}
+	})
+	b.OnVoiceEvent("watchlist", b.reportWatchedActivity)
+	if b.eventWebhook != nil {
+		b.OnVoiceEvent("event webhook", b.eventWebhook.publish)
+	}
+	if b.eventHistory != nil {
+		b.OnVoiceEvent("event history", b.eventHistory.record)
+	}
+}
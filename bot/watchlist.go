@@ -0,0 +1,257 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const watchlistColor = 0xED4245 // Discord Red
+
+var manageServerPermission int64 = discordgo.PermissionManageServer
+
+// watchlistCommands returns the slash commands for the moderator watchlist
+func watchlistCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "watch",
+			DefaultMemberPermissions: &manageServerPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "voice-channel",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+				},
+			},
+		},
+		{
+			Name:                     "unwatch",
+			DefaultMemberPermissions: &manageServerPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "voice-channel",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+				},
+			},
+		},
+		{
+			Name:                     "watchlist",
+			DefaultMemberPermissions: &manageServerPermission,
+		},
+	}
+}
+
+func (b *Bot) handleWatch(s DiscordSession, i *discordgo.InteractionCreate, watch bool) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	voiceChannelID := i.ApplicationCommandData().Options[0].ChannelValue(nil).ID
+	channelName := b.getChannelName(s, voiceChannelID)
+	moderator := interactionUserID(i)
+
+	var changed bool
+	var responseText string
+	if watch {
+		changed = b.addWatch(i.GuildID, voiceChannelID)
+		responseText = b.t(i.GuildID, "watchlist.added", channelName)
+		if !changed {
+			responseText = b.t(i.GuildID, "watchlist.already", channelName)
+		}
+	} else {
+		changed = b.removeWatch(i.GuildID, voiceChannelID)
+		responseText = b.t(i.GuildID, "watchlist.removed", channelName)
+		if !changed {
+			responseText = b.t(i.GuildID, "watchlist.not-watched", channelName)
+		}
+	}
+
+	if changed {
+		slog.Info("Watchlist changed", "audit", true, "guild_id", i.GuildID, "user_id", moderator, "watch", watch, "voice_channel_id", voiceChannelID)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: responseText,
+		},
+	})
+}
+
+func (b *Bot) handleWatchlist(s DiscordSession, i *discordgo.InteractionCreate) {
+	if !b.requireAdminChannel(s, i) {
+		return
+	}
+
+	b.mu.RLock()
+	watched := slices.Clone(b.watchlist[i.GuildID])
+	b.mu.RUnlock()
+
+	if len(watched) == 0 {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "watchlist.empty"))
+		return
+	}
+
+	var description strings.Builder
+	for _, voiceChannelID := range watched {
+		description.WriteString(fmt.Sprintf("👁️ <#%s>\n", voiceChannelID))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       b.t(i.GuildID, "watchlist.title"),
+				Description: description.String(),
+				Color:       watchlistColor,
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// addWatch adds a voice channel to the guild's watchlist and returns whether it was added
+func (b *Bot) addWatch(guildID, voiceChannelID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if slices.Contains(b.watchlist[guildID], voiceChannelID) {
+		return false
+	}
+	b.watchlist[guildID] = append(b.watchlist[guildID], voiceChannelID)
+
+	b.savePersistedDataAsync()
+	return true
+}
+
+// removeWatch removes a voice channel from the guild's watchlist and returns whether it was watched
+func (b *Bot) removeWatch(guildID, voiceChannelID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	idx := slices.Index(b.watchlist[guildID], voiceChannelID)
+	if idx < 0 {
+		return false
+	}
+
+	b.watchlist[guildID] = slices.Delete(b.watchlist[guildID], idx, idx+1)
+	if len(b.watchlist[guildID]) == 0 {
+		delete(b.watchlist, guildID)
+	}
+
+	b.savePersistedDataAsync()
+	return true
+}
+
+// isWatched reports whether a voice channel is on the guild's watchlist
+func (b *Bot) isWatched(guildID, voiceChannelID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Contains(b.watchlist[guildID], voiceChannelID)
+}
+
+// reportWatchedActivity posts a detailed, undebounced report to the admin
+// channel when a user enters or leaves a watched voice channel. Every report
+// is also written to the audit log.
+func (b *Bot) reportWatchedActivity(s DiscordSession, voiceEvent VoiceEvent) {
+	joinedChannelID, leftChannelID := voiceEvent.Joined(), voiceEvent.Left()
+	state, member := voiceEvent.State, voiceEvent.Member
+	watchedJoin := joinedChannelID != "" && b.isWatched(state.GuildID, joinedChannelID)
+	watchedLeave := leftChannelID != "" && b.isWatched(state.GuildID, leftChannelID)
+	if !watchedJoin && !watchedLeave {
+		return
+	}
+
+	event := "moved"
+	switch {
+	case leftChannelID == "":
+		event = "joined"
+	case joinedChannelID == "":
+		event = "left"
+	}
+	guildID := state.GuildID
+
+	slog.Info("Watched voice activity", "audit", true, "guild_id", state.GuildID, "user_id", state.UserID, "event_type", event,
+		"from", leftChannelID, "to", joinedChannelID, "self_mute", state.SelfMute, "self_deaf", state.SelfDeaf, "mute", state.Mute, "deaf", state.Deaf)
+
+	b.mu.RLock()
+	adminChannelID, hasAdminChannel := b.adminChannels[state.GuildID]
+	b.mu.RUnlock()
+
+	if !hasAdminChannel {
+		return
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: b.t(guildID, "watchlist.user"), Value: fmt.Sprintf("<@%s> (%s)", state.UserID, getUsername(member)), Inline: true},
+		{Name: b.t(guildID, "watchlist.event"), Value: b.t(guildID, "watchlist.event."+event), Inline: true},
+	}
+	if leftChannelID != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: b.t(guildID, "watchlist.from"), Value: fmt.Sprintf("<#%s>", leftChannelID), Inline: true})
+	}
+	if joinedChannelID != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: b.t(guildID, "watchlist.to"), Value: fmt.Sprintf("<#%s>", joinedChannelID), Inline: true})
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   b.t(guildID, "watchlist.audio"),
+			Value:  b.audioState(guildID, state),
+			Inline: false,
+		})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     b.t(guildID, "watchlist.report"),
+		Color:     watchlistColor,
+		Fields:    fields,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	// Reports need no ordering, so they don't hold up later voice event handlers
+	embed = b.presentEmbed(state.GuildID, embed)
+	go func() {
+		if _, err := s.ChannelMessageSendEmbed(adminChannelID, embed); err != nil {
+			slog.Error("Error sending watchlist report", "guild_id", state.GuildID, "channel_id", adminChannelID, "event_type", "watchlist", "error", err)
+		}
+	}()
+}
+
+// audioState describes the mute/deafen state of a voice state
+func (b *Bot) audioState(guildID string, vs *discordgo.VoiceState) string {
+	var states []string
+	for _, flag := range []struct {
+		on  bool
+		key string
+	}{
+		{vs.SelfMute, "audio.self-muted"},
+		{vs.SelfDeaf, "audio.self-deafened"},
+		{vs.Mute, "audio.server-muted"},
+		{vs.Deaf, "audio.server-deafened"},
+		{vs.SelfStream, "audio.streaming"},
+		{vs.SelfVideo, "audio.camera"},
+	} {
+		if flag.on {
+			states = append(states, b.t(guildID, flag.key))
+		}
+	}
+	if len(states) == 0 {
+		return b.t(guildID, "audio.unmuted")
+	}
+	return strings.Join(states, ", ")
+}
+
+// interactionUserID returns the ID of the user who triggered an interaction
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,269 @@
+package bot
+
+import (
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// webhookName is the name of the webhook the bot creates in text channels
+const webhookName = "VoiceActivityBot"
+
+// channelWebhook returns the bot's webhook for a text channel, creating it if needed
+func (b *Bot) channelWebhook(s DiscordSession, textChannelID string) (*discordgo.Webhook, error) {
+	b.webhookMu.Lock()
+	defer b.webhookMu.Unlock()
+
+	if webhook, ok := b.webhooks[textChannelID]; ok {
+		return webhook, nil
+	}
+
+	webhooks, err := s.ChannelWebhooks(textChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.Name == webhookName && webhook.Token != "" && webhook.User != nil && webhook.User.ID == b.session.State.User.ID {
+			b.webhooks[textChannelID] = webhook
+			return webhook, nil
+		}
+	}
+
+	webhook, err := s.WebhookCreate(textChannelID, webhookName, "")
+	if err != nil {
+		return nil, err
+	}
+	b.webhooks[textChannelID] = webhook
+	return webhook, nil
+}
+
+// deliver sends a notification message to a subscription's text channel,
+// through a webhook when webhook delivery is enabled
+func (b *Bot) deliver(s DiscordSession, sub subscription, message *discordgo.MessageSend) (sent *discordgo.Message, err error) {
+	message, allowed := b.moderate(sub.GuildId, message)
+	if !allowed {
+		slog.Info("Notification vetoed by moderation", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "event_type", "moderation")
+		return nil, nil
+	}
+	defer func() { b.deliveryStats.record(sub, err) }()
+
+	message = b.presentMessage(sub.GuildId, message)
+	message = b.fitMessage(message, sub.MaxLength, sub.GuildId, sub.TextChannelId)
+	if sub.isDM() || (!b.webhookDelivery && sub.Style != styleWebhook) {
+		return s.ChannelMessageSendComplex(sub.TextChannelId, message)
+	}
+
+	webhook, err := b.channelWebhook(s, sub.TextChannelId)
+	if err != nil {
+		slog.Warn("Webhook unavailable, falling back to bot message", "guild_id", sub.GuildId, "channel_id", sub.TextChannelId, "error", err)
+		return s.ChannelMessageSendComplex(sub.TextChannelId, message)
+	}
+
+	params := &discordgo.WebhookParams{
+		Content:         message.Content,
+		Components:      message.Components,
+		Embeds:          message.Embeds,
+		Username:        sub.WebhookName,
+		AvatarURL:       sub.WebhookAvatarURL,
+		AllowedMentions: message.AllowedMentions,
+		Flags:           message.Flags,
+	}
+
+	sent, err = s.WebhookExecute(webhook.ID, webhook.Token, true, params)
+	if restErr, ok := err.(*discordgo.RESTError); ok && restErr.Response != nil && restErr.Response.StatusCode == 404 {
+		// Webhook was deleted, recreate it on the next delivery
+		b.webhookMu.Lock()
+		delete(b.webhooks, sub.TextChannelId)
+		b.webhookMu.Unlock()
+	}
+	return sent, err
+}
+
+// subscriptionSettingsCommand returns the /subscription-settings command definition
+func subscriptionSettingsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     "subscription-settings",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+		},
+	}
+}
+
+// handleSubscriptionSettings opens the settings modal for a subscription
+func (b *Bot) handleSubscriptionSettings(s DiscordSession, i *discordgo.InteractionCreate) {
+	voiceChannelID := i.ApplicationCommandData().Options[0].ChannelValue(nil).ID
+
+	sub, found := b.getSubscription(voiceChannelID, i.ChannelID)
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "common.not-subscribed", b.getChannelName(s, voiceChannelID)))
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "subscription_settings:" + voiceChannelID,
+			Title:    b.t(i.GuildID, "settings.title"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "webhook_name",
+							Label:       b.t(i.GuildID, "settings.name"),
+							Style:       discordgo.TextInputShort,
+							Placeholder: b.t(i.GuildID, "settings.name.placeholder"),
+							Value:       sub.WebhookName,
+							MaxLength:   80,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "webhook_avatar",
+							Label:       b.t(i.GuildID, "settings.avatar"),
+							Style:       discordgo.TextInputShort,
+							Placeholder: b.t(i.GuildID, "settings.avatar.placeholder"),
+							Value:       sub.WebhookAvatarURL,
+							MaxLength:   500,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "style",
+							Label:       b.t(i.GuildID, "settings.style"),
+							Style:       discordgo.TextInputShort,
+							Placeholder: b.t(i.GuildID, "settings.style.placeholder"),
+							Value:       sub.Style,
+							MaxLength:   20,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "max_length",
+							Label:       b.t(i.GuildID, "settings.max-length"),
+							Style:       discordgo.TextInputShort,
+							Placeholder: b.t(i.GuildID, "settings.max-length.placeholder", minMessageLength, maxMessageLength, maxMessageLength),
+							Value:       maxLengthValue(sub.MaxLength),
+							MaxLength:   4,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "label",
+							Label:       b.t(i.GuildID, "settings.label"),
+							Style:       discordgo.TextInputShort,
+							Placeholder: b.t(i.GuildID, "settings.label.placeholder"),
+							Value:       sub.Label,
+							MaxLength:   maxLabelLength,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Error opening subscription settings modal", "guild_id", i.GuildID, "channel_id", i.ChannelID, "error", err)
+	}
+}
+
+// handleSubscriptionSettingsSubmit stores the values entered in the settings modal
+func (b *Bot) handleSubscriptionSettingsSubmit(s DiscordSession, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	voiceChannelID := strings.TrimPrefix(data.CustomID, "subscription_settings:")
+	values := modalValues(data)
+
+	name := strings.TrimSpace(values["webhook_name"])
+	avatarURL := strings.TrimSpace(values["webhook_avatar"])
+	style := strings.ToLower(strings.TrimSpace(values["style"]))
+	label := cleanLabel(values["label"])
+	if style == stylePlain {
+		style = ""
+	}
+
+	if strings.Contains(strings.ToLower(name), "discord") {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "settings.invalid-name"))
+		return
+	}
+	maxLength := 0
+	if value := strings.TrimSpace(values["max_length"]); value != "" {
+		var err error
+		maxLength, err = strconv.Atoi(value)
+		if err != nil || maxLength < minMessageLength || maxLength > maxMessageLength {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "settings.invalid-max-length", minMessageLength, maxMessageLength))
+			return
+		}
+		if maxLength == maxMessageLength {
+			maxLength = 0
+		}
+	}
+	if !validStyle(style) {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "settings.invalid-style", strings.Join(notificationStyles, ", ")))
+		return
+	}
+	if avatarURL != "" {
+		if u, err := url.Parse(avatarURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			respondWithError(s, i.Interaction, b.t(i.GuildID, "settings.invalid-avatar"))
+			return
+		}
+	}
+
+	found := b.updateSubscription(voiceChannelID, i.ChannelID, func(sub *subscription) {
+		sub.WebhookName = name
+		sub.WebhookAvatarURL = avatarURL
+		sub.Style = style
+		sub.MaxLength = maxLength
+		sub.Label = label
+	})
+	if !found {
+		respondWithError(s, i.Interaction, b.t(i.GuildID, "settings.gone"))
+		return
+	}
+
+	responseText := b.t(i.GuildID, "settings.saved", b.getChannelName(s, voiceChannelID))
+	if !b.webhookDelivery && style != styleWebhook && (name != "" || avatarURL != "") {
+		responseText += "\n" + b.t(i.GuildID, "settings.webhook-disabled")
+	}
+	respondEphemeral(s, i.Interaction, responseText)
+}
+
+// maxLengthValue shows a subscription's maximum message length in the settings modal
+func maxLengthValue(maxLength int) string {
+	if maxLength == 0 {
+		return ""
+	}
+	return strconv.Itoa(maxLength)
+}
+
+// modalValues collects text input values of a modal submission by custom ID
+func modalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := make(map[string]string)
+	for _, row := range data.Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxWhoIsInLength bounds the member list of /who-is-in
+const maxWhoIsInLength = 4000
+
+type (
+	// voiceMember is a user in a voice channel as /who-is-in shows them
+	voiceMember struct {
+		name  string
+		state *discordgo.VoiceState
+		since time.Time // zero if unknown
+	}
+)
+
+// whoIsInCommand returns the /who-is-in command definition
+func whoIsInCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name: "who-is-in",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "voice-channel",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleWhoIsIn(s DiscordSession, i *discordgo.InteractionCreate) {
+	voiceChannelID := optionMap(i.ApplicationCommandData().Options)["voice-channel"].ChannelValue(nil).ID
+
+	embed := b.whoIsInEmbed(s, i.GuildID, voiceChannelID)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{b.presentEmbed(i.GuildID, embed)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// voiceMembers returns the users in a voice channel from the state cache,
+// longest present first
+func (b *Bot) voiceMembers(guildID, voiceChannelID string) []voiceMember {
+	state := b.session.State
+	guild, err := state.Guild(guildID)
+	if err != nil {
+		return nil
+	}
+
+	state.RLock()
+	var voiceStates []discordgo.VoiceState
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID == voiceChannelID {
+			voiceStates = append(voiceStates, *vs)
+		}
+	}
+	state.RUnlock()
+
+	members := make([]voiceMember, 0, len(voiceStates))
+	for _, vs := range voiceStates {
+		member := vs.Member
+		if member == nil {
+			member, _ = state.Member(guildID, vs.UserID)
+		}
+		if member != nil && member.User != nil && member.User.Bot {
+			continue
+		}
+		name := fmt.Sprintf("<@%s>", vs.UserID)
+		if member != nil && member.User != nil {
+			name = getUsername(member)
+		}
+		since, _ := b.sessions.activeSince(vs.UserID, voiceChannelID)
+		members = append(members, voiceMember{name: name, state: &vs, since: since})
+	}
+
+	slices.SortFunc(members, func(a, b voiceMember) int {
+		if c := a.since.Compare(b.since); c != 0 && !a.since.IsZero() && !b.since.IsZero() {
+			return c
+		}
+		return cmp.Compare(strings.ToLower(a.name), strings.ToLower(b.name))
+	})
+	return members
+}
+
+// whoIsInEmbed lists the members of a voice channel with their mute and
+// deafen status and how long they have been there
+func (b *Bot) whoIsInEmbed(s DiscordSession, guildID, voiceChannelID string) *discordgo.MessageEmbed {
+	members := b.voiceMembers(guildID, voiceChannelID)
+
+	description := b.t(guildID, "statusboard.empty")
+	if len(members) > 0 {
+		lines := make([]string, len(members))
+		for idx, member := range members {
+			lines[idx] = b.memberLine(guildID, member)
+		}
+		description = b.truncateLines(guildID, strings.Join(lines, "\n"), maxWhoIsInLength)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🔊 %s", b.getChannelName(s, voiceChannelID)),
+		Description: description,
+		Color:       0x5865F2,
+		Footer:      &discordgo.MessageEmbedFooter{Text: b.t(guildID, "whoisin.footer", len(members))},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if channel, err := b.session.State.Channel(voiceChannelID); err == nil && channel.UserLimit > 0 {
+		embed.Footer.Text = b.t(guildID, "whoisin.footer-limit", len(members), channel.UserLimit)
+	}
+	return embed
+}
+
+// memberLine renders a member as "• **Alice** (🔇 muted, 📺 streaming) — 1h 5m".
+// The status is spelled out so it survives the emoji-free presentation.
+func (b *Bot) memberLine(guildID string, m voiceMember) string {
+	var status []string
+	switch {
+	case m.state.Deaf || m.state.SelfDeaf:
+		status = append(status, "🔕 "+b.t(guildID, "whoisin.deafened"))
+	case m.state.Mute || m.state.SelfMute:
+		status = append(status, "🔇 "+b.t(guildID, "whoisin.muted"))
+	}
+	if m.state.SelfStream {
+		status = append(status, "📺 "+b.t(guildID, "audio.streaming"))
+	}
+	if m.state.SelfVideo {
+		status = append(status, "📷 "+b.t(guildID, "audio.camera"))
+	}
+
+	line := fmt.Sprintf("• **%s**", m.name)
+	if len(status) > 0 {
+		line += " (" + strings.Join(status, ", ") + ")"
+	}
+	switch {
+	case m.since.IsZero():
+	case time.Since(m.since) < time.Minute:
+		line += " — " + b.t(guildID, "whoisin.just-joined")
+	default:
+		line += " — " + formatDuration(time.Since(m.since))
+	}
+	return line
+}
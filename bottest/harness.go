@@ -0,0 +1,233 @@
+// Package bottest runs a Bot against a fake Discord. Synthetic gateway events
+// (guilds, channels, voice states, interactions) are fed through the bot's
+// own handlers, and everything it sends is recorded by a discordfake.Session,
+// so whole features can be exercised end-to-end without a connection.
+package bottest
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/bot"
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/CS-5/VoiceActivityBot/discordfake"
+	"github.com/bwmarrin/discordgo"
+)
+
+// BotUserId is the user ID of the bot in the fake Discord
+const BotUserId = "100000000000000000"
+
+type (
+	// Harness is a bot connected to a fake Discord
+	Harness struct {
+		Bot     *bot.Bot
+		Discord *discordfake.Session
+		Timeout time.Duration // how long the Wait helpers wait, 2s by default
+
+		mu     sync.Mutex
+		nextId uint64
+	}
+)
+
+// DefaultConfig returns the configuration New uses when given nil: the
+// defaults with in-memory storage and a short trailing debounce, so
+// notifications arrive quickly
+func DefaultConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Token = "bottest"
+	cfg.Storage.Backend = "memory"
+	cfg.Debounce.Interval = 10 * time.Millisecond
+	return cfg
+}
+
+// New creates a bot with cfg, or DefaultConfig if cfg is nil. The bot is
+// never started; events only reach it through the harness.
+func New(cfg *config.Config) (*Harness, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	b, err := bot.NewBot(cfg)
+	if err != nil {
+		return nil, err
+	}
+	b.State().User = &discordgo.User{ID: BotUserId, Username: "VoiceActivityBot", Bot: true}
+	fake := discordfake.New()
+	b.UseSession(fake)
+
+	return &Harness{
+		Bot:     b,
+		Discord: fake,
+		Timeout: 2 * time.Second,
+		nextId:  200000000000000000,
+	}, nil
+}
+
+// id returns a new snowflake-shaped ID
+func (h *Harness) id() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextId++
+	return strconv.FormatUint(h.nextId, 10)
+}
+
+// Send feeds any gateway event to the bot
+func (h *Harness) Send(event any) {
+	h.Bot.HandleEvent(h.Discord, event)
+}
+
+// AddGuild makes the bot join a guild with the given channels, as the
+// GUILD_CREATE after connecting would, and returns the guild
+func (h *Harness) AddGuild(name string, channels ...*discordgo.Channel) *discordgo.Guild {
+	guild := &discordgo.Guild{ID: h.id(), Name: name}
+	for _, channel := range channels {
+		channel.GuildID = guild.ID
+		guild.Channels = append(guild.Channels, channel)
+		h.Discord.AddChannel(channel)
+	}
+	h.Send(&discordgo.GuildCreate{Guild: guild})
+	return guild
+}
+
+// RemoveGuild makes the bot leave a guild
+func (h *Harness) RemoveGuild(guildID string) {
+	h.Send(&discordgo.GuildDelete{Guild: &discordgo.Guild{ID: guildID}})
+}
+
+// VoiceChannel returns a voice channel for AddGuild or AddChannel
+func (h *Harness) VoiceChannel(name string) *discordgo.Channel {
+	return &discordgo.Channel{ID: h.id(), Name: name, Type: discordgo.ChannelTypeGuildVoice}
+}
+
+// TextChannel returns a text channel for AddGuild or AddChannel
+func (h *Harness) TextChannel(name string) *discordgo.Channel {
+	return &discordgo.Channel{ID: h.id(), Name: name, Type: discordgo.ChannelTypeGuildText}
+}
+
+// AddChannel creates a channel in a guild
+func (h *Harness) AddChannel(guildID string, channel *discordgo.Channel) {
+	channel.GuildID = guildID
+	h.Discord.AddChannel(channel)
+	h.Send(&discordgo.ChannelCreate{Channel: channel})
+}
+
+// DeleteChannel deletes a channel
+func (h *Harness) DeleteChannel(channel *discordgo.Channel) {
+	h.Discord.RemoveChannel(channel.ID)
+	h.Send(&discordgo.ChannelDelete{Channel: channel})
+}
+
+// AddMember adds a member to a guild. permissions are the member's
+// permissions in interactions, e.g. discordgo.PermissionManageChannels.
+func (h *Harness) AddMember(guildID, name string, permissions int64) *discordgo.Member {
+	member := &discordgo.Member{
+		GuildID:     guildID,
+		User:        &discordgo.User{ID: h.id(), Username: name},
+		Permissions: permissions,
+	}
+	h.Discord.AddMember(guildID, member)
+	return member
+}
+
+// Join moves a member into a voice channel, from wherever they were
+func (h *Harness) Join(member *discordgo.Member, channelID string) {
+	h.UpdateVoiceState(member, &discordgo.VoiceState{ChannelID: channelID})
+}
+
+// Leave disconnects a member from voice
+func (h *Harness) Leave(member *discordgo.Member) {
+	h.UpdateVoiceState(member, &discordgo.VoiceState{})
+}
+
+// UpdateVoiceState sends a member's new voice state, e.g. with SelfMute or
+// SelfStream set. The previous state comes from the bot's cache.
+func (h *Harness) UpdateVoiceState(member *discordgo.Member, state *discordgo.VoiceState) {
+	state.GuildID = member.GuildID
+	state.UserID = member.User.ID
+	state.Member = member
+	state.SessionID = "session-" + member.User.ID
+	h.Send(&discordgo.VoiceStateUpdate{VoiceState: state})
+}
+
+// Command runs a slash command as member in a channel and returns the bot's
+// responses to it
+func (h *Harness) Command(member *discordgo.Member, channelID, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) []discordfake.Response {
+	return h.interact(member, channelID, discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{
+		ID:      h.id(),
+		Name:    name,
+		Options: options,
+	})
+}
+
+// Autocomplete types into a command option as member and returns the bot's
+// suggestions. One of options should be Focused.
+func (h *Harness) Autocomplete(member *discordgo.Member, channelID, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandOptionChoice {
+	responses := h.interact(member, channelID, discordgo.InteractionApplicationCommandAutocomplete, discordgo.ApplicationCommandInteractionData{
+		ID:      h.id(),
+		Name:    name,
+		Options: options,
+	})
+	for _, response := range responses {
+		if response.Response != nil && response.Response.Type == discordgo.InteractionApplicationCommandAutocompleteResult {
+			return response.Response.Data.Choices
+		}
+	}
+	return nil
+}
+
+// Component clicks a button or picks select menu values as member and returns
+// the bot's responses to it
+func (h *Harness) Component(member *discordgo.Member, channelID, customID string, values ...string) []discordfake.Response {
+	componentType := discordgo.ButtonComponent
+	if len(values) > 0 {
+		componentType = discordgo.SelectMenuComponent
+	}
+	return h.interact(member, channelID, discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{
+		CustomID:      customID,
+		ComponentType: componentType,
+		Values:        values,
+	})
+}
+
+// SubmitModal submits a modal with text inputs by custom ID as member and
+// returns the bot's responses to it
+func (h *Harness) SubmitModal(member *discordgo.Member, channelID, customID string, inputs map[string]string) []discordfake.Response {
+	var rows []discordgo.MessageComponent
+	for inputID, value := range inputs {
+		rows = append(rows, &discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			&discordgo.TextInput{CustomID: inputID, Value: value},
+		}})
+	}
+	return h.interact(member, channelID, discordgo.InteractionModalSubmit, discordgo.ModalSubmitInteractionData{
+		CustomID:   customID,
+		Components: rows,
+	})
+}
+
+func (h *Harness) interact(member *discordgo.Member, channelID string, interactionType discordgo.InteractionType, data discordgo.InteractionData) []discordfake.Response {
+	interaction := &discordgo.Interaction{
+		ID:        h.id(),
+		AppID:     BotUserId,
+		Type:      interactionType,
+		Data:      data,
+		GuildID:   member.GuildID,
+		ChannelID: channelID,
+		Member:    member,
+		Token:     "token",
+	}
+	h.Send(&discordgo.InteractionCreate{Interaction: interaction})
+	return h.Discord.ResponsesTo(interaction.ID)
+}
+
+// WaitForMessages waits until at least n messages were sent to a channel, or
+// Timeout passed, and returns the messages sent so far
+func (h *Harness) WaitForMessages(channelID string, n int) []discordfake.Message {
+	deadline := time.Now().Add(h.Timeout)
+	for {
+		sent := h.Discord.Sent(channelID)
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
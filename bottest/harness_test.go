@@ -0,0 +1,155 @@
+package bottest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CS-5/VoiceActivityBot/discordfake"
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// testGuild is a guild with one voice and one text channel and a member
+	// who may subscribe
+	testGuild struct {
+		*Harness
+		guild  *discordgo.Guild
+		voice  *discordgo.Channel
+		text   *discordgo.Channel
+		admin  *discordgo.Member
+		member *discordgo.Member
+	}
+)
+
+func newTestGuild(t *testing.T) *testGuild {
+	t.Helper()
+	h, err := New(nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g := &testGuild{Harness: h, voice: h.VoiceChannel("Lobby"), text: h.TextChannel("general")}
+	g.guild = h.AddGuild("Test", g.voice, g.text)
+	g.admin = h.AddMember(g.guild.ID, "admin", discordgo.PermissionManageChannels)
+	g.member = h.AddMember(g.guild.ID, "alice", 0)
+	return g
+}
+
+// subscribe subscribes the text channel to the voice channel
+func (g *testGuild) subscribe(t *testing.T) {
+	t.Helper()
+	responses := g.Command(g.admin, g.text.ID, "subscribe", String("voice-channel", g.voice.ID))
+	if len(responses) == 0 || !strings.HasPrefix(responses[0].Response.Data.Content, "✅") {
+		t.Fatalf("subscribe responses = %+v, want a success", responses)
+	}
+}
+
+// content returns the content of the first response
+func content(t *testing.T, responses []discordfake.Response) string {
+	t.Helper()
+	if len(responses) == 0 || responses[0].Response == nil || responses[0].Response.Data == nil {
+		t.Fatalf("responses = %+v, want a message", responses)
+	}
+	return responses[0].Response.Data.Content
+}
+
+// quiet waits several debounce intervals, after which nothing else is sent
+func (g *testGuild) quiet() {
+	time.Sleep(10 * DefaultConfig().Debounce.Interval)
+}
+
+func TestJoinSendsNotification(t *testing.T) {
+	g := newTestGuild(t)
+	g.subscribe(t)
+
+	g.Join(g.member, g.voice.ID)
+	sent := g.WaitForMessages(g.text.ID, 1)
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	if got := sent[0].Send.Content; !strings.Contains(got, "alice") || !strings.Contains(got, "Lobby") {
+		t.Errorf("notification = %q, want alice and Lobby in it", got)
+	}
+}
+
+func TestJoinWithoutSubscriptionIsSilent(t *testing.T) {
+	g := newTestGuild(t)
+
+	g.Join(g.member, g.voice.ID)
+	g.quiet()
+	if sent := g.Discord.Sent(g.text.ID); len(sent) != 0 {
+		t.Errorf("sent %d messages without a subscription, want 0", len(sent))
+	}
+}
+
+func TestSubscribeAndUnsubscribe(t *testing.T) {
+	g := newTestGuild(t)
+
+	if got := content(t, g.Command(g.member, g.text.ID, "subscribe", String("voice-channel", g.voice.ID))); !strings.HasPrefix(got, "❌") {
+		t.Errorf("subscribe without Manage Channels = %q, want an error", got)
+	}
+	g.subscribe(t)
+
+	if got := content(t, g.Command(g.admin, g.text.ID, "unsubscribe", String("voice-channel", g.voice.ID))); !strings.HasPrefix(got, "✅") {
+		t.Fatalf("unsubscribe = %q, want a success", got)
+	}
+	if got := content(t, g.Command(g.admin, g.text.ID, "unsubscribe", String("voice-channel", g.voice.ID))); !strings.HasPrefix(got, "ℹ️") {
+		t.Errorf("second unsubscribe = %q, want a note that it isn't subscribed", got)
+	}
+
+	g.Join(g.member, g.voice.ID)
+	g.quiet()
+	if sent := g.Discord.Sent(g.text.ID); len(sent) != 0 {
+		t.Errorf("sent %d messages after unsubscribing, want 0", len(sent))
+	}
+}
+
+func TestDebounceCoalescesRejoins(t *testing.T) {
+	g := newTestGuild(t)
+	g.subscribe(t)
+
+	// A rejoin within the debounce interval is a single join
+	g.Join(g.member, g.voice.ID)
+	g.Leave(g.member)
+	g.Join(g.member, g.voice.ID)
+	g.quiet()
+	if sent := g.Discord.Sent(g.text.ID); len(sent) != 1 {
+		t.Errorf("sent %d messages for a rejoin, want 1", len(sent))
+	}
+}
+
+func TestDebounceDropsFlaps(t *testing.T) {
+	g := newTestGuild(t)
+	g.subscribe(t)
+
+	// A join followed by a leave within the debounce interval is never announced
+	g.Join(g.member, g.voice.ID)
+	g.Leave(g.member)
+	g.quiet()
+	if sent := g.Discord.Sent(g.text.ID); len(sent) != 0 {
+		t.Errorf("sent %d messages for a flap, want 0", len(sent))
+	}
+}
+
+func TestMoveSendsOneNotification(t *testing.T) {
+	g := newTestGuild(t)
+	other := g.VoiceChannel("Games")
+	g.AddChannel(g.guild.ID, other)
+	g.subscribe(t)
+	if got := content(t, g.Command(g.admin, g.text.ID, "subscribe", String("voice-channel", other.ID))); !strings.HasPrefix(got, "✅") {
+		t.Fatalf("subscribe = %q, want a success", got)
+	}
+
+	g.Join(g.member, g.voice.ID)
+	g.WaitForMessages(g.text.ID, 1)
+	g.Join(g.member, other.ID)
+	g.WaitForMessages(g.text.ID, 2)
+	g.quiet()
+	sent := g.Discord.Sent(g.text.ID)
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages for a join and a move, want 2", len(sent))
+	}
+	if got := sent[1].Send.Content; !strings.Contains(got, "Lobby") || !strings.Contains(got, "Games") {
+		t.Errorf("move notification = %q, want both channels in it", got)
+	}
+}
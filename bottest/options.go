@@ -0,0 +1,48 @@
+package bottest
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// String returns a string option for Command
+func String(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+// Int returns an integer option for Command
+func Int(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	// Discord sends numbers as JSON numbers, which decode to float64
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
+
+// Bool returns a boolean option for Command
+func Bool(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+}
+
+// Channel returns a channel option for Command
+func Channel(name, channelID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionChannel, Value: channelID}
+}
+
+// User returns a user option for Command
+func User(name, userID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionUser, Value: userID}
+}
+
+// Role returns a role option for Command
+func Role(name, roleID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionRole, Value: roleID}
+}
+
+// Subcommand returns a subcommand with its options for Command
+func Subcommand(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options}
+}
+
+// Focused returns the string option being typed, for Autocomplete
+func Focused(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	opt := String(name, value)
+	opt.Focused = true
+	return opt
+}
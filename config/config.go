@@ -0,0 +1,943 @@
+// Package config loads the bot's startup configuration from an optional YAML
+// file, environment variables, and command-line flags, in increasing order of
+// precedence.
+package config
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Allowed values, matching the names the bot package uses
+var (
+	storageBackends     = []string{"file", "postgres", "memory", "redis"}
+	debounceStrategies  = []string{"trailing", "leading", "batch"}
+	logLevels           = []string{"debug", "info", "warn", "error"}
+	logFormats          = []string{"text", "json"}
+	notificationModes   = []string{"individual", "summary"}
+	duplicateActions    = []string{"alert", "stand-down"}
+	moderationActions   = []string{"redact", "veto"}
+	moderationOnError   = []string{"send", "drop"}
+	historyBackends     = []string{"influxdb", "clickhouse"}
+	stateCaches         = []string{"channels", "threads", "members", "roles", "voice", "emojis", "stickers", "presences", "thread_members"}
+	snowflakePattern    = regexp.MustCompile(`^[0-9]{17,20}$`)
+	instanceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+)
+
+type (
+	// Config is the validated startup configuration
+	Config struct {
+		Token           string            `yaml:"token"`
+		Storage         Storage           `yaml:"storage"`
+		Debounce        Debounce          `yaml:"debounce"`
+		AdminChannels   map[string]string `yaml:"admin_channels"` // guildID -> channelID
+		Log             Log               `yaml:"log"`
+		Bots            []Instance        `yaml:"bots"` // several bot identities in one process, see Instances
+		ShutdownTimeout time.Duration     `yaml:"shutdown_timeout"`
+		Gateway         Gateway           `yaml:"gateway"`
+		Notifications   Notifications     `yaml:"notifications"`
+		Limits          Limits            `yaml:"limits"`
+		Moderation      Moderation        `yaml:"moderation"`
+		LocalesDir      string            `yaml:"locales_dir"`    // overrides for the built-in message catalogs
+		TelegramToken   string            `yaml:"telegram_token"` // enables Telegram forwarding
+		Health          Health            `yaml:"health"`
+		API             API               `yaml:"api"`
+		Dashboard       Dashboard         `yaml:"dashboard"`
+		EventWebhook    EventWebhook      `yaml:"event_webhook"`
+		EventHistory    EventHistory      `yaml:"event_history"`
+		Federation      Federation        `yaml:"federation"`
+
+		// Set by Instances
+		Name string `yaml:"-"` // the instance's name, empty for a single bot
+	}
+
+	// Instance is one bot identity of a multi-bot deployment. Unset fields
+	// fall back to the shared configuration.
+	Instance struct {
+		Name          string            `yaml:"name"`
+		Token         string            `yaml:"token"`
+		Storage       *Storage          `yaml:"storage"`        // defaults to the shared storage, kept apart by name
+		AdminChannels map[string]string `yaml:"admin_channels"` // guildID -> channelID
+		Dashboard     *Dashboard        `yaml:"dashboard"`      // each bot has its own OAuth2 application, so its own dashboard
+		EventWebhook  *EventWebhook     `yaml:"event_webhook"`
+		EventHistory  *EventHistory     `yaml:"event_history"` // defaults to the shared history, the file kept apart by name
+		Federation    *Federation       `yaml:"federation"`
+	}
+
+	// Storage selects where subscriptions and sessions are kept
+	Storage struct {
+		Backend      string        `yaml:"backend"` // empty selects postgres when DatabaseURL is set, file otherwise
+		File         string        `yaml:"file"`
+		SessionsFile string        `yaml:"sessions_file"`
+		DatabaseURL  string        `yaml:"database_url"`
+		RedisURL     string        `yaml:"redis_url"`
+		RedisPrefix  string        `yaml:"redis_prefix"`
+		Backups      int           `yaml:"backups"` // previous versions of File to keep
+		SaveInterval time.Duration `yaml:"save_interval"`
+		ImportFile   string        `yaml:"import_file"` // guild exports to import on startup
+		ArchiveDir   string        `yaml:"archive_dir"` // where a guild's export is kept when the bot is removed
+	}
+
+	// Debounce configures how join events are coalesced
+	Debounce struct {
+		Interval time.Duration `yaml:"interval"`
+		Strategy string        `yaml:"strategy"`
+		FastPath int           `yaml:"fast_path"` // events per minute below which a guild skips debouncing, 0 disables
+	}
+
+	// Log configures the default logger
+	Log struct {
+		Level  string `yaml:"level"`
+		Format string `yaml:"format"`
+	}
+
+	// Gateway configures the Discord connection
+	Gateway struct {
+		MemberIntent    bool          `yaml:"member_intent"`
+		StateCache      []string      `yaml:"state_cache"` // empty caches nothing beyond the guild list
+		CacheTTL        time.Duration `yaml:"cache_ttl"`   // how long entities fetched through the API are reused, 0 disables
+		FastStart       bool          `yaml:"fast_start"`
+		ShardCount      string        `yaml:"shard_count"` // a number or auto, empty runs unsharded
+		ShardId         int           `yaml:"shard_id"`
+		DuplicateAction string        `yaml:"duplicate_action"`
+	}
+
+	// Notifications configures how voice activity is announced
+	Notifications struct {
+		Mode                 string        `yaml:"mode"`
+		SummaryWindow        time.Duration `yaml:"summary_window"`
+		Moves                bool          `yaml:"moves"`
+		ThreadButton         bool          `yaml:"thread_button"`
+		ReminderDelay        time.Duration `yaml:"reminder_delay"` // 0 disables the remind me button
+		WebhookDelivery      bool          `yaml:"webhook_delivery"`
+		MentionCooldown      time.Duration `yaml:"mention_cooldown"`
+		UserCooldown         time.Duration `yaml:"user_cooldown"`
+		RejoinCooldown       time.Duration `yaml:"rejoin_cooldown"`
+		RateLimit            int           `yaml:"rate_limit"` // per text channel and minute, 0 disables
+		Retries              int           `yaml:"retries"`
+		FailureLimit         int           `yaml:"failure_limit"` // permanent failures after which a subscription is paused
+		DeadLetterFile       string        `yaml:"dead_letter_file"`
+		PermissionRecheck    time.Duration `yaml:"permission_recheck"`
+		ChannelRecreateGrace time.Duration `yaml:"channel_recreate_grace"` // 0 disables re-subscription
+	}
+
+	// Limits caps the number of subscriptions, 0 meaning unlimited
+	Limits struct {
+		GuildSubscriptions   int `yaml:"guild_subscriptions"`
+		ChannelSubscriptions int `yaml:"channel_subscriptions"`
+	}
+
+	// Moderation configures the filters notifications pass before sending
+	Moderation struct {
+		Words     []string `yaml:"words"`
+		WordsFile string   `yaml:"words_file"`
+		Action    string   `yaml:"action"`
+		URL       string   `yaml:"url"`
+		OnError   string   `yaml:"on_error"`
+	}
+
+	// Health configures the health and metrics endpoint, port 0 disables it
+	Health struct {
+		Port int `yaml:"port"`
+	}
+
+	// API configures the HTTP API, port 0 disables it
+	API struct {
+		Port  int    `yaml:"port"`
+		Token string `yaml:"token"`
+	}
+
+	// Dashboard configures the web dashboard, port 0 disables it
+	Dashboard struct {
+		Port         int    `yaml:"port"`
+		ClientId     string `yaml:"client_id"`
+		ClientSecret string `yaml:"client_secret"`
+		URL          string `yaml:"url"`
+	}
+
+	// EventWebhook configures the endpoint every voice event is posted to
+	EventWebhook struct {
+		URL     string `yaml:"url"`
+		Secret  string `yaml:"secret"`
+		Retries int    `yaml:"retries"`
+	}
+
+	// EventHistory configures where voice events are recorded
+	EventHistory struct {
+		File    string `yaml:"file"`
+		Backend string `yaml:"backend"` // empty writes no database
+		URL     string `yaml:"url"`
+		User    string `yaml:"user"`
+		Token   string `yaml:"token"`
+		Table   string `yaml:"table"`
+	}
+
+	// Federation configures the events accepted from other deployments
+	Federation struct {
+		Peers   map[string]string `yaml:"peers"` // peer name -> token
+		Channel string            `yaml:"channel"`
+	}
+
+	// setting is a value that can come from the environment and, when flag is
+	// set, from the command line
+	setting struct {
+		env   string
+		flag  string
+		usage string
+		apply func(cfg *Config, value string) error
+	}
+)
+
+// settings lists every environment variable and flag Load understands
+var settings = []setting{
+	{"DISCORD_TOKEN", "token", "Discord bot token", func(cfg *Config, value string) error {
+		cfg.Token = value
+		return nil
+	}},
+	{"DISCORD_TOKENS", "tokens", "several bots as name:token,...", func(cfg *Config, value string) error {
+		var invalid []string
+		for _, pair := range strings.Split(value, ",") {
+			name, token, found := strings.Cut(strings.TrimSpace(pair), ":")
+			if !found || name == "" || token == "" {
+				invalid = append(invalid, fmt.Sprintf("%q", name))
+				continue
+			}
+			cfg.Bots = append(cfg.Bots, Instance{Name: name, Token: token})
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("expected name:token pairs, got %s", strings.Join(invalid, ", "))
+		}
+		return nil
+	}},
+	{"STORAGE_BACKEND", "storage", "storage backend: " + strings.Join(storageBackends, ", "), func(cfg *Config, value string) error {
+		cfg.Storage.Backend = value
+		return nil
+	}},
+	{"PERSISTENCE_FILE", "persistence-file", "subscriptions file of the file backend", func(cfg *Config, value string) error {
+		cfg.Storage.File = value
+		return nil
+	}},
+	{"SESSIONS_FILE", "sessions-file", "voice session history file", func(cfg *Config, value string) error {
+		cfg.Storage.SessionsFile = value
+		return nil
+	}},
+	{"DATABASE_URL", "database-url", "PostgreSQL connection URL", func(cfg *Config, value string) error {
+		cfg.Storage.DatabaseURL = value
+		return nil
+	}},
+	{"REDIS_URL", "redis-url", "Redis connection URL", func(cfg *Config, value string) error {
+		cfg.Storage.RedisURL = value
+		return nil
+	}},
+	{"REDIS_PREFIX", "redis-prefix", "Redis key prefix", func(cfg *Config, value string) error {
+		cfg.Storage.RedisPrefix = value
+		return nil
+	}},
+	{"DEBOUNCE_INTERVAL", "debounce", "debounce interval, e.g. 5s", func(cfg *Config, value string) error {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration, use e.g. 500ms, 5s, or 1m", value)
+		}
+		cfg.Debounce.Interval = interval
+		return nil
+	}},
+	{"DEBOUNCE_STRATEGY", "debounce-strategy", "debounce strategy: " + strings.Join(debounceStrategies, ", "), func(cfg *Config, value string) error {
+		cfg.Debounce.Strategy = value
+		return nil
+	}},
+	{"DEBOUNCE_FAST_PATH", "debounce-fast-path", "events per minute below which a server is notified without debouncing, 0 disables", func(cfg *Config, value string) error {
+		threshold, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		cfg.Debounce.FastPath = threshold
+		return nil
+	}},
+	{"ADMIN_CHANNELS", "admin-channels", "admin channels as guildID:channelID,...", func(cfg *Config, value string) error {
+		channels, err := parseAdminChannels(value)
+		if cfg.AdminChannels == nil {
+			cfg.AdminChannels = make(map[string]string, len(channels))
+		}
+		for guildID, channelID := range channels {
+			cfg.AdminChannels[guildID] = channelID
+		}
+		return err
+	}},
+	{"LOG_LEVEL", "log-level", "log level: " + strings.Join(logLevels, ", "), func(cfg *Config, value string) error {
+		cfg.Log.Level = strings.ToLower(value)
+		return nil
+	}},
+	{"LOG_FORMAT", "log-format", "log format: " + strings.Join(logFormats, ", "), func(cfg *Config, value string) error {
+		cfg.Log.Format = strings.ToLower(value)
+		return nil
+	}},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long shutdown may take", durationSetting(func(cfg *Config) *time.Duration { return &cfg.ShutdownTimeout })},
+	{"PERSISTENCE_BACKUPS", "persistence-backups", "previous versions of the subscriptions file to keep", intSetting(func(cfg *Config) *int { return &cfg.Storage.Backups })},
+	{"SAVE_INTERVAL", "save-interval", "how often changes are written to storage", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Storage.SaveInterval })},
+	{"IMPORT_FILE", "import-file", "guild exports to import on startup", stringSetting(func(cfg *Config) *string { return &cfg.Storage.ImportFile })},
+	{"GUILD_ARCHIVE_DIR", "guild-archive-dir", "directory for the configuration of servers the bot is removed from", stringSetting(func(cfg *Config) *string { return &cfg.Storage.ArchiveDir })},
+	{"MEMBER_INTENT", "member-intent", "request the privileged Server Members intent", boolSetting(func(cfg *Config) *bool { return &cfg.Gateway.MemberIntent })},
+	{"STATE_CACHE", "state-cache", "Discord data cached in memory, comma-separated, or none", func(cfg *Config, value string) error {
+		cfg.Gateway.StateCache = []string{}
+		if strings.ToLower(strings.TrimSpace(value)) != "none" {
+			cfg.Gateway.StateCache = splitList(strings.ToLower(value))
+		}
+		return nil
+	}},
+	{"CACHE_TTL", "cache-ttl", "how long entities fetched through the API are reused, 0 disables", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Gateway.CacheTTL })},
+	{"FAST_START", "fast-start", "keep commands registered across restarts", boolSetting(func(cfg *Config) *bool { return &cfg.Gateway.FastStart })},
+	{"SHARD_COUNT", "shard-count", "gateway shard count or auto", stringSetting(func(cfg *Config) *string { return &cfg.Gateway.ShardCount })},
+	{"SHARD_ID", "shard-id", "gateway shard of this process", intSetting(func(cfg *Config) *int { return &cfg.Gateway.ShardId })},
+	{"DUPLICATE_INSTANCE_ACTION", "duplicate-instance-action", "reaction to another instance with the same token: " + strings.Join(duplicateActions, ", "), stringSetting(func(cfg *Config) *string { return &cfg.Gateway.DuplicateAction })},
+	{"NOTIFICATION_MODE", "notification-mode", "notification mode: " + strings.Join(notificationModes, ", "), stringSetting(func(cfg *Config) *string { return &cfg.Notifications.Mode })},
+	{"SUMMARY_WINDOW", "summary-window", "aggregation window of summary mode", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Notifications.SummaryWindow })},
+	{"MOVE_NOTIFICATIONS", "move-notifications", "announce moves between voice channels as one message", boolSetting(func(cfg *Config) *bool { return &cfg.Notifications.Moves })},
+	{"SESSION_THREAD_BUTTON", "session-thread-button", "add an open chat thread button to session starts", boolSetting(func(cfg *Config) *bool { return &cfg.Notifications.ThreadButton })},
+	{"REMINDER_DELAY", "reminder-delay", "delay of the remind me button, 0 disables it", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Notifications.ReminderDelay })},
+	{"WEBHOOK_DELIVERY", "webhook-delivery", "post notifications through channel webhooks", boolSetting(func(cfg *Config) *bool { return &cfg.Notifications.WebhookDelivery })},
+	{"MENTION_COOLDOWN", "mention-cooldown", "how long a mentioned role or user is not mentioned again", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Notifications.MentionCooldown })},
+	{"USER_COOLDOWN", "user-cooldown", "announce each user at most once per duration and server, 0 disables", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Notifications.UserCooldown })},
+	{"REJOIN_COOLDOWN", "rejoin-cooldown", "announce rejoins of a channel at most once per duration, 0 disables", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Notifications.RejoinCooldown })},
+	{"RATE_LIMIT_PER_MINUTE", "rate-limit-per-minute", "notifications per text channel and minute, 0 disables", intSetting(func(cfg *Config) *int { return &cfg.Notifications.RateLimit })},
+	{"NOTIFICATION_RETRIES", "notification-retries", "retries after a transient delivery failure", intSetting(func(cfg *Config) *int { return &cfg.Notifications.Retries })},
+	{"DELIVERY_FAILURE_LIMIT", "delivery-failure-limit", "permanent failures after which a subscription is paused", intSetting(func(cfg *Config) *int { return &cfg.Notifications.FailureLimit })},
+	{"DEAD_LETTER_FILE", "dead-letter-file", "file that given up notifications are appended to", stringSetting(func(cfg *Config) *string { return &cfg.Notifications.DeadLetterFile })},
+	{"PERMISSION_RECHECK_INTERVAL", "permission-recheck-interval", "how often paused subscriptions re-check their permissions", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Notifications.PermissionRecheck })},
+	{"CHANNEL_RECREATE_GRACE", "channel-recreate-grace", "how long a deleted voice channel's subscriptions wait for a replacement, 0 disables", durationSetting(func(cfg *Config) *time.Duration { return &cfg.Notifications.ChannelRecreateGrace })},
+	{"MAX_GUILD_SUBSCRIPTIONS", "max-guild-subscriptions", "most subscriptions per server, 0 is unlimited", intSetting(func(cfg *Config) *int { return &cfg.Limits.GuildSubscriptions })},
+	{"MAX_CHANNEL_SUBSCRIPTIONS", "max-channel-subscriptions", "most subscriptions per voice channel, 0 is unlimited", intSetting(func(cfg *Config) *int { return &cfg.Limits.ChannelSubscriptions })},
+	{"MODERATION_WORDS", "moderation-words", "words that must not appear in notifications, comma-separated", func(cfg *Config, value string) error {
+		cfg.Moderation.Words = splitList(value)
+		return nil
+	}},
+	{"MODERATION_WORDS_FILE", "moderation-words-file", "file of words that must not appear in notifications", stringSetting(func(cfg *Config) *string { return &cfg.Moderation.WordsFile })},
+	{"MODERATION_ACTION", "moderation-action", "what happens to matched words: " + strings.Join(moderationActions, ", "), stringSetting(func(cfg *Config) *string { return &cfg.Moderation.Action })},
+	{"MODERATION_URL", "moderation-url", "external check every notification goes through", stringSetting(func(cfg *Config) *string { return &cfg.Moderation.URL })},
+	{"MODERATION_ON_ERROR", "moderation-on-error", "what happens when the external check fails: " + strings.Join(moderationOnError, ", "), stringSetting(func(cfg *Config) *string { return &cfg.Moderation.OnError })},
+	{"LOCALES_DIR", "locales-dir", "directory of message catalogs", stringSetting(func(cfg *Config) *string { return &cfg.LocalesDir })},
+	{"TELEGRAM_BOT_TOKEN", "telegram-bot-token", "Telegram bot token for Telegram forwarding", stringSetting(func(cfg *Config) *string { return &cfg.TelegramToken })},
+	{"HEALTH_PORT", "health-port", "port of the health endpoint", intSetting(func(cfg *Config) *int { return &cfg.Health.Port })},
+	{"API_PORT", "api-port", "port of the HTTP API", intSetting(func(cfg *Config) *int { return &cfg.API.Port })},
+	{"API_TOKEN", "api-token", "bearer token of the HTTP API", stringSetting(func(cfg *Config) *string { return &cfg.API.Token })},
+	{"DASHBOARD_PORT", "dashboard-port", "port of the web dashboard", intSetting(func(cfg *Config) *int { return &cfg.Dashboard.Port })},
+	{"DISCORD_CLIENT_ID", "discord-client-id", "OAuth2 client ID of the dashboard", stringSetting(func(cfg *Config) *string { return &cfg.Dashboard.ClientId })},
+	{"DISCORD_CLIENT_SECRET", "discord-client-secret", "OAuth2 client secret of the dashboard", stringSetting(func(cfg *Config) *string { return &cfg.Dashboard.ClientSecret })},
+	{"DASHBOARD_URL", "dashboard-url", "public URL of the dashboard", func(cfg *Config, value string) error {
+		cfg.Dashboard.URL = strings.TrimSuffix(value, "/")
+		return nil
+	}},
+	{"EVENT_WEBHOOK_URL", "event-webhook-url", "endpoint every voice event is posted to", stringSetting(func(cfg *Config) *string { return &cfg.EventWebhook.URL })},
+	{"EVENT_WEBHOOK_SECRET", "event-webhook-secret", "secret event webhook requests are signed with", stringSetting(func(cfg *Config) *string { return &cfg.EventWebhook.Secret })},
+	{"EVENT_WEBHOOK_RETRIES", "event-webhook-retries", "retries of a failed event webhook request", intSetting(func(cfg *Config) *int { return &cfg.EventWebhook.Retries })},
+	{"EVENT_HISTORY_FILE", "event-history-file", "file voice events are appended to", stringSetting(func(cfg *Config) *string { return &cfg.EventHistory.File })},
+	{"EVENT_HISTORY_BACKEND", "event-history-backend", "database voice events are written to: " + strings.Join(historyBackends, ", "), func(cfg *Config, value string) error {
+		cfg.EventHistory.Backend = strings.ToLower(value)
+		return nil
+	}},
+	{"EVENT_HISTORY_URL", "event-history-url", "InfluxDB write URL or ClickHouse HTTP URL", stringSetting(func(cfg *Config) *string { return &cfg.EventHistory.URL })},
+	{"EVENT_HISTORY_USER", "event-history-user", "ClickHouse user", stringSetting(func(cfg *Config) *string { return &cfg.EventHistory.User })},
+	{"EVENT_HISTORY_TOKEN", "event-history-token", "InfluxDB API token or ClickHouse password", stringSetting(func(cfg *Config) *string { return &cfg.EventHistory.Token })},
+	{"EVENT_HISTORY_TABLE", "event-history-table", "ClickHouse table", stringSetting(func(cfg *Config) *string { return &cfg.EventHistory.Table })},
+	{"FEDERATION_PEERS", "federation-peers", "peers accepted for federated events as name:token,...", func(cfg *Config, value string) error {
+		var invalid []string
+		cfg.Federation.Peers = make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			name, token, found := strings.Cut(strings.TrimSpace(pair), ":")
+			if !found || name == "" || token == "" {
+				invalid = append(invalid, fmt.Sprintf("%q", name))
+				continue
+			}
+			cfg.Federation.Peers[name] = token
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("expected name:token pairs, got %s", strings.Join(invalid, ", "))
+		}
+		return nil
+	}},
+	{"FEDERATION_CHANNEL", "federation-channel", "text channel federated events are posted to", stringSetting(func(cfg *Config) *string { return &cfg.Federation.Channel })},
+}
+
+// stringSetting applies a value to a string field
+func stringSetting(field func(cfg *Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+// boolSetting parses a value into a bool field
+func boolSetting(field func(cfg *Config) *bool) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*field(cfg) = enabled
+		return nil
+	}
+}
+
+// intSetting parses a value into an int field
+func intSetting(field func(cfg *Config) *int) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*field(cfg) = number
+		return nil
+	}
+}
+
+// durationSetting parses a value into a duration field
+func durationSetting(field func(cfg *Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration, use e.g. 500ms, 5s, or 1m", value)
+		}
+		*field(cfg) = duration
+		return nil
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Default returns the configuration used for everything the file, the
+// environment, and the flags leave unset
+func Default() *Config {
+	return &Config{
+		Storage: Storage{
+			File:         "subscriptions.json",
+			SessionsFile: "sessions.jsonl",
+			Backups:      3,
+			SaveInterval: 2 * time.Second,
+		},
+		Debounce: Debounce{
+			Interval: 3 * time.Second,
+			Strategy: "trailing",
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "text",
+		},
+		ShutdownTimeout: 10 * time.Second,
+		Gateway: Gateway{
+			StateCache:      []string{"channels", "threads", "members", "roles", "voice"},
+			CacheTTL:        5 * time.Minute,
+			DuplicateAction: "alert",
+		},
+		Notifications: Notifications{
+			Mode:              "individual",
+			SummaryWindow:     60 * time.Second,
+			Moves:             true,
+			MentionCooldown:   10 * time.Minute,
+			Retries:           4,
+			FailureLimit:      3,
+			PermissionRecheck: 5 * time.Minute,
+		},
+		Limits: Limits{
+			GuildSubscriptions:   50,
+			ChannelSubscriptions: 10,
+		},
+		Moderation: Moderation{
+			Action:  "redact",
+			OnError: "send",
+		},
+		EventWebhook: EventWebhook{
+			Retries: 4,
+		},
+		EventHistory: EventHistory{
+			Table: "voice_events",
+		},
+	}
+}
+
+// Load builds the configuration from the YAML file named by -config or
+// CONFIG_FILE, then the environment, then args, and validates the result.
+// Every problem found is reported, not just the first.
+func Load(args []string) (*Config, error) {
+	cfg, err := load(args)
+	if err != nil {
+		return nil, err
+	}
+	if errs := cfg.validate(); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+// LoadPartial reads the configuration like Load, but doesn't require a
+// complete bot configuration. For subcommands that only need a few settings.
+func LoadPartial(args []string) (*Config, error) {
+	return load(args)
+}
+
+// load reads the file, the environment, and args and reports values that
+// can't be parsed
+func load(args []string) (*Config, error) {
+	cfg := Default()
+
+	flags := flag.NewFlagSet("voiceactivitybot", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML config file (env CONFIG_FILE)")
+	flagValues := make(map[string]*string)
+	for _, s := range settings {
+		if s.flag != "" {
+			flagValues[s.flag] = flags.String(s.flag, "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if value := os.Getenv(s.env); value != "" {
+			if err := s.apply(cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name {
+				if err := s.apply(cfg, *flagValues[f.Name]); err != nil {
+					errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+				}
+			}
+		}
+	})
+
+	if cfg.Storage.Backend == "" {
+		cfg.Storage.Backend = "file"
+		if cfg.Storage.DatabaseURL != "" {
+			cfg.Storage.Backend = "postgres"
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+// loadFile applies a YAML config file. Unknown keys are an error, so typos
+// don't go unnoticed.
+func (cfg *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	cfg.Log.Level = strings.ToLower(cfg.Log.Level)
+	cfg.Log.Format = strings.ToLower(cfg.Log.Format)
+	cfg.EventHistory.Backend = strings.ToLower(cfg.EventHistory.Backend)
+	cfg.Dashboard.URL = strings.TrimSuffix(cfg.Dashboard.URL, "/")
+	for idx, name := range cfg.Gateway.StateCache {
+		cfg.Gateway.StateCache[idx] = strings.ToLower(name)
+	}
+	return nil
+}
+
+// Instances returns one configuration per bot identity: cfg itself for a
+// single bot, or a copy per entry of Bots. Instances without their own
+// storage get the shared one with their name appended to file names and the
+// Redis prefix, so no two bots share state. The same goes for the files and
+// directories of the other shared settings.
+func (cfg *Config) Instances() []*Config {
+	if len(cfg.Bots) == 0 {
+		return []*Config{cfg}
+	}
+
+	instances := make([]*Config, 0, len(cfg.Bots))
+	for _, bot := range cfg.Bots {
+		instance := *cfg
+		instance.Bots = nil
+		instance.Name = bot.Name
+		instance.Token = bot.Token
+		if bot.AdminChannels != nil {
+			instance.AdminChannels = bot.AdminChannels
+		}
+		if bot.Storage != nil {
+			instance.Storage = *bot.Storage
+			instance.Storage.Backend = cmp.Or(instance.Storage.Backend, cfg.Storage.Backend)
+			instance.Storage.SessionsFile = cmp.Or(instance.Storage.SessionsFile, withInstanceName(cfg.Storage.SessionsFile, bot.Name))
+			instance.Storage.Backups = cmp.Or(instance.Storage.Backups, cfg.Storage.Backups)
+			instance.Storage.SaveInterval = cmp.Or(instance.Storage.SaveInterval, cfg.Storage.SaveInterval)
+		} else {
+			instance.Storage.File = withInstanceName(cfg.Storage.File, bot.Name)
+			instance.Storage.SessionsFile = withInstanceName(cfg.Storage.SessionsFile, bot.Name)
+			// The bot package's default prefix, followed by the name
+			instance.Storage.RedisPrefix = cmp.Or(cfg.Storage.RedisPrefix, "voiceactivitybot:") + bot.Name + ":"
+			instance.Storage.ImportFile = withInstanceName(cfg.Storage.ImportFile, bot.Name)
+			if cfg.Storage.ArchiveDir != "" {
+				instance.Storage.ArchiveDir = filepath.Join(cfg.Storage.ArchiveDir, bot.Name)
+			}
+		}
+		instance.Notifications.DeadLetterFile = withInstanceName(cfg.Notifications.DeadLetterFile, bot.Name)
+		if bot.Dashboard != nil {
+			instance.Dashboard = *bot.Dashboard
+		}
+		if bot.EventWebhook != nil {
+			instance.EventWebhook = *bot.EventWebhook
+			instance.EventWebhook.Retries = cmp.Or(instance.EventWebhook.Retries, cfg.EventWebhook.Retries)
+		}
+		if bot.EventHistory != nil {
+			instance.EventHistory = *bot.EventHistory
+			instance.EventHistory.Backend = strings.ToLower(instance.EventHistory.Backend)
+			instance.EventHistory.Table = cmp.Or(instance.EventHistory.Table, cfg.EventHistory.Table)
+		} else {
+			instance.EventHistory.File = withInstanceName(cfg.EventHistory.File, bot.Name)
+		}
+		if bot.Federation != nil {
+			instance.Federation = *bot.Federation
+		}
+		instances = append(instances, &instance)
+	}
+	return instances
+}
+
+// withInstanceName inserts a name before a file's extension, e.g.
+// subscriptions-staging.json
+func withInstanceName(path, name string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + name + ext
+}
+
+// validate returns every problem with the configuration
+func (cfg *Config) validate() []error {
+	var errs []error
+	if len(cfg.Bots) > 0 {
+		errs = append(errs, cfg.validateInstances()...)
+	} else {
+		if cfg.Token == "" {
+			errs = append(errs, errors.New("token: a Discord bot token is required, set DISCORD_TOKEN, -token, or token in the config file"))
+		}
+		errs = append(errs, cfg.validateInstance()...)
+	}
+
+	if cfg.Debounce.Interval < 0 {
+		errs = append(errs, fmt.Errorf("debounce.interval: %s is negative", cfg.Debounce.Interval))
+	}
+	if cfg.Debounce.FastPath < 0 {
+		errs = append(errs, fmt.Errorf("debounce.fast_path: %d is negative", cfg.Debounce.FastPath))
+	}
+	if !slices.Contains(debounceStrategies, cfg.Debounce.Strategy) {
+		errs = append(errs, fmt.Errorf("debounce.strategy: unknown strategy %q, use one of %s", cfg.Debounce.Strategy, strings.Join(debounceStrategies, ", ")))
+	}
+
+	if !slices.Contains(logLevels, cfg.Log.Level) {
+		errs = append(errs, fmt.Errorf("log.level: unknown level %q, use one of %s", cfg.Log.Level, strings.Join(logLevels, ", ")))
+	}
+	if !slices.Contains(logFormats, cfg.Log.Format) {
+		errs = append(errs, fmt.Errorf("log.format: unknown format %q, use one of %s", cfg.Log.Format, strings.Join(logFormats, ", ")))
+	}
+
+	if cfg.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown_timeout: %s must be positive", cfg.ShutdownTimeout))
+	}
+	if cfg.Storage.Backups < 0 {
+		errs = append(errs, fmt.Errorf("storage.backups: %d is negative", cfg.Storage.Backups))
+	}
+	if cfg.Storage.SaveInterval <= 0 {
+		errs = append(errs, fmt.Errorf("storage.save_interval: %s must be positive", cfg.Storage.SaveInterval))
+	}
+	errs = append(errs, cfg.validateGateway()...)
+	errs = append(errs, cfg.validateNotifications()...)
+	errs = append(errs, cfg.validateIntegrations()...)
+	return errs
+}
+
+// validateGateway returns every problem with the gateway settings
+func (cfg *Config) validateGateway() []error {
+	var errs []error
+	for _, name := range cfg.Gateway.StateCache {
+		if !slices.Contains(stateCaches, name) {
+			errs = append(errs, fmt.Errorf("gateway.state_cache: unknown cache %q, use any of %s", name, strings.Join(stateCaches, ", ")))
+		}
+	}
+	if cfg.Gateway.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("gateway.cache_ttl: %s is negative", cfg.Gateway.CacheTTL))
+	}
+	switch count, err := strconv.Atoi(cfg.Gateway.ShardCount); {
+	case cfg.Gateway.ShardCount == "" || cfg.Gateway.ShardCount == "auto":
+		if cfg.Gateway.ShardId < 0 {
+			errs = append(errs, fmt.Errorf("gateway.shard_id: %d is negative", cfg.Gateway.ShardId))
+		}
+	case err != nil || count < 1:
+		errs = append(errs, fmt.Errorf("gateway.shard_count: %q must be a positive number or auto", cfg.Gateway.ShardCount))
+	case cfg.Gateway.ShardId < 0 || cfg.Gateway.ShardId >= count:
+		errs = append(errs, fmt.Errorf("gateway.shard_id: %d must be between 0 and %d", cfg.Gateway.ShardId, count-1))
+	}
+	if !slices.Contains(duplicateActions, cfg.Gateway.DuplicateAction) {
+		errs = append(errs, fmt.Errorf("gateway.duplicate_action: unknown action %q, use one of %s", cfg.Gateway.DuplicateAction, strings.Join(duplicateActions, ", ")))
+	}
+	return errs
+}
+
+// validateNotifications returns every problem with the notification settings,
+// the subscription limits, and moderation
+func (cfg *Config) validateNotifications() []error {
+	var errs []error
+	n := cfg.Notifications
+	if !slices.Contains(notificationModes, n.Mode) {
+		errs = append(errs, fmt.Errorf("notifications.mode: unknown mode %q, use one of %s", n.Mode, strings.Join(notificationModes, ", ")))
+	}
+	if n.SummaryWindow <= 0 {
+		errs = append(errs, fmt.Errorf("notifications.summary_window: %s must be positive", n.SummaryWindow))
+	}
+	if n.ReminderDelay != 0 && n.ReminderDelay < time.Minute {
+		errs = append(errs, fmt.Errorf("notifications.reminder_delay: %s is shorter than the minimum of 1m, use 0 to disable reminders", n.ReminderDelay))
+	}
+	if n.PermissionRecheck <= 0 {
+		errs = append(errs, fmt.Errorf("notifications.permission_recheck: %s must be positive", n.PermissionRecheck))
+	}
+	for name, duration := range map[string]time.Duration{
+		"mention_cooldown":       n.MentionCooldown,
+		"user_cooldown":          n.UserCooldown,
+		"rejoin_cooldown":        n.RejoinCooldown,
+		"channel_recreate_grace": n.ChannelRecreateGrace,
+	} {
+		if duration < 0 {
+			errs = append(errs, fmt.Errorf("notifications.%s: %s is negative", name, duration))
+		}
+	}
+	for name, number := range map[string]int{
+		"notifications.rate_limit":     n.RateLimit,
+		"notifications.retries":        n.Retries,
+		"limits.guild_subscriptions":   cfg.Limits.GuildSubscriptions,
+		"limits.channel_subscriptions": cfg.Limits.ChannelSubscriptions,
+	} {
+		if number < 0 {
+			errs = append(errs, fmt.Errorf("%s: %d is negative", name, number))
+		}
+	}
+	if n.FailureLimit < 1 {
+		errs = append(errs, fmt.Errorf("notifications.failure_limit: %d must be at least 1", n.FailureLimit))
+	}
+
+	if !slices.Contains(moderationActions, cfg.Moderation.Action) {
+		errs = append(errs, fmt.Errorf("moderation.action: unknown action %q, use one of %s", cfg.Moderation.Action, strings.Join(moderationActions, ", ")))
+	}
+	if !slices.Contains(moderationOnError, cfg.Moderation.OnError) {
+		errs = append(errs, fmt.Errorf("moderation.on_error: unknown value %q, use one of %s", cfg.Moderation.OnError, strings.Join(moderationOnError, ", ")))
+	}
+	if cfg.Moderation.URL != "" && !isHTTPURL(cfg.Moderation.URL) {
+		errs = append(errs, fmt.Errorf("moderation.url: %q is not an http or https URL", cfg.Moderation.URL))
+	}
+	return errs
+}
+
+// validateIntegrations returns every problem with the HTTP servers and the
+// services voice events are sent to
+func (cfg *Config) validateIntegrations() []error {
+	var errs []error
+	ports := make(map[int]string)
+	for _, server := range []struct {
+		name string
+		port int
+	}{{"health.port", cfg.Health.Port}, {"api.port", cfg.API.Port}, {"dashboard.port", cfg.Dashboard.Port}} {
+		if server.port < 0 || server.port > 65535 {
+			errs = append(errs, fmt.Errorf("%s: %d is not a port", server.name, server.port))
+		} else if other, ok := ports[server.port]; ok && server.port != 0 {
+			errs = append(errs, fmt.Errorf("%s: %d is already the %s", server.name, server.port, other))
+		}
+		ports[server.port] = server.name
+	}
+	if cfg.API.Port != 0 && cfg.API.Token == "" {
+		errs = append(errs, errors.New("api.token: the HTTP API needs API_TOKEN"))
+	}
+	if cfg.Dashboard.Port != 0 && (cfg.Dashboard.ClientId == "" || cfg.Dashboard.ClientSecret == "" || cfg.Dashboard.URL == "") {
+		errs = append(errs, errors.New("dashboard: the dashboard needs DISCORD_CLIENT_ID, DISCORD_CLIENT_SECRET, and DASHBOARD_URL"))
+	}
+
+	if cfg.EventWebhook.URL != "" && !isHTTPURL(cfg.EventWebhook.URL) {
+		errs = append(errs, errors.New("event_webhook.url: not an http or https URL"))
+	}
+	if cfg.EventWebhook.Retries < 0 {
+		errs = append(errs, fmt.Errorf("event_webhook.retries: %d is negative", cfg.EventWebhook.Retries))
+	}
+
+	switch {
+	case cfg.EventHistory.Backend == "":
+	case !slices.Contains(historyBackends, cfg.EventHistory.Backend):
+		errs = append(errs, fmt.Errorf("event_history.backend: unknown backend %q, use one of %s", cfg.EventHistory.Backend, strings.Join(historyBackends, ", ")))
+	case cfg.EventHistory.URL == "":
+		errs = append(errs, fmt.Errorf("event_history.url: the %s backend needs EVENT_HISTORY_URL", cfg.EventHistory.Backend))
+	case !isHTTPURL(cfg.EventHistory.URL):
+		errs = append(errs, errors.New("event_history.url: not an http or https URL"))
+	}
+
+	federation := cfg.Federation
+	if (len(federation.Peers) == 0) != (federation.Channel == "") {
+		errs = append(errs, errors.New("federation: accepting federated events needs both FEDERATION_PEERS and FEDERATION_CHANNEL"))
+	}
+	if federation.Channel != "" && !snowflakePattern.MatchString(federation.Channel) {
+		errs = append(errs, fmt.Errorf("federation.channel: %q is not a Discord ID", federation.Channel))
+	}
+	for name, token := range federation.Peers {
+		if name == "" || token == "" {
+			errs = append(errs, fmt.Errorf("federation.peers: peer %q needs a name and a token", name))
+		}
+	}
+	return errs
+}
+
+// isHTTPURL reports whether value is an absolute http or https URL
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// validateInstances checks a multi-bot configuration: every bot is valid on
+// its own and no two bots share a token or storage
+func (cfg *Config) validateInstances() []error {
+	var errs []error
+	if cfg.Token != "" {
+		errs = append(errs, errors.New("token: set either a single token or bots, not both"))
+	}
+
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	storages := make(map[string]string)
+	dashboards := make(map[int]string)
+	for idx, instance := range cfg.Instances() {
+		prefix := "bots." + instance.Name
+		if !instanceNamePattern.MatchString(instance.Name) {
+			errs = append(errs, fmt.Errorf("bots: name %q must be 1 to 32 lowercase letters, digits, - or _", instance.Name))
+		} else if names[instance.Name] {
+			errs = append(errs, fmt.Errorf("bots: name %q is used twice", instance.Name))
+		}
+		names[instance.Name] = true
+
+		if instance.Token == "" {
+			errs = append(errs, fmt.Errorf("%s.token: a Discord bot token is required", prefix))
+		} else if tokens[instance.Token] {
+			errs = append(errs, fmt.Errorf("%s.token: the token is used by another bot", prefix))
+		}
+		tokens[instance.Token] = true
+
+		for _, err := range instance.validateInstance() {
+			errs = append(errs, fmt.Errorf("%s.%w", prefix, err))
+		}
+		if bot := cfg.Bots[idx]; bot.Dashboard != nil || bot.EventWebhook != nil || bot.EventHistory != nil || bot.Federation != nil {
+			for _, err := range instance.validateIntegrations() {
+				errs = append(errs, fmt.Errorf("%s.%w", prefix, err))
+			}
+		}
+
+		if port := instance.Dashboard.Port; port != 0 {
+			if other, ok := dashboards[port]; ok {
+				errs = append(errs, fmt.Errorf("%s.dashboard.port: %d is used by bot %s, each bot needs its own dashboard since the login goes through its own application", prefix, port, other))
+			} else if port == cfg.Health.Port || port == cfg.API.Port {
+				errs = append(errs, fmt.Errorf("%s.dashboard.port: %d is used by the health endpoint or the API", prefix, port))
+			}
+			dashboards[port] = instance.Name
+		}
+
+		var location string
+		switch instance.Storage.Backend {
+		case "file":
+			location = "file " + instance.Storage.File
+		case "postgres":
+			location = "database " + instance.Storage.DatabaseURL
+		case "redis":
+			location = "redis " + instance.Storage.RedisURL + " " + instance.Storage.RedisPrefix
+		}
+		if other, ok := storages[location]; ok && location != "" {
+			errs = append(errs, fmt.Errorf("%s.storage: shared with bot %s, give each bot its own storage", prefix, other))
+		} else if location != "" {
+			storages[location] = instance.Name
+		}
+	}
+	return errs
+}
+
+// validateInstance returns every problem with the storage and admin channels
+// of one bot
+func (cfg *Config) validateInstance() []error {
+	var errs []error
+	switch cfg.Storage.Backend {
+	case "file":
+		if cfg.Storage.File == "" {
+			errs = append(errs, errors.New("storage.file: the file backend needs a file path (PERSISTENCE_FILE)"))
+		}
+	case "postgres":
+		if cfg.Storage.DatabaseURL == "" {
+			errs = append(errs, errors.New("storage.database_url: the postgres backend needs DATABASE_URL"))
+		}
+	case "redis":
+		if cfg.Storage.RedisURL == "" {
+			errs = append(errs, errors.New("storage.redis_url: the redis backend needs REDIS_URL"))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("storage.backend: unknown backend %q, use one of %s", cfg.Storage.Backend, strings.Join(storageBackends, ", ")))
+	}
+
+	for guildID, channelID := range cfg.AdminChannels {
+		if !snowflakePattern.MatchString(guildID) || !snowflakePattern.MatchString(channelID) {
+			errs = append(errs, fmt.Errorf("admin_channels: %s:%s is not a pair of Discord IDs", guildID, channelID))
+		}
+	}
+	return errs
+}
+
+// parseAdminChannels parses guildID:channelID pairs separated by commas
+func parseAdminChannels(value string) (map[string]string, error) {
+	channels := make(map[string]string)
+	var invalid []string
+	for _, pair := range strings.Split(value, ",") {
+		guildID, channelID, found := strings.Cut(strings.TrimSpace(pair), ":")
+		guildID, channelID = strings.TrimSpace(guildID), strings.TrimSpace(channelID)
+		if !found || guildID == "" || channelID == "" {
+			invalid = append(invalid, fmt.Sprintf("%q", pair))
+			continue
+		}
+		channels[guildID] = channelID
+	}
+	if len(invalid) > 0 {
+		return channels, fmt.Errorf("expected guildID:channelID pairs, got %s", strings.Join(invalid, ", "))
+	}
+	return channels, nil
+}
@@ -0,0 +1,395 @@
+// Package discordfake provides an in-memory stand-in for the Discord REST
+// API so the bot's handlers can be exercised without a live gateway.
+package discordfake
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/CS-5/VoiceActivityBot/bot"
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// Session implements bot.DiscordSession. It answers lookups from the
+	// channels and members it was given and records everything the bot sends.
+	Session struct {
+		mu sync.Mutex
+
+		Channels        map[string]*discordgo.Channel // by channel ID
+		Members         map[string]*discordgo.Member  // by guild ID + ":" + user ID
+		ScheduledEvents map[string][]*discordgo.GuildScheduledEvent
+		Permissions     int64 // returned by UserChannelPermissions
+		Err             error // returned by every call when set
+
+		Messages  []Message
+		Deleted   []string // message IDs
+		Responses []Response
+		Webhooks  []*discordgo.Webhook
+		Commands  []*discordgo.ApplicationCommand
+
+		nextId int
+	}
+
+	// Message is a message the bot sent or edited
+	Message struct {
+		Id        string
+		ChannelId string
+		Send      *discordgo.MessageSend
+		Webhook   bool // sent through a channel webhook
+		Edited    bool
+	}
+
+	// Response is an interaction response or edit
+	Response struct {
+		InteractionId string
+		Response      *discordgo.InteractionResponse
+		Edit          *discordgo.WebhookEdit
+	}
+)
+
+var _ bot.DiscordSession = (*Session)(nil)
+
+// New returns a session where every permission is granted
+func New() *Session {
+	return &Session{
+		Channels:        make(map[string]*discordgo.Channel),
+		Members:         make(map[string]*discordgo.Member),
+		ScheduledEvents: make(map[string][]*discordgo.GuildScheduledEvent),
+		Permissions:     discordgo.PermissionAll,
+	}
+}
+
+// AddChannel registers a channel for lookups
+func (f *Session) AddChannel(channel *discordgo.Channel) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Channels[channel.ID] = channel
+}
+
+// RemoveChannel forgets a channel, as if it was deleted
+func (f *Session) RemoveChannel(channelID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.Channels, channelID)
+}
+
+// AddMember registers a guild member for lookups
+func (f *Session) AddMember(guildID string, member *discordgo.Member) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Members[guildID+":"+member.User.ID] = member
+}
+
+// Sent returns the messages sent to a channel so far
+func (f *Session) Sent(channelID string) []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var sent []Message
+	for _, message := range f.Messages {
+		if message.ChannelId == channelID {
+			sent = append(sent, message)
+		}
+	}
+	return sent
+}
+
+// ResponsesTo returns the responses and edits to an interaction so far
+func (f *Session) ResponsesTo(interactionID string) []Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var responses []Response
+	for _, response := range f.Responses {
+		if response.InteractionId == interactionID {
+			responses = append(responses, response)
+		}
+	}
+	return responses
+}
+
+// Reset forgets everything recorded so far
+func (f *Session) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Messages, f.Deleted, f.Responses = nil, nil, nil
+}
+
+func (f *Session) id() string {
+	f.nextId++
+	return strconv.Itoa(f.nextId)
+}
+
+// record stores a sent message and returns it as Discord would
+func (f *Session) record(channelID string, send *discordgo.MessageSend, webhook bool) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	message := Message{Id: f.id(), ChannelId: channelID, Send: send, Webhook: webhook}
+	f.Messages = append(f.Messages, message)
+	return &discordgo.Message{ID: message.Id, ChannelID: channelID, Content: send.Content, Embeds: send.Embeds}, nil
+}
+
+func (f *Session) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	channel, ok := f.Channels[channelID]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel %s", channelID)
+	}
+	return channel, nil
+}
+
+func (f *Session) GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	var channels []*discordgo.Channel
+	for _, channel := range f.Channels {
+		if channel.GuildID == guildID {
+			channels = append(channels, channel)
+		}
+	}
+	slices.SortFunc(channels, func(a, b *discordgo.Channel) int { return a.Position - b.Position })
+	return channels, nil
+}
+
+func (f *Session) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return f.record(channelID, &discordgo.MessageSend{Content: content}, false)
+}
+
+func (f *Session) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return f.record(channelID, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}, false)
+}
+
+func (f *Session) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return f.record(channelID, data, false)
+}
+
+func (f *Session) ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.Messages = append(f.Messages, Message{
+		Id:        messageID,
+		ChannelId: channelID,
+		Send:      &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}},
+		Edited:    true,
+	})
+	return &discordgo.Message{ID: messageID, ChannelID: channelID, Embeds: []*discordgo.MessageEmbed{embed}}, nil
+}
+
+func (f *Session) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Deleted = append(f.Deleted, messageID)
+	return nil
+}
+
+func (f *Session) ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	return f.err()
+}
+
+func (f *Session) ChannelMessageUnpin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	return f.err()
+}
+
+func (f *Session) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Responses = append(f.Responses, Response{InteractionId: interaction.ID, Response: resp})
+	return nil
+}
+
+func (f *Session) InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.Responses = append(f.Responses, Response{InteractionId: interaction.ID, Edit: newresp})
+	return &discordgo.Message{ID: f.id(), ChannelID: interaction.ChannelID}, nil
+}
+
+// UserChannelCreate opens a DM channel whose ID is the user's ID prefixed with "dm-"
+func (f *Session) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func (f *Session) UserChannelPermissions(userID, channelID string, fetchOptions ...discordgo.RequestOption) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return 0, f.Err
+	}
+	return f.Permissions, nil
+}
+
+func (f *Session) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	member, ok := f.Members[guildID+":"+userID]
+	if !ok {
+		return nil, fmt.Errorf("unknown member %s", userID)
+	}
+	return member, nil
+}
+
+func (f *Session) GuildScheduledEvents(guildID string, userCount bool, options ...discordgo.RequestOption) ([]*discordgo.GuildScheduledEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.ScheduledEvents[guildID], nil
+}
+
+func (f *Session) MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	thread := &discordgo.Channel{ID: f.id(), ParentID: channelID, Name: data.Name, Type: discordgo.ChannelTypeGuildPublicThread}
+	f.Channels[thread.ID] = thread
+	return thread, nil
+}
+
+func (f *Session) ChannelWebhooks(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	var webhooks []*discordgo.Webhook
+	for _, webhook := range f.Webhooks {
+		if webhook.ChannelID == channelID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (f *Session) WebhookCreate(channelID, name, avatar string, options ...discordgo.RequestOption) (*discordgo.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	webhook := &discordgo.Webhook{ID: f.id(), ChannelID: channelID, Name: name, Token: "token"}
+	f.Webhooks = append(f.Webhooks, webhook)
+	return webhook, nil
+}
+
+func (f *Session) WebhookExecute(webhookID, token string, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	channelID := ""
+	for _, webhook := range f.Webhooks {
+		if webhook.ID == webhookID {
+			channelID = webhook.ChannelID
+		}
+	}
+	f.mu.Unlock()
+
+	return f.record(channelID, &discordgo.MessageSend{
+		Content:         data.Content,
+		Embeds:          data.Embeds,
+		Components:      data.Components,
+		AllowedMentions: data.AllowedMentions,
+		Flags:           data.Flags,
+	}, true)
+}
+
+func (f *Session) Application(appID string) (*discordgo.Application, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return &discordgo.Application{ID: appID, Owner: &discordgo.User{ID: "owner"}}, nil
+}
+
+func (f *Session) ApplicationCommandCreate(appID string, guildID string, cmd *discordgo.ApplicationCommand, options ...discordgo.RequestOption) (*discordgo.ApplicationCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	created := *cmd
+	created.ID = f.id()
+	created.GuildID = guildID
+	f.Commands = append(f.Commands, &created)
+	return &created, nil
+}
+
+func (f *Session) ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.Commands = slices.DeleteFunc(f.Commands, func(cmd *discordgo.ApplicationCommand) bool { return cmd.GuildID == guildID })
+	var created []*discordgo.ApplicationCommand
+	for _, cmd := range commands {
+		c := *cmd
+		c.ID = f.id()
+		c.GuildID = guildID
+		created = append(created, &c)
+	}
+	f.Commands = append(f.Commands, created...)
+	return created, nil
+}
+
+func (f *Session) ApplicationCommandDelete(appID, guildID, cmdID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Commands = slices.DeleteFunc(f.Commands, func(cmd *discordgo.ApplicationCommand) bool { return cmd.GuildID == guildID && cmd.ID == cmdID })
+	return nil
+}
+
+func (f *Session) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Err
+}
@@ -1,36 +1,99 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/CS-5/VoiceActivityBot/bot"
+	"github.com/CS-5/VoiceActivityBot/config"
 )
 
 func main() {
-	token := os.Getenv("DISCORD_TOKEN")
-	if token == "" {
-		log.Fatal("DISCORD_TOKEN environment variable is required")
+	if len(os.Args) > 1 && os.Args[1] == "template-test" {
+		os.Exit(runTemplateTest(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "merge" {
+		os.Exit(runMerge(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "shard-plan" {
+		os.Exit(runShardPlan(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		cfg, err := config.LoadPartial(os.Args[2:])
+		if err == nil {
+			err = bot.CheckHealth(cfg.Health)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
-	bot, err := bot.NewBot(token)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Fatal("Error creating bot:", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	err = bot.Start()
-	if err != nil {
-		log.Fatal("Error starting bot:", err)
+	bot.SetupLogging(cfg.Log)
+
+	timeout := cfg.ShutdownTimeout
+
+	// One bot per configured identity, each with its own state. A bot that
+	// fails to start is stopped along with the others.
+	var bots []*bot.Bot
+	for _, instance := range cfg.Instances() {
+		b, err := bot.NewBot(instance)
+		if err == nil {
+			bots = append(bots, b)
+			err = b.Start(context.Background())
+		}
+		if err != nil {
+			slog.Error("Error starting bot", "bot", instance.Name, "error", err)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			stopBots(ctx, bots)
+			cancel()
+			os.Exit(1)
+		}
 	}
 
-	log.Println("Bot is now running. SIGINT, SIGTERM, or CTRL+C to exit.")
+	// The health endpoint and the API cover every bot
+	servers := bot.NewServers(cfg, bots)
+	servers.Start()
+
+	slog.Info("Bot is now running. SIGINT, SIGTERM, or CTRL+C to exit.", "bots", len(bots))
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
 
-	// Cleanup: unregister commands
-	log.Println("Shutting down, cleaning up commands...")
-	bot.Stop()
+	// Cleanup: flush notifications, save, and unregister commands
+	slog.Info("Shutting down, cleaning up commands...", "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	servers.Stop(ctx)
+	stopBots(ctx, bots)
+}
+
+// stopBots stops every bot at once, sharing the shutdown deadline of ctx
+func stopBots(ctx context.Context, bots []*bot.Bot) {
+	var wg sync.WaitGroup
+	for _, b := range bots {
+		wg.Go(func() {
+			if err := b.Stop(ctx); err != nil {
+				slog.Warn("Shutdown did not complete in time", "error", err)
+			}
+		})
+	}
+	wg.Wait()
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/CS-5/VoiceActivityBot/bot"
+	"github.com/CS-5/VoiceActivityBot/config"
+)
+
+// runMerge merges the state of one store into another, e.g. after
+// consolidating two deployments. Stores are file paths or postgres:// and
+// redis:// URLs.
+func runMerge(args []string) int {
+	cfg, err := config.LoadPartial(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would change without saving")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: voiceactivitybot merge [-dry-run] <target> <source>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	target, err := bot.OpenStore(flags.Arg(0), cfg.Storage.RedisPrefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening target: %v\n", err)
+		return 1
+	}
+	source, err := bot.OpenStore(flags.Arg(1), cfg.Storage.RedisPrefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening source: %v\n", err)
+		return 1
+	}
+
+	targetData, err := target.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading target: %v\n", err)
+		return 1
+	}
+	sourceData, err := source.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading source: %v\n", err)
+		return 1
+	}
+
+	report := bot.MergeData(targetData, sourceData)
+	fmt.Printf("added: %d, duplicates: %d, conflicts: %d\n", report.Added, report.Duplicates, len(report.Conflicts))
+	for _, conflict := range report.Conflicts {
+		fmt.Printf("  conflict (kept target): %s\n", conflict)
+	}
+
+	if *dryRun {
+		fmt.Println("dry run, nothing saved")
+		return 0
+	}
+	if err := target.Save(targetData); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving target: %v\n", err)
+		return 1
+	}
+	fmt.Println("saved merged state to", flags.Arg(0))
+	return 0
+}
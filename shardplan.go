@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/CS-5/VoiceActivityBot/bot"
+	"github.com/CS-5/VoiceActivityBot/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+// runShardPlan reports how the configured guilds in a store spread over the
+// current and a proposed shard count. Without -count it asks Discord for the
+// recommended shard count, which requires the bot token.
+func runShardPlan(args []string) int {
+	cfg, err := config.LoadPartial(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("shard-plan", flag.ContinueOnError)
+	count := flags.Int("count", 0, "shard count to plan for (default: Discord's recommendation)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: voiceactivitybot shard-plan [-count N] <store>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	if *count < 1 {
+		if cfg.Token == "" {
+			fmt.Fprintln(os.Stderr, "DISCORD_TOKEN is required to fetch the recommended shard count, or pass -count")
+			return 2
+		}
+		dg, err := discordgo.New("Bot " + cfg.Token)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error creating Discord session: %v\n", err)
+			return 1
+		}
+		gateway, err := dg.GatewayBot()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error fetching recommended shard count: %v\n", err)
+			return 1
+		}
+		*count = gateway.Shards
+		fmt.Printf("Discord recommends %d shard(s)\n", *count)
+	}
+
+	store, err := bot.OpenStore(flags.Arg(0), cfg.Storage.RedisPrefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening store: %v\n", err)
+		return 1
+	}
+	data, err := store.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading store: %v\n", err)
+		return 1
+	}
+
+	if current := cfg.Gateway.ShardCount; current != "" && current != "auto" {
+		fmt.Printf("current SHARD_COUNT: %s\n", current)
+	}
+	fmt.Printf("configured servers per shard with %d shard(s):\n", *count)
+	for id, guilds := range bot.ShardDistribution(data, *count) {
+		fmt.Printf("  shard %d: %d\n", id, guilds)
+	}
+	return 0
+}
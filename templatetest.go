@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/CS-5/VoiceActivityBot/bot"
+)
+
+// runTemplateTest renders templates against synthetic events locally.
+// With -template it renders once; otherwise it reads templates from stdin, one per line.
+func runTemplateTest(args []string) int {
+	flags := flag.NewFlagSet("template-test", flag.ContinueOnError)
+	templateText := flags.String("template", "", "template to render (reads templates from stdin when empty)")
+	event := flags.String("event", bot.TemplateEventJoin, "event type: "+strings.Join(bot.TemplateEvents, ", "))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *templateText != "" {
+		if !renderSamples(os.Stdout, *templateText, *event) {
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Println("Enter a template per line (Ctrl+D to exit). Fields: .User .UserID .Channel .ChannelID .FromChannel .FromChannelID .Guild .Count .Time")
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("template> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return 0
+		}
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			renderSamples(os.Stdout, line, *event)
+		}
+	}
+}
+
+// renderSamples prints the template rendered against every sample event
+func renderSamples(w io.Writer, text, event string) bool {
+	if _, err := bot.ParseTemplate(text); err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return false
+	}
+
+	ok := true
+	for _, sample := range bot.SampleEvents(event) {
+		rendered, err := bot.RenderTemplate(text, sample)
+		if err != nil {
+			fmt.Fprintf(w, "  %s: error: %v\n", sample.User, err)
+			ok = false
+			continue
+		}
+		fmt.Fprintf(w, "  %s\n", rendered)
+	}
+	return ok
+}